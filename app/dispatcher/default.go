@@ -194,6 +194,24 @@ func (d *DefaultDispatcher) getLink(ctx context.Context) (*transport.Link, *tran
 	return inboundLink, outboundLink
 }
 
+// applyPeerIdentity uses the identity the transport authenticates the peer
+// with as the user email of inbounds without one, for routing and stats.
+func applyPeerIdentity(ctx context.Context) {
+	inbound := session.InboundFromContext(ctx)
+	if inbound == nil || (inbound.User != nil && len(inbound.User.Email) > 0) {
+		return
+	}
+	if inbound.PeerIdentity == "" {
+		return
+	}
+	user := &protocol.MemoryUser{Email: inbound.PeerIdentity}
+	if inbound.User != nil {
+		user.Level = inbound.User.Level
+		user.Account = inbound.User.Account
+	}
+	inbound.User = user
+}
+
 func (d *DefaultDispatcher) shouldOverride(ctx context.Context, result SniffResult, request session.SniffingRequest, destination net.Destination) bool {
 	domain := result.Domain()
 	if domain == "" {
@@ -258,6 +276,8 @@ func (d *DefaultDispatcher) Dispatch(ctx context.Context, destination net.Destin
 		ctx = session.ContextWithContent(ctx, content)
 	}
 
+	applyPeerIdentity(ctx)
+
 	sniffingRequest := content.SniffingRequest
 	inbound, outbound := d.getLink(ctx)
 	if !sniffingRequest.Enabled {
@@ -314,6 +334,8 @@ func (d *DefaultDispatcher) DispatchLink(ctx context.Context, destination net.De
 		content = new(session.Content)
 		ctx = session.ContextWithContent(ctx, content)
 	}
+	applyPeerIdentity(ctx)
+
 	sniffingRequest := content.SniffingRequest
 	if !sniffingRequest.Enabled {
 		d.routedDispatch(ctx, outbound, destination)
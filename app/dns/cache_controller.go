@@ -21,6 +21,16 @@ type CacheController struct {
 	cacheCleanup *task.Periodic
 	name         string
 	disableCache bool
+	prefetch     *prefetcher
+}
+
+type prefetcher struct {
+	ctx       context.Context
+	threshold uint32
+	ahead     time.Duration
+	slots     chan struct{}
+	query     func(ctx context.Context, noResponseErrCh chan<- error, domain string, option dns_feature.IPOption)
+	task      *task.Periodic
 }
 
 func NewCacheController(name string, disableCache bool) *CacheController {
@@ -51,9 +61,11 @@ func (c *CacheController) CacheCleanup() error {
 	for domain, record := range c.ips {
 		if record.A != nil && record.A.Expire.Before(now) {
 			record.A = nil
+			record.hits.Store(0)
 		}
 		if record.AAAA != nil && record.AAAA.Expire.Before(now) {
 			record.AAAA = nil
+			record.hits.Store(0)
 		}
 
 		if record.A == nil && record.AAAA == nil {
@@ -71,6 +83,129 @@ func (c *CacheController) CacheCleanup() error {
 	return nil
 }
 
+// enablePrefetch makes the controller re-resolve popular names shortly before
+// their records expire, sending the refreshing queries through query.
+func (c *CacheController) enablePrefetch(ctx context.Context, config *PrefetchConfig, query func(context.Context, chan<- error, string, dns_feature.IPOption)) {
+	if c.disableCache {
+		return
+	}
+
+	threshold := config.Threshold
+	if threshold == 0 {
+		threshold = 3
+	}
+	concurrency := config.Concurrency
+	if concurrency == 0 {
+		concurrency = 4
+	}
+	ahead := time.Duration(config.Ahead) * time.Second
+	if ahead == 0 {
+		ahead = 10 * time.Second
+	}
+
+	c.prefetch = &prefetcher{
+		ctx:       ctx,
+		threshold: threshold,
+		ahead:     ahead,
+		slots:     make(chan struct{}, concurrency),
+		query:     query,
+	}
+	c.prefetch.task = &task.Periodic{
+		Interval: time.Second,
+		Execute:  c.Prefetch,
+	}
+}
+
+// Close stops the background tasks of the controller.
+func (c *CacheController) Close() error {
+	var prefetchErr error
+	if c.prefetch != nil {
+		prefetchErr = c.prefetch.task.Close()
+	}
+	return errors.Combine(c.cacheCleanup.Close(), prefetchErr)
+}
+
+// Prefetch refreshes popular records which are about to expire
+func (c *CacheController) Prefetch() error {
+	now := time.Now()
+	deadline := now.Add(c.prefetch.ahead)
+
+	type candidate struct {
+		domain string
+		rec    *record
+		option dns_feature.IPOption
+	}
+	var candidates []candidate
+
+	c.RLock()
+	if len(c.ips) == 0 {
+		c.RUnlock()
+		return errors.New("nothing to do. stopping...")
+	}
+	for domain, rec := range c.ips {
+		if rec.hits.Load() < c.prefetch.threshold || rec.prefetching.Load() {
+			continue
+		}
+		option := dns_feature.IPOption{
+			IPv4Enable: rec.A.expiresWithin(now, deadline),
+			IPv6Enable: rec.AAAA.expiresWithin(now, deadline),
+		}
+		if option.IPv4Enable || option.IPv6Enable {
+			candidates = append(candidates, candidate{domain, rec, option})
+		}
+	}
+	c.RUnlock()
+
+	for _, cand := range candidates {
+		select {
+		case c.prefetch.slots <- struct{}{}:
+		default:
+			// concurrency cap reached, the rest is retried on next tick
+			return nil
+		}
+		if !cand.rec.prefetching.CompareAndSwap(false, true) {
+			<-c.prefetch.slots
+			continue
+		}
+		cand.rec.hits.Store(0)
+		go c.prefetchDomain(cand.domain, cand.rec, cand.option)
+	}
+
+	return nil
+}
+
+func (c *CacheController) prefetchDomain(domain string, rec *record, option dns_feature.IPOption) {
+	defer func() {
+		rec.prefetching.Store(false)
+		<-c.prefetch.slots
+	}()
+
+	ctx, cancel := context.WithTimeout(c.prefetch.ctx, 4*time.Second)
+	defer cancel()
+
+	sub4, sub6 := c.registerSubscribers(domain, option)
+	defer closeSubscribers(sub4, sub6)
+
+	errors.LogDebug(ctx, c.name, " prefetching ", domain)
+	noResponseErrCh := make(chan error, 2)
+	c.prefetch.query(ctx, noResponseErrCh, domain, option)
+
+	for _, sub := range []*pubsub.Subscriber{sub4, sub6} {
+		if sub == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			errors.LogInfoInner(ctx, ctx.Err(), c.name, " failed to prefetch ", domain)
+			return
+		case err := <-noResponseErrCh:
+			errors.LogInfoInner(ctx, err, c.name, " failed to prefetch ", domain)
+			return
+		case <-sub.Wait():
+		}
+	}
+}
+
 func (c *CacheController) updateIP(req *dnsRequest, ipRec *IPRecord) {
 	elapsed := time.Since(req.start)
 
@@ -79,6 +214,8 @@ func (c *CacheController) updateIP(req *dnsRequest, ipRec *IPRecord) {
 	if !found {
 		rec = &record{}
 	}
+	// the threshold counts queries within the TTL of the current answer
+	rec.hits.Store(0)
 
 	switch req.reqType {
 	case dnsmessage.TypeA:
@@ -111,16 +248,24 @@ func (c *CacheController) updateIP(req *dnsRequest, ipRec *IPRecord) {
 
 	c.Unlock()
 	common.Must(c.cacheCleanup.Start())
+	if c.prefetch != nil {
+		common.Must(c.prefetch.task.Start())
+	}
 }
 
 func (c *CacheController) findIPsForDomain(domain string, option dns_feature.IPOption) ([]net.IP, uint32, error) {
 	c.RLock()
 	record, found := c.ips[domain]
+	var a, aaaa *IPRecord
+	if found {
+		a, aaaa = record.A, record.AAAA
+	}
 	c.RUnlock()
 
 	if !found {
 		return nil, 0, errRecordNotFound
 	}
+	record.hits.Add(1)
 
 	var errs []error
 	var allIPs []net.IP
@@ -129,7 +274,7 @@ func (c *CacheController) findIPsForDomain(domain string, option dns_feature.IPO
 	mergeReq := option.IPv4Enable && option.IPv6Enable
 
 	if option.IPv4Enable {
-		ips, ttl, err := record.A.getIPs()
+		ips, ttl, err := a.getIPs()
 		if !mergeReq || go_errors.Is(err, errRecordNotFound) {
 			return ips, ttl, err
 		}
@@ -144,7 +289,7 @@ func (c *CacheController) findIPsForDomain(domain string, option dns_feature.IPO
 	}
 
 	if option.IPv6Enable {
-		ips, ttl, err := record.AAAA.getIPs()
+		ips, ttl, err := aaaa.getIPs()
 		if !mergeReq || go_errors.Is(err, errRecordNotFound) {
 			return ips, ttl, err
 		}
@@ -0,0 +1,105 @@
+package dns
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common/net"
+	dns_feature "github.com/xtls/xray-core/features/dns"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestCacheControllerPrefetch(t *testing.T) {
+	c := NewCacheController("test", false)
+	defer c.Close()
+
+	var queries atomic.Int32
+	query := func(ctx context.Context, _ chan<- error, domain string, option dns_feature.IPOption) {
+		queries.Add(1)
+		c.updateIP(&dnsRequest{reqType: dnsmessage.TypeA, domain: domain, start: time.Now()}, &IPRecord{
+			IP:     []net.IP{net.IP{1, 1, 1, 1}},
+			Expire: time.Now().Add(time.Minute),
+			RCode:  dnsmessage.RCodeSuccess,
+		})
+	}
+	c.enablePrefetch(context.Background(), &PrefetchConfig{Threshold: 2, Ahead: 5}, query)
+
+	for _, domain := range []string{"popular.com.", "rare.com."} {
+		c.updateIP(&dnsRequest{reqType: dnsmessage.TypeA, domain: domain, start: time.Now()}, &IPRecord{
+			IP:     []net.IP{net.IP{8, 8, 8, 8}},
+			Expire: time.Now().Add(2 * time.Second),
+			RCode:  dnsmessage.RCodeSuccess,
+		})
+	}
+
+	option := dns_feature.IPOption{IPv4Enable: true}
+	for i := 0; i < 2; i++ {
+		if _, _, err := c.findIPsForDomain("popular.com.", option); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := c.findIPsForDomain("rare.com.", option); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Prefetch(); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		ips, ttl, err := c.findIPsForDomain("popular.com.", option)
+		if err == nil && ips[0].Equal(net.IP{1, 1, 1, 1}) && ttl > 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("popular name not prefetched: ", ips, ttl, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if n := queries.Load(); n != 1 {
+		t.Error("unexpected prefetch queries: ", n)
+	}
+	ips, _, _ := c.findIPsForDomain("rare.com.", option)
+	if !ips[0].Equal(net.IP{8, 8, 8, 8}) {
+		t.Error("rare name should not be prefetched: ", ips)
+	}
+}
+
+func TestCacheControllerPrefetchCountsHitsPerTTL(t *testing.T) {
+	c := NewCacheController("test", false)
+	defer c.Close()
+
+	var queries atomic.Int32
+	query := func(ctx context.Context, _ chan<- error, domain string, option dns_feature.IPOption) {
+		queries.Add(1)
+	}
+	c.enablePrefetch(context.Background(), &PrefetchConfig{Threshold: 2, Ahead: 5}, query)
+
+	update := func() {
+		c.updateIP(&dnsRequest{reqType: dnsmessage.TypeA, domain: "rare.com.", start: time.Now()}, &IPRecord{
+			IP:     []net.IP{net.IP{8, 8, 8, 8}},
+			Expire: time.Now().Add(2 * time.Second),
+			RCode:  dnsmessage.RCodeSuccess,
+		})
+	}
+
+	option := dns_feature.IPOption{IPv4Enable: true}
+	for i := 0; i < 3; i++ {
+		update()
+		if _, _, err := c.findIPsForDomain("rare.com.", option); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := c.Prefetch(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := queries.Load(); n != 0 {
+		t.Error("name queried once per TTL should not be prefetched: ", n)
+	}
+}
@@ -128,7 +128,7 @@ func New(ctx context.Context, config *Config) (*DNS, error) {
 			return nil, errors.New("no QueryStrategy available for ", ns.Address)
 		}
 
-		client, err := NewClient(ctx, ns, myClientIP, disableCache, tag, clientIPOption, &matcherInfos, updateDomain)
+		client, err := NewClient(ctx, ns, myClientIP, disableCache, config.Prefetch, tag, clientIPOption, &matcherInfos, updateDomain)
 		if err != nil {
 			return nil, errors.New("failed to create client").Base(err)
 		}
@@ -165,7 +165,13 @@ func (s *DNS) Start() error {
 
 // Close implements common.Closable.
 func (s *DNS) Close() error {
-	return nil
+	var errs []error
+	for _, client := range s.clients {
+		if err := common.Close(client.server); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Combine(errs...)
 }
 
 // IsOwnLink implements proxy.dns.ownLinkVerifier
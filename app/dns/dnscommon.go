@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/binary"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/xtls/xray-core/common"
@@ -27,6 +28,10 @@ func Fqdn(domain string) string {
 type record struct {
 	A    *IPRecord
 	AAAA *IPRecord
+
+	// hits counts lookups since the record was last refreshed or expired.
+	hits        atomic.Uint32
+	prefetching atomic.Bool
 }
 
 // IPRecord is a cacheable item for a resolved domain
@@ -61,6 +66,11 @@ func (r *IPRecord) getIPs() ([]net.IP, uint32, error) {
 	return r.IP, ttl, nil
 }
 
+// expiresWithin reports whether the record is still valid but expires before deadline.
+func (r *IPRecord) expiresWithin(now, deadline time.Time) bool {
+	return r != nil && r.Expire.After(now) && !r.Expire.After(deadline)
+}
+
 var errRecordNotFound = errors.New("record not found")
 
 type dnsRequest struct {
@@ -6,12 +6,14 @@ import (
 	"math/big"
 	gonet "net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/cache"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/task"
 	"github.com/xtls/xray-core/features/dns"
 )
 
@@ -21,6 +23,11 @@ type Holder struct {
 	mu         *sync.Mutex
 
 	config *FakeDnsPool
+
+	dirty       atomic.Bool
+	persistTask *task.Periodic
+	// persistMu serializes saves, and Close with them.
+	persistMu sync.Mutex
 }
 
 func (fkdns *Holder) IsIPInIPPool(ip net.Address) bool {
@@ -44,12 +51,32 @@ func (*Holder) Type() interface{} {
 
 func (fkdns *Holder) Start() error {
 	if fkdns.config != nil && fkdns.config.IpPool != "" && fkdns.config.LruSize != 0 {
-		return fkdns.initializeFromConfig()
+		if err := fkdns.initializeFromConfig(); err != nil {
+			return err
+		}
+		if fkdns.config.PersistPath != "" {
+			if err := fkdns.restore(); err != nil {
+				errors.LogWarningInner(context.Background(), err, "failed to restore fake DNS mappings from ", fkdns.config.PersistPath)
+			}
+			fkdns.startPersistence()
+		}
+		return nil
 	}
 	return errors.New("invalid fakeDNS setting")
 }
 
 func (fkdns *Holder) Close() error {
+	if fkdns.persistTask != nil {
+		fkdns.persistTask.Close()
+	}
+	// a periodic save may still be running
+	fkdns.persistMu.Lock()
+	defer fkdns.persistMu.Unlock()
+	if fkdns.config != nil && fkdns.config.PersistPath != "" && fkdns.domainToIP != nil {
+		if err := fkdns.saveLocked(); err != nil {
+			errors.LogWarningInner(context.Background(), err, "fake DNS snapshot on close")
+		}
+	}
 	fkdns.domainToIP = nil
 	fkdns.ipRange = nil
 	fkdns.mu = nil
@@ -71,7 +98,7 @@ func NewFakeDNSHolder() (*Holder, error) {
 }
 
 func NewFakeDNSHolderConfigOnly(conf *FakeDnsPool) (*Holder, error) {
-	return &Holder{nil, nil, nil, conf}, nil
+	return &Holder{config: conf}, nil
 }
 
 func (fkdns *Holder) initializeFromConfig() error {
@@ -127,6 +154,7 @@ func (fkdns *Holder) GetFakeIPForDomain(domain string) []net.Address {
 		}
 	}
 	fkdns.domainToIP.Put(domain, ip)
+	fkdns.dirty.Store(true)
 	return []net.Address{ip}
 }
 
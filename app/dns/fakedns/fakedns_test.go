@@ -2,6 +2,7 @@ package fakedns
 
 import (
 	gonet "net"
+	"path/filepath"
 	"strconv"
 	"testing"
 
@@ -204,3 +205,84 @@ func TestFakeDNSMulti(t *testing.T) {
 		})
 	})
 }
+
+func TestFakeDnsHolderPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fakedns.json")
+
+	fkdns, err := NewFakeDNSHolderConfigOnly(&FakeDnsPool{
+		IpPool:      dns.FakeIPv4Pool,
+		LruSize:     256,
+		PersistPath: path,
+	})
+	common.Must(err)
+	common.Must(fkdns.Start())
+
+	addr := fkdns.GetFakeIPForDomain("fakednstest.example.com")
+	addr2 := fkdns.GetFakeIPForDomain("fakednstest2.example.com")
+	common.Must(fkdns.Close())
+
+	t.Run("restore", func(t *testing.T) {
+		restored, err := NewFakeDNSHolderConfigOnly(&FakeDnsPool{
+			IpPool:      dns.FakeIPv4Pool,
+			LruSize:     256,
+			PersistPath: path,
+		})
+		common.Must(err)
+		common.Must(restored.Start())
+
+		assert.Equal(t, "fakednstest.example.com", restored.GetDomainFromFakeDNS(addr[0]))
+		assert.Equal(t, "fakednstest2.example.com", restored.GetDomainFromFakeDNS(addr2[0]))
+		assert.Equal(t, addr[0].IP().String(), restored.GetFakeIPForDomain("fakednstest.example.com")[0].IP().String())
+	})
+
+	t.Run("shrunkPool", func(t *testing.T) {
+		restored, err := NewFakeDNSHolderConfigOnly(&FakeDnsPool{
+			IpPool:      dns.FakeIPv4Pool,
+			LruSize:     1,
+			PersistPath: path,
+		})
+		common.Must(err)
+		common.Must(restored.Start())
+
+		assert.Equal(t, "", restored.GetDomainFromFakeDNS(addr[0]))
+		assert.Equal(t, "fakednstest2.example.com", restored.GetDomainFromFakeDNS(addr2[0]))
+	})
+
+	t.Run("changedPool", func(t *testing.T) {
+		restored, err := NewFakeDNSHolderConfigOnly(&FakeDnsPool{
+			IpPool:      "240.0.0.0/12",
+			LruSize:     256,
+			PersistPath: path,
+		})
+		common.Must(err)
+		common.Must(restored.Start())
+
+		assert.Equal(t, "", restored.GetDomainFromFakeDNS(addr[0]))
+		assert.True(t, restored.IsIPInIPPool(restored.GetFakeIPForDomain("fakednstest.example.com")[0]))
+	})
+}
+
+func TestFakeDnsHolderCloseWhileSaving(t *testing.T) {
+	fkdns, err := NewFakeDNSHolderConfigOnly(&FakeDnsPool{
+		IpPool:      dns.FakeIPv4Pool,
+		LruSize:     256,
+		PersistPath: filepath.Join(t.TempDir(), "fakedns.json"),
+	})
+	common.Must(err)
+	common.Must(fkdns.Start())
+	for i := 0; i < 64; i++ {
+		fkdns.GetFakeIPForDomain("fakednstest" + strconv.Itoa(i) + ".example.com")
+	}
+
+	// saves of the periodic task may run while closing
+	var errg errgroup.Group
+	for i := 0; i < 8; i++ {
+		errg.Go(func() error {
+			fkdns.dirty.Store(true)
+			return fkdns.save()
+		})
+	}
+	errg.Go(fkdns.Close)
+	common.Must(errg.Wait())
+	common.Must(fkdns.save())
+}
@@ -0,0 +1,129 @@
+package fakedns
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/platform/filesystem"
+	"github.com/xtls/xray-core/common/task"
+)
+
+// snapshot is the on-disk form of a fake DNS pool mapping.
+type snapshot struct {
+	IPPool string `json:"ipPool"`
+	// Entries are ordered from the least to the most recently used.
+	Entries []snapshotEntry `json:"entries"`
+}
+
+type snapshotEntry struct {
+	Domain string `json:"domain"`
+	IP     string `json:"ip"`
+}
+
+func (fkdns *Holder) startPersistence() {
+	if fkdns.config.PersistInterval == 0 {
+		return
+	}
+	fkdns.persistTask = &task.Periodic{
+		Interval: time.Duration(fkdns.config.PersistInterval) * time.Second,
+		Execute: func() error {
+			if err := fkdns.save(); err != nil {
+				errors.LogWarningInner(context.Background(), err, "fake DNS periodic snapshot")
+			}
+			return nil
+		},
+	}
+	fkdns.persistTask.Start()
+}
+
+// restore loads the mapping saved by a previous run. Entries outside the
+// current pool are dropped, and only the most recently used ones are kept
+// if the pool size has shrunk.
+func (fkdns *Holder) restore() error {
+	b, err := filesystem.ReadFile(fkdns.config.PersistPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var s snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if s.IPPool != fkdns.config.IpPool {
+		errors.LogWarning(context.Background(), "fake DNS pool changed from ", s.IPPool, " to ", fkdns.config.IpPool, ", restoring mappings still inside it")
+	}
+
+	restored := 0
+	for _, e := range s.Entries {
+		ip := net.ParseAddress(e.IP)
+		if e.Domain == "" || !ip.Family().IsIP() || !fkdns.ipRange.Contains(ip.IP()) {
+			continue
+		}
+		if _, ok := fkdns.domainToIP.PeekKeyFromValue(ip); ok {
+			continue
+		}
+		fkdns.domainToIP.Put(e.Domain, ip)
+		restored++
+	}
+	errors.LogInfo(context.Background(), "restored ", restored, " fake DNS mappings from ", fkdns.config.PersistPath)
+	return nil
+}
+
+// save writes the current mapping to the persist path if it has changed since the last save.
+func (fkdns *Holder) save() error {
+	fkdns.persistMu.Lock()
+	defer fkdns.persistMu.Unlock()
+	if fkdns.domainToIP == nil {
+		// closed
+		return nil
+	}
+	return fkdns.saveLocked()
+}
+
+// saveLocked is save with persistMu held.
+func (fkdns *Holder) saveLocked() error {
+	if !fkdns.dirty.Swap(false) {
+		return nil
+	}
+
+	s := snapshot{IPPool: fkdns.config.IpPool}
+	fkdns.domainToIP.Range(func(key, value interface{}) bool {
+		s.Entries = append(s.Entries, snapshotEntry{
+			Domain: key.(string),
+			IP:     value.(net.Address).String(),
+		})
+		return true
+	})
+	b, err := json.Marshal(s)
+	if err == nil {
+		err = writeFileAtomic(fkdns.config.PersistPath, b)
+	}
+	if err != nil {
+		fkdns.dirty.Store(true)
+		return errors.New("failed to save fake DNS mappings to ", fkdns.config.PersistPath).Base(err)
+	}
+	return nil
+}
+
+func writeFileAtomic(path string, b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -24,6 +24,11 @@ type Server interface {
 	QueryIP(ctx context.Context, domain string, option dns.IPOption) ([]net.IP, uint32, error)
 }
 
+// prefetchServer is implemented by name servers whose cache can prefetch popular names.
+type prefetchServer interface {
+	enablePrefetch(ctx context.Context, config *PrefetchConfig)
+}
+
 // Client is the interface for DNS client.
 type Client struct {
 	server        Server
@@ -90,6 +95,7 @@ func NewClient(
 	ns *NameServer,
 	clientIP net.IP,
 	disableCache bool,
+	prefetch *PrefetchConfig,
 	tag string,
 	ipOption dns.IPOption,
 	matcherInfos *[]*DomainMatcherInfo,
@@ -189,6 +195,12 @@ func NewClient(
 
 		checkSystem := ns.QueryStrategy == QueryStrategy_USE_SYS
 
+		if prefetch != nil && !disableCache {
+			if ps, ok := server.(prefetchServer); ok {
+				ps.enablePrefetch(session.ContextWithInbound(ctx, &session.Inbound{Tag: tag}), prefetch)
+			}
+		}
+
 		client.server = server
 		client.skipFallback = ns.SkipFallback
 		client.domains = rules
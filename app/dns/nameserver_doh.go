@@ -117,6 +117,15 @@ func (s *DoHNameServer) Name() string {
 	return s.cacheController.name
 }
 
+func (s *DoHNameServer) enablePrefetch(ctx context.Context, config *PrefetchConfig) {
+	s.cacheController.enablePrefetch(ctx, config, s.sendQuery)
+}
+
+// Close implements common.Closable.
+func (s *DoHNameServer) Close() error {
+	return s.cacheController.Close()
+}
+
 func (s *DoHNameServer) newReqID() uint16 {
 	return 0
 }
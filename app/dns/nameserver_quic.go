@@ -64,6 +64,15 @@ func (s *QUICNameServer) Name() string {
 	return s.cacheController.name
 }
 
+func (s *QUICNameServer) enablePrefetch(ctx context.Context, config *PrefetchConfig) {
+	s.cacheController.enablePrefetch(ctx, config, s.sendQuery)
+}
+
+// Close implements common.Closable.
+func (s *QUICNameServer) Close() error {
+	return s.cacheController.Close()
+}
+
 func (s *QUICNameServer) newReqID() uint16 {
 	return 0
 }
@@ -95,6 +95,15 @@ func (s *TCPNameServer) Name() string {
 	return s.cacheController.name
 }
 
+func (s *TCPNameServer) enablePrefetch(ctx context.Context, config *PrefetchConfig) {
+	s.cacheController.enablePrefetch(ctx, config, s.sendQuery)
+}
+
+// Close implements common.Closable.
+func (s *TCPNameServer) Close() error {
+	return s.cacheController.Close()
+}
+
 func (s *TCPNameServer) newReqID() uint16 {
 	return uint16(atomic.AddUint32(&s.reqID, 1))
 }
@@ -65,6 +65,15 @@ func (s *ClassicNameServer) Name() string {
 	return s.cacheController.name
 }
 
+func (s *ClassicNameServer) enablePrefetch(ctx context.Context, config *PrefetchConfig) {
+	s.cacheController.enablePrefetch(ctx, config, s.sendQuery)
+}
+
+// Close implements common.Closable.
+func (s *ClassicNameServer) Close() error {
+	return s.cacheController.Close()
+}
+
 // RequestsCleanup clears expired items from cache
 func (s *ClassicNameServer) RequestsCleanup() error {
 	now := time.Now()
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"sync"
+	"time"
 
 	"github.com/xtls/xray-core/app/observatory"
 	"github.com/xtls/xray-core/common"
@@ -36,28 +37,24 @@ func (o *Observer) createResult() []*observatory.OutboundStatus {
 	var result []*observatory.OutboundStatus
 	o.hp.access.Lock()
 	defer o.hp.access.Unlock()
-	for name, value := range o.hp.Results {
-		status := observatory.OutboundStatus{
-			Alive:           value.getStatistics().All != value.getStatistics().Fail,
-			Delay:           value.getStatistics().Average.Milliseconds(),
-			LastErrorReason: "",
-			OutboundTag:     name,
-			LastSeenTime:    0,
-			LastTryTime:     0,
-			HealthPing: &observatory.HealthPingMeasurementResult{
-				All:       int64(value.getStatistics().All),
-				Fail:      int64(value.getStatistics().Fail),
-				Deviation: int64(value.getStatistics().Deviation),
-				Average:   int64(value.getStatistics().Average),
-				Max:       int64(value.getStatistics().Max),
-				Min:       int64(value.getStatistics().Min),
-			},
-		}
-		result = append(result, &status)
+	for name := range o.hp.Results {
+		result = append(result, o.hp.statusLockHolderOnly(name))
 	}
 	return result
 }
 
+// History implements observatory.HistoryProvider.
+func (o *Observer) History() *observatory.History {
+	return o.hp.history
+}
+
+// ObserveOutbound implements extension.PassiveObservatory.
+func (o *Observer) ObserveOutbound(outbound string, rtt time.Duration, err error) {
+	if o.config.GetEnablePassive() {
+		o.hp.PutPassiveResult(outbound, rtt, err)
+	}
+}
+
 func (o *Observer) Type() interface{} {
 	return extension.ObservatoryType()
 }
@@ -98,6 +95,11 @@ func New(ctx context.Context, config *Config) (*Observer, error) {
 		return nil, errors.New("Cannot get depended features").Base(err)
 	}
 	hp := NewHealthPing(ctx, dispatcher, config.PingConfig)
+	hp.history = observatory.NewHistory(int(config.HistorySize))
+	hp.passiveTolerance = float64(config.PassiveTolerance)
+	if hp.passiveTolerance <= 0 {
+		hp.passiveTolerance = 0.5
+	}
 	return &Observer{
 		config: config,
 		ctx:    ctx,
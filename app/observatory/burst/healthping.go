@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/xtls/xray-core/app/observatory"
 	"github.com/xtls/xray-core/common/dice"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/features/routing"
@@ -20,6 +21,8 @@ type HealthPingSettings struct {
 	SamplingCount int           `json:"sampling"`
 	Timeout       time.Duration `json:"timeout"`
 	HttpMethod    string        `json:"httpMethod"`
+
+	Probe *observatory.ProbeConfig `json:"probe"`
 }
 
 // HealthPing is the health checker for balancers
@@ -32,6 +35,76 @@ type HealthPing struct {
 
 	Settings *HealthPingSettings
 	Results  map[string]*HealthPingRTTS
+	// Failures holds the error of the last failed check of each handler
+	// since its last success
+	Failures map[string]error
+
+	history *observatory.History
+
+	// passiveTolerance is the rate of failed connections tolerated in the
+	// passive samples of a handler
+	passiveTolerance float64
+	passive          map[string]*passiveSamples
+}
+
+// passiveMinSamples is how many passive samples of a handler there must be,
+// before its rate of failures is trusted
+const passiveMinSamples = 3
+
+// passiveWeight is how much a passive sample weighs in the statistics of a
+// handler, to that of a ping
+const passiveWeight = 0.25
+
+// passiveSamples keeps whether the recent connections through a handler failed
+type passiveSamples struct {
+	failed []bool
+	idx    int
+	count  int
+	// rtts holds the dial times of the recent connections, and their failures
+	// beyond the tolerance, apart from the pings so that busy handlers keep
+	// their pings
+	rtts *HealthPingRTTS
+}
+
+func (s *passiveSamples) put(failed bool) {
+	s.failed[s.idx] = failed
+	s.idx = (s.idx + 1) % len(s.failed)
+	if s.count < len(s.failed) {
+		s.count++
+	}
+}
+
+// failureRate returns the rate of failed connections in the samples
+func (s *passiveSamples) failureRate() float64 {
+	fail := 0
+	for i := 0; i < s.count; i++ {
+		if s.failed[i] {
+			fail++
+		}
+	}
+	return float64(fail) / float64(s.count)
+}
+
+// mergePassive merges the statistics of the passive samples of a handler into
+// those of its pings, weighing every passive sample passiveWeight of a ping.
+func mergePassive(pings, passive *HealthPingStats) *HealthPingStats {
+	merged := *pings
+	merged.All += passive.All
+	merged.Fail += passive.Fail
+	n := float64(pings.All - pings.Fail)
+	m := float64(passive.All-passive.Fail) * passiveWeight
+	if m == 0 {
+		return &merged
+	}
+	merged.Average = time.Duration((float64(pings.Average)*n + float64(passive.Average)*m) / (n + m))
+	merged.Deviation = time.Duration((float64(pings.Deviation)*n + float64(passive.Deviation)*m) / (n + m))
+	if n == 0 || passive.Max > merged.Max {
+		merged.Max = passive.Max
+	}
+	if n == 0 || passive.Min < merged.Min {
+		merged.Min = passive.Min
+	}
+	return &merged
 }
 
 // NewHealthPing creates a new HealthPing with settings
@@ -53,6 +126,7 @@ func NewHealthPing(ctx context.Context, dispatcher routing.Dispatcher, config *H
 			SamplingCount: int(config.SamplingCount),
 			Timeout:       time.Duration(config.Timeout),
 			HttpMethod:    httpMethod,
+			Probe:         config.Probe,
 		}
 	}
 	if settings.Destination == "" {
@@ -147,6 +221,7 @@ func (h *HealthPing) Check(tags []string) error {
 type rtt struct {
 	handler string
 	value   time.Duration
+	err     error
 }
 
 // doCheck performs the 'rounds' amount checks in given 'duration'. You should make
@@ -160,12 +235,12 @@ func (h *HealthPing) doCheck(tags []string, duration time.Duration, rounds int)
 
 	for _, tag := range tags {
 		handler := tag
-		client := newPingClient(
-			h.ctx,
+		prober := observatory.NewProber(
 			h.dispatcher,
+			h.Settings.Probe,
 			h.Settings.Destination,
+			h.Settings.HttpMethod,
 			h.Settings.Timeout,
-			handler,
 		)
 		for i := 0; i < rounds; i++ {
 			delay := time.Duration(0)
@@ -174,7 +249,7 @@ func (h *HealthPing) doCheck(tags []string, duration time.Duration, rounds int)
 			}
 			time.AfterFunc(delay, func() {
 				errors.LogDebug(h.ctx, "checking ", handler)
-				delay, err := client.MeasureDelay(h.Settings.HttpMethod)
+				delay, err := prober.Probe(h.ctx, handler)
 				if err == nil {
 					ch <- &rtt{
 						handler: handler,
@@ -199,6 +274,7 @@ func (h *HealthPing) doCheck(tags []string, duration time.Duration, rounds int)
 				ch <- &rtt{
 					handler: handler,
 					value:   rttFailed,
+					err:     err,
 				}
 			})
 		}
@@ -208,8 +284,66 @@ func (h *HealthPing) doCheck(tags []string, duration time.Duration, rounds int)
 		if rtt.value > 0 {
 			// should not put results when network is down
 			h.PutResult(rtt.handler, rtt.value)
+			h.putFailure(rtt.handler, rtt.err)
 		}
 	}
+	h.recordHistory(tags)
+}
+
+// recordHistory records the status of tags to the history
+func (h *HealthPing) recordHistory(tags []string) {
+	if h.history == nil {
+		return
+	}
+	h.access.Lock()
+	defer h.access.Unlock()
+	for _, tag := range tags {
+		if _, ok := h.Results[tag]; ok {
+			h.history.Record(h.statusLockHolderOnly(tag))
+		}
+	}
+}
+
+// statisticsLockHolderOnly returns the statistics of the pings and passive
+// samples of tag, which must have results
+func (h *HealthPing) statisticsLockHolderOnly(tag string) *HealthPingStats {
+	stats := h.Results[tag].getStatistics()
+	if samples, ok := h.passive[tag]; ok {
+		stats = mergePassive(stats, samples.rtts.getStatistics())
+	}
+	return stats
+}
+
+// statusLockHolderOnly returns the status of tag, which must have results
+func (h *HealthPing) statusLockHolderOnly(tag string) *observatory.OutboundStatus {
+	r := h.Results[tag]
+	stats := h.statisticsLockHolderOnly(tag)
+	var lastErrorReason string
+	failure := h.Failures[tag]
+	if failure != nil {
+		lastErrorReason = failure.Error()
+	}
+	var lastTryTime int64
+	if r.rtts != nil {
+		lastTryTime = r.rtts[r.idx].time.Unix()
+	}
+	return &observatory.OutboundStatus{
+		Alive:           stats.All != stats.Fail,
+		Delay:           stats.Average.Milliseconds(),
+		LastErrorReason: lastErrorReason,
+		LastFailure:     observatory.ProbeFailureOf(failure),
+		OutboundTag:     tag,
+		LastSeenTime:    0,
+		LastTryTime:     lastTryTime,
+		HealthPing: &observatory.HealthPingMeasurementResult{
+			All:       int64(stats.All),
+			Fail:      int64(stats.Fail),
+			Deviation: int64(stats.Deviation),
+			Average:   int64(stats.Average),
+			Max:       int64(stats.Max),
+			Min:       int64(stats.Min),
+		},
+	}
 }
 
 // PutResult put a ping rtt to results
@@ -232,6 +366,67 @@ func (h *HealthPing) PutResult(tag string, rtt time.Duration) {
 	r.Put(rtt)
 }
 
+// PutPassiveResult puts the result of a connection through tag, if tag is
+// checked. rtt is the time to dial the server of tag, 0 if unknown. A failure
+// is only put once the rate of failures in the recent connections exceeds the
+// passive tolerance.
+func (h *HealthPing) PutPassiveResult(tag string, rtt time.Duration, err error) {
+	h.access.Lock()
+	defer h.access.Unlock()
+	if _, ok := h.Results[tag]; !ok {
+		return
+	}
+	if h.passive == nil {
+		h.passive = make(map[string]*passiveSamples)
+	}
+	samples, ok := h.passive[tag]
+	if !ok {
+		validity := h.Settings.Interval * time.Duration(h.Settings.SamplingCount) * 2
+		samples = &passiveSamples{
+			failed: make([]bool, h.Settings.SamplingCount),
+			rtts:   NewHealthPingResult(h.Settings.SamplingCount, validity),
+		}
+		h.passive[tag] = samples
+	}
+	samples.put(err != nil)
+
+	before := h.statisticsLockHolderOnly(tag)
+	if err != nil {
+		if samples.count < passiveMinSamples || samples.failureRate() <= h.passiveTolerance {
+			return
+		}
+		samples.rtts.Put(rttFailed)
+		if h.Failures == nil {
+			h.Failures = make(map[string]error)
+		}
+		h.Failures[tag] = err
+	} else {
+		if rtt > 0 {
+			samples.rtts.Put(rtt)
+		}
+		delete(h.Failures, tag)
+	}
+	// record the changes of liveness between checks
+	after := h.statisticsLockHolderOnly(tag)
+	if h.history != nil && (before.All != before.Fail) != (after.All != after.Fail) {
+		h.history.Record(h.statusLockHolderOnly(tag))
+	}
+}
+
+// putFailure records err as the last failure of tag, or clears it if err is nil
+func (h *HealthPing) putFailure(tag string, err error) {
+	h.access.Lock()
+	defer h.access.Unlock()
+	if err == nil {
+		delete(h.Failures, tag)
+		return
+	}
+	if h.Failures == nil {
+		h.Failures = make(map[string]error)
+	}
+	h.Failures[tag] = err
+}
+
 // Cleanup removes results of removed handlers,
 // tags should be all valid tags of the Balancer now
 func (h *HealthPing) Cleanup(tags []string) {
@@ -247,6 +442,11 @@ func (h *HealthPing) Cleanup(tags []string) {
 		}
 		if !found {
 			delete(h.Results, tag)
+			delete(h.Failures, tag)
+			delete(h.passive, tag)
+			if h.history != nil {
+				h.history.Remove(tag)
+			}
 		}
 	}
 }
@@ -0,0 +1,74 @@
+package burst
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+)
+
+func TestPutPassiveResult(t *testing.T) {
+	h := NewHealthPing(context.Background(), nil, &HealthPingConfig{SamplingCount: 10})
+	h.passiveTolerance = 0.5
+	h.PutResult("proxy", 100*time.Millisecond)
+	h.PutResult("burst", 100*time.Millisecond)
+	failure := errors.New("failed to dial")
+
+	failures := func(tag string) int {
+		h.access.Lock()
+		defer h.access.Unlock()
+		return h.statisticsLockHolderOnly(tag).Fail
+	}
+
+	// a failure is not trusted on its own
+	h.PutPassiveResult("burst", 0, failure)
+	h.PutPassiveResult("burst", 0, failure)
+	if fail := failures("burst"); fail != 0 {
+		t.Error("failures after a burst: ", fail)
+	}
+	if h.Failures["burst"] != nil {
+		t.Error("failure after a burst: ", h.Failures["burst"])
+	}
+	h.PutPassiveResult("burst", 0, failure)
+	if fail := failures("burst"); fail != 1 {
+		t.Error("failures after failures in a row: ", fail)
+	}
+
+	for i := 0; i < 4; i++ {
+		h.PutPassiveResult("proxy", 50*time.Millisecond, nil)
+	}
+	// 4 failures in 8 connections are tolerated
+	for i := 0; i < 4; i++ {
+		h.PutPassiveResult("proxy", 0, failure)
+		if fail := failures("proxy"); fail != 0 {
+			t.Fatal("failures in tolerance: ", fail)
+		}
+	}
+	h.PutPassiveResult("proxy", 0, failure)
+	if fail := failures("proxy"); fail != 1 {
+		t.Error("failures beyond tolerance: ", fail)
+	}
+	if h.Failures["proxy"] != failure {
+		t.Error("failure: ", h.Failures["proxy"])
+	}
+
+	// passive samples keep the pings of busy handlers, and weigh less
+	h.PutResult("busy", 100*time.Millisecond)
+	for i := 0; i < 20; i++ {
+		h.PutPassiveResult("busy", time.Second, nil)
+	}
+	if all := h.Results["busy"].getStatistics().All; all != 1 {
+		t.Error("pings after passive samples: ", all)
+	}
+	stats := h.statisticsLockHolderOnly("busy")
+	if stats.All != 11 || stats.Average != 742857142 {
+		t.Error("statistics of pings and passive samples: ", stats.All, " ", stats.Average)
+	}
+
+	// handlers which are not checked are ignored
+	h.PutPassiveResult("unknown", 0, failure)
+	if _, found := h.passive["unknown"]; found {
+		t.Error("sampled an unchecked handler")
+	}
+}
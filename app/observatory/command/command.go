@@ -5,6 +5,7 @@ import (
 
 	"github.com/xtls/xray-core/app/observatory"
 	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
 	core "github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/extension"
 	"google.golang.org/grpc"
@@ -28,6 +29,52 @@ func (s *service) GetOutboundStatus(ctx context.Context, request *GetOutboundSta
 	}, nil
 }
 
+func (s *service) history() (*observatory.History, error) {
+	provider, ok := s.observatory.(observatory.HistoryProvider)
+	if !ok {
+		return nil, errors.New("observatory does not keep history")
+	}
+	return provider.History(), nil
+}
+
+func (s *service) GetOutboundStatusHistory(ctx context.Context, request *GetOutboundStatusHistoryRequest) (*GetOutboundStatusHistoryResponse, error) {
+	history, err := s.history()
+	if err != nil {
+		return nil, err
+	}
+	return &GetOutboundStatusHistoryResponse{
+		History: history.Get(request.OutboundTags),
+	}, nil
+}
+
+func (s *service) SubscribeOutboundStatus(request *SubscribeOutboundStatusRequest, stream ObservatoryService_SubscribeOutboundStatusServer) error {
+	history, err := s.history()
+	if err != nil {
+		return err
+	}
+	subscriber := history.Subscribe()
+	defer subscriber.Close()
+
+	tags := make(map[string]bool)
+	for _, tag := range request.OutboundTags {
+		tags[tag] = true
+	}
+	for {
+		select {
+		case msg := <-subscriber.Wait():
+			status := msg.(*observatory.OutboundStatus)
+			if len(tags) > 0 && !tags[status.OutboundTag] {
+				continue
+			}
+			if err := stream.Send(&SubscribeOutboundStatusResponse{Status: status}); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
 func (s *service) Register(server *grpc.Server) {
 	RegisterObservatoryServiceServer(server, s)
 }
@@ -19,7 +19,9 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ObservatoryService_GetOutboundStatus_FullMethodName = "/xray.core.app.observatory.command.ObservatoryService/GetOutboundStatus"
+	ObservatoryService_GetOutboundStatus_FullMethodName        = "/xray.core.app.observatory.command.ObservatoryService/GetOutboundStatus"
+	ObservatoryService_GetOutboundStatusHistory_FullMethodName = "/xray.core.app.observatory.command.ObservatoryService/GetOutboundStatusHistory"
+	ObservatoryService_SubscribeOutboundStatus_FullMethodName  = "/xray.core.app.observatory.command.ObservatoryService/SubscribeOutboundStatus"
 )
 
 // ObservatoryServiceClient is the client API for ObservatoryService service.
@@ -27,6 +29,8 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ObservatoryServiceClient interface {
 	GetOutboundStatus(ctx context.Context, in *GetOutboundStatusRequest, opts ...grpc.CallOption) (*GetOutboundStatusResponse, error)
+	GetOutboundStatusHistory(ctx context.Context, in *GetOutboundStatusHistoryRequest, opts ...grpc.CallOption) (*GetOutboundStatusHistoryResponse, error)
+	SubscribeOutboundStatus(ctx context.Context, in *SubscribeOutboundStatusRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubscribeOutboundStatusResponse], error)
 }
 
 type observatoryServiceClient struct {
@@ -47,11 +51,42 @@ func (c *observatoryServiceClient) GetOutboundStatus(ctx context.Context, in *Ge
 	return out, nil
 }
 
+func (c *observatoryServiceClient) GetOutboundStatusHistory(ctx context.Context, in *GetOutboundStatusHistoryRequest, opts ...grpc.CallOption) (*GetOutboundStatusHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOutboundStatusHistoryResponse)
+	err := c.cc.Invoke(ctx, ObservatoryService_GetOutboundStatusHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *observatoryServiceClient) SubscribeOutboundStatus(ctx context.Context, in *SubscribeOutboundStatusRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubscribeOutboundStatusResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ObservatoryService_ServiceDesc.Streams[0], ObservatoryService_SubscribeOutboundStatus_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeOutboundStatusRequest, SubscribeOutboundStatusResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ObservatoryService_SubscribeOutboundStatusClient = grpc.ServerStreamingClient[SubscribeOutboundStatusResponse]
+
 // ObservatoryServiceServer is the server API for ObservatoryService service.
 // All implementations must embed UnimplementedObservatoryServiceServer
 // for forward compatibility.
 type ObservatoryServiceServer interface {
 	GetOutboundStatus(context.Context, *GetOutboundStatusRequest) (*GetOutboundStatusResponse, error)
+	GetOutboundStatusHistory(context.Context, *GetOutboundStatusHistoryRequest) (*GetOutboundStatusHistoryResponse, error)
+	SubscribeOutboundStatus(*SubscribeOutboundStatusRequest, grpc.ServerStreamingServer[SubscribeOutboundStatusResponse]) error
 	mustEmbedUnimplementedObservatoryServiceServer()
 }
 
@@ -65,6 +100,12 @@ type UnimplementedObservatoryServiceServer struct{}
 func (UnimplementedObservatoryServiceServer) GetOutboundStatus(context.Context, *GetOutboundStatusRequest) (*GetOutboundStatusResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetOutboundStatus not implemented")
 }
+func (UnimplementedObservatoryServiceServer) GetOutboundStatusHistory(context.Context, *GetOutboundStatusHistoryRequest) (*GetOutboundStatusHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOutboundStatusHistory not implemented")
+}
+func (UnimplementedObservatoryServiceServer) SubscribeOutboundStatus(*SubscribeOutboundStatusRequest, grpc.ServerStreamingServer[SubscribeOutboundStatusResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeOutboundStatus not implemented")
+}
 func (UnimplementedObservatoryServiceServer) mustEmbedUnimplementedObservatoryServiceServer() {}
 func (UnimplementedObservatoryServiceServer) testEmbeddedByValue()                            {}
 
@@ -104,6 +145,35 @@ func _ObservatoryService_GetOutboundStatus_Handler(srv interface{}, ctx context.
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ObservatoryService_GetOutboundStatusHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOutboundStatusHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ObservatoryServiceServer).GetOutboundStatusHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ObservatoryService_GetOutboundStatusHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ObservatoryServiceServer).GetOutboundStatusHistory(ctx, req.(*GetOutboundStatusHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ObservatoryService_SubscribeOutboundStatus_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeOutboundStatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ObservatoryServiceServer).SubscribeOutboundStatus(m, &grpc.GenericServerStream[SubscribeOutboundStatusRequest, SubscribeOutboundStatusResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ObservatoryService_SubscribeOutboundStatusServer = grpc.ServerStreamingServer[SubscribeOutboundStatusResponse]
+
 // ObservatoryService_ServiceDesc is the grpc.ServiceDesc for ObservatoryService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,7 +185,17 @@ var ObservatoryService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetOutboundStatus",
 			Handler:    _ObservatoryService_GetOutboundStatus_Handler,
 		},
+		{
+			MethodName: "GetOutboundStatusHistory",
+			Handler:    _ObservatoryService_GetOutboundStatusHistory_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeOutboundStatus",
+			Handler:       _ObservatoryService_SubscribeOutboundStatus_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "app/observatory/command/command.proto",
 }
@@ -0,0 +1,97 @@
+package observatory
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/xtls/xray-core/common/signal/pubsub"
+	"google.golang.org/protobuf/proto"
+)
+
+// defaultHistorySize is how many statuses of each outbound History keeps by
+// default.
+const defaultHistorySize = 128
+
+const historyTopic = "status"
+
+// HistoryProvider is an observatory which keeps the history of outbound
+// statuses.
+type HistoryProvider interface {
+	History() *History
+}
+
+// History keeps the recent statuses of each outbound, and publishes new ones
+// to subscribers.
+type History struct {
+	access  sync.Mutex
+	size    int
+	records map[string][]*OutboundStatus
+	pub     *pubsub.Service
+}
+
+// NewHistory creates a History which keeps size statuses of each outbound.
+func NewHistory(size int) *History {
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+	return &History{
+		size:    size,
+		records: make(map[string][]*OutboundStatus),
+		pub:     pubsub.NewService(),
+	}
+}
+
+// Record records a copy of status, and publishes it.
+func (h *History) Record(status *OutboundStatus) {
+	status = proto.Clone(status).(*OutboundStatus)
+
+	h.access.Lock()
+	records := h.records[status.OutboundTag]
+	if len(records) >= h.size {
+		copy(records, records[len(records)-h.size+1:])
+		records = records[:h.size-1]
+	}
+	h.records[status.OutboundTag] = append(records, status)
+	h.access.Unlock()
+
+	h.pub.Publish(historyTopic, status)
+}
+
+// Remove removes the history of the outbound of tag.
+func (h *History) Remove(tag string) {
+	h.access.Lock()
+	defer h.access.Unlock()
+	delete(h.records, tag)
+}
+
+// Get returns the history of the outbounds of tags, or of all outbounds if
+// tags is empty.
+func (h *History) Get(tags []string) []*OutboundStatusHistory {
+	h.access.Lock()
+	defer h.access.Unlock()
+
+	if len(tags) == 0 {
+		for tag := range h.records {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+	}
+	var result []*OutboundStatusHistory
+	for _, tag := range tags {
+		records, found := h.records[tag]
+		if !found {
+			continue
+		}
+		result = append(result, &OutboundStatusHistory{
+			OutboundTag: tag,
+			Status:      append([]*OutboundStatus(nil), records...),
+		})
+	}
+	return result
+}
+
+// Subscribe subscribes to new statuses, which are *OutboundStatus. Statuses
+// are dropped if the subscriber falls behind.
+func (h *History) Subscribe() *pubsub.Subscriber {
+	return h.pub.Subscribe(historyTopic)
+}
@@ -0,0 +1,55 @@
+package observatory_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/xtls/xray-core/app/observatory"
+)
+
+func TestHistory(t *testing.T) {
+	history := NewHistory(3)
+	subscriber := history.Subscribe()
+	defer subscriber.Close()
+
+	status := &OutboundStatus{OutboundTag: "b"}
+	for i := int64(1); i <= 5; i++ {
+		status.Delay = i
+		history.Record(status)
+	}
+	history.Record(&OutboundStatus{OutboundTag: "a", Delay: 10})
+
+	result := history.Get(nil)
+	if len(result) != 2 || result[0].OutboundTag != "a" || result[1].OutboundTag != "b" {
+		t.Fatal("unexpected history ", result)
+	}
+	if len(result[1].Status) != 3 {
+		t.Fatal("expected 3 statuses, but got ", len(result[1].Status))
+	}
+	for i, s := range result[1].Status {
+		if s.Delay != int64(i+3) {
+			t.Error("expected delay ", i+3, ", but got ", s.Delay)
+		}
+	}
+
+	if result := history.Get([]string{"a", "c"}); len(result) != 1 || result[0].Status[0].Delay != 10 {
+		t.Error("unexpected history of a ", result)
+	}
+
+	for i := int64(1); i <= 6; i++ {
+		select {
+		case msg := <-subscriber.Wait():
+			s := msg.(*OutboundStatus)
+			if i <= 5 && s.Delay != i || i == 6 && s.OutboundTag != "a" {
+				t.Error("unexpected status ", s)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for status ", i)
+		}
+	}
+
+	history.Remove("b")
+	if result := history.Get(nil); len(result) != 1 {
+		t.Error("expected the history of b to be removed")
+	}
+}
@@ -2,24 +2,20 @@ package observatory
 
 import (
 	"context"
-	"net"
 	"net/http"
-	"net/url"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/errors"
-	v2net "github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/session"
 	"github.com/xtls/xray-core/common/signal/done"
-	"github.com/xtls/xray-core/common/task"
 	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/extension"
 	"github.com/xtls/xray-core/features/outbound"
 	"github.com/xtls/xray-core/features/routing"
-	"github.com/xtls/xray-core/transport/internet/tagged"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -34,12 +30,28 @@ type Observer struct {
 
 	ohm        outbound.Manager
 	dispatcher routing.Dispatcher
+
+	history *History
+
+	// passiveFailures counts the connections in a row each outbound failed
+	// to relay. Guarded by statusLock.
+	passiveFailures map[string]int
 }
 
+// passiveFailureThreshold is how many connections in a row an outbound fails
+// to relay before it is considered dead, so that a few unreachable targets
+// do not take it down.
+const passiveFailureThreshold = 3
+
 func (o *Observer) GetObservation(ctx context.Context) (proto.Message, error) {
 	return &ObservationResult{Status: o.status}, nil
 }
 
+// History implements HistoryProvider.
+func (o *Observer) History() *History {
+	return o.history
+}
+
 func (o *Observer) Type() interface{} {
 	return extension.ObservatoryType()
 }
@@ -119,67 +131,21 @@ func (o *Observer) updateStatus(outbounds []string) {
 
 func (o *Observer) probe(outbound string) ProbeResult {
 	errorCollectorForRequest := newErrorCollector()
+	trackedCtx := session.TrackedConnectionError(o.ctx, errorCollectorForRequest)
 
-	httpTransport := http.Transport{
-		Proxy: func(*http.Request) (*url.URL, error) {
-			return nil, nil
-		},
-		DialContext: func(ctx context.Context, network string, addr string) (net.Conn, error) {
-			var connection net.Conn
-			taskErr := task.Run(ctx, func() error {
-				// MUST use Xray's built in context system
-				dest, err := v2net.ParseDestination(network + ":" + addr)
-				if err != nil {
-					return errors.New("cannot understand address").Base(err)
-				}
-				trackedCtx := session.TrackedConnectionError(o.ctx, errorCollectorForRequest)
-				conn, err := tagged.Dialer(trackedCtx, o.dispatcher, dest, outbound)
-				if err != nil {
-					return errors.New("cannot dial remote address ", dest).Base(err)
-				}
-				connection = conn
-				return nil
-			})
-			if taskErr != nil {
-				return nil, errors.New("cannot finish connection").Base(taskErr)
-			}
-			return connection, nil
-		},
-		TLSHandshakeTimeout: time.Second * 5,
-	}
-	httpClient := &http.Client{
-		Transport: &httpTransport,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-		Jar:     nil,
-		Timeout: time.Second * 5,
+	probeURL := "https://www.google.com/generate_204"
+	if o.config.ProbeUrl != "" {
+		probeURL = o.config.ProbeUrl
 	}
-	var GETTime time.Duration
-	err := task.Run(o.ctx, func() error {
-		startTime := time.Now()
-		probeURL := "https://www.google.com/generate_204"
-		if o.config.ProbeUrl != "" {
-			probeURL = o.config.ProbeUrl
-		}
-		response, err := httpClient.Get(probeURL)
-		if err != nil {
-			return errors.New("outbound failed to relay connection").Base(err)
-		}
-		if response.Body != nil {
-			response.Body.Close()
-		}
-		endTime := time.Now()
-		GETTime = endTime.Sub(startTime)
-		return nil
-	})
+	prober := NewProber(o.dispatcher, o.config.Probe, probeURL, http.MethodGet, time.Second*5)
+	delay, err := prober.Probe(trackedCtx, outbound)
 	if err != nil {
-		var errorMessage = "the outbound " + outbound + " is dead: GET request failed:" + err.Error() + "with outbound handler report underlying connection failed"
+		var errorMessage = "the outbound " + outbound + " is dead: " + o.config.Probe.GetKind().String() + " probe failed:" + err.Error() + "with outbound handler report underlying connection failed"
 		errors.LogInfoInner(o.ctx, errorCollectorForRequest.UnderlyingError(), errorMessage)
-		return ProbeResult{Alive: false, LastErrorReason: errorMessage}
+		return ProbeResult{Alive: false, LastErrorReason: errorMessage, Failure: ProbeFailureOf(err)}
 	}
-	errors.LogInfo(o.ctx, "the outbound ", outbound, " is alive:", GETTime.Seconds())
-	return ProbeResult{Alive: true, Delay: GETTime.Milliseconds()}
+	errors.LogInfo(o.ctx, "the outbound ", outbound, " is alive:", delay.Seconds())
+	return ProbeResult{Alive: true, Delay: delay.Milliseconds()}
 }
 
 func (o *Observer) updateStatusForResult(outbound string, result *ProbeResult) {
@@ -200,9 +166,66 @@ func (o *Observer) updateStatusForResult(outbound string, result *ProbeResult) {
 		status.Delay = result.Delay
 		status.LastSeenTime = status.LastTryTime
 		status.LastErrorReason = ""
+		status.LastFailure = ProbeFailure_NONE
+		delete(o.passiveFailures, outbound)
 	} else {
 		status.LastErrorReason = result.LastErrorReason
+		status.LastFailure = result.Failure
+		status.Delay = 99999999
+	}
+	o.history.Record(status)
+}
+
+// ObserveOutbound implements extension.PassiveObservatory.
+func (o *Observer) ObserveOutbound(outbound string, rtt time.Duration, err error) {
+	if !o.config.GetEnablePassive() {
+		return
+	}
+	o.statusLock.Lock()
+	defer o.statusLock.Unlock()
+	// only refine the status of outbounds which have been probed
+	location := o.findStatusLocationLockHolderOnly(outbound)
+	if location == -1 {
+		return
+	}
+	status := o.status[location]
+
+	if err != nil {
+		if o.passiveFailures == nil {
+			o.passiveFailures = make(map[string]int)
+		}
+		o.passiveFailures[outbound]++
+		failures := o.passiveFailures[outbound]
+		if failures < passiveFailureThreshold {
+			return
+		}
+		status.LastTryTime = time.Now().Unix()
+		status.Alive = false
+		status.LastErrorReason = "the outbound " + outbound + " failed to relay " + strconv.Itoa(failures) + " connections in a row: " + err.Error()
+		status.LastFailure = ProbeFailure_CONNECTION
 		status.Delay = 99999999
+		if failures == passiveFailureThreshold {
+			o.history.Record(status)
+		}
+		return
+	}
+
+	delete(o.passiveFailures, outbound)
+	status.LastTryTime = time.Now().Unix()
+	status.LastSeenTime = status.LastTryTime
+	if status.Alive {
+		// weigh the passive samples less than the probes
+		if rtt > 0 {
+			status.Delay = (status.Delay*3 + rtt.Milliseconds()) / 4
+		}
+	} else {
+		status.Alive = true
+		if rtt > 0 {
+			status.Delay = rtt.Milliseconds()
+		}
+		status.LastErrorReason = ""
+		status.LastFailure = ProbeFailure_NONE
+		o.history.Record(status)
 	}
 }
 
@@ -230,6 +253,7 @@ func New(ctx context.Context, config *Config) (*Observer, error) {
 		ctx:        ctx,
 		ohm:        outboundManager,
 		dispatcher: dispatcher,
+		history:    NewHistory(int(config.HistorySize)),
 	}, nil
 }
 
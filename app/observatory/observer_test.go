@@ -0,0 +1,50 @@
+package observatory
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestObserveOutbound(t *testing.T) {
+	o := &Observer{config: &Config{EnablePassive: true}, history: NewHistory(0)}
+	o.ObserveOutbound("proxy", time.Second, nil)
+	if len(o.status) != 0 {
+		t.Fatal("expected no status of outbounds which have not been probed")
+	}
+
+	o.updateStatusForResult("proxy", &ProbeResult{Alive: true, Delay: 100})
+	o.ObserveOutbound("proxy", 500*time.Millisecond, nil)
+	status := o.status[0]
+	if !status.Alive || status.Delay != 200 {
+		t.Error("expected alive with delay 200, but got ", status.Alive, " ", status.Delay)
+	}
+
+	for i := 1; i < passiveFailureThreshold; i++ {
+		o.ObserveOutbound("proxy", time.Second, errors.New("connection refused"))
+	}
+	if !status.Alive {
+		t.Error("expected alive before ", passiveFailureThreshold, " failures in a row")
+	}
+	o.ObserveOutbound("proxy", time.Second, errors.New("connection refused"))
+	if status.Alive || status.LastFailure != ProbeFailure_CONNECTION {
+		t.Error("expected dead after ", passiveFailureThreshold, " failures in a row")
+	}
+
+	o.ObserveOutbound("proxy", 300*time.Millisecond, nil)
+	if !status.Alive || status.Delay != 300 || status.LastErrorReason != "" {
+		t.Error("expected alive again with delay 300, but got ", status.Alive, " ", status.Delay)
+	}
+
+	// connections over existing connections do not tell the round trip time
+	o.ObserveOutbound("proxy", 0, nil)
+	if status.Delay != 300 {
+		t.Error("expected delay 300 after a sample without round trip time, but got ", status.Delay)
+	}
+
+	o.config.EnablePassive = false
+	o.ObserveOutbound("proxy", time.Second, nil)
+	if status.Delay != 300 {
+		t.Error("expected no change when passive observation is disabled")
+	}
+}
@@ -0,0 +1,313 @@
+package observatory
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	gotls "crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	v2net "github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport/internet/tagged"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// maxProbeBodySize is how much of the body HTTP probes read at most.
+const maxProbeBodySize = 64 * 1024
+
+// ProbeError is the error of a failed probe.
+type ProbeError struct {
+	Failure ProbeFailure
+	err     error
+}
+
+func (e *ProbeError) Error() string {
+	return strings.ToLower(e.Failure.String()) + ": " + e.err.Error()
+}
+
+func (e *ProbeError) Unwrap() error {
+	return e.err
+}
+
+func newProbeError(failure ProbeFailure, err error) *ProbeError {
+	return &ProbeError{Failure: failure, err: err}
+}
+
+// ProbeFailureOf returns why a probe failed with err.
+func ProbeFailureOf(err error) ProbeFailure {
+	if err == nil {
+		return ProbeFailure_NONE
+	}
+	if probeErr, ok := err.(*ProbeError); ok {
+		return probeErr.Failure
+	}
+	return ProbeFailure_CONNECTION
+}
+
+// Prober measures the round trip time of a probe through outbounds.
+type Prober struct {
+	dispatcher routing.Dispatcher
+	config     *ProbeConfig
+	url        string
+	method     string
+	timeout    time.Duration
+}
+
+// NewProber creates a Prober of config. HTTP probes send a request of method
+// to url.
+func NewProber(dispatcher routing.Dispatcher, config *ProbeConfig, url string, method string, timeout time.Duration) *Prober {
+	if config == nil {
+		config = &ProbeConfig{}
+	}
+	return &Prober{
+		dispatcher: dispatcher,
+		config:     config,
+		url:        url,
+		method:     method,
+		timeout:    timeout,
+	}
+}
+
+// Probe probes through the outbound, and returns the round trip time. ctx
+// must hold the Xray instance.
+func (p *Prober) Probe(ctx context.Context, outbound string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	var rtt time.Duration
+	var err error
+	switch p.config.Kind {
+	case ProbeKind_TCP:
+		rtt, err = p.probeTCP(ctx, outbound)
+	case ProbeKind_TLS:
+		rtt, err = p.probeTLS(ctx, outbound)
+	case ProbeKind_DNS:
+		rtt, err = p.probeDNS(ctx, outbound)
+	case ProbeKind_UDP:
+		rtt, err = p.probeUDP(ctx, outbound)
+	default:
+		rtt, err = p.probeHTTP(ctx, outbound)
+	}
+	if err != nil && ctx.Err() != nil {
+		if _, ok := err.(*ProbeError); !ok {
+			err = newProbeError(ProbeFailure_TIMEOUT, err)
+		}
+	}
+	return rtt, err
+}
+
+// dial dials to the destination of config through the outbound. The
+// connection is closed when ctx is done.
+func (p *Prober) dial(ctx context.Context, network v2net.Network, outbound string) (net.Conn, error) {
+	dest, err := v2net.ParseDestination(p.config.Destination)
+	if err != nil {
+		return nil, errors.New("invalid probe destination ", p.config.Destination).Base(err)
+	}
+	dest.Network = network
+	conn, err := tagged.Dialer(ctx, p.dispatcher, dest, outbound)
+	if err != nil {
+		return nil, errors.New("cannot dial remote address ", dest).Base(err)
+	}
+	context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	return conn, nil
+}
+
+// probeTCP succeeds once the first byte of reply arrives, to payload if it is
+// set. Outbounds tell nothing of the connection to destination until then, so
+// servers which wait for the client to speak first need a payload.
+func (p *Prober) probeTCP(ctx context.Context, outbound string) (time.Duration, error) {
+	start := time.Now()
+	conn, err := p.dial(ctx, v2net.Network_TCP, outbound)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if len(p.config.Payload) > 0 {
+		if _, err := conn.Write(p.config.Payload); err != nil {
+			return 0, err
+		}
+	}
+	var b [1]byte
+	if _, err := conn.Read(b[:]); err != nil {
+		return 0, errors.New("no reply").Base(err)
+	}
+	return time.Since(start), nil
+}
+
+func (p *Prober) probeTLS(ctx context.Context, outbound string) (time.Duration, error) {
+	serverName := p.config.ServerName
+	if serverName == "" {
+		if host, _, err := net.SplitHostPort(p.config.Destination); err == nil {
+			serverName = host
+		}
+	}
+
+	start := time.Now()
+	conn, err := p.dial(ctx, v2net.Network_TCP, outbound)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	tlsConn := gotls.Client(conn, &gotls.Config{
+		ServerName: serverName,
+	})
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		if ctx.Err() != nil {
+			return 0, err
+		}
+		return 0, newProbeError(ProbeFailure_TLS_HANDSHAKE, err)
+	}
+	return time.Since(start), nil
+}
+
+func (p *Prober) probeDNS(ctx context.Context, outbound string) (time.Duration, error) {
+	domain := p.config.Domain
+	if domain == "" {
+		domain = "www.google.com"
+	}
+	if !strings.HasSuffix(domain, ".") {
+		domain += "."
+	}
+	name, err := dnsmessage.NewName(domain)
+	if err != nil {
+		return 0, errors.New("invalid probe domain ", domain).Base(err)
+	}
+	var id [2]byte
+	rand.Read(id[:])
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               uint16(id[0])<<8 | uint16(id[1]),
+			RecursionDesired: true,
+		},
+		Questions: []dnsmessage.Question{{
+			Name:  name,
+			Type:  dnsmessage.TypeA,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	packet, err := query.Pack()
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	conn, err := p.dial(ctx, v2net.Network_UDP, outbound)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(packet); err != nil {
+		return 0, err
+	}
+	b := make([]byte, 2048)
+	for {
+		n, err := conn.Read(b)
+		if err != nil {
+			return 0, errors.New("no reply").Base(err)
+		}
+		var response dnsmessage.Message
+		if err := response.Unpack(b[:n]); err != nil || response.ID != query.ID {
+			continue
+		}
+		if response.RCode != dnsmessage.RCodeSuccess {
+			return 0, newProbeError(ProbeFailure_DNS_RESPONSE, errors.New("DNS server answered ", response.RCode.String()))
+		}
+		if len(response.Answers) == 0 {
+			return 0, newProbeError(ProbeFailure_DNS_RESPONSE, errors.New("no answer of ", domain))
+		}
+		return time.Since(start), nil
+	}
+}
+
+func (p *Prober) probeUDP(ctx context.Context, outbound string) (time.Duration, error) {
+	payload := p.config.Payload
+	if len(payload) == 0 {
+		payload = make([]byte, 16)
+		rand.Read(payload)
+	}
+
+	start := time.Now()
+	conn, err := p.dial(ctx, v2net.Network_UDP, outbound)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(payload); err != nil {
+		return 0, err
+	}
+	b := make([]byte, len(payload)+1)
+	n, err := conn.Read(b)
+	if err != nil {
+		return 0, errors.New("no reply").Base(err)
+	}
+	if !bytes.Equal(b[:n], payload) {
+		return 0, newProbeError(ProbeFailure_UNEXPECTED_REPLY, errors.New("reply is not the echo of payload"))
+	}
+	return time.Since(start), nil
+}
+
+func (p *Prober) probeHTTP(ctx context.Context, outbound string) (time.Duration, error) {
+	transport := &http.Transport{
+		Proxy: func(*http.Request) (*url.URL, error) {
+			return nil, nil
+		},
+		DialContext: func(dialCtx context.Context, network string, addr string) (net.Conn, error) {
+			dest, err := v2net.ParseDestination(network + ":" + addr)
+			if err != nil {
+				return nil, errors.New("cannot understand address").Base(err)
+			}
+			conn, err := tagged.Dialer(ctx, p.dispatcher, dest, outbound)
+			if err != nil {
+				return nil, errors.New("cannot dial remote address ", dest).Base(err)
+			}
+			return conn, nil
+		},
+		DisableKeepAlives:   true,
+		TLSHandshakeTimeout: p.timeout,
+	}
+	client := &http.Client{
+		Transport: transport,
+		// don't follow redirect
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	request, err := http.NewRequestWithContext(ctx, p.method, p.url, nil)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if p.config.ExpectedStatus != 0 && response.StatusCode != int(p.config.ExpectedStatus) {
+		return 0, newProbeError(ProbeFailure_UNEXPECTED_STATUS, errors.New("unexpected status ", response.Status))
+	}
+	if p.config.ExpectedBody != "" {
+		body, err := io.ReadAll(io.LimitReader(response.Body, maxProbeBodySize))
+		if err != nil {
+			return 0, err
+		}
+		if !strings.Contains(string(body), p.config.ExpectedBody) {
+			return 0, newProbeError(ProbeFailure_UNEXPECTED_BODY, errors.New("body does not contain ", p.config.ExpectedBody))
+		}
+	}
+	return time.Since(start), nil
+}
@@ -0,0 +1,154 @@
+package observatory
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/app/dispatcher"
+	"github.com/xtls/xray-core/app/proxyman"
+	_ "github.com/xtls/xray-core/app/proxyman/outbound"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/extension"
+	"github.com/xtls/xray-core/proxy/freedom"
+	"github.com/xtls/xray-core/testing/servers/tcp"
+	"github.com/xtls/xray-core/testing/servers/udp"
+	_ "github.com/xtls/xray-core/transport/internet/tagged/taggedimpl"
+	_ "github.com/xtls/xray-core/transport/internet/tcp"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// probe probes through the freedom outbound "proxy" of a new instance.
+func probe(config *ProbeConfig, url string) (time.Duration, error) {
+	instance, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&Config{}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{{
+			Tag:           "proxy",
+			ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+		}},
+	})
+	common.Must(err)
+	common.Must(instance.Start())
+	defer instance.Close()
+
+	observer := instance.GetFeature(extension.ObservatoryType()).(*Observer)
+	return NewProber(observer.dispatcher, config, url, http.MethodGet, time.Second*2).Probe(observer.ctx, "proxy")
+}
+
+func TestProbeTCPAndTLS(t *testing.T) {
+	tcpServer := tcp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	dest, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+
+	if _, err := probe(&ProbeConfig{Kind: ProbeKind_TCP, Destination: dest.NetAddr(), Payload: []byte("ping")}, ""); err != nil {
+		t.Error(err)
+	}
+
+	_, err = probe(&ProbeConfig{Kind: ProbeKind_TLS, Destination: dest.NetAddr()}, "")
+	if failure := ProbeFailureOf(err); failure != ProbeFailure_TLS_HANDSHAKE {
+		t.Error("expected TLS handshake failure, but got ", failure, ": ", err)
+	}
+
+	// the server sends nothing before the client does
+	_, err = probe(&ProbeConfig{Kind: ProbeKind_TCP, Destination: dest.NetAddr()}, "")
+	if failure := ProbeFailureOf(err); failure != ProbeFailure_TIMEOUT {
+		t.Error("expected timeout, but got ", failure, ": ", err)
+	}
+
+	tcpServer.Close()
+	if _, err := probe(&ProbeConfig{Kind: ProbeKind_TCP, Destination: dest.NetAddr()}, ""); err == nil {
+		t.Error("probe of a closed port succeeded")
+	}
+
+	bannerServer := tcp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+		SendFirst: []byte("SSH-2.0-OpenSSH\r\n"),
+	}
+	dest, err = bannerServer.Start()
+	common.Must(err)
+	defer bannerServer.Close()
+
+	if _, err := probe(&ProbeConfig{Kind: ProbeKind_TCP, Destination: dest.NetAddr()}, ""); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProbeUDPAndDNS(t *testing.T) {
+	echoServer := udp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	echoDest, err := echoServer.Start()
+	common.Must(err)
+	defer echoServer.Close()
+
+	if _, err := probe(&ProbeConfig{Kind: ProbeKind_UDP, Destination: echoDest.NetAddr()}, ""); err != nil {
+		t.Error(err)
+	}
+
+	for _, rcode := range []dnsmessage.RCode{dnsmessage.RCodeSuccess, dnsmessage.RCodeServerFailure} {
+		dnsServer := udp.Server{
+			MsgProcessor: func(msg []byte) []byte {
+				var query dnsmessage.Message
+				common.Must(query.Unpack(msg))
+				response := dnsmessage.Message{
+					Header:    dnsmessage.Header{ID: query.ID, Response: true, RCode: rcode},
+					Questions: query.Questions,
+				}
+				if rcode == dnsmessage.RCodeSuccess {
+					response.Answers = []dnsmessage.Resource{{
+						Header: dnsmessage.ResourceHeader{Name: query.Questions[0].Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET},
+						Body:   &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}},
+					}}
+				}
+				return common.Must2(response.Pack())
+			},
+		}
+		dnsDest, err := dnsServer.Start()
+		common.Must(err)
+
+		_, err = probe(&ProbeConfig{Kind: ProbeKind_DNS, Destination: dnsDest.NetAddr(), Domain: "example.com"}, "")
+		dnsServer.Close()
+		if failure := ProbeFailureOf(err); rcode == dnsmessage.RCodeSuccess && err != nil {
+			t.Error(err)
+		} else if rcode != dnsmessage.RCodeSuccess && failure != ProbeFailure_DNS_RESPONSE {
+			t.Error("expected DNS response failure, but got ", failure, ": ", err)
+		}
+	}
+}
+
+func TestProbeHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	if _, err := probe(&ProbeConfig{ExpectedStatus: http.StatusNoContent}, server.URL); err != nil {
+		t.Error(err)
+	}
+
+	_, err := probe(&ProbeConfig{ExpectedStatus: http.StatusOK}, server.URL)
+	if failure := ProbeFailureOf(err); failure != ProbeFailure_UNEXPECTED_STATUS {
+		t.Error("expected unexpected status, but got ", failure, ": ", err)
+	}
+
+	_, err = probe(&ProbeConfig{ExpectedBody: "ok"}, server.URL)
+	if failure := ProbeFailureOf(err); failure != ProbeFailure_UNEXPECTED_BODY {
+		t.Error("expected unexpected body, but got ", failure, ": ", err)
+	}
+}
@@ -89,6 +89,7 @@ func (p *SystemPolicy) ToCorePolicy() policy.System {
 			InboundDownlink:  p.Stats.InboundDownlink,
 			OutboundUplink:   p.Stats.OutboundUplink,
 			OutboundDownlink: p.Stats.OutboundDownlink,
+			InboundTransport: p.Stats.InboundTransport,
 		},
 	}
 }
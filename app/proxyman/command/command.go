@@ -6,6 +6,7 @@ import (
 	"github.com/xtls/xray-core/app/commander"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/mux"
 	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/features/inbound"
@@ -204,6 +205,44 @@ func (s *handlerServer) ListOutbounds(ctx context.Context, request *ListOutbound
 	return response, nil
 }
 
+func (s *handlerServer) GetOutboundMuxStats(ctx context.Context, request *GetOutboundMuxStatsRequest) (*GetOutboundMuxStatsResponse, error) {
+	handler := s.ohm.GetHandler(request.Tag)
+	if handler == nil {
+		return nil, errors.New("outbound not found: ", request.Tag)
+	}
+	h, ok := handler.(interface {
+		MuxWorkerStats() ([]mux.WorkerStats, []mux.WorkerStats)
+	})
+	if !ok {
+		return nil, errors.New("outbound has no Mux workers: ", request.Tag)
+	}
+	muxWorkers, xudpWorkers := h.MuxWorkerStats()
+	response := &GetOutboundMuxStatsResponse{}
+	for _, w := range muxWorkers {
+		response.Workers = append(response.Workers, toMuxWorkerStats(w, false))
+	}
+	for _, w := range xudpWorkers {
+		response.Workers = append(response.Workers, toMuxWorkerStats(w, true))
+	}
+	return response, nil
+}
+
+func toMuxWorkerStats(w mux.WorkerStats, xudp bool) *MuxWorkerStats {
+	return &MuxWorkerStats{
+		Id:            w.ID,
+		CreatedAt:     w.Created.Unix(),
+		ActiveStreams: w.ActiveStreams,
+		TotalStreams:  w.TotalStreams,
+		Concurrency:   w.Concurrency,
+		UplinkBytes:   w.UplinkBytes,
+		DownlinkBytes: w.DownlinkBytes,
+		Throughput:    w.Throughput,
+		LatencyMs:     w.Latency.Milliseconds(),
+		Draining:      w.Draining,
+		Xudp:          xudp,
+	}
+}
+
 func (s *handlerServer) mustEmbedUnimplementedHandlerServiceServer() {}
 
 type service struct {
@@ -16,6 +16,8 @@ import (
 	"github.com/xtls/xray-core/common/session"
 	"github.com/xtls/xray-core/common/signal/done"
 	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/policy"
 	"github.com/xtls/xray-core/features/routing"
 	"github.com/xtls/xray-core/features/stats"
 	"github.com/xtls/xray-core/proxy"
@@ -50,6 +52,25 @@ type tcpWorker struct {
 	ctx context.Context
 }
 
+// listenContext returns the context to listen in. If transport stats are
+// enabled, it holds the stats manager and the tag of the inbound, which
+// transports register their counters with.
+func listenContext(ctx context.Context, tag string) context.Context {
+	listenCtx := context.Background()
+	if len(tag) == 0 {
+		return listenCtx
+	}
+	v := core.FromContext(ctx)
+	if v == nil {
+		return listenCtx
+	}
+	if !v.GetFeature(policy.ManagerType()).(policy.Manager).ForSystem().Stats.InboundTransport {
+		return listenCtx
+	}
+	listenCtx = stats.ContextWithManager(listenCtx, v.GetFeature(stats.ManagerType()).(stats.Manager))
+	return session.ContextWithInbound(listenCtx, &session.Inbound{Tag: tag})
+}
+
 func getTProxyType(s *internet.MemoryStreamConfig) internet.SocketConfig_TProxyMode {
 	if s == nil || s.SocketSettings == nil {
 		return internet.SocketConfig_Off
@@ -90,11 +111,12 @@ func (w *tcpWorker) callback(conn stat.Connection) {
 		}
 	}
 	ctx = session.ContextWithInbound(ctx, &session.Inbound{
-		Source:  net.DestinationFromAddr(conn.RemoteAddr()),
-		Local:   net.DestinationFromAddr(conn.LocalAddr()),
-		Gateway: net.TCPDestination(w.address, w.port),
-		Tag:     w.tag,
-		Conn:    conn,
+		Source:       net.DestinationFromAddr(conn.RemoteAddr()),
+		Local:        net.DestinationFromAddr(conn.LocalAddr()),
+		Gateway:      net.TCPDestination(w.address, w.port),
+		Tag:          w.tag,
+		Conn:         conn,
+		PeerIdentity: stat.PeerIdentity(conn),
 	})
 
 	content := new(session.Content)
@@ -119,7 +141,7 @@ func (w *tcpWorker) Proxy() proxy.Inbound {
 }
 
 func (w *tcpWorker) Start() error {
-	ctx := context.Background()
+	ctx := listenContext(w.ctx, w.tag)
 	hub, err := internet.ListenTCP(ctx, w.address, w.port, w.stream, func(conn stat.Connection) {
 		go w.callback(conn)
 	})
@@ -474,11 +496,12 @@ func (w *dsWorker) callback(conn stat.Connection) {
 		}
 	}
 	ctx = session.ContextWithInbound(ctx, &session.Inbound{
-		Source:  net.DestinationFromAddr(conn.RemoteAddr()),
-		Local:   net.DestinationFromAddr(conn.LocalAddr()),
-		Gateway: net.UnixDestination(w.address),
-		Tag:     w.tag,
-		Conn:    conn,
+		Source:       net.DestinationFromAddr(conn.RemoteAddr()),
+		Local:        net.DestinationFromAddr(conn.LocalAddr()),
+		Gateway:      net.UnixDestination(w.address),
+		Tag:          w.tag,
+		Conn:         conn,
+		PeerIdentity: stat.PeerIdentity(conn),
 	})
 
 	content := new(session.Content)
@@ -509,7 +532,7 @@ func (w *dsWorker) Port() net.Port {
 }
 
 func (w *dsWorker) Start() error {
-	ctx := context.Background()
+	ctx := listenContext(w.ctx, w.tag)
 	hub, err := internet.ListenUnix(ctx, w.address, w.stream, func(conn stat.Connection) {
 		go w.callback(conn)
 	})
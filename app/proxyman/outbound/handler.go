@@ -9,6 +9,7 @@ import (
 	"math/big"
 	gonet "net"
 	"os"
+	"time"
 
 	"github.com/xtls/xray-core/app/proxyman"
 	"github.com/xtls/xray-core/common"
@@ -20,6 +21,7 @@ import (
 	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/common/session"
 	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/extension"
 	"github.com/xtls/xray-core/features/outbound"
 	"github.com/xtls/xray-core/features/policy"
 	"github.com/xtls/xray-core/features/stats"
@@ -70,6 +72,7 @@ type Handler struct {
 	udp443          string
 	uplinkCounter   stats.Counter
 	downlinkCounter stats.Counter
+	observatory     extension.PassiveObservatory
 }
 
 // NewHandler creates a new Handler based on the given configuration.
@@ -82,6 +85,11 @@ func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbou
 		uplinkCounter:   uplinkCounter,
 		downlinkCounter: downlinkCounter,
 	}
+	if len(config.Tag) > 0 {
+		core.OptionalFeatures(ctx, func(o extension.Observatory) {
+			h.observatory, _ = o.(extension.PassiveObservatory)
+		})
+	}
 
 	if config.SenderSettings != nil {
 		senderSettings, err := config.SenderSettings.GetInstance()
@@ -133,8 +141,12 @@ func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbou
 							Proxy:  proxyHandler,
 							Dialer: h,
 							Strategy: mux.ClientStrategy{
-								MaxConcurrency: uint32(config.Concurrency),
-								MaxConnection:  128,
+								MaxConcurrency:    uint32(config.Concurrency),
+								MaxConnection:     128,
+								Adaptive:          config.Adaptive,
+								MaxLifetime:       time.Duration(config.MaxLifetime) * time.Second,
+								MaxBytes:          config.MaxBytes,
+								FlowControlWindow: config.FlowControlWindow,
 							},
 						},
 					},
@@ -156,6 +168,9 @@ func NewHandler(ctx context.Context, config *core.OutboundHandlerConfig) (outbou
 							Strategy: mux.ClientStrategy{
 								MaxConcurrency: uint32(config.XudpConcurrency),
 								MaxConnection:  128,
+								Adaptive:       config.Adaptive,
+								MaxLifetime:    time.Duration(config.MaxLifetime) * time.Second,
+								MaxBytes:       config.MaxBytes,
 							},
 						},
 					},
@@ -201,6 +216,11 @@ func (h *Handler) Dispatch(ctx context.Context, link *transport.Link) {
 		link.Reader = &buf.EndpointOverrideReader{Reader: link.Reader, Dest: ob.Target.Address, OriginalDest: ob.OriginalTarget.Address}
 		link.Writer = &buf.EndpointOverrideWriter{Writer: link.Writer, Dest: ob.Target.Address, OriginalDest: ob.OriginalTarget.Address}
 	}
+	if h.observatory != nil {
+		observed := newObservedWriter(link.Writer, h.observatory, h.tag)
+		link.Writer = observed
+		ctx = contextWithObservedWriter(ctx, observed)
+	}
 	if h.mux != nil {
 		test := func(err error) {
 			if err != nil {
@@ -334,14 +354,44 @@ func (h *Handler) Dial(ctx context.Context, dest net.Destination) (stat.Connecti
 		return conn, err
 	}
 
+	start := time.Now()
 	conn, err := internet.Dial(ctx, dest, h.streamSettings)
 	conn = h.getStatCouterConnection(conn)
 	outbounds := session.OutboundsFromContext(ctx)
 	ob := outbounds[len(outbounds)-1]
 	ob.Conn = conn
+	h.observeDial(ctx, ob, dest, time.Since(start), err)
 	return conn, err
 }
 
+// observeDial reports to the observatory how the outbound dialed its server,
+// in rtt, or failed to with err. Dials of the target itself, as of direct
+// outbounds, tell nothing about the health of the outbound.
+func (h *Handler) observeDial(ctx context.Context, ob *session.Outbound, dest net.Destination, rtt time.Duration, err error) {
+	if h.observatory == nil || dest == ob.Target {
+		return
+	}
+	switch ob.Name {
+	case "freedom", "dns":
+		return
+	}
+	observed := observedWriterFromContext(ctx)
+	if err == nil {
+		// the connection is reported once its first response is written back
+		if observed != nil {
+			observed.Dialed(rtt)
+		}
+		return
+	}
+	err = errors.New("failed to dial ", dest).Base(err)
+	if observed != nil {
+		observed.Fail(err)
+		return
+	}
+	// mux workers dial outside of the connections they carry
+	h.observatory.ObserveOutbound(h.tag, 0, err)
+}
+
 func (h *Handler) getStatCouterConnection(conn stat.Connection) stat.Connection {
 	if h.uplinkCounter != nil || h.downlinkCounter != nil {
 		return &stat.CounterConnection{
@@ -358,6 +408,17 @@ func (h *Handler) GetOutbound() proxy.Outbound {
 	return h.proxy
 }
 
+// MuxWorkerStats returns the stats of the Mux and XUDP workers of this handler.
+func (h *Handler) MuxWorkerStats() (muxWorkers []mux.WorkerStats, xudpWorkers []mux.WorkerStats) {
+	if h.mux != nil && h.mux.Enabled {
+		muxWorkers = h.mux.WorkerStats()
+	}
+	if h.xudp != nil && h.xudp.Enabled {
+		xudpWorkers = h.xudp.WorkerStats()
+	}
+	return
+}
+
 // Start implements common.Runnable.
 func (h *Handler) Start() error {
 	return nil
@@ -145,6 +145,53 @@ func (m *Manager) RemoveHandler(ctx context.Context, tag string) error {
 	return nil
 }
 
+// ReplaceHandlers implements outbound.HandlerReplacer.
+func (m *Manager) ReplaceHandlers(ctx context.Context, tags []string, handlers []outbound.Handler) error {
+	m.access.Lock()
+
+	replaced := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		replaced[tag] = true
+	}
+	for _, handler := range handlers {
+		tag := handler.Tag()
+		if len(tag) == 0 {
+			m.access.Unlock()
+			return errors.New("handlers to replace with must be tagged")
+		}
+		if _, found := m.taggedHandler[tag]; found && !replaced[tag] {
+			m.access.Unlock()
+			return errors.New("existing tag found: " + tag)
+		}
+	}
+
+	m.tagsCache = &sync.Map{}
+
+	var removed []outbound.Handler
+	for _, tag := range tags {
+		if handler, found := m.taggedHandler[tag]; found {
+			removed = append(removed, handler)
+			delete(m.taggedHandler, tag)
+		}
+		if m.defaultHandler != nil && m.defaultHandler.Tag() == tag {
+			m.defaultHandler = nil
+		}
+	}
+	var errs []error
+	for _, handler := range handlers {
+		m.taggedHandler[handler.Tag()] = handler
+		if m.running {
+			errs = append(errs, handler.Start())
+		}
+	}
+	m.access.Unlock()
+
+	for _, handler := range removed {
+		errs = append(errs, handler.Close())
+	}
+	return errors.Combine(errs...)
+}
+
 // ListHandlers implements outbound.Manager.
 func (m *Manager) ListHandlers(ctx context.Context) []outbound.Handler {
 	m.access.RLock()
@@ -162,15 +209,14 @@ func (m *Manager) ListHandlers(ctx context.Context) []outbound.Handler {
 
 // Select implements outbound.HandlerSelector.
 func (m *Manager) Select(selectors []string) []string {
+	m.access.RLock()
+	defer m.access.RUnlock()
 
 	key := strings.Join(selectors, ",")
 	if cache, ok := m.tagsCache.Load(key); ok {
 		return cache.([]string)
 	}
 
-	m.access.RLock()
-	defer m.access.RUnlock()
-
 	tags := make([]string, 0, len(selectors))
 
 	for tag := range m.taggedHandler {
@@ -0,0 +1,80 @@
+package outbound
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/features/extension"
+)
+
+type observedKey int
+
+const observedWriterKey observedKey = 0
+
+// observedWriter reports a connection through an outbound to the observatory,
+// once the first response is written back, or once the outbound fails to dial
+// its server. The round trip time reported is that of dialing the server, as
+// the time to the first response includes how long the application thinks.
+type observedWriter struct {
+	buf.Writer
+	observatory extension.PassiveObservatory
+	tag         string
+	dialRTT     atomic.Int64
+	reported    atomic.Bool
+}
+
+func newObservedWriter(writer buf.Writer, observatory extension.PassiveObservatory, tag string) *observedWriter {
+	return &observedWriter{
+		Writer:      writer,
+		observatory: observatory,
+		tag:         tag,
+	}
+}
+
+func contextWithObservedWriter(ctx context.Context, w *observedWriter) context.Context {
+	return context.WithValue(ctx, observedWriterKey, w)
+}
+
+func observedWriterFromContext(ctx context.Context) *observedWriter {
+	if w, ok := ctx.Value(observedWriterKey).(*observedWriter); ok {
+		return w
+	}
+	return nil
+}
+
+func (w *observedWriter) report(err error) {
+	if w.reported.CompareAndSwap(false, true) {
+		w.observatory.ObserveOutbound(w.tag, time.Duration(w.dialRTT.Load()), err)
+	}
+}
+
+// WriteMultiBuffer implements buf.Writer.
+func (w *observedWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	if !mb.IsEmpty() {
+		w.report(nil)
+	}
+	return w.Writer.WriteMultiBuffer(mb)
+}
+
+// Dialed records the time the outbound took to dial its server.
+func (w *observedWriter) Dialed(rtt time.Duration) {
+	w.dialRTT.CompareAndSwap(0, int64(rtt))
+}
+
+// Fail reports err, unless the connection has got a response.
+func (w *observedWriter) Fail(err error) {
+	w.report(err)
+}
+
+// Close implements common.Closable.
+func (w *observedWriter) Close() error {
+	return common.Close(w.Writer)
+}
+
+// Interrupt implements common.Interruptible.
+func (w *observedWriter) Interrupt() {
+	common.Interrupt(w.Writer)
+}
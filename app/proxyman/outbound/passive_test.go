@@ -0,0 +1,198 @@
+package outbound
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/app/policy"
+	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/extension"
+	"github.com/xtls/xray-core/features/outbound"
+	"github.com/xtls/xray-core/proxy/freedom"
+	"github.com/xtls/xray-core/testing/servers/tcp"
+	_ "github.com/xtls/xray-core/transport/internet/tcp"
+	"google.golang.org/protobuf/proto"
+)
+
+type passiveResult struct {
+	tag string
+	rtt time.Duration
+	err error
+}
+
+type recordingObservatory struct {
+	access  sync.Mutex
+	results []passiveResult
+}
+
+func (o *recordingObservatory) Type() interface{} {
+	return extension.ObservatoryType()
+}
+
+func (o *recordingObservatory) Start() error {
+	return nil
+}
+
+func (o *recordingObservatory) Close() error {
+	return nil
+}
+
+func (o *recordingObservatory) GetObservation(ctx context.Context) (proto.Message, error) {
+	return nil, nil
+}
+
+func (o *recordingObservatory) ObserveOutbound(tag string, rtt time.Duration, err error) {
+	o.access.Lock()
+	defer o.access.Unlock()
+	o.results = append(o.results, passiveResult{tag: tag, rtt: rtt, err: err})
+}
+
+func (o *recordingObservatory) take() []passiveResult {
+	o.access.Lock()
+	defer o.access.Unlock()
+	results := o.results
+	o.results = nil
+	return results
+}
+
+func newObservedHandler(t *testing.T) (context.Context, *Handler, *recordingObservatory) {
+	v, err := core.New(&core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&stats.Config{}),
+			serial.ToTypedMessage(&policy.Config{}),
+		},
+	})
+	common.Must(err)
+	observatory := &recordingObservatory{}
+	common.Must(v.AddFeature((outbound.Manager)(new(Manager))))
+	common.Must(v.AddFeature(observatory))
+	ctx := context.WithValue(context.Background(), core.XrayKey(1), v)
+
+	h, err := NewHandler(ctx, &core.OutboundHandlerConfig{
+		Tag:           "proxy",
+		ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+	})
+	common.Must(err)
+	if h.(*Handler).observatory == nil {
+		t.Fatal("the handler does not observe the observatory")
+	}
+	return ctx, h.(*Handler), observatory
+}
+
+func TestObserveDialFailures(t *testing.T) {
+	ctx, h, observatory := newObservedHandler(t)
+	// nothing listens on the port
+	server := net.TCPDestination(net.LocalHostIP, tcp.PickPort())
+	target := net.TCPDestination(net.DomainAddress("example.com"), 443)
+
+	testCases := []struct {
+		name     string
+		outbound *session.Outbound
+		failures int
+	}{
+		{
+			name:     "server of a proxy",
+			outbound: &session.Outbound{Name: "vless", Target: target},
+			failures: 1,
+		},
+		{
+			name:     "target of a direct outbound",
+			outbound: &session.Outbound{Name: "freedom", Target: target},
+		},
+		{
+			name:     "target itself",
+			outbound: &session.Outbound{Name: "socks", Target: server},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := session.ContextWithOutbounds(ctx, []*session.Outbound{testCase.outbound})
+			if _, err := h.Dial(ctx, server); err == nil {
+				t.Fatal("dialed a closed port")
+			}
+			results := observatory.take()
+			if len(results) != testCase.failures {
+				t.Fatal("results: ", results)
+			}
+			for _, result := range results {
+				if result.tag != "proxy" || result.err == nil {
+					t.Error("result: ", result)
+				}
+			}
+		})
+	}
+}
+
+func TestObserveConnection(t *testing.T) {
+	ctx, h, observatory := newObservedHandler(t)
+	server := net.TCPDestination(net.LocalHostIP, tcp.PickPort())
+	target := net.TCPDestination(net.DomainAddress("example.com"), 443)
+
+	// a connection is reported once, even if its outbound dials again
+	observed := newObservedWriter(buf.Discard, h.observatory, h.tag)
+	ctx = contextWithObservedWriter(ctx, observed)
+	ctx = session.ContextWithOutbounds(ctx, []*session.Outbound{{Name: "vmess", Target: target}})
+	for i := 0; i < 2; i++ {
+		if _, err := h.Dial(ctx, server); err == nil {
+			t.Fatal("dialed a closed port")
+		}
+	}
+	if results := observatory.take(); len(results) != 1 || results[0].err == nil {
+		t.Error("results: ", results)
+	}
+
+	observed = newObservedWriter(buf.Discard, h.observatory, h.tag)
+	common.Must(observed.WriteMultiBuffer(buf.MultiBuffer{}))
+	if results := observatory.take(); len(results) != 0 {
+		t.Error("results of an empty write: ", results)
+	}
+	b := buf.New()
+	b.WriteString("response")
+	common.Must(observed.WriteMultiBuffer(buf.MultiBuffer{b}))
+	observed.Fail(errors.New("failed after the response"))
+	if results := observatory.take(); len(results) != 1 || results[0].err != nil {
+		t.Error("results of a response: ", results)
+	}
+}
+
+func TestObserveDialTime(t *testing.T) {
+	ctx, h, observatory := newObservedHandler(t)
+	tcpServer := tcp.Server{
+		MsgProcessor: func(msg []byte) []byte {
+			return msg
+		},
+	}
+	server, err := tcpServer.Start()
+	common.Must(err)
+	defer tcpServer.Close()
+	target := net.TCPDestination(net.DomainAddress("example.com"), 443)
+
+	// the round trip time is that of the dial, not of the first response
+	observed := newObservedWriter(buf.Discard, h.observatory, h.tag)
+	ctx = contextWithObservedWriter(ctx, observed)
+	ctx = session.ContextWithOutbounds(ctx, []*session.Outbound{{Name: "vmess", Target: target}})
+	conn, err := h.Dial(ctx, server)
+	common.Must(err)
+	defer conn.Close()
+	if results := observatory.take(); len(results) != 0 {
+		t.Error("results before a response: ", results)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+	b := buf.New()
+	b.WriteString("response")
+	common.Must(observed.WriteMultiBuffer(buf.MultiBuffer{b}))
+	results := observatory.take()
+	if len(results) != 1 || results[0].err != nil || results[0].rtt <= 0 || results[0].rtt >= 500*time.Millisecond {
+		t.Error("results of a response: ", results)
+	}
+}
@@ -83,6 +83,7 @@ func (s *RoundRobinStrategy) PickOutbound(tags []string) string {
 }
 
 type Balancer struct {
+	access      sync.RWMutex
 	selectors   []string
 	strategy    BalancingStrategy
 	ohm         outbound.Manager
@@ -130,10 +131,28 @@ func (b *Balancer) SelectOutbounds() ([]string, error) {
 	if !ok {
 		return nil, errors.New("outbound.Manager is not a HandlerSelector")
 	}
-	tags := hs.Select(b.selectors)
+	b.access.RLock()
+	selectors := b.selectors
+	b.access.RUnlock()
+	tags := hs.Select(selectors)
 	return tags, nil
 }
 
+// AddSelector adds selector to the selectors of the Balancer, if it is not
+// there
+func (b *Balancer) AddSelector(selector string) {
+	b.access.Lock()
+	defer b.access.Unlock()
+	for _, s := range b.selectors {
+		if s == selector {
+			return
+		}
+	}
+	selectors := make([]string, len(b.selectors), len(b.selectors)+1)
+	copy(selectors, b.selectors)
+	b.selectors = append(selectors, selector)
+}
+
 // GetPrincipleTarget implements routing.BalancerPrincipleTarget
 func (r *Router) GetPrincipleTarget(tag string) ([]string, error) {
 	if b, ok := r.balancers[tag]; ok {
@@ -149,6 +168,15 @@ func (r *Router) GetPrincipleTarget(tag string) ([]string, error) {
 	return nil, errors.New("cannot find tag")
 }
 
+// AddBalancerSelector implements routing.BalancerSelectorAdder
+func (r *Router) AddBalancerSelector(tag, selector string) error {
+	if b, ok := r.balancers[tag]; ok {
+		b.AddSelector(selector)
+		return nil
+	}
+	return errors.New("cannot find tag")
+}
+
 // SetOverrideTarget implements routing.BalancerOverrider
 func (r *Router) SetOverrideTarget(tag, target string) error {
 	if b, ok := r.balancers[tag]; ok {
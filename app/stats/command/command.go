@@ -34,20 +34,23 @@ func (s *statsServer) GetStats(ctx context.Context, request *GetStatsRequest) (*
 	if c == nil {
 		return nil, status.Error(codes.NotFound, request.Name+" not found.")
 	}
-	var value int64
-	if request.Reset_ {
-		value = c.Set(0)
-	} else {
-		value = c.Value()
-	}
 	return &GetStatsResponse{
 		Stat: &Stat{
 			Name:  request.Name,
-			Value: value,
+			Value: readCounter(c, request.Reset_),
 		},
 	}, nil
 }
 
+// readCounter returns the value of c, and resets it if reset is true. Gauges
+// are never reset.
+func readCounter(c feature_stats.Counter, reset bool) int64 {
+	if _, ok := c.(*stats.Gauge); ok || !reset {
+		return c.Value()
+	}
+	return c.Set(0)
+}
+
 func (s *statsServer) GetStatsOnline(ctx context.Context, request *GetStatsRequest) (*GetStatsResponse, error) {
 	c := s.stats.GetOnlineMap(request.Name)
 	if c == nil {
@@ -95,15 +98,9 @@ func (s *statsServer) QueryStats(ctx context.Context, request *QueryStatsRequest
 
 	manager.VisitCounters(func(name string, c feature_stats.Counter) bool {
 		if matcher.Match(name) {
-			var value int64
-			if request.Reset_ {
-				value = c.Set(0)
-			} else {
-				value = c.Value()
-			}
 			response.Stat = append(response.Stat, &Stat{
 				Name:  name,
-				Value: value,
+				Value: readCounter(c, request.Reset_),
 			})
 		}
 		return true
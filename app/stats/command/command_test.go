@@ -20,6 +20,10 @@ func TestGetStats(t *testing.T) {
 
 	sc.Set(1)
 
+	g, err := m.RegisterGauge("test_gauge")
+	common.Must(err)
+	g.Set(2)
+
 	s := NewStatsServer(m)
 
 	testCases := []struct {
@@ -41,6 +45,15 @@ func TestGetStats(t *testing.T) {
 			name:  "test_counter",
 			value: 0,
 		},
+		{
+			name:  "test_gauge",
+			reset: true,
+			value: 2,
+		},
+		{
+			name:  "test_gauge",
+			value: 2,
+		},
 	}
 	for _, tc := range testCases {
 		resp, err := s.GetStats(context.Background(), &GetStatsRequest{
@@ -21,3 +21,9 @@ func (c *Counter) Set(newValue int64) int64 {
 func (c *Counter) Add(delta int64) int64 {
 	return atomic.AddInt64(&c.value, delta)
 }
+
+// Gauge is an implementation of stats.Counter, which counts a current amount,
+// such as open sessions. Queries of stats with reset do not reset it.
+type Gauge struct {
+	Counter
+}
@@ -12,7 +12,7 @@ import (
 // Manager is an implementation of stats.Manager.
 type Manager struct {
 	access    sync.RWMutex
-	counters  map[string]*Counter
+	counters  map[string]stats.Counter
 	onlineMap map[string]*OnlineMap
 	channels  map[string]*Channel
 	running   bool
@@ -21,7 +21,7 @@ type Manager struct {
 // NewManager creates an instance of Statistics Manager.
 func NewManager(ctx context.Context, config *Config) (*Manager, error) {
 	m := &Manager{
-		counters:  make(map[string]*Counter),
+		counters:  make(map[string]stats.Counter),
 		onlineMap: make(map[string]*OnlineMap),
 		channels:  make(map[string]*Channel),
 	}
@@ -36,6 +36,15 @@ func (*Manager) Type() interface{} {
 
 // RegisterCounter implements stats.Manager.
 func (m *Manager) RegisterCounter(name string) (stats.Counter, error) {
+	return m.registerCounter(name, new(Counter))
+}
+
+// RegisterGauge implements stats.GaugeManager.
+func (m *Manager) RegisterGauge(name string) (stats.Counter, error) {
+	return m.registerCounter(name, new(Gauge))
+}
+
+func (m *Manager) registerCounter(name string, c stats.Counter) (stats.Counter, error) {
 	m.access.Lock()
 	defer m.access.Unlock()
 
@@ -43,7 +52,6 @@ func (m *Manager) RegisterCounter(name string) (stats.Counter, error) {
 		return nil, errors.New("Counter ", name, " already registered.")
 	}
 	errors.LogDebug(context.Background(), "create new counter ", name)
-	c := new(Counter)
 	m.counters[name] = c
 	return c, nil
 }
@@ -12,6 +12,7 @@ import (
 
 func TestInterface(t *testing.T) {
 	_ = (stats.Manager)(new(Manager))
+	_ = (stats.GaugeManager)(new(Manager))
 }
 
 func TestStatsChannelRunnable(t *testing.T) {
@@ -0,0 +1,240 @@
+package subscription
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/signal/done"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/outbound"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport/internet/tagged"
+)
+
+// ParseOutbounds parses the content of a subscription to outbounds. It is
+// set by the config loader.
+var ParseOutbounds func(content []byte) ([]*core.OutboundHandlerConfig, error)
+
+// maxContentSize is the maximum size of the content of a subscription.
+const maxContentSize = 16 * 1024 * 1024
+
+// Manager keeps the outbounds of subscriptions up to date.
+type Manager struct {
+	ctx        context.Context
+	instance   *core.Instance
+	dispatcher routing.Dispatcher
+	ohm        outbound.Manager
+	router     routing.Router
+
+	providers []*provider
+	finished  *done.Instance
+}
+
+// New creates a Manager of config.
+func New(ctx context.Context, config *Config) (*Manager, error) {
+	m := &Manager{
+		ctx:      ctx,
+		instance: core.MustFromContext(ctx),
+		finished: done.New(),
+	}
+	if err := core.RequireFeatures(ctx, func(d routing.Dispatcher, om outbound.Manager, r routing.Router) {
+		m.dispatcher = d
+		m.ohm = om
+		m.router = r
+	}); err != nil {
+		return nil, errors.New("Cannot get depended features").Base(err)
+	}
+	for _, s := range config.Subscription {
+		if s.Tag == "" || s.Url == "" {
+			return nil, errors.New("subscription requires a tag and an URL")
+		}
+		for _, p := range m.providers {
+			if strings.HasPrefix(p.prefix(), s.Tag+"-") || strings.HasPrefix(s.Tag+"-", p.prefix()) {
+				return nil, errors.New("the outbounds of subscriptions ", p.config.Tag, " and ", s.Tag, " share a prefix")
+			}
+		}
+		m.providers = append(m.providers, &provider{config: s, m: m})
+	}
+	return m, nil
+}
+
+// Type implements common.HasType.
+func (m *Manager) Type() interface{} {
+	return (*Manager)(nil)
+}
+
+// Start implements common.Runnable.
+func (m *Manager) Start() error {
+	for _, p := range m.providers {
+		go p.run()
+	}
+	return nil
+}
+
+// Close implements common.Closable.
+func (m *Manager) Close() error {
+	return m.finished.Close()
+}
+
+// provider keeps the outbounds of a subscription.
+type provider struct {
+	config *Subscription
+	m      *Manager
+
+	access sync.Mutex
+	hash   [sha256.Size]byte
+	tags   []string
+}
+
+// prefix returns the prefix of the tags of the outbounds of the subscription.
+func (p *provider) prefix() string {
+	return p.config.Tag + "-"
+}
+
+func (p *provider) run() {
+	interval := time.Duration(p.config.Interval)
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	for {
+		if err := p.update(); err != nil {
+			errors.LogWarningInner(p.m.ctx, err, "failed to update subscription ", p.config.Tag)
+		}
+		select {
+		case <-p.m.finished.Wait():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// update fetches the subscription, and replaces the outbounds of the previous
+// content, if it is changed.
+func (p *provider) update() error {
+	p.access.Lock()
+	defer p.access.Unlock()
+
+	content, err := p.fetch()
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(content)
+	if hash == p.hash {
+		return nil
+	}
+	if ParseOutbounds == nil {
+		return errors.New("no parser of subscriptions")
+	}
+	configs, err := ParseOutbounds(content)
+	if err != nil {
+		return errors.New("failed to parse subscription").Base(err)
+	}
+	if len(configs) == 0 {
+		return errors.New("no outbound in subscription")
+	}
+
+	replacer, ok := p.m.ohm.(outbound.HandlerReplacer)
+	if !ok {
+		return errors.New("outbound.Manager is not a HandlerReplacer")
+	}
+	handlers := make([]outbound.Handler, 0, len(configs))
+	tags := make([]string, 0, len(configs))
+	seen := make(map[string]bool, len(configs))
+	for i, config := range configs {
+		name := config.Tag
+		if name == "" || seen[name] {
+			name = strconv.Itoa(i + 1)
+		}
+		seen[name] = true
+		config.Tag = p.prefix() + name
+
+		rawHandler, err := core.CreateObject(p.m.instance, config)
+		if err == nil {
+			handler, ok := rawHandler.(outbound.Handler)
+			if ok {
+				handlers = append(handlers, handler)
+				tags = append(tags, config.Tag)
+				continue
+			}
+			common.Close(rawHandler)
+			err = errors.New("not an OutboundHandler")
+		}
+		for _, handler := range handlers {
+			common.Close(handler)
+		}
+		return errors.New("failed to create outbound ", config.Tag).Base(err)
+	}
+
+	if err := replacer.ReplaceHandlers(p.m.ctx, p.tags, handlers); err != nil {
+		// the handlers are in place, if only some of them failed to start
+		for _, handler := range handlers {
+			if p.m.ohm.GetHandler(handler.Tag()) == handler {
+				p.tags = tags
+			} else {
+				common.Close(handler)
+			}
+		}
+		return errors.New("failed to replace outbounds").Base(err)
+	}
+	p.tags = tags
+
+	if len(p.config.BalancerTags) > 0 {
+		adder, ok := p.m.router.(routing.BalancerSelectorAdder)
+		if !ok {
+			return errors.New("router does not support adding balancer selectors")
+		}
+		for _, tag := range p.config.BalancerTags {
+			if err := adder.AddBalancerSelector(tag, p.prefix()); err != nil {
+				return errors.New("failed to add outbounds to balancer ", tag).Base(err)
+			}
+		}
+	}
+	// only skip the content once it is all applied
+	p.hash = hash
+	errors.LogInfo(p.m.ctx, "subscription ", p.config.Tag, " updated with ", len(handlers), " outbounds")
+	return nil
+}
+
+func (p *provider) fetch() ([]byte, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy: func(*http.Request) (*url.URL, error) {
+				return nil, nil
+			},
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				dest, err := net.ParseDestination(network + ":" + addr)
+				if err != nil {
+					return nil, errors.New("cannot understand address").Base(err)
+				}
+				return tagged.Dialer(p.m.ctx, p.m.dispatcher, dest, p.config.OutboundTag)
+			},
+			DisableKeepAlives: true,
+		},
+		Timeout: time.Minute,
+	}
+	response, err := client.Get(p.config.Url)
+	if err != nil {
+		return nil, errors.New("failed to fetch subscription").Base(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.New("unexpected status ", response.Status)
+	}
+	return io.ReadAll(io.LimitReader(response.Body, maxContentSize))
+}
+
+func init() {
+	common.Must(common.RegisterConfig((*Config)(nil), func(ctx context.Context, config interface{}) (interface{}, error) {
+		return New(ctx, config.(*Config))
+	}))
+}
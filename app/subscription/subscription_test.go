@@ -0,0 +1,117 @@
+package subscription_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/app/dispatcher"
+	"github.com/xtls/xray-core/app/proxyman"
+	_ "github.com/xtls/xray-core/app/proxyman/inbound"
+	_ "github.com/xtls/xray-core/app/proxyman/outbound"
+	"github.com/xtls/xray-core/app/router"
+	. "github.com/xtls/xray-core/app/subscription"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/features/outbound"
+	"github.com/xtls/xray-core/features/routing"
+	routing_session "github.com/xtls/xray-core/features/routing/session"
+	_ "github.com/xtls/xray-core/infra/conf"
+	"github.com/xtls/xray-core/proxy/freedom"
+	_ "github.com/xtls/xray-core/transport/internet/tagged/taggedimpl"
+)
+
+func TestSubscription(t *testing.T) {
+	var access sync.Mutex
+	content := `[
+		{"protocol": "trojan", "tag": "a", "settings": {"servers": [{"address": "127.0.0.1", "port": 443, "password": "password"}]}},
+		{"protocol": "trojan", "settings": {"servers": [{"address": "127.0.0.1", "port": 443, "password": "password"}]}}
+	]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		access.Lock()
+		defer access.Unlock()
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	config := &core.Config{
+		App: []*serial.TypedMessage{
+			serial.ToTypedMessage(&dispatcher.Config{}),
+			serial.ToTypedMessage(&proxyman.InboundConfig{}),
+			serial.ToTypedMessage(&proxyman.OutboundConfig{}),
+			serial.ToTypedMessage(&router.Config{
+				Rule: []*router.RoutingRule{{
+					TargetTag: &router.RoutingRule_BalancingTag{BalancingTag: "balancer"},
+					Networks:  []net.Network{net.Network_TCP},
+				}},
+				BalancingRule: []*router.BalancingRule{{
+					Tag:              "balancer",
+					OutboundSelector: []string{"none"},
+				}},
+			}),
+			serial.ToTypedMessage(&Config{
+				Subscription: []*Subscription{{
+					Tag:          "sub",
+					Url:          server.URL,
+					OutboundTag:  "direct",
+					Interval:     int64(200 * time.Millisecond),
+					BalancerTags: []string{"balancer"},
+				}},
+			}),
+		},
+		Outbound: []*core.OutboundHandlerConfig{{
+			Tag:           "direct",
+			ProxySettings: serial.ToTypedMessage(&freedom.Config{}),
+		}},
+	}
+	instance, err := core.New(config)
+	common.Must(err)
+	common.Must(instance.Start())
+	defer instance.Close()
+
+	ohm := instance.GetFeature(outbound.ManagerType()).(outbound.Manager)
+	waitForTags := func(expected ...string) {
+		t.Helper()
+		var tags []string
+		for i := 0; i < 50; i++ {
+			tags = ohm.(outbound.HandlerSelector).Select([]string{"sub-"})
+			if strings.Join(tags, ",") == strings.Join(expected, ",") {
+				return
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		t.Fatal("expected outbounds ", expected, ", but got ", tags)
+	}
+	waitForTags("sub-2", "sub-a")
+
+	r := instance.GetFeature(routing.RouterType()).(routing.Router)
+	ctx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{
+		Target: net.TCPDestination(net.DomainAddress("example.com"), 80),
+	}})
+	route, err := r.PickRoute(routing_session.AsRoutingContext(ctx))
+	common.Must(err)
+	if tag := route.GetOutboundTag(); !strings.HasPrefix(tag, "sub-") {
+		t.Error("expected the balancer to pick an outbound of the subscription, but got ", tag)
+	}
+
+	links := []string{
+		"vless://27848739-7e62-4138-9fd3-098a63964b6b@127.0.0.1:443?security=tls&sni=example.com&type=ws&path=%2Fws#first",
+		"trojan://password@127.0.0.1:443#second",
+	}
+	access.Lock()
+	content = base64.StdEncoding.EncodeToString([]byte(strings.Join(links, "\n")))
+	access.Unlock()
+	waitForTags("sub-first", "sub-second")
+
+	if ohm.GetHandler("direct") == nil {
+		t.Error("expected other outbounds to be kept")
+	}
+}
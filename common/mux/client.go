@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/xtls/xray-core/common"
@@ -40,6 +41,14 @@ func (m *ClientManager) Dispatch(ctx context.Context, link *transport.Link) erro
 	return errors.New("unable to find an available mux client").AtWarning()
 }
 
+// WorkerStats returns the stats of the workers, if the picker keeps any.
+func (m *ClientManager) WorkerStats() []WorkerStats {
+	if p, ok := m.Picker.(interface{ WorkerStats() []WorkerStats }); ok {
+		return p.WorkerStats()
+	}
+	return nil
+}
+
 type WorkerPicker interface {
 	PickAvailable() (*ClientWorker, error)
 }
@@ -75,13 +84,21 @@ func (p *IncrementalWorkerPicker) cleanup() {
 }
 
 func (p *IncrementalWorkerPicker) findAvailable() int {
+	found := -1
 	for idx, w := range p.workers {
-		if !w.IsFull() {
+		if w.IsFull() {
+			continue
+		}
+		if !w.strategy.Adaptive {
 			return idx
 		}
+		// adaptive workers take new streams by the least throughput
+		if found < 0 || w.throughput.Load() < p.workers[found].throughput.Load() {
+			found = idx
+		}
 	}
 
-	return -1
+	return found
 }
 
 func (p *IncrementalWorkerPicker) pickInternal() (*ClientWorker, bool, error) {
@@ -124,6 +141,19 @@ func (p *IncrementalWorkerPicker) PickAvailable() (*ClientWorker, error) {
 	return worker, err
 }
 
+// WorkerStats returns the stats of the workers which are not closed.
+func (p *IncrementalWorkerPicker) WorkerStats() []WorkerStats {
+	p.access.Lock()
+	defer p.access.Unlock()
+
+	p.cleanup()
+	stats := make([]WorkerStats, 0, len(p.workers))
+	for _, w := range p.workers {
+		stats = append(stats, w.Stats())
+	}
+	return stats
+}
+
 type ClientWorkerFactory interface {
 	Create() (*ClientWorker, error)
 }
@@ -167,6 +197,17 @@ func (f *DialingWorkerFactory) Create() (*ClientWorker, error) {
 type ClientStrategy struct {
 	MaxConcurrency uint32
 	MaxConnection  uint32
+	// Adaptive lowers MaxConcurrency of a worker while the round trip time of
+	// its connection is higher than it used to be, so more workers share the
+	// load.
+	Adaptive bool
+	// MaxLifetime and MaxBytes retire a worker, which takes no new streams
+	// and closes after its streams end.
+	MaxLifetime time.Duration
+	MaxBytes    uint64
+	// FlowControlWindow asks servers for flow control of TCP streams with
+	// this receive window, see flow.go.
+	FlowControlWindow uint32
 }
 
 type ClientWorker struct {
@@ -175,8 +216,40 @@ type ClientWorker struct {
 	done           *done.Instance
 	timer          *time.Ticker
 	strategy       ClientStrategy
+
+	id       uint32
+	created  time.Time
+	draining atomic.Bool
+	uplink   atomic.Uint64
+	downlink atomic.Uint64
+	// throughput is in bytes per second during the last monitor period.
+	throughput atomic.Uint64
+	// rtt is of the connection, measured by pings, see ping.go, in the
+	// adaptive mode or since the stats are requested.
+	rtt       rttStats
+	rttWanted atomic.Bool
+}
+
+// WorkerStats is a snapshot of a ClientWorker.
+type WorkerStats struct {
+	ID            uint32
+	Created       time.Time
+	ActiveStreams uint32
+	TotalStreams  uint32
+	// Concurrency is the number of streams the worker takes now.
+	Concurrency   uint32
+	UplinkBytes   uint64
+	DownlinkBytes uint64
+	Throughput    uint64
+	// Latency is the round trip time of the connection, zero if the server
+	// does not answer pings. Out of the adaptive mode, pings are only sent
+	// since the stats are first requested.
+	Latency  time.Duration
+	Draining bool
 }
 
+var workerID atomic.Uint32
+
 var (
 	muxCoolAddress = net.DomainAddress("v1.mux.cool")
 	muxCoolPort    = net.Port(9527)
@@ -190,7 +263,11 @@ func NewClientWorker(stream transport.Link, s ClientStrategy) (*ClientWorker, er
 		done:           done.New(),
 		timer:          time.NewTicker(time.Second * 16),
 		strategy:       s,
+		id:             workerID.Add(1),
+		created:        time.Now(),
 	}
+	c.link.Reader = &countingReader{Reader: stream.Reader, counter: &c.downlink}
+	c.link.Writer = &countingWriter{Writer: stream.Writer, counter: &c.uplink}
 
 	go c.fetchOutput()
 	go c.monitor()
@@ -218,6 +295,10 @@ func (m *ClientWorker) GetTimer() *time.Ticker {
 func (m *ClientWorker) monitor() {
 	defer m.timer.Stop()
 
+	if m.strategy.Adaptive {
+		m.ping(m.created)
+	}
+	lastBytes, lastTime := uint64(0), m.created
 	for {
 		select {
 		case <-m.done.Wait():
@@ -225,7 +306,16 @@ func (m *ClientWorker) monitor() {
 			common.Close(m.link.Writer)
 			common.Interrupt(m.link.Reader)
 			return
-		case <-m.timer.C:
+		case now := <-m.timer.C:
+			bytes := m.uplink.Load() + m.downlink.Load()
+			if elapsed := now.Sub(lastTime).Seconds(); elapsed > 0 {
+				m.throughput.Store(uint64(float64(bytes-lastBytes) / elapsed))
+			}
+			lastBytes, lastTime = bytes, now
+			if m.strategy.Adaptive || m.rttWanted.Load() {
+				m.ping(now)
+			}
+
 			size := m.sessionManager.Size()
 			if size == 0 && m.sessionManager.CloseIfNoSession() {
 				common.Must(m.done.Close())
@@ -256,6 +346,8 @@ func fetchInput(ctx context.Context, s *Session, output buf.Writer) {
 	}
 	s.transferType = transferType
 	writer := NewWriter(s.ID, ob.Target, output, transferType, xudp.GetGlobalID(ctx))
+	writer.window = s.window
+	writer.receiveWindow = s.receiveWindow
 	defer s.Close(false)
 	defer writer.Close()
 
@@ -278,9 +370,78 @@ func (m *ClientWorker) IsClosing() bool {
 	if m.strategy.MaxConnection > 0 && sm.Count() >= int(m.strategy.MaxConnection) {
 		return true
 	}
+	return m.IsDraining()
+}
+
+// IsDraining returns true if this ClientWorker is retired by its lifetime or
+// bytes, it takes no new streams and closes after the existing ones end.
+func (m *ClientWorker) IsDraining() bool {
+	if m.draining.Load() {
+		return true
+	}
+	s := m.strategy
+	if (s.MaxLifetime > 0 && time.Since(m.created) >= s.MaxLifetime) ||
+		(s.MaxBytes > 0 && m.uplink.Load()+m.downlink.Load() >= s.MaxBytes) {
+		if m.draining.CompareAndSwap(false, true) {
+			errors.LogInfo(context.Background(), "mux worker ", m.id, " retired, draining ", m.sessionManager.Size(), " streams")
+		}
+		return true
+	}
 	return false
 }
 
+// concurrency returns the number of streams this ClientWorker takes. In the
+// adaptive mode it shrinks with the round trip time of the connection beyond
+// twice the lowest one of late, as the connection is congested or throttled.
+func (m *ClientWorker) concurrency() uint32 {
+	limit := m.strategy.MaxConcurrency
+	if !m.strategy.Adaptive || limit == 0 {
+		return limit
+	}
+	rtt, lowest := m.rtt.get(time.Now())
+	if rtt <= 2*lowest {
+		return limit
+	}
+	return max(1, uint32(int64(limit)*2*int64(lowest)/int64(rtt)))
+}
+
+// ping measures the round trip time of the connection, unless a ping is
+// waiting for the answer.
+func (m *ClientWorker) ping(now time.Time) {
+	if !m.rtt.ping(now) {
+		return
+	}
+	if err := writePing(m.link.Writer); err != nil {
+		errors.LogInfoInner(context.Background(), err, "failed to ping mux server")
+	}
+}
+
+// latency returns the moving average of the round trip time of the
+// connection, or zero if the server does not answer pings.
+func (m *ClientWorker) latency() time.Duration {
+	rtt, _ := m.rtt.get(time.Now())
+	return rtt
+}
+
+// Stats returns the stats of this ClientWorker.
+func (m *ClientWorker) Stats() WorkerStats {
+	if !m.strategy.Adaptive && m.rttWanted.CompareAndSwap(false, true) {
+		m.ping(time.Now())
+	}
+	return WorkerStats{
+		ID:            m.id,
+		Created:       m.created,
+		ActiveStreams: m.ActiveConnections(),
+		TotalStreams:  m.TotalConnections(),
+		Concurrency:   m.concurrency(),
+		UplinkBytes:   m.uplink.Load(),
+		DownlinkBytes: m.downlink.Load(),
+		Throughput:    m.throughput.Load(),
+		Latency:       m.latency(),
+		Draining:      m.IsDraining(),
+	}
+}
+
 // IsFull returns true if this ClientWorker is unable to accept more connections.
 // it might be because it is closing, or the number of connections has reached the limit.
 func (m *ClientWorker) IsFull() bool {
@@ -289,7 +450,7 @@ func (m *ClientWorker) IsFull() bool {
 	}
 
 	sm := m.sessionManager
-	if m.strategy.MaxConcurrency > 0 && sm.Size() >= int(m.strategy.MaxConcurrency) {
+	if limit := m.concurrency(); limit > 0 && sm.Size() >= int(limit) {
 		return true
 	}
 	return false
@@ -307,11 +468,20 @@ func (m *ClientWorker) Dispatch(ctx context.Context, link *transport.Link) bool
 	}
 	s.input = link.Reader
 	s.output = link.Writer
+	if outbounds := session.OutboundsFromContext(ctx); m.strategy.FlowControlWindow > 0 && len(outbounds) > 0 &&
+		outbounds[len(outbounds)-1].Target.Network == net.Network_TCP {
+		s.window = newSendWindow(false, 0)
+		s.receiveWindow = m.strategy.FlowControlWindow
+	}
 	go fetchInput(ctx, s, m.link.Writer)
 	return true
 }
 
 func (m *ClientWorker) handleStatueKeepAlive(meta *FrameMetadata, reader *buf.BufferedReader) error {
+	if meta.Option.Has(OptionPing) && !meta.Option.Has(OptionData) {
+		m.rtt.pong(time.Now())
+		return nil
+	}
 	if meta.Option.Has(OptionData) {
 		return buf.Copy(NewStreamReader(reader), buf.Discard)
 	}
@@ -326,6 +496,18 @@ func (m *ClientWorker) handleStatusNew(meta *FrameMetadata, reader *buf.Buffered
 }
 
 func (m *ClientWorker) handleStatusKeep(meta *FrameMetadata, reader *buf.BufferedReader) error {
+	if meta.Option.Has(OptionFlowControl) && !meta.Option.Has(OptionData) {
+		// the first window update tells the server supports flow control
+		if s, found := m.sessionManager.Get(meta.SessionID); found && s.window != nil {
+			if s.receive == nil {
+				s.parent.Lock()
+				s.receive = newReceiveQueue(s, s.receiveWindow, m.link.Writer)
+				s.parent.Unlock()
+			}
+			s.window.grant(meta.Window)
+		}
+		return nil
+	}
 	if !meta.Option.Has(OptionData) {
 		return nil
 	}
@@ -340,7 +522,7 @@ func (m *ClientWorker) handleStatusKeep(meta *FrameMetadata, reader *buf.Buffere
 	}
 
 	rr := s.NewReader(reader, &meta.Target)
-	err := buf.Copy(rr, s.output)
+	err := buf.Copy(rr, s.downstream())
 	if err != nil && buf.IsWriteError(err) {
 		errors.LogInfoInner(context.Background(), err, "failed to write to downstream. closing session ", s.ID)
 		s.Close(false)
@@ -398,3 +580,38 @@ func (m *ClientWorker) fetchOutput() {
 		}
 	}
 }
+
+// countingReader counts the bytes a ClientWorker reads from its connection.
+type countingReader struct {
+	buf.Reader
+	counter *atomic.Uint64
+}
+
+func (r *countingReader) ReadMultiBuffer() (buf.MultiBuffer, error) {
+	mb, err := r.Reader.ReadMultiBuffer()
+	r.counter.Add(uint64(mb.Len()))
+	return mb, err
+}
+
+func (r *countingReader) Interrupt() {
+	common.Interrupt(r.Reader)
+}
+
+// countingWriter counts the bytes a ClientWorker writes to its connection.
+type countingWriter struct {
+	buf.Writer
+	counter *atomic.Uint64
+}
+
+func (w *countingWriter) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	w.counter.Add(uint64(mb.Len()))
+	return w.Writer.WriteMultiBuffer(mb)
+}
+
+func (w *countingWriter) Close() error {
+	return common.Close(w.Writer)
+}
+
+func (w *countingWriter) Interrupt() {
+	common.Interrupt(w.Writer)
+}
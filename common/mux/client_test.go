@@ -7,6 +7,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/mux"
 	"github.com/xtls/xray-core/common/net"
@@ -114,3 +115,64 @@ func TestClientWorkerClose(t *testing.T) {
 
 	common.Must(w2.Close())
 }
+
+func TestClientWorkerRetire(t *testing.T) {
+	r, w := pipe.New(pipe.WithoutSizeLimit())
+	defer w.Close()
+	worker, err := mux.NewClientWorker(transport.Link{
+		Reader: r,
+		Writer: w,
+	}, mux.ClientStrategy{
+		MaxConcurrency: 4,
+		MaxBytes:       1,
+	})
+	common.Must(err)
+
+	if worker.IsFull() {
+		t.Fatal("new worker is full")
+	}
+
+	tr, tw := pipe.New(pipe.WithoutSizeLimit())
+	defer tw.Close()
+	ctx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{
+		Target: net.TCPDestination(net.DomainAddress("www.example.com"), 80),
+	}})
+	if !worker.Dispatch(ctx, &transport.Link{Reader: tr, Writer: tw}) {
+		t.Fatal("failed to dispatch")
+	}
+	common.Must(tw.WriteMultiBuffer(buf.MergeBytes(nil, []byte("hello"))))
+
+	time.Sleep(time.Millisecond * 500)
+	stats := worker.Stats()
+	if stats.UplinkBytes == 0 || stats.ActiveStreams != 1 {
+		t.Error("stats: ", stats)
+	}
+	if !stats.Draining || !worker.IsFull() {
+		t.Error("worker over its bytes is not draining")
+	}
+	if worker.Closed() {
+		t.Error("draining worker closed with an active stream")
+	}
+}
+
+func TestClientWorkerPingOnlyForStats(t *testing.T) {
+	downlinkReader, downlinkWriter := pipe.New(pipe.WithoutSizeLimit())
+	defer downlinkWriter.Close()
+	uplinkReader, uplinkWriter := pipe.New(pipe.WithoutSizeLimit())
+	worker, err := mux.NewClientWorker(transport.Link{
+		Reader: downlinkReader,
+		Writer: uplinkWriter,
+	}, mux.ClientStrategy{MaxConcurrency: 4})
+	common.Must(err)
+
+	if mb, err := uplinkReader.ReadMultiBufferTimeout(time.Millisecond * 200); err == nil {
+		t.Error("worker out of the adaptive mode pinged: ", mb)
+	}
+
+	worker.Stats()
+	mb, err := uplinkReader.ReadMultiBufferTimeout(time.Second)
+	if err != nil {
+		t.Fatal("no ping after the stats are requested: ", err)
+	}
+	buf.ReleaseMulti(mb)
+}
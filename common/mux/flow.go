@@ -0,0 +1,181 @@
+package mux
+
+import (
+	"context"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/transport/pipe"
+)
+
+/*
+Flow control
+
+A client which supports flow control sets OptionFlowControl in the New frame
+of a TCP session, followed by its receive window in 4 bytes after the address.
+A server which supports it answers with a window update, a Keep frame with
+OptionFlowControl and no data, whose 4 bytes after the option grant the
+client that many bytes to send. Both sides then send no more than they are
+granted, and grant what their downstream consumes. Peers which do not know
+the option ignore it, and the session goes on without flow control.
+*/
+
+// defaultFlowControlWindow is the receive window of servers.
+const defaultFlowControlWindow = 256 * 1024
+
+// sendWindow holds the bytes a session may send. It does not limit the
+// client until the server grants any, which tells it supports flow control.
+type sendWindow struct {
+	access  sync.Mutex
+	credit  int64
+	enabled bool
+	closed  bool
+	update  chan struct{}
+}
+
+func newSendWindow(enabled bool, credit uint32) *sendWindow {
+	return &sendWindow{
+		credit:  int64(credit),
+		enabled: enabled,
+		update:  make(chan struct{}, 1),
+	}
+}
+
+// acquire waits for credit, and takes up to size bytes of it.
+func (w *sendWindow) acquire(size int32) (int32, error) {
+	for {
+		w.access.Lock()
+		if w.closed {
+			w.access.Unlock()
+			return 0, io.ErrClosedPipe
+		}
+		if !w.enabled || w.credit > 0 {
+			if w.enabled && int64(size) > w.credit {
+				size = int32(w.credit)
+			}
+			w.credit -= int64(size)
+			w.access.Unlock()
+			return size, nil
+		}
+		w.access.Unlock()
+		<-w.update
+	}
+}
+
+func (w *sendWindow) grant(size uint32) {
+	w.access.Lock()
+	defer w.access.Unlock()
+
+	if w.closed {
+		return
+	}
+	w.credit += int64(size)
+	w.enabled = true
+	select {
+	case w.update <- struct{}{}:
+	default:
+	}
+}
+
+func (w *sendWindow) close() {
+	w.access.Lock()
+	defer w.access.Unlock()
+
+	if !w.closed {
+		w.closed = true
+		close(w.update)
+	}
+}
+
+// receiveQueue takes the data of a session without blocking the Mux
+// connection, and grants the peer what the downstream consumes. A peer which
+// sends more than it is granted overruns the window, and fails the write.
+type receiveQueue struct {
+	reader *pipe.Reader
+	writer *pipe.Writer
+
+	access sync.Mutex
+	window int64
+	// pending is the data received and not granted to the peer again.
+	pending int64
+}
+
+// newReceiveQueue starts to move the data of s to its output.
+func newReceiveQueue(s *Session, window uint32, output buf.Writer) *receiveQueue {
+	// the window bounds the queue, the limit only guards against mistakes
+	reader, writer := pipe.New(pipe.WithSizeLimit(int32(min(window, math.MaxInt32))))
+	q := &receiveQueue{
+		reader: reader,
+		writer: writer,
+		window: int64(window),
+	}
+	go func() {
+		defer common.Close(s.output)
+
+		var consumed uint32
+		for {
+			mb, err := reader.ReadMultiBuffer()
+			if err != nil {
+				return
+			}
+			size := uint32(mb.Len())
+			if err := s.output.WriteMultiBuffer(mb); err != nil {
+				reader.Interrupt()
+				return
+			}
+			consumed += size
+			if consumed >= window/4 {
+				// taken off before the grant, so data sent after it fits
+				q.access.Lock()
+				q.pending -= int64(consumed)
+				q.access.Unlock()
+				if err := writeWindowUpdate(output, s.ID, consumed); err != nil {
+					errors.LogDebugInner(context.Background(), err, "failed to write window update of session ", s.ID)
+				}
+				consumed = 0
+			}
+		}
+	}()
+	return q
+}
+
+// WriteMultiBuffer implements buf.Writer.
+func (q *receiveQueue) WriteMultiBuffer(mb buf.MultiBuffer) error {
+	size := int64(mb.Len())
+	q.access.Lock()
+	if q.pending+size > q.window {
+		q.access.Unlock()
+		buf.ReleaseMulti(mb)
+		return errors.New("peer overruns the flow control window of ", q.window, " bytes")
+	}
+	q.pending += size
+	q.access.Unlock()
+	return q.writer.WriteMultiBuffer(mb)
+}
+
+// Close implements common.Closable, the queue closes the output of the
+// session after the data in it is written.
+func (q *receiveQueue) Close() error {
+	return q.writer.Close()
+}
+
+// writeWindowUpdate grants the peer size bytes to send in the session.
+func writeWindowUpdate(writer buf.Writer, id uint16, size uint32) error {
+	meta := FrameMetadata{
+		SessionID:     id,
+		SessionStatus: SessionStatusKeep,
+		Window:        size,
+	}
+	meta.Option.Set(OptionFlowControl)
+
+	frame := buf.New()
+	if err := meta.WriteTo(frame); err != nil {
+		frame.Release()
+		return err
+	}
+	return writer.WriteMultiBuffer(buf.MultiBuffer{frame})
+}
@@ -25,6 +25,10 @@ const (
 const (
 	OptionData  bitmask.Byte = 0x01
 	OptionError bitmask.Byte = 0x02
+	// OptionFlowControl carries a window, see flow.go.
+	OptionFlowControl bitmask.Byte = 0x04
+	// OptionPing in KeepAlive frames asks for or answers a ping, see ping.go.
+	OptionPing bitmask.Byte = 0x08
 )
 
 type TargetNetwork byte
@@ -51,6 +55,14 @@ Frame format
 1 byte - network
 2 bytes - port
 n bytes - address
+4 bytes - window, in New TCP frames with OptionFlowControl
+
+Window updates are Keep frames with OptionFlowControl and no data:
+2 bytes - length
+2 bytes - session id
+1 bytes - status
+1 bytes - option
+4 bytes - window
 
 */
 
@@ -60,6 +72,7 @@ type FrameMetadata struct {
 	Option        bitmask.Byte
 	SessionStatus SessionStatus
 	GlobalID      [8]byte
+	Window        uint32
 }
 
 func (f FrameMetadata) WriteTo(b *buf.Buffer) error {
@@ -72,7 +85,9 @@ func (f FrameMetadata) WriteTo(b *buf.Buffer) error {
 	common.Must(b.WriteByte(byte(f.SessionStatus)))
 	common.Must(b.WriteByte(byte(f.Option)))
 
-	if f.SessionStatus == SessionStatusNew {
+	if f.SessionStatus == SessionStatusKeep && f.Option.Has(OptionFlowControl) {
+		binary.BigEndian.PutUint32(b.Extend(4), f.Window)
+	} else if f.SessionStatus == SessionStatusNew {
 		switch f.Target.Network {
 		case net.Network_TCP:
 			common.Must(b.WriteByte(byte(TargetNetworkTCP)))
@@ -85,6 +100,8 @@ func (f FrameMetadata) WriteTo(b *buf.Buffer) error {
 		}
 		if b.UDP != nil { // make sure it's user's proxy request
 			b.Write(f.GlobalID[:]) // no need to check whether it's empty
+		} else if f.Target.Network == net.Network_TCP && f.Option.Has(OptionFlowControl) {
+			binary.BigEndian.PutUint32(b.Extend(4), f.Window)
 		}
 	} else if b.UDP != nil {
 		b.WriteByte(byte(TargetNetworkUDP))
@@ -126,6 +143,15 @@ func (f *FrameMetadata) UnmarshalFromBuffer(b *buf.Buffer) error {
 	f.SessionStatus = SessionStatus(b.Byte(2))
 	f.Option = bitmask.Byte(b.Byte(3))
 	f.Target.Network = net.Network_Unknown
+	f.Window = 0
+
+	if f.SessionStatus == SessionStatusKeep && f.Option.Has(OptionFlowControl) {
+		if b.Len() < 8 {
+			return errors.New("insufficient buffer: ", b.Len())
+		}
+		f.Window = binary.BigEndian.Uint32(b.BytesRange(4, 8))
+		return nil
+	}
 
 	if f.SessionStatus == SessionStatusNew || (f.SessionStatus == SessionStatusKeep && b.Len() > 4 &&
 		TargetNetwork(b.Byte(4)) == TargetNetworkUDP) { // MUST check the flag first
@@ -143,6 +169,9 @@ func (f *FrameMetadata) UnmarshalFromBuffer(b *buf.Buffer) error {
 		switch network {
 		case TargetNetworkTCP:
 			f.Target = net.TCPDestination(addr, port)
+			if f.SessionStatus == SessionStatusNew && f.Option.Has(OptionFlowControl) && b.Len() >= 4 {
+				f.Window = binary.BigEndian.Uint32(b.BytesTo(4))
+			}
 		case TargetNetworkUDP:
 			f.Target = net.UDPDestination(addr, port)
 		default:
@@ -23,3 +23,34 @@ func BenchmarkFrameWrite(b *testing.B) {
 		writer.Clear()
 	}
 }
+
+func TestFrameFlowControl(t *testing.T) {
+	cases := []mux.FrameMetadata{
+		{
+			Target:        net.TCPDestination(net.DomainAddress("www.example.com"), net.Port(80)),
+			SessionID:     1,
+			SessionStatus: mux.SessionStatusNew,
+			Window:        65536,
+		},
+		{
+			SessionID:     1,
+			SessionStatus: mux.SessionStatusKeep,
+			Window:        262144,
+		},
+	}
+	for _, meta := range cases {
+		meta.Option.Set(mux.OptionFlowControl)
+		b := buf.New()
+		common.Must(meta.WriteTo(b))
+
+		var got mux.FrameMetadata
+		common.Must(got.Unmarshal(b))
+		if got.SessionID != meta.SessionID || got.SessionStatus != meta.SessionStatus || got.Window != meta.Window {
+			t.Error("unexpected frame: ", got, ", expected ", meta)
+		}
+		if !got.Option.Has(mux.OptionFlowControl) {
+			t.Error("flow control option lost")
+		}
+		b.Release()
+	}
+}
@@ -0,0 +1,106 @@
+package mux
+
+import (
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/buf"
+)
+
+/*
+Ping
+
+A client which measures the round trip time of its connection, in the
+adaptive mode or once its stats are requested, sends
+KeepAlive frames with OptionPing and no data. A server which supports it
+answers each of them with the same frame. Servers which do not know the
+option ignore it, and the client has no round trip time.
+*/
+
+const (
+	// pingTimeout is how long a ping waits for the answer, before another
+	// one is sent.
+	pingTimeout = 16 * time.Second
+	// rttWindow is how long a round trip time counts for the lowest one, so
+	// that the lowest follows changes of the route.
+	rttWindow = 5 * time.Minute
+)
+
+// writePing sends a ping, or the answer of one.
+func writePing(writer buf.Writer) error {
+	meta := FrameMetadata{
+		SessionStatus: SessionStatusKeepAlive,
+	}
+	meta.Option.Set(OptionPing)
+
+	frame := buf.New()
+	if err := meta.WriteTo(frame); err != nil {
+		frame.Release()
+		return err
+	}
+	return writer.WriteMultiBuffer(buf.MultiBuffer{frame})
+}
+
+type rttSample struct {
+	rtt  time.Duration
+	time time.Time
+}
+
+// rttStats keeps the moving average of the round trip times of pings, and
+// the lowest of them in rttWindow.
+type rttStats struct {
+	access   sync.Mutex
+	sent     time.Time
+	smoothed time.Duration
+	samples  []rttSample
+}
+
+// ping returns whether a ping should be sent now, and records the time.
+func (s *rttStats) ping(now time.Time) bool {
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	if !s.sent.IsZero() && now.Sub(s.sent) < pingTimeout {
+		return false
+	}
+	s.sent = now
+	return true
+}
+
+// pong records the round trip time of the ping answered now.
+func (s *rttStats) pong(now time.Time) {
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	if s.sent.IsZero() {
+		return
+	}
+	rtt := now.Sub(s.sent)
+	s.sent = time.Time{}
+
+	if s.smoothed == 0 {
+		s.smoothed = rtt
+	} else {
+		s.smoothed += (rtt - s.smoothed) / 8
+	}
+	// samples which are not lower than the new one can never be the lowest
+	for len(s.samples) > 0 && s.samples[len(s.samples)-1].rtt >= rtt {
+		s.samples = s.samples[:len(s.samples)-1]
+	}
+	s.samples = append(s.samples, rttSample{rtt: rtt, time: now})
+}
+
+// get returns the moving average and the lowest round trip time in
+// rttWindow, or zeros if there is none.
+func (s *rttStats) get(now time.Time) (smoothed, lowest time.Duration) {
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	for len(s.samples) > 1 && now.Sub(s.samples[0].time) > rttWindow {
+		s.samples = s.samples[1:]
+	}
+	if len(s.samples) == 0 {
+		return 0, 0
+	}
+	return s.smoothed, s.samples[0].rtt
+}
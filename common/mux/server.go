@@ -93,6 +93,7 @@ func NewServerWorker(ctx context.Context, d routing.Dispatcher, link *transport.
 
 func handle(ctx context.Context, s *Session, output buf.Writer) {
 	writer := NewResponseWriter(s.ID, output, s.transferType)
+	writer.window = s.window
 	if err := buf.Copy(s.input, writer); err != nil {
 		errors.LogInfoInner(ctx, err, "session ", s.ID, " ends.")
 		writer.hasError = true
@@ -111,6 +112,9 @@ func (w *ServerWorker) Closed() bool {
 }
 
 func (w *ServerWorker) handleStatusKeepAlive(meta *FrameMetadata, reader *buf.BufferedReader) error {
+	if meta.Option.Has(OptionPing) && !meta.Option.Has(OptionData) {
+		return writePing(w.link.Writer)
+	}
 	if meta.Option.Has(OptionData) {
 		return buf.Copy(NewStreamReader(reader), buf.Discard)
 	}
@@ -227,17 +231,27 @@ func (w *ServerWorker) handleStatusNew(ctx context.Context, meta *FrameMetadata,
 	if meta.Target.Network == net.Network_UDP {
 		s.transferType = protocol.TransferTypePacket
 	}
+	flowControl := meta.Option.Has(OptionFlowControl) && meta.Window > 0 && s.transferType == protocol.TransferTypeStream
+	if flowControl {
+		s.window = newSendWindow(true, meta.Window)
+	}
 	if !w.sessionManager.Add(s) {
 		s.Close(false)
 		return errors.New("failed to add new session")
 	}
+	if flowControl {
+		if err := s.enableFlowControl(defaultFlowControlWindow, w.link.Writer); err != nil {
+			s.Close(false)
+			return errors.New("failed to grant flow control window").Base(err)
+		}
+	}
 	go handle(ctx, s, w.link.Writer)
 	if !meta.Option.Has(OptionData) {
 		return nil
 	}
 
 	rr := s.NewReader(reader, &meta.Target)
-	err = buf.Copy(rr, s.output)
+	err = buf.Copy(rr, s.downstream())
 
 	if err != nil && buf.IsWriteError(err) {
 		s.Close(false)
@@ -247,6 +261,12 @@ func (w *ServerWorker) handleStatusNew(ctx context.Context, meta *FrameMetadata,
 }
 
 func (w *ServerWorker) handleStatusKeep(meta *FrameMetadata, reader *buf.BufferedReader) error {
+	if meta.Option.Has(OptionFlowControl) && !meta.Option.Has(OptionData) {
+		if s, found := w.sessionManager.Get(meta.SessionID); found && s.window != nil {
+			s.window.grant(meta.Window)
+		}
+		return nil
+	}
 	if !meta.Option.Has(OptionData) {
 		return nil
 	}
@@ -261,7 +281,7 @@ func (w *ServerWorker) handleStatusKeep(meta *FrameMetadata, reader *buf.Buffere
 	}
 
 	rr := s.NewReader(reader, &meta.Target)
-	err := buf.Copy(rr, s.output)
+	err := buf.Copy(rr, s.downstream())
 
 	if err != nil && buf.IsWriteError(err) {
 		errors.LogInfoInner(context.Background(), err, "failed to write to downstream writer. closing session ", s.ID)
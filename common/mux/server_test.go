@@ -3,11 +3,13 @@ package mux_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/mux"
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/common/session"
 	"github.com/xtls/xray-core/features/routing"
 	"github.com/xtls/xray-core/transport"
@@ -122,3 +124,178 @@ func TestRegressionOutboundLeak(t *testing.T) {
 		t.Error("outbound target got leaked: ", outbounds[0].Target.String())
 	}
 }
+
+func TestFlowControlHeadOfLineBlocking(t *testing.T) {
+	serverCtx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{}})
+
+	// the website of port 80 does not read
+	stalledReader, stalledWriter := pipe.New(pipe.WithSizeLimit(1024))
+	defer stalledReader.Interrupt()
+	websiteUplink, websiteDownlink := newLinkPair()
+
+	dispatcher := TestDispatcher{
+		OnDispatch: func(ctx context.Context, dest net.Destination) (*transport.Link, error) {
+			if dest.Port == 80 {
+				downlinkReader, _ := pipe.New(pipe.WithoutSizeLimit())
+				return &transport.Link{Reader: downlinkReader, Writer: stalledWriter}, nil
+			}
+			return websiteDownlink, nil
+		},
+	}
+
+	muxServerUplink, muxServerDownlink := newLinkPair()
+	_, err := mux.NewServerWorker(serverCtx, &dispatcher, muxServerUplink)
+	common.Must(err)
+
+	client, err := mux.NewClientWorker(*muxServerDownlink, mux.ClientStrategy{
+		FlowControlWindow: 65536,
+	})
+	common.Must(err)
+
+	dispatch := func(port net.Port) *transport.Link {
+		ctx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{
+			Target: net.TCPDestination(net.DomainAddress("www.example.com"), port),
+		}})
+		uplink, downlink := newLinkPair()
+		if !client.Dispatch(ctx, uplink) {
+			t.Fatal("failed to dispatch")
+		}
+		return downlink
+	}
+
+	stalled := dispatch(80)
+	for range 64 {
+		common.Must(stalled.Writer.WriteMultiBuffer(buf.MultiBuffer{buf.FromBytes(make([]byte, 32*1024))}))
+	}
+
+	active := dispatch(81)
+	common.Must(active.Writer.WriteMultiBuffer(buf.MultiBuffer{buf.FromBytes([]byte("hello"))}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		resMb, err := websiteUplink.Reader.ReadMultiBuffer()
+		common.Must(err)
+		if res := resMb.String(); res != "hello" {
+			t.Error("upload: ", res)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("session blocked by a stalled session")
+	}
+}
+
+func TestFlowControlWindowOverrun(t *testing.T) {
+	serverCtx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{}})
+
+	// the website does not read
+	stalledReader, stalledWriter := pipe.New(pipe.WithSizeLimit(1024))
+	defer stalledReader.Interrupt()
+	dispatcher := TestDispatcher{
+		OnDispatch: func(ctx context.Context, dest net.Destination) (*transport.Link, error) {
+			downlinkReader, _ := pipe.New(pipe.WithoutSizeLimit())
+			return &transport.Link{Reader: downlinkReader, Writer: stalledWriter}, nil
+		},
+	}
+
+	muxServerUplink, muxServerDownlink := newLinkPair()
+	_, err := mux.NewServerWorker(serverCtx, &dispatcher, muxServerUplink)
+	common.Must(err)
+
+	// a client which asks for flow control and ignores its window
+	meta := mux.FrameMetadata{
+		SessionID:     1,
+		SessionStatus: mux.SessionStatusNew,
+		Target:        net.TCPDestination(net.DomainAddress("www.example.com"), 80),
+		Window:        65536,
+	}
+	meta.Option.Set(mux.OptionFlowControl)
+	frame := buf.New()
+	common.Must(meta.WriteTo(frame))
+	common.Must(muxServerDownlink.Writer.WriteMultiBuffer(buf.MultiBuffer{frame}))
+	go func() {
+		meta := mux.FrameMetadata{
+			SessionID:     1,
+			SessionStatus: mux.SessionStatusKeep,
+		}
+		meta.Option.Set(mux.OptionData)
+		for range 128 {
+			frame := buf.New()
+			common.Must(meta.WriteTo(frame))
+			common.Must2(serial.WriteUint16(frame, 4*1024))
+			common.Must2(frame.Write(make([]byte, 4*1024)))
+			if muxServerDownlink.Writer.WriteMultiBuffer(buf.MultiBuffer{frame}) != nil {
+				return
+			}
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		reader := &buf.BufferedReader{Reader: muxServerDownlink.Reader}
+		for {
+			var meta mux.FrameMetadata
+			if err := meta.Unmarshal(reader); err != nil {
+				t.Error(err)
+				return
+			}
+			if meta.Option.Has(mux.OptionData) {
+				common.Must(buf.Copy(mux.NewStreamReader(reader), buf.Discard))
+			}
+			if meta.SessionStatus == mux.SessionStatusEnd {
+				if meta.SessionID != 1 {
+					t.Error("unexpected end of session ", meta.SessionID)
+				}
+				return
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("session not closed after its window is overrun")
+	}
+}
+
+func TestClientWorkerPing(t *testing.T) {
+	serverCtx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{}})
+
+	// the website does not respond, which is no matter of the connection
+	_, websiteDownlink := newLinkPair()
+	dispatcher := TestDispatcher{
+		OnDispatch: func(ctx context.Context, dest net.Destination) (*transport.Link, error) {
+			return websiteDownlink, nil
+		},
+	}
+
+	muxServerUplink, muxServerDownlink := newLinkPair()
+	_, err := mux.NewServerWorker(serverCtx, &dispatcher, muxServerUplink)
+	common.Must(err)
+
+	client, err := mux.NewClientWorker(*muxServerDownlink, mux.ClientStrategy{
+		MaxConcurrency: 4,
+		Adaptive:       true,
+	})
+	common.Must(err)
+
+	clientCtx := session.ContextWithOutbounds(context.Background(), []*session.Outbound{{
+		Target: net.TCPDestination(net.DomainAddress("www.example.com"), 80),
+	}})
+	muxClientUplink, muxClientDownlink := newLinkPair()
+	if !client.Dispatch(clientCtx, muxClientUplink) {
+		t.Fatal("failed to dispatch")
+	}
+	common.Must(muxClientDownlink.Writer.WriteMultiBuffer(buf.MultiBuffer{buf.FromBytes([]byte("hello"))}))
+
+	time.Sleep(time.Millisecond * 200)
+	stats := client.Stats()
+	if stats.Latency <= 0 {
+		t.Error("no round trip time of the connection")
+	}
+	if stats.Concurrency != 4 {
+		t.Error("concurrency: ", stats.Concurrency)
+	}
+}
@@ -158,6 +158,28 @@ type Session struct {
 	transferType protocol.TransferType
 	closed       bool
 	XUDP         *XUDP
+	// window and receive are set if the session has flow control, see flow.go.
+	// receiveWindow is what a client session asks the server for.
+	window        *sendWindow
+	receive       *receiveQueue
+	receiveWindow uint32
+}
+
+// downstream returns where the data received for this session goes.
+func (s *Session) downstream() buf.Writer {
+	if s.receive != nil {
+		return s.receive
+	}
+	return s.output
+}
+
+// enableFlowControl queues the data received for this session, and grants
+// the peer window bytes to send.
+func (s *Session) enableFlowControl(window uint32, output buf.Writer) error {
+	s.parent.Lock()
+	s.receive = newReceiveQueue(s, window, output)
+	s.parent.Unlock()
+	return writeWindowUpdate(output, s.ID, window)
 }
 
 // Close closes all resources associated with this session.
@@ -171,7 +193,13 @@ func (s *Session) Close(locked bool) error {
 		return nil
 	}
 	s.closed = true
-	if s.XUDP == nil {
+	if s.window != nil {
+		s.window.close()
+	}
+	if s.receive != nil {
+		common.Interrupt(s.input)
+		common.Close(s.receive)
+	} else if s.XUDP == nil {
 		common.Interrupt(s.input)
 		common.Close(s.output)
 	} else {
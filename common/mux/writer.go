@@ -16,6 +16,10 @@ type Writer struct {
 	hasError     bool
 	transferType protocol.TransferType
 	globalID     [8]byte
+	// window limits the data to write if the session has flow control,
+	// receiveWindow is advertised in the New frame to ask for it.
+	window        *sendWindow
+	receiveWindow uint32
 }
 
 func NewWriter(id uint16, dest net.Destination, writer buf.Writer, transferType protocol.TransferType, globalID [8]byte) *Writer {
@@ -50,6 +54,10 @@ func (w *Writer) getNextFrameMeta() FrameMetadata {
 	} else {
 		w.followup = true
 		meta.SessionStatus = SessionStatusNew
+		if w.receiveWindow > 0 {
+			meta.Option.Set(OptionFlowControl)
+			meta.Window = w.receiveWindow
+		}
 	}
 
 	return meta
@@ -100,7 +108,14 @@ func (w *Writer) WriteMultiBuffer(mb buf.MultiBuffer) error {
 	for !mb.IsEmpty() {
 		var chunk buf.MultiBuffer
 		if w.transferType == protocol.TransferTypeStream {
-			mb, chunk = buf.SplitSize(mb, 8*1024)
+			size := min(mb.Len(), 8*1024)
+			if w.window != nil {
+				var err error
+				if size, err = w.window.acquire(size); err != nil {
+					return err
+				}
+			}
+			mb, chunk = buf.SplitSize(mb, size)
 		} else {
 			mb2, b := buf.SplitFirst(mb)
 			mb = mb2
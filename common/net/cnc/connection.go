@@ -24,6 +24,12 @@ func ConnectionRemoteAddr(a net.Addr) ConnectionOption {
 	}
 }
 
+func ConnectionPeerIdentity(identity string) ConnectionOption {
+	return func(c *connection) {
+		c.peerIdentity = identity
+	}
+}
+
 func ConnectionInput(writer io.Writer) ConnectionOption {
 	return func(c *connection) {
 		c.writer = buf.NewWriter(writer)
@@ -90,6 +96,8 @@ type connection struct {
 	onClose io.Closer
 	local   net.Addr
 	remote  net.Addr
+
+	peerIdentity string
 }
 
 func (c *connection) Read(b []byte) (int, error) {
@@ -144,6 +152,11 @@ func (c *connection) RemoteAddr() net.Addr {
 	return c.remote
 }
 
+// PeerIdentity implements stat.PeerIdentifier.
+func (c *connection) PeerIdentity() string {
+	return c.peerIdentity
+}
+
 // SetDeadline implements net.Conn.SetDeadline().
 func (c *connection) SetDeadline(t time.Time) error {
 	return nil
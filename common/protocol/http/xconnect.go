@@ -0,0 +1,14 @@
+package http
+
+import (
+	"os"
+	"strings"
+)
+
+// ExtendedConnectEnabled returns whether the HTTP/2 servers of
+// golang.org/x/net/http2 accept extended CONNECT requests (RFC 8441). They
+// only do when the process is started with GODEBUG=http2xconnect=1, which is
+// needed for CONNECT-UDP and WebSockets over HTTP/2.
+func ExtendedConnectEnabled() bool {
+	return strings.Contains(os.Getenv("GODEBUG"), "http2xconnect=1")
+}
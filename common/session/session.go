@@ -48,6 +48,9 @@ type Inbound struct {
 	User *protocol.MemoryUser
 	// Conn is actually internet.Connection. May be nil.
 	Conn net.Conn
+	// PeerIdentity is the identity the transport authenticates the peer with,
+	// like the subject of a verified TLS client certificate. May be empty.
+	PeerIdentity string
 	// Timer of the inbound buf copier. May be nil.
 	Timer *signal.ActivityTimer
 	// CanSpliceCopy is a property for this connection
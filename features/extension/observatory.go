@@ -2,6 +2,7 @@ package extension
 
 import (
 	"context"
+	"time"
 
 	"github.com/xtls/xray-core/features"
 	"google.golang.org/protobuf/proto"
@@ -13,6 +14,18 @@ type Observatory interface {
 	GetObservation(ctx context.Context) (proto.Message, error)
 }
 
+// PassiveObservatory is an Observatory which also learns the health of
+// outbounds from real traffic.
+type PassiveObservatory interface {
+	Observatory
+
+	// ObserveOutbound reports a connection through the outbound of tag, once
+	// it gets the first response, or err if it failed before that. rtt is the
+	// time the outbound took to dial its server, 0 if unknown, as of
+	// connections over an existing mux connection.
+	ObserveOutbound(tag string, rtt time.Duration, err error)
+}
+
 func ObservatoryType() interface{} {
 	return (*Observatory)(nil)
 }
@@ -24,6 +24,14 @@ type HandlerSelector interface {
 	Select([]string) []string
 }
 
+// HandlerReplacer is an outbound.Manager which replaces a set of handlers at
+// once.
+type HandlerReplacer interface {
+	// ReplaceHandlers removes the handlers of tags and adds handlers in their
+	// place at once. Removed handlers are closed.
+	ReplaceHandlers(ctx context.Context, tags []string, handlers []Handler) error
+}
+
 // Manager is a feature that manages outbound.Handlers.
 //
 // xray:api:stable
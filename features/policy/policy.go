@@ -47,6 +47,8 @@ type SystemStats struct {
 	OutboundUplink bool
 	// Whether or not to enable stat counter for downlink traffic in outbound handlers.
 	OutboundDownlink bool
+	// Whether or not to enable stat counters of transports, such as XHTTP sessions, in inbound handlers.
+	InboundTransport bool
 }
 
 // System contains policy settings at system level.
@@ -8,3 +8,7 @@ type BalancerOverrider interface {
 type BalancerPrincipleTarget interface {
 	GetPrincipleTarget(tag string) ([]string, error)
 }
+
+type BalancerSelectorAdder interface {
+	AddBalancerSelector(tag, selector string) error
+}
@@ -0,0 +1,24 @@
+package stats
+
+import (
+	"context"
+)
+
+type managerKeyType int
+
+const managerKey managerKeyType = 1
+
+// ContextWithManager returns a context with the Manager, in which transports
+// register their counters.
+func ContextWithManager(ctx context.Context, m Manager) context.Context {
+	return context.WithValue(ctx, managerKey, m)
+}
+
+// ManagerFromContext returns the Manager of the context, or nil if the
+// context doesn't contain one.
+func ManagerFromContext(ctx context.Context) Manager {
+	if m, ok := ctx.Value(managerKey).(Manager); ok {
+		return m
+	}
+	return nil
+}
@@ -100,6 +100,15 @@ type Manager interface {
 	GetChannel(string) Channel
 }
 
+// GaugeManager is a Manager, which registers gauges. Gauges are counters of
+// current amounts, such as open sessions, which are not reset with others.
+type GaugeManager interface {
+	Manager
+
+	// RegisterGauge registers a new gauge to the manager. The identifier string must not be empty, and unique among other counters.
+	RegisterGauge(string) (Counter, error)
+}
+
 // GetOrRegisterCounter tries to get the StatCounter first. If not exist, it then tries to create a new counter.
 func GetOrRegisterCounter(m Manager, name string) (Counter, error) {
 	counter := m.GetCounter(name)
@@ -110,6 +119,19 @@ func GetOrRegisterCounter(m Manager, name string) (Counter, error) {
 	return m.RegisterCounter(name)
 }
 
+// GetOrRegisterGauge tries to get the gauge first. If not exist, it then tries to create a new gauge, or a counter if m has no gauges.
+func GetOrRegisterGauge(m Manager, name string) (Counter, error) {
+	counter := m.GetCounter(name)
+	if counter != nil {
+		return counter, nil
+	}
+
+	if gm, ok := m.(GaugeManager); ok {
+		return gm.RegisterGauge(name)
+	}
+	return m.RegisterCounter(name)
+}
+
 // GetOrRegisterOnlineMap tries to get the OnlineMap first. If not exist, it then tries to create a new onlinemap.
 func GetOrRegisterOnlineMap(m Manager, name string) (OnlineMap, error) {
 	onlineMap := m.GetOnlineMap(name)
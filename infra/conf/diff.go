@@ -0,0 +1,160 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/xtls/xray-core/common/errors"
+)
+
+// ConfigChange is a difference between configs. Old is nil if it is added,
+// and New is nil if it is removed.
+type ConfigChange struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+func (c *ConfigChange) String() string {
+	switch {
+	case c.Old == nil:
+		return "+ " + c.Path + ": " + compactJSON(c.New)
+	case c.New == nil:
+		return "- " + c.Path + ": " + compactJSON(c.Old)
+	default:
+		return "~ " + c.Path + ": " + compactJSON(c.Old) + " -> " + compactJSON(c.New)
+	}
+}
+
+// keyedList is a list whose elements are matched by keys instead of indexes.
+type keyedList map[string]interface{}
+
+// DiffBuiltConfigs returns the differences between two built configs, in
+// JSON with type info, as common/reflect marshals them. Inbounds and
+// outbounds are matched by tags, and apps by types.
+func DiffBuiltConfigs(a []byte, b []byte) ([]*ConfigChange, error) {
+	x, err := keyBuiltConfig(a)
+	if err != nil {
+		return nil, err
+	}
+	y, err := keyBuiltConfig(b)
+	if err != nil {
+		return nil, err
+	}
+	var changes []*ConfigChange
+	diffConfigValues("", x, y, &changes)
+	return changes, nil
+}
+
+func keyBuiltConfig(j []byte) (map[string]interface{}, error) {
+	var config map[string]interface{}
+	if err := json.Unmarshal(j, &config); err != nil {
+		return nil, errors.New("invalid built config").Base(err)
+	}
+	config["app"] = keyBy(config["app"], "_TypedMessage_")
+	config["inbound"] = keyBy(config["inbound"], "tag")
+	config["outbound"] = keyBy(config["outbound"], "tag")
+	return config, nil
+}
+
+// keyBy returns a keyedList of list by the key of elements, or list itself if
+// the key is missing or duplicated.
+func keyBy(list interface{}, key string) interface{} {
+	elements, ok := list.([]interface{})
+	if !ok {
+		return list
+	}
+	keyed := make(keyedList, len(elements))
+	for _, element := range elements {
+		m, ok := element.(map[string]interface{})
+		if !ok {
+			return list
+		}
+		k, ok := m[key].(string)
+		if !ok || k == "" {
+			return list
+		}
+		if _, found := keyed[k]; found {
+			return list
+		}
+		keyed[k] = element
+	}
+	return keyed
+}
+
+func diffConfigValues(path string, a interface{}, b interface{}, changes *[]*ConfigChange) {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		if b, ok := b.(map[string]interface{}); ok {
+			diffConfigMaps(a, b, changes, func(k string) string {
+				if path == "" {
+					return k
+				}
+				return path + "." + k
+			})
+			return
+		}
+	case keyedList:
+		if b, ok := b.(keyedList); ok {
+			diffConfigMaps(a, b, changes, func(k string) string {
+				return path + "[" + k + "]"
+			})
+			return
+		}
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok {
+			am := make(map[string]interface{}, len(a))
+			for i, v := range a {
+				am[strconv.Itoa(i)] = v
+			}
+			bm := make(map[string]interface{}, len(b))
+			for i, v := range b {
+				bm[strconv.Itoa(i)] = v
+			}
+			diffConfigMaps(am, bm, changes, func(k string) string {
+				return path + "[" + k + "]"
+			})
+			return
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, &ConfigChange{Path: path, Old: a, New: b})
+	}
+}
+
+func diffConfigMaps(a map[string]interface{}, b map[string]interface{}, changes *[]*ConfigChange, child func(string) string) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, found := a[k]; !found {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		x, errX := strconv.Atoi(keys[i])
+		y, errY := strconv.Atoi(keys[j])
+		if errX == nil && errY == nil {
+			return x < y
+		}
+		return keys[i] < keys[j]
+	})
+	for _, k := range keys {
+		diffConfigValues(child(k), a[k], b[k], changes)
+	}
+}
+
+func compactJSON(v interface{}) string {
+	if l, ok := v.(keyedList); ok {
+		v = map[string]interface{}(l)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,104 @@
+package conf_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	creflect "github.com/xtls/xray-core/common/reflect"
+	. "github.com/xtls/xray-core/infra/conf"
+)
+
+func builtConfigJSON(t *testing.T, s string) []byte {
+	t.Helper()
+	config := new(Config)
+	common.Must(json.Unmarshal([]byte(s), config))
+	pbConfig, err := config.Build()
+	common.Must(err)
+	j, ok := creflect.MarshalToJson(pbConfig, true)
+	if !ok {
+		t.Fatal("failed to marshal config to json")
+	}
+	return []byte(j)
+}
+
+func TestDiffBuiltConfigs(t *testing.T) {
+	base := `{
+		"inbounds": [
+			{"tag": "socks", "protocol": "socks", "listen": "127.0.0.1", "port": 1080},
+			{"tag": "http", "protocol": "http", "listen": "127.0.0.1", "port": 8080}
+		],
+		"outbounds": [
+			{"tag": "direct", "protocol": "freedom"},
+			{"tag": "block", "protocol": "blackhole"}
+		]
+	}`
+
+	testCases := []struct {
+		name    string
+		config  string
+		changes []string
+	}{
+		{
+			name:   "identical",
+			config: base,
+		},
+		{
+			name: "reordered and reformatted",
+			config: `{
+				"outbounds": [
+					{"protocol": "blackhole", "tag": "block"},
+					{"protocol": "freedom", "tag": "direct", "settings": {}}
+				],
+				"inbounds": [
+					{"protocol": "http", "tag": "http", "listen": "127.0.0.1", "port": "8080"},
+					{"protocol": "socks", "tag": "socks", "listen": "127.0.0.1", "port": 1080}
+				]
+			}`,
+		},
+		{
+			name: "changed in keyed entries",
+			config: `{
+				"inbounds": [
+					{"tag": "http", "protocol": "http", "listen": "127.0.0.1", "port": 8080},
+					{"tag": "socks", "protocol": "socks", "listen": "127.0.0.1", "port": 1081}
+				],
+				"outbounds": [
+					{"tag": "block", "protocol": "blackhole"},
+					{"tag": "direct", "protocol": "freedom"}
+				]
+			}`,
+			changes: []string{"~ inbound[socks].receiverSettings.portList: 1080 -> 1081"},
+		},
+		{
+			name: "added and removed entries",
+			config: `{
+				"inbounds": [
+					{"tag": "socks", "protocol": "socks", "listen": "127.0.0.1", "port": 1080},
+					{"tag": "http", "protocol": "http", "listen": "127.0.0.1", "port": 8080}
+				],
+				"outbounds": [
+					{"tag": "direct", "protocol": "freedom"},
+					{"tag": "dns", "protocol": "dns"}
+				]
+			}`,
+			changes: []string{"- outbound[block]", "+ outbound[dns]"},
+		},
+	}
+	a := builtConfigJSON(t, base)
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			changes, err := DiffBuiltConfigs(a, builtConfigJSON(t, testCase.config))
+			common.Must(err)
+			if len(changes) != len(testCase.changes) {
+				t.Fatal("changes: ", changes)
+			}
+			for i, change := range changes {
+				if s := change.String(); !strings.HasPrefix(s, testCase.changes[i]) {
+					t.Error("change: ", s, ", expected: ", testCase.changes[i])
+				}
+			}
+		})
+	}
+}
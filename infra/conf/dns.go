@@ -197,6 +197,23 @@ type DNSConfig struct {
 	DisableFallback        bool                `json:"disableFallback"`
 	DisableFallbackIfMatch bool                `json:"disableFallbackIfMatch"`
 	UseSystemHosts         bool                `json:"useSystemHosts"`
+	Prefetch               *DNSPrefetchConfig  `json:"prefetch"`
+}
+
+// DNSPrefetchConfig is a JSON serializable object for dns.PrefetchConfig.
+type DNSPrefetchConfig struct {
+	Threshold   uint32 `json:"threshold"`
+	Concurrency uint32 `json:"concurrency"`
+	Ahead       uint32 `json:"ahead"`
+}
+
+// Build implements Buildable
+func (c *DNSPrefetchConfig) Build() *dns.PrefetchConfig {
+	return &dns.PrefetchConfig{
+		Threshold:   c.Threshold,
+		Concurrency: c.Concurrency,
+		Ahead:       c.Ahead,
+	}
 }
 
 type HostAddress struct {
@@ -403,6 +420,10 @@ func (c *DNSConfig) Build() (*dns.Config, error) {
 		config.ClientIp = []byte(c.ClientIP.IP())
 	}
 
+	if c.Prefetch != nil {
+		config.Prefetch = c.Prefetch.Build()
+	}
+
 	for _, server := range c.Servers {
 		ns, err := server.Build()
 		if err != nil {
@@ -103,5 +103,35 @@ func TestDNSConfigParsing(t *testing.T) {
 				DisableFallback: true,
 			},
 		},
+		{
+			Input: `{
+				"servers": ["8.8.8.8"],
+				"prefetch": {
+					"threshold": 5,
+					"concurrency": 2,
+					"ahead": 15
+				}
+			}`,
+			Parser: parserCreator(),
+			Output: &dns.Config{
+				NameServer: []*dns.NameServer{
+					{
+						Address: &net.Endpoint{
+							Address: &net.IPOrDomain{
+								Address: &net.IPOrDomain_Ip{
+									Ip: []byte{8, 8, 8, 8},
+								},
+							},
+							Network: net.Network_UDP,
+						},
+					},
+				},
+				Prefetch: &dns.PrefetchConfig{
+					Threshold:   5,
+					Concurrency: 2,
+					Ahead:       15,
+				},
+			},
+		},
 	})
 }
@@ -3,6 +3,7 @@ package conf
 import (
 	"context"
 	"encoding/json"
+	"path/filepath"
 	"strings"
 
 	"github.com/xtls/xray-core/app/dns/fakedns"
@@ -11,8 +12,19 @@ import (
 )
 
 type FakeDNSPoolElementConfig struct {
-	IPPool  string `json:"ipPool"`
-	LRUSize int64  `json:"poolSize"`
+	IPPool          string `json:"ipPool"`
+	LRUSize         int64  `json:"poolSize"`
+	PersistPath     string `json:"persistPath"`
+	PersistInterval uint32 `json:"persistInterval"`
+}
+
+func (c *FakeDNSPoolElementConfig) Build() *fakedns.FakeDnsPool {
+	return &fakedns.FakeDnsPool{
+		IpPool:          c.IPPool,
+		LruSize:         c.LRUSize,
+		PersistPath:     c.PersistPath,
+		PersistInterval: c.PersistInterval,
+	}
 }
 
 type FakeDNSConfig struct {
@@ -51,16 +63,22 @@ func (f *FakeDNSConfig) Build() (*fakedns.FakeDnsPoolMulti, error) {
 	fakeDNSPool := fakedns.FakeDnsPoolMulti{}
 
 	if f.pool != nil {
-		fakeDNSPool.Pools = append(fakeDNSPool.Pools, &fakedns.FakeDnsPool{
-			IpPool:  f.pool.IPPool,
-			LruSize: f.pool.LRUSize,
-		})
+		fakeDNSPool.Pools = append(fakeDNSPool.Pools, f.pool.Build())
 		return &fakeDNSPool, nil
 	}
 
 	if f.pools != nil {
+		// pools with the same persist path would overwrite the mappings of each other
+		persistPaths := make(map[string]bool, len(f.pools))
 		for _, v := range f.pools {
-			fakeDNSPool.Pools = append(fakeDNSPool.Pools, &fakedns.FakeDnsPool{IpPool: v.IPPool, LruSize: v.LRUSize})
+			if v.PersistPath != "" {
+				path := filepath.Clean(v.PersistPath)
+				if persistPaths[path] {
+					return nil, errors.New("fake DNS pools share the persist path ", v.PersistPath)
+				}
+				persistPaths[path] = true
+			}
+			fakeDNSPool.Pools = append(fakeDNSPool.Pools, v.Build())
 		}
 		return &fakeDNSPool, nil
 	}
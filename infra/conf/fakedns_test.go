@@ -0,0 +1,25 @@
+package conf_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	. "github.com/xtls/xray-core/infra/conf"
+)
+
+func TestFakeDNSConfigPersistPaths(t *testing.T) {
+	for input, valid := range map[string]bool{
+		`[{"ipPool": "198.18.0.0/15", "poolSize": 256, "persistPath": "/var/lib/xray/fakedns4.json"},
+		  {"ipPool": "fc00::/18", "poolSize": 256, "persistPath": "/var/lib/xray/fakedns6.json"}]`: true,
+		`[{"ipPool": "198.18.0.0/15", "poolSize": 256}, {"ipPool": "fc00::/18", "poolSize": 256}]`: true,
+		`[{"ipPool": "198.18.0.0/15", "poolSize": 256, "persistPath": "/var/lib/xray/fakedns.json"},
+		  {"ipPool": "fc00::/18", "poolSize": 256, "persistPath": "/var/lib/xray/../xray/fakedns.json"}]`: false,
+	} {
+		config := new(FakeDNSConfig)
+		common.Must(json.Unmarshal([]byte(input), config))
+		if _, err := config.Build(); (err == nil) != valid {
+			t.Error("unexpected error of ", input, ": ", err)
+		}
+	}
+}
@@ -0,0 +1,1302 @@
+package conf
+
+import (
+	"encoding/hex"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xtls/xray-core/app/commander"
+	"github.com/xtls/xray-core/app/dispatcher"
+	"github.com/xtls/xray-core/app/dns"
+	"github.com/xtls/xray-core/app/log"
+	loggerservice "github.com/xtls/xray-core/app/log/command"
+	observatoryservice "github.com/xtls/xray-core/app/observatory/command"
+	"github.com/xtls/xray-core/app/policy"
+	"github.com/xtls/xray-core/app/proxyman"
+	handlerservice "github.com/xtls/xray-core/app/proxyman/command"
+	"github.com/xtls/xray-core/app/router"
+	routerservice "github.com/xtls/xray-core/app/router/command"
+	"github.com/xtls/xray-core/app/stats"
+	statsservice "github.com/xtls/xray-core/app/stats/command"
+	"github.com/xtls/xray-core/common/errors"
+	clog "github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/proxy/blackhole"
+	dnsproxy "github.com/xtls/xray-core/proxy/dns"
+	"github.com/xtls/xray-core/proxy/dokodemo"
+	"github.com/xtls/xray-core/proxy/freedom"
+	"github.com/xtls/xray-core/proxy/http"
+	"github.com/xtls/xray-core/proxy/loopback"
+	"github.com/xtls/xray-core/proxy/shadowsocks"
+	"github.com/xtls/xray-core/proxy/shadowsocks_2022"
+	"github.com/xtls/xray-core/proxy/socks"
+	"github.com/xtls/xray-core/proxy/trojan"
+	"github.com/xtls/xray-core/proxy/vless"
+	vlessinbound "github.com/xtls/xray-core/proxy/vless/inbound"
+	vlessoutbound "github.com/xtls/xray-core/proxy/vless/outbound"
+	"github.com/xtls/xray-core/proxy/vmess"
+	vmessinbound "github.com/xtls/xray-core/proxy/vmess/inbound"
+	vmessoutbound "github.com/xtls/xray-core/proxy/vmess/outbound"
+	"github.com/xtls/xray-core/transport/internet"
+	"google.golang.org/protobuf/proto"
+)
+
+// jsonObject is a JSON object converted from protobuf configs.
+type jsonObject map[string]interface{}
+
+// set sets key to value, unless value is empty, so that defaults are left out.
+func (o jsonObject) set(key string, value interface{}) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || v.IsZero() {
+		return
+	}
+	if (v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && v.Len() == 0 {
+		return
+	}
+	o[key] = value
+}
+
+// ConfigFromProto converts a built config back to the JSON config format.
+// Apps that are always built, such as the dispatcher, are left out, and so are
+// default values. Apps, proxies and transports that can't be converted yet
+// result in errors.
+func ConfigFromProto(config *core.Config) (map[string]interface{}, error) {
+	c := jsonObject{}
+	for _, app := range config.App {
+		instance, err := app.GetInstance()
+		if err != nil {
+			return nil, errors.New("failed to get app ", app.Type).Base(err)
+		}
+		switch instance := instance.(type) {
+		case *dispatcher.Config, *proxyman.InboundConfig, *proxyman.OutboundConfig:
+		case *log.Config:
+			if !proto.Equal(instance, DefaultLogConfig()) {
+				c["log"] = logFromProto(instance)
+			}
+		case *commander.Config:
+			api, err := apiFromProto(instance)
+			if err != nil {
+				return nil, errors.New("failed to convert API config").Base(err)
+			}
+			c["api"] = api
+		case *stats.Config:
+			c["stats"] = jsonObject{}
+		case *policy.Config:
+			c["policy"] = policyFromProto(instance)
+		case *router.Config:
+			routing, err := routerFromProto(instance)
+			if err != nil {
+				return nil, errors.New("failed to convert routing config").Base(err)
+			}
+			c["routing"] = routing
+		case *dns.Config:
+			dnsConfig, err := dnsFromProto(instance)
+			if err != nil {
+				return nil, errors.New("failed to convert DNS config").Base(err)
+			}
+			c["dns"] = dnsConfig
+		default:
+			return nil, errors.New("unsupported app: ", app.Type)
+		}
+	}
+	if len(config.Extension) > 0 {
+		return nil, errors.New("unsupported extensions")
+	}
+
+	inbounds := make([]interface{}, 0, len(config.Inbound))
+	for _, inbound := range config.Inbound {
+		ib, err := InboundFromProto(inbound)
+		if err != nil {
+			return nil, errors.New("failed to convert inbound config with tag ", inbound.Tag).Base(err)
+		}
+		inbounds = append(inbounds, ib)
+	}
+	c.set("inbounds", inbounds)
+
+	outbounds := make([]interface{}, 0, len(config.Outbound))
+	for _, outbound := range config.Outbound {
+		ob, err := OutboundFromProto(outbound)
+		if err != nil {
+			return nil, errors.New("failed to convert outbound config with tag ", outbound.Tag).Base(err)
+		}
+		outbounds = append(outbounds, ob)
+	}
+	c.set("outbounds", outbounds)
+	return c, nil
+}
+
+func logFromProto(config *log.Config) jsonObject {
+	c := jsonObject{}
+	c.set("access", logPathFromProto(config.AccessLogType, config.AccessLogPath))
+	c.set("error", logPathFromProto(config.ErrorLogType, config.ErrorLogPath))
+	switch config.ErrorLogLevel {
+	case clog.Severity_Debug:
+		c["loglevel"] = "debug"
+	case clog.Severity_Info:
+		c["loglevel"] = "info"
+	case clog.Severity_Error:
+		c["loglevel"] = "error"
+	case clog.Severity_Unknown:
+		c["loglevel"] = "none"
+	}
+	c.set("dnsLog", config.EnableDnsLog)
+	c.set("maskAddress", config.MaskAddress)
+	return c
+}
+
+func logPathFromProto(logType log.LogType, path string) string {
+	switch logType {
+	case log.LogType_None:
+		return "none"
+	case log.LogType_File:
+		return path
+	default:
+		return ""
+	}
+}
+
+var apiServices = map[string]string{
+	serial.GetMessageType(&commander.ReflectionConfig{}): "ReflectionService",
+	serial.GetMessageType(&handlerservice.Config{}):      "HandlerService",
+	serial.GetMessageType(&loggerservice.Config{}):       "LoggerService",
+	serial.GetMessageType(&statsservice.Config{}):        "StatsService",
+	serial.GetMessageType(&observatoryservice.Config{}):  "ObservatoryService",
+	serial.GetMessageType(&routerservice.Config{}):       "RoutingService",
+}
+
+func apiFromProto(config *commander.Config) (jsonObject, error) {
+	c := jsonObject{}
+	c.set("tag", config.Tag)
+	c.set("listen", config.Listen)
+	services := make([]string, 0, len(config.Service))
+	for _, service := range config.Service {
+		name, found := apiServices[service.Type]
+		if !found {
+			return nil, errors.New("unsupported API service: ", service.Type)
+		}
+		services = append(services, name)
+	}
+	c.set("services", services)
+	return c, nil
+}
+
+func policyFromProto(config *policy.Config) jsonObject {
+	c := jsonObject{}
+	levels := jsonObject{}
+	for level, p := range config.Level {
+		levels[strconv.Itoa(int(level))] = levelPolicyFromProto(p)
+	}
+	c.set("levels", levels)
+	if config.System != nil {
+		system := jsonObject{}
+		if stats := config.System.Stats; stats != nil {
+			system.set("statsInboundUplink", stats.InboundUplink)
+			system.set("statsInboundDownlink", stats.InboundDownlink)
+			system.set("statsOutboundUplink", stats.OutboundUplink)
+			system.set("statsOutboundDownlink", stats.OutboundDownlink)
+			system.set("statsInboundTransport", stats.InboundTransport)
+		}
+		c["system"] = system
+	}
+	return c
+}
+
+func levelPolicyFromProto(p *policy.Policy) jsonObject {
+	c := jsonObject{}
+	if timeout := p.Timeout; timeout != nil {
+		if timeout.Handshake != nil {
+			c["handshake"] = timeout.Handshake.Value
+		}
+		if timeout.ConnectionIdle != nil {
+			c["connIdle"] = timeout.ConnectionIdle.Value
+		}
+		if timeout.UplinkOnly != nil {
+			c["uplinkOnly"] = timeout.UplinkOnly.Value
+		}
+		if timeout.DownlinkOnly != nil {
+			c["downlinkOnly"] = timeout.DownlinkOnly.Value
+		}
+	}
+	if stats := p.Stats; stats != nil {
+		c.set("statsUserUplink", stats.UserUplink)
+		c.set("statsUserDownlink", stats.UserDownlink)
+		c.set("statsUserOnline", stats.UserOnline)
+	}
+	if p.Buffer != nil {
+		size := p.Buffer.Connection
+		if size >= 0 {
+			size /= 1024
+		}
+		c["bufferSize"] = size
+	}
+	return c
+}
+
+func routerFromProto(config *router.Config) (jsonObject, error) {
+	c := jsonObject{}
+	switch config.DomainStrategy {
+	case router.Config_UseIp:
+		c["domainStrategy"] = "AlwaysIP"
+	case router.Config_IpIfNonMatch:
+		c["domainStrategy"] = "IPIfNonMatch"
+	case router.Config_IpOnDemand:
+		c["domainStrategy"] = "IPOnDemand"
+	}
+
+	rules := make([]interface{}, 0, len(config.Rule))
+	for i, rule := range config.Rule {
+		r, err := ruleFromProto(rule)
+		if err != nil {
+			return nil, errors.New("failed to convert rule ", i).Base(err)
+		}
+		rules = append(rules, r)
+	}
+	c.set("rules", rules)
+
+	balancers := make([]interface{}, 0, len(config.BalancingRule))
+	for _, balancer := range config.BalancingRule {
+		b, err := balancerFromProto(balancer)
+		if err != nil {
+			return nil, errors.New("failed to convert balancer ", balancer.Tag).Base(err)
+		}
+		balancers = append(balancers, b)
+	}
+	c.set("balancers", balancers)
+	return c, nil
+}
+
+func ruleFromProto(rule *router.RoutingRule) (jsonObject, error) {
+	c := jsonObject{}
+	c.set("ruleTag", rule.RuleTag)
+	switch target := rule.TargetTag.(type) {
+	case *router.RoutingRule_Tag:
+		c.set("outboundTag", target.Tag)
+	case *router.RoutingRule_BalancingTag:
+		c.set("balancerTag", target.BalancingTag)
+	}
+	c.set("domainMatcher", rule.DomainMatcher)
+	c.set("domain", domainsFromProto(rule.Domain))
+
+	ips, err := geoIPsFromProto(rule.Geoip)
+	if err != nil {
+		return nil, err
+	}
+	c.set("ip", ips)
+	c.set("port", portListFromProto(rule.PortList))
+	c.set("network", networksFromProto(rule.Networks))
+
+	sourceIPs, err := geoIPsFromProto(rule.SourceGeoip)
+	if err != nil {
+		return nil, err
+	}
+	c.set("source", sourceIPs)
+	c.set("sourcePort", portListFromProto(rule.SourcePortList))
+
+	localIPs, err := geoIPsFromProto(rule.LocalGeoip)
+	if err != nil {
+		return nil, err
+	}
+	c.set("localIP", localIPs)
+	c.set("localPort", portListFromProto(rule.LocalPortList))
+
+	c.set("user", rule.UserEmail)
+	c.set("inboundTag", rule.InboundTag)
+	c.set("protocol", rule.Protocol)
+	c.set("attrs", rule.Attributes)
+	return c, nil
+}
+
+// domainsFromProto converts domains back to domain rules. Domains from
+// geosite lists are listed one by one, as the list names are not kept.
+func domainsFromProto(domains []*router.Domain) []string {
+	rules := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		switch domain.Type {
+		case router.Domain_Plain:
+			rules = append(rules, "keyword:"+domain.Value)
+		case router.Domain_Regex:
+			rules = append(rules, "regexp:"+domain.Value)
+		case router.Domain_Domain:
+			rules = append(rules, "domain:"+domain.Value)
+		case router.Domain_Full:
+			rules = append(rules, "full:"+domain.Value)
+		}
+	}
+	return rules
+}
+
+// geoIPsFromProto converts GeoIP lists back to IP rules. Lists from external
+// files are listed as CIDRs, as the file names are not kept.
+func geoIPsFromProto(geoips []*router.GeoIP) ([]string, error) {
+	var rules []string
+	for _, geoip := range geoips {
+		if geoip.CountryCode != "" && !strings.Contains(geoip.CountryCode, "_") {
+			code := strings.ToLower(geoip.CountryCode)
+			if geoip.ReverseMatch {
+				code = "!" + code
+			}
+			rules = append(rules, "geoip:"+code)
+			continue
+		}
+		if geoip.ReverseMatch {
+			return nil, errors.New("reverse matched IP list ", geoip.CountryCode, " can't be converted")
+		}
+		for _, cidr := range geoip.Cidr {
+			rules = append(rules, cidrFromProto(cidr))
+		}
+	}
+	return rules, nil
+}
+
+func cidrFromProto(cidr *router.CIDR) string {
+	ip := net.IP(cidr.Ip).String()
+	if int(cidr.Prefix) == len(cidr.Ip)*8 {
+		return ip
+	}
+	return ip + "/" + strconv.Itoa(int(cidr.Prefix))
+}
+
+func portListFromProto(list *net.PortList) *PortList {
+	if list == nil || len(list.Range) == 0 {
+		return nil
+	}
+	ports := new(PortList)
+	for _, r := range list.Range {
+		ports.Range = append(ports.Range, PortRange{From: r.From, To: r.To})
+	}
+	return ports
+}
+
+func networksFromProto(networks []net.Network) *NetworkList {
+	list := make(NetworkList, 0, len(networks))
+	for _, network := range networks {
+		switch network {
+		case net.Network_TCP:
+			list = append(list, "tcp")
+		case net.Network_UDP:
+			list = append(list, "udp")
+		case net.Network_UNIX:
+			list = append(list, "unix")
+		}
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return &list
+}
+
+// proxyNetworksFromProto is networksFromProto for proxies, which default to
+// TCP.
+func proxyNetworksFromProto(networks []net.Network) *NetworkList {
+	if len(networks) == 1 && networks[0] == net.Network_TCP {
+		return nil
+	}
+	return networksFromProto(networks)
+}
+
+func addressFromProto(address *net.IPOrDomain) string {
+	if address == nil {
+		return ""
+	}
+	addr := address.AsAddress()
+	if addr.Family().IsIP() {
+		return addr.IP().String()
+	}
+	return addr.Domain()
+}
+
+func ipFromProto(ip []byte) string {
+	if len(ip) == 0 {
+		return ""
+	}
+	return net.IP(ip).String()
+}
+
+func balancerFromProto(balancer *router.BalancingRule) (jsonObject, error) {
+	c := jsonObject{}
+	c.set("tag", balancer.Tag)
+	c.set("selector", balancer.OutboundSelector)
+	strategy := jsonObject{}
+	strategy.set("type", balancer.Strategy)
+	if balancer.StrategySettings != nil {
+		instance, err := balancer.StrategySettings.GetInstance()
+		if err != nil {
+			return nil, errors.New("failed to get strategy settings").Base(err)
+		}
+		leastLoad, ok := instance.(*router.StrategyLeastLoadConfig)
+		if !ok {
+			return nil, errors.New("unsupported strategy settings: ", balancer.StrategySettings.Type)
+		}
+		settings := jsonObject{}
+		settings.set("costs", leastLoad.Costs)
+		baselines := make([]string, 0, len(leastLoad.Baselines))
+		for _, baseline := range leastLoad.Baselines {
+			baselines = append(baselines, time.Duration(baseline).String())
+		}
+		settings.set("baselines", baselines)
+		settings.set("expected", leastLoad.Expected)
+		if leastLoad.MaxRTT > 0 {
+			settings["maxRTT"] = time.Duration(leastLoad.MaxRTT).String()
+		}
+		settings.set("tolerance", leastLoad.Tolerance)
+		strategy.set("settings", settings)
+	}
+	c.set("strategy", strategy)
+	c.set("fallbackTag", balancer.FallbackTag)
+	return c, nil
+}
+
+func dnsFromProto(config *dns.Config) (jsonObject, error) {
+	c := jsonObject{}
+	servers := make([]interface{}, 0, len(config.NameServer))
+	for _, ns := range config.NameServer {
+		server, err := nameServerFromProto(ns)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, server)
+	}
+	c.set("servers", servers)
+
+	hosts := jsonObject{}
+	for _, mapping := range config.StaticHosts {
+		domain := dnsDomainFromProto(mapping.Type, mapping.Domain)
+		if mapping.ProxiedDomain != "" {
+			hosts[domain] = mapping.ProxiedDomain
+			continue
+		}
+		ips := make([]string, 0, len(mapping.Ip))
+		for _, ip := range mapping.Ip {
+			ips = append(ips, ipFromProto(ip))
+		}
+		if len(ips) == 1 {
+			hosts[domain] = ips[0]
+		} else {
+			hosts[domain] = ips
+		}
+	}
+	c.set("hosts", hosts)
+
+	c.set("clientIp", ipFromProto(config.ClientIp))
+	c.set("tag", config.Tag)
+	c.set("queryStrategy", queryStrategyFromProto(config.QueryStrategy))
+	c.set("disableCache", config.DisableCache)
+	c.set("disableFallback", config.DisableFallback)
+	c.set("disableFallbackIfMatch", config.DisableFallbackIfMatch)
+	if config.Prefetch != nil {
+		prefetch := jsonObject{}
+		prefetch.set("threshold", config.Prefetch.Threshold)
+		prefetch.set("concurrency", config.Prefetch.Concurrency)
+		prefetch.set("ahead", config.Prefetch.Ahead)
+		c["prefetch"] = prefetch
+	}
+	return c, nil
+}
+
+// nameServerFromProto returns the address of ns, or an object if ns has
+// more settings.
+func nameServerFromProto(ns *dns.NameServer) (interface{}, error) {
+	c := jsonObject{}
+	var address string
+	if ns.Address != nil {
+		address = addressFromProto(ns.Address.Address)
+		c.set("port", ns.Address.Port)
+	}
+	c.set("clientIp", ipFromProto(ns.ClientIp))
+	c.set("skipFallback", ns.SkipFallback)
+
+	// Original rules keep geosite lists, unless the counts mismatch.
+	var size uint32
+	for _, rule := range ns.OriginalRules {
+		size += rule.Size
+	}
+	domains := make([]string, 0, len(ns.PrioritizedDomain))
+	if int(size) == len(ns.PrioritizedDomain) {
+		for _, rule := range ns.OriginalRules {
+			domains = append(domains, rule.Rule)
+		}
+	} else {
+		for _, domain := range ns.PrioritizedDomain {
+			domains = append(domains, dnsDomainFromProto(domain.Type, domain.Domain))
+		}
+	}
+	c.set("domains", domains)
+
+	expectedIPs, err := geoIPsFromProto(ns.ExpectedGeoip)
+	if err != nil {
+		return nil, err
+	}
+	if ns.ActPrior {
+		expectedIPs = append(expectedIPs, "*")
+	}
+	c.set("expectedIPs", expectedIPs)
+	unexpectedIPs, err := geoIPsFromProto(ns.UnexpectedGeoip)
+	if err != nil {
+		return nil, err
+	}
+	if ns.ActUnprior {
+		unexpectedIPs = append(unexpectedIPs, "*")
+	}
+	c.set("unexpectedIPs", unexpectedIPs)
+
+	c.set("queryStrategy", queryStrategyFromProto(ns.QueryStrategy))
+	c.set("tag", ns.Tag)
+	c.set("timeoutMs", ns.TimeoutMs)
+	c.set("disableCache", ns.DisableCache)
+	c.set("finalQuery", ns.FinalQuery)
+	if len(c) == 0 {
+		return address, nil
+	}
+	c["address"] = address
+	return c, nil
+}
+
+func dnsDomainFromProto(t dns.DomainMatchingType, domain string) string {
+	switch t {
+	case dns.DomainMatchingType_Subdomain:
+		return "domain:" + domain
+	case dns.DomainMatchingType_Keyword:
+		return "keyword:" + domain
+	case dns.DomainMatchingType_Regex:
+		return "regexp:" + domain
+	default:
+		return domain
+	}
+}
+
+func queryStrategyFromProto(strategy dns.QueryStrategy) string {
+	switch strategy {
+	case dns.QueryStrategy_USE_IP4:
+		return "UseIPv4"
+	case dns.QueryStrategy_USE_IP6:
+		return "UseIPv6"
+	case dns.QueryStrategy_USE_SYS:
+		return "UseSystem"
+	default:
+		return ""
+	}
+}
+
+var domainStrategies = map[internet.DomainStrategy]string{
+	internet.DomainStrategy_USE_IP:     "UseIP",
+	internet.DomainStrategy_USE_IP4:    "UseIPv4",
+	internet.DomainStrategy_USE_IP6:    "UseIPv6",
+	internet.DomainStrategy_USE_IP46:   "UseIPv4v6",
+	internet.DomainStrategy_USE_IP64:   "UseIPv6v4",
+	internet.DomainStrategy_FORCE_IP:   "ForceIP",
+	internet.DomainStrategy_FORCE_IP4:  "ForceIPv4",
+	internet.DomainStrategy_FORCE_IP6:  "ForceIPv6",
+	internet.DomainStrategy_FORCE_IP46: "ForceIPv4v6",
+	internet.DomainStrategy_FORCE_IP64: "ForceIPv6v4",
+}
+
+// InboundFromProto converts a built inbound back to the JSON config format.
+func InboundFromProto(config *core.InboundHandlerConfig) (map[string]interface{}, error) {
+	c := jsonObject{}
+	c.set("tag", config.Tag)
+	if config.ReceiverSettings != nil {
+		instance, err := config.ReceiverSettings.GetInstance()
+		if err != nil {
+			return nil, errors.New("failed to get receiver settings").Base(err)
+		}
+		receiver, ok := instance.(*proxyman.ReceiverConfig)
+		if !ok {
+			return nil, errors.New("unsupported receiver settings: ", config.ReceiverSettings.Type)
+		}
+		c.set("listen", addressFromProto(receiver.Listen))
+		c.set("port", portListFromProto(receiver.PortList))
+		if allocation := receiver.AllocationStrategy; allocation != nil {
+			allocate := jsonObject{"strategy": strings.ToLower(allocation.Type.String())}
+			if allocation.Concurrency != nil {
+				allocate["concurrency"] = allocation.Concurrency.Value
+			}
+			if allocation.Refresh != nil {
+				allocate["refresh"] = allocation.Refresh.Value
+			}
+			c["allocate"] = allocate
+		}
+		if receiver.StreamSettings != nil {
+			stream, err := streamFromProto(receiver.StreamSettings)
+			if err != nil {
+				return nil, errors.New("failed to convert stream settings").Base(err)
+			}
+			c["streamSettings"] = stream
+		}
+		if sniffing := receiver.SniffingSettings; sniffing != nil {
+			s := jsonObject{}
+			s.set("enabled", sniffing.Enabled)
+			s.set("destOverride", sniffing.DestinationOverride)
+			s.set("domainsExcluded", sniffing.DomainsExcluded)
+			s.set("metadataOnly", sniffing.MetadataOnly)
+			s.set("routeOnly", sniffing.RouteOnly)
+			c["sniffing"] = s
+		}
+	}
+	if config.ProxySettings != nil {
+		instance, err := config.ProxySettings.GetInstance()
+		if err != nil {
+			return nil, errors.New("failed to get proxy settings").Base(err)
+		}
+		protocol, settings, err := inboundProxyFromProto(instance)
+		if err != nil {
+			return nil, err
+		}
+		c["protocol"] = protocol
+		c.set("settings", settings)
+	}
+	return c, nil
+}
+
+func inboundProxyFromProto(config proto.Message) (string, jsonObject, error) {
+	c := jsonObject{}
+	switch config := config.(type) {
+	case *vlessinbound.Config:
+		clients, err := vlessUsersFromProto(config.Clients)
+		if err != nil {
+			return "", nil, err
+		}
+		c.set("clients", clients)
+		c.set("decryption", config.Decryption)
+		fallbacks := make([]interface{}, 0, len(config.Fallbacks))
+		for _, fb := range config.Fallbacks {
+			fallbacks = append(fallbacks, fallbackFromProto(fb.Name, fb.Alpn, fb.Path, fb.Type, fb.Dest, fb.Xver))
+		}
+		c.set("fallbacks", fallbacks)
+		return "vless", c, nil
+
+	case *vmessinbound.Config:
+		clients, err := vmessUsersFromProto(config.User)
+		if err != nil {
+			return "", nil, err
+		}
+		c.set("clients", clients)
+		if config.Default != nil {
+			c["default"] = jsonObject{"level": config.Default.Level}
+		}
+		if config.Detour != nil {
+			c["detour"] = jsonObject{"to": config.Detour.To}
+		}
+		return "vmess", c, nil
+
+	case *trojan.ServerConfig:
+		clients, err := trojanUsersFromProto(config.Users)
+		if err != nil {
+			return "", nil, err
+		}
+		c.set("clients", clients)
+		fallbacks := make([]interface{}, 0, len(config.Fallbacks))
+		for _, fb := range config.Fallbacks {
+			fallbacks = append(fallbacks, fallbackFromProto(fb.Name, fb.Alpn, fb.Path, fb.Type, fb.Dest, fb.Xver))
+		}
+		c.set("fallbacks", fallbacks)
+		return "trojan", c, nil
+
+	case *shadowsocks.ServerConfig:
+		clients := make([]jsonObject, 0, len(config.Users))
+		for _, user := range config.Users {
+			u, account, err := userFromProto(user)
+			if err != nil {
+				return "", nil, err
+			}
+			a, ok := account.(*shadowsocks.Account)
+			if !ok {
+				return "", nil, errors.New("unexpected account of Shadowsocks user ", user.Email)
+			}
+			u["method"] = cipherFromProto(a.CipherType)
+			u["password"] = a.Password
+			c.set("ivCheck", a.IvCheck)
+			clients = append(clients, u)
+		}
+		if len(clients) == 1 {
+			for k, v := range clients[0] {
+				c[k] = v
+			}
+		} else {
+			c.set("clients", clients)
+		}
+		c.set("network", proxyNetworksFromProto(config.Network))
+		return "shadowsocks", c, nil
+
+	case *shadowsocks_2022.ServerConfig:
+		c.set("method", config.Method)
+		c.set("password", config.Key)
+		c.set("email", config.Email)
+		c.set("network", proxyNetworksFromProto(config.Network))
+		return "shadowsocks", c, nil
+
+	case *shadowsocks_2022.MultiUserServerConfig:
+		c.set("method", config.Method)
+		c.set("password", config.Key)
+		clients := make([]interface{}, 0, len(config.Users))
+		for _, user := range config.Users {
+			u, account, err := userFromProto(user)
+			if err != nil {
+				return "", nil, err
+			}
+			a, ok := account.(*shadowsocks_2022.Account)
+			if !ok {
+				return "", nil, errors.New("unexpected account of Shadowsocks user ", user.Email)
+			}
+			u["password"] = a.Key
+			clients = append(clients, u)
+		}
+		c.set("clients", clients)
+		c.set("network", proxyNetworksFromProto(config.Network))
+		return "shadowsocks", c, nil
+
+	case *shadowsocks_2022.RelayServerConfig:
+		c.set("method", config.Method)
+		c.set("password", config.Key)
+		clients := make([]interface{}, 0, len(config.Destinations))
+		for _, destination := range config.Destinations {
+			d := jsonObject{}
+			d.set("password", destination.Key)
+			d.set("email", destination.Email)
+			d.set("address", addressFromProto(destination.Address))
+			d.set("port", destination.Port)
+			clients = append(clients, d)
+		}
+		c.set("clients", clients)
+		c.set("network", proxyNetworksFromProto(config.Network))
+		return "shadowsocks", c, nil
+
+	case *socks.ServerConfig:
+		if config.AuthType == socks.AuthType_PASSWORD {
+			c["auth"] = AuthMethodUserPass
+		}
+		accounts := make([]interface{}, 0, len(config.Users))
+		for _, user := range config.Users {
+			_, account, err := userFromProto(user)
+			if err != nil {
+				return "", nil, err
+			}
+			a, ok := account.(*socks.Account)
+			if !ok {
+				return "", nil, errors.New("unexpected account of SOCKS user ", user.Email)
+			}
+			accounts = append(accounts, serverAccountFromProto(user, a.Username, a.Password, config.UserLevel))
+		}
+		accounts = append(accounts, legacyAccountsFromProto(config.Accounts)...)
+		c.set("accounts", accounts)
+		c.set("udp", config.UdpEnabled)
+		c.set("ip", addressFromProto(config.Address))
+		c.set("userLevel", config.UserLevel)
+		return "socks", c, nil
+
+	case *http.ServerConfig:
+		accounts := make([]interface{}, 0, len(config.Users))
+		for _, user := range config.Users {
+			_, account, err := userFromProto(user)
+			if err != nil {
+				return "", nil, err
+			}
+			a, ok := account.(*http.Account)
+			if !ok {
+				return "", nil, errors.New("unexpected account of HTTP user ", user.Email)
+			}
+			accounts = append(accounts, serverAccountFromProto(user, a.Username, a.Password, config.UserLevel))
+		}
+		accounts = append(accounts, legacyAccountsFromProto(config.Accounts)...)
+		c.set("accounts", accounts)
+		c.set("allowTransparent", config.AllowTransparent)
+		c.set("userLevel", config.UserLevel)
+		if config.H3TlsSettings != nil {
+			tls, err := tlsFromProto(config.H3TlsSettings)
+			if err != nil {
+				return "", nil, errors.New("failed to convert HTTP/3 TLS settings").Base(err)
+			}
+			c["h3TlsSettings"] = tls
+		}
+		return "http", c, nil
+
+	case *dokodemo.Config:
+		c.set("address", addressFromProto(config.Address))
+		c.set("port", config.Port)
+		c.set("portMap", config.PortMap)
+		c.set("network", proxyNetworksFromProto(config.Networks))
+		c.set("followRedirect", config.FollowRedirect)
+		c.set("userLevel", config.UserLevel)
+		return "dokodemo-door", c, nil
+
+	default:
+		return "", nil, errors.New("unsupported inbound proxy: ", serial.GetMessageType(config))
+	}
+}
+
+// OutboundFromProto converts a built outbound back to the JSON config format.
+func OutboundFromProto(config *core.OutboundHandlerConfig) (map[string]interface{}, error) {
+	c := jsonObject{}
+	c.set("tag", config.Tag)
+	if config.SenderSettings != nil {
+		instance, err := config.SenderSettings.GetInstance()
+		if err != nil {
+			return nil, errors.New("failed to get sender settings").Base(err)
+		}
+		sender, ok := instance.(*proxyman.SenderConfig)
+		if !ok {
+			return nil, errors.New("unsupported sender settings: ", config.SenderSettings.Type)
+		}
+		if via := addressFromProto(sender.Via); via != "" {
+			if sender.ViaCidr != "" {
+				via += "/" + sender.ViaCidr
+			}
+			c["sendThrough"] = via
+		}
+		c.set("targetStrategy", domainStrategies[sender.TargetStrategy])
+		if sender.StreamSettings != nil {
+			stream, err := streamFromProto(sender.StreamSettings)
+			if err != nil {
+				return nil, errors.New("failed to convert stream settings").Base(err)
+			}
+			c["streamSettings"] = stream
+		}
+		if sender.ProxySettings != nil {
+			c["proxySettings"] = jsonObject{"tag": sender.ProxySettings.Tag}
+		}
+		if mux := sender.MultiplexSettings; mux != nil {
+			m := jsonObject{}
+			m.set("enabled", mux.Enabled)
+			m.set("concurrency", mux.Concurrency)
+			m.set("xudpConcurrency", mux.XudpConcurrency)
+			if mux.XudpProxyUDP443 != "reject" {
+				m.set("xudpProxyUDP443", mux.XudpProxyUDP443)
+			}
+			m.set("adaptive", mux.Adaptive)
+			m.set("maxLifetime", mux.MaxLifetime)
+			m.set("maxBytes", mux.MaxBytes)
+			m.set("flowControlWindow", mux.FlowControlWindow)
+			c["mux"] = m
+		}
+	}
+	if config.ProxySettings != nil {
+		instance, err := config.ProxySettings.GetInstance()
+		if err != nil {
+			return nil, errors.New("failed to get proxy settings").Base(err)
+		}
+		protocol, settings, err := outboundProxyFromProto(instance)
+		if err != nil {
+			return nil, err
+		}
+		c["protocol"] = protocol
+		c.set("settings", settings)
+	}
+	return c, nil
+}
+
+func outboundProxyFromProto(config proto.Message) (string, jsonObject, error) {
+	c := jsonObject{}
+	switch config := config.(type) {
+	case *freedom.Config:
+		c.set("domainStrategy", domainStrategies[internet.DomainStrategy(config.DomainStrategy)])
+		if override := config.DestinationOverride; override != nil && override.Server != nil {
+			if override.Server.Address == nil {
+				c["redirect"] = ":" + strconv.Itoa(int(override.Server.Port))
+			} else {
+				c["redirect"] = net.TCPDestination(override.Server.Address.AsAddress(), net.Port(override.Server.Port)).NetAddr()
+			}
+		}
+		c.set("userLevel", config.UserLevel)
+		if fragment := config.Fragment; fragment != nil {
+			f := jsonObject{}
+			switch {
+			case fragment.PacketsFrom == 0 && fragment.PacketsTo == 1:
+				f["packets"] = "tlshello"
+			case fragment.PacketsFrom != 0 || fragment.PacketsTo != 0:
+				f["packets"] = rangeFromProto(fragment.PacketsFrom, fragment.PacketsTo)
+			}
+			f["length"] = rangeFromProto(fragment.LengthMin, fragment.LengthMax)
+			f["interval"] = rangeFromProto(fragment.IntervalMin, fragment.IntervalMax)
+			if fragment.MaxSplitMin != 0 || fragment.MaxSplitMax != 0 {
+				f["maxSplit"] = rangeFromProto(fragment.MaxSplitMin, fragment.MaxSplitMax)
+			}
+			c["fragment"] = f
+		}
+		noises := make([]interface{}, 0, len(config.Noises))
+		for _, noise := range config.Noises {
+			n := jsonObject{}
+			if noise.LengthMin != 0 {
+				n["type"] = "rand"
+				n["packet"] = rangeFromProto(noise.LengthMin, noise.LengthMax)
+			} else {
+				n["type"] = "hex"
+				n["packet"] = hex.EncodeToString(noise.Packet)
+			}
+			if noise.DelayMin != 0 || noise.DelayMax != 0 {
+				n["delay"] = rangeFromProto(noise.DelayMin, noise.DelayMax)
+			}
+			if noise.ApplyTo != "ip" {
+				n.set("applyTo", noise.ApplyTo)
+			}
+			noises = append(noises, n)
+		}
+		c.set("noises", noises)
+		c.set("proxyProtocol", config.ProxyProtocol)
+		return "freedom", c, nil
+
+	case *blackhole.Config:
+		if config.Response != nil {
+			instance, err := config.Response.GetInstance()
+			if err != nil {
+				return "", nil, errors.New("failed to get blackhole response").Base(err)
+			}
+			switch instance.(type) {
+			case *blackhole.HTTPResponse:
+				c["response"] = jsonObject{"type": "http"}
+			case *blackhole.NoneResponse:
+				c["response"] = jsonObject{"type": "none"}
+			default:
+				return "", nil, errors.New("unsupported blackhole response: ", config.Response.Type)
+			}
+		}
+		return "blackhole", c, nil
+
+	case *vlessoutbound.Config:
+		vnext := make([]interface{}, 0, len(config.Vnext))
+		for _, server := range config.Vnext {
+			users, err := vlessUsersFromProto(server.User)
+			if err != nil {
+				return "", nil, err
+			}
+			vnext = append(vnext, serverFromProto(server, users))
+		}
+		c.set("vnext", vnext)
+		return "vless", c, nil
+
+	case *vmessoutbound.Config:
+		vnext := make([]interface{}, 0, len(config.Receiver))
+		for _, server := range config.Receiver {
+			users, err := vmessUsersFromProto(server.User)
+			if err != nil {
+				return "", nil, err
+			}
+			vnext = append(vnext, serverFromProto(server, users))
+		}
+		c.set("vnext", vnext)
+		return "vmess", c, nil
+
+	case *trojan.ClientConfig:
+		servers := make([]interface{}, 0, len(config.Server))
+		for _, server := range config.Server {
+			users, err := trojanUsersFromProto(server.User)
+			if err != nil {
+				return "", nil, err
+			}
+			if len(users) != 1 {
+				return "", nil, errors.New("Trojan server should have exactly one user")
+			}
+			s := users[0].(jsonObject)
+			s.set("address", addressFromProto(server.Address))
+			s.set("port", server.Port)
+			servers = append(servers, s)
+		}
+		c.set("servers", servers)
+		return "trojan", c, nil
+
+	case *shadowsocks.ClientConfig:
+		servers := make([]interface{}, 0, len(config.Server))
+		for _, server := range config.Server {
+			if len(server.User) != 1 {
+				return "", nil, errors.New("Shadowsocks server should have exactly one user")
+			}
+			s, account, err := userFromProto(server.User[0])
+			if err != nil {
+				return "", nil, err
+			}
+			a, ok := account.(*shadowsocks.Account)
+			if !ok {
+				return "", nil, errors.New("unexpected account of Shadowsocks user ", server.User[0].Email)
+			}
+			s.set("address", addressFromProto(server.Address))
+			s.set("port", server.Port)
+			s["method"] = cipherFromProto(a.CipherType)
+			s["password"] = a.Password
+			s.set("ivCheck", a.IvCheck)
+			servers = append(servers, s)
+		}
+		c.set("servers", servers)
+		return "shadowsocks", c, nil
+
+	case *shadowsocks_2022.ClientConfig:
+		s := jsonObject{}
+		s.set("address", addressFromProto(config.Address))
+		s.set("port", config.Port)
+		s.set("method", config.Method)
+		s.set("password", config.Key)
+		s.set("uot", config.UdpOverTcp)
+		s.set("uotVersion", config.UdpOverTcpVersion)
+		c["servers"] = []interface{}{s}
+		return "shadowsocks", c, nil
+
+	case *socks.ClientConfig:
+		servers := make([]interface{}, 0, len(config.Server))
+		for _, server := range config.Server {
+			users, err := clientAccountsFromProto(server.User)
+			if err != nil {
+				return "", nil, err
+			}
+			servers = append(servers, serverFromProto(server, users))
+		}
+		c.set("servers", servers)
+		return "socks", c, nil
+
+	case *http.ClientConfig:
+		servers := make([]interface{}, 0, len(config.Server))
+		for _, server := range config.Server {
+			users, err := clientAccountsFromProto(server.User)
+			if err != nil {
+				return "", nil, err
+			}
+			servers = append(servers, serverFromProto(server, users))
+		}
+		c.set("servers", servers)
+		headers := make(map[string]string, len(config.Header))
+		for _, header := range config.Header {
+			headers[header.Key] = header.Value
+		}
+		c.set("headers", headers)
+		c.set("udpOverH3", config.UdpOverH3)
+		if config.H3TlsSettings != nil {
+			tls, err := tlsFromProto(config.H3TlsSettings)
+			if err != nil {
+				return "", nil, errors.New("failed to convert HTTP/3 TLS settings").Base(err)
+			}
+			c["h3TlsSettings"] = tls
+		}
+		return "http", c, nil
+
+	case *dnsproxy.Config:
+		if server := config.Server; server != nil {
+			switch server.Network {
+			case net.Network_TCP:
+				c["network"] = "tcp"
+			case net.Network_UDP:
+				c["network"] = "udp"
+			}
+			c.set("address", addressFromProto(server.Address))
+			c.set("port", server.Port)
+		}
+		c.set("userLevel", config.UserLevel)
+		if config.Non_IPQuery != "drop" {
+			c.set("nonIPQuery", config.Non_IPQuery)
+		}
+		c.set("blockTypes", config.BlockTypes)
+		return "dns", c, nil
+
+	case *loopback.Config:
+		c.set("inboundTag", config.InboundTag)
+		return "loopback", c, nil
+
+	default:
+		return "", nil, errors.New("unsupported outbound proxy: ", serial.GetMessageType(config))
+	}
+}
+
+// rangeFromProto returns the string of a range, which Int32Range accepts.
+func rangeFromProto(from, to uint64) string {
+	if from == to {
+		return strconv.FormatUint(from, 10)
+	}
+	return strconv.FormatUint(from, 10) + "-" + strconv.FormatUint(to, 10)
+}
+
+// userFromProto returns the email and level of user, and its account.
+func userFromProto(user *protocol.User) (jsonObject, proto.Message, error) {
+	if user.Account == nil {
+		return nil, nil, errors.New("no account for user ", user.Email)
+	}
+	account, err := user.Account.GetInstance()
+	if err != nil {
+		return nil, nil, errors.New("failed to get account of user ", user.Email).Base(err)
+	}
+	c := jsonObject{}
+	c.set("email", user.Email)
+	c.set("level", user.Level)
+	return c, account, nil
+}
+
+func vlessUsersFromProto(users []*protocol.User) ([]interface{}, error) {
+	list := make([]interface{}, 0, len(users))
+	for _, user := range users {
+		u, account, err := userFromProto(user)
+		if err != nil {
+			return nil, err
+		}
+		a, ok := account.(*vless.Account)
+		if !ok {
+			return nil, errors.New("unexpected account of VLESS user ", user.Email)
+		}
+		u["id"] = a.Id
+		u.set("flow", a.Flow)
+		u.set("encryption", a.Encryption)
+		list = append(list, u)
+	}
+	return list, nil
+}
+
+func vmessUsersFromProto(users []*protocol.User) ([]interface{}, error) {
+	list := make([]interface{}, 0, len(users))
+	for _, user := range users {
+		u, account, err := userFromProto(user)
+		if err != nil {
+			return nil, err
+		}
+		a, ok := account.(*vmess.Account)
+		if !ok {
+			return nil, errors.New("unexpected account of VMess user ", user.Email)
+		}
+		u["id"] = a.Id
+		if a.SecuritySettings != nil {
+			switch a.SecuritySettings.Type {
+			case protocol.SecurityType_AES128_GCM:
+				u["security"] = "aes-128-gcm"
+			case protocol.SecurityType_CHACHA20_POLY1305:
+				u["security"] = "chacha20-poly1305"
+			case protocol.SecurityType_NONE:
+				u["security"] = "none"
+			case protocol.SecurityType_ZERO:
+				u["security"] = "zero"
+			}
+		}
+		u.set("experiments", a.TestsEnabled)
+		list = append(list, u)
+	}
+	return list, nil
+}
+
+func trojanUsersFromProto(users []*protocol.User) ([]interface{}, error) {
+	list := make([]interface{}, 0, len(users))
+	for _, user := range users {
+		u, account, err := userFromProto(user)
+		if err != nil {
+			return nil, err
+		}
+		a, ok := account.(*trojan.Account)
+		if !ok {
+			return nil, errors.New("unexpected account of Trojan user ", user.Email)
+		}
+		u["password"] = a.Password
+		list = append(list, u)
+	}
+	return list, nil
+}
+
+// serverAccountFromProto returns a SOCKS or HTTP server account, leaving out
+// the email and level if they are the defaults.
+func serverAccountFromProto(user *protocol.User, username, password string, userLevel uint32) jsonObject {
+	c := jsonObject{"user": username, "pass": password}
+	if user.Email != username {
+		c.set("email", user.Email)
+	}
+	if user.Level != userLevel {
+		c.set("level", user.Level)
+	}
+	return c
+}
+
+// legacyAccountsFromProto returns the deprecated accounts map of a SOCKS or
+// HTTP server, sorted by username.
+func legacyAccountsFromProto(accounts map[string]string) []interface{} {
+	usernames := make([]string, 0, len(accounts))
+	for username := range accounts {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+	list := make([]interface{}, 0, len(usernames))
+	for _, username := range usernames {
+		list = append(list, jsonObject{"user": username, "pass": accounts[username]})
+	}
+	return list
+}
+
+// clientAccountsFromProto returns the users of a SOCKS or HTTP server.
+func clientAccountsFromProto(users []*protocol.User) ([]interface{}, error) {
+	list := make([]interface{}, 0, len(users))
+	for _, user := range users {
+		u, account, err := userFromProto(user)
+		if err != nil {
+			return nil, err
+		}
+		switch a := account.(type) {
+		case *socks.Account:
+			u["user"] = a.Username
+			u["pass"] = a.Password
+		case *http.Account:
+			u["user"] = a.Username
+			u["pass"] = a.Password
+		default:
+			return nil, errors.New("unexpected account of user ", user.Email)
+		}
+		list = append(list, u)
+	}
+	return list, nil
+}
+
+func serverFromProto(server *protocol.ServerEndpoint, users []interface{}) jsonObject {
+	c := jsonObject{}
+	c.set("address", addressFromProto(server.Address))
+	c.set("port", server.Port)
+	c.set("users", users)
+	return c
+}
+
+func cipherFromProto(cipher shadowsocks.CipherType) string {
+	switch cipher {
+	case shadowsocks.CipherType_AES_128_GCM:
+		return "aes-128-gcm"
+	case shadowsocks.CipherType_AES_256_GCM:
+		return "aes-256-gcm"
+	case shadowsocks.CipherType_CHACHA20_POLY1305:
+		return "chacha20-poly1305"
+	case shadowsocks.CipherType_XCHACHA20_POLY1305:
+		return "xchacha20-poly1305"
+	case shadowsocks.CipherType_NONE:
+		return "none"
+	default:
+		return ""
+	}
+}
+
+// fallbackFromProto converts a VLESS or Trojan fallback. The type is left
+// out if the destination implies it.
+func fallbackFromProto(name, alpn, path, typ, dest string, xver uint64) jsonObject {
+	c := jsonObject{}
+	c.set("name", name)
+	c.set("alpn", alpn)
+	c.set("path", path)
+	typ, dest = destFromProto(typ, dest)
+	c.set("type", typ)
+	c.set("dest", dest)
+	c.set("xver", xver)
+	return c
+}
+
+// destFromProto returns the type and destination of a fallback or REALITY
+// target as configured. The type is empty if the destination implies it.
+func destFromProto(typ, dest string) (string, string) {
+	if typ == "unix" && strings.HasPrefix(dest, "@") && strings.HasSuffix(dest, "\x00") {
+		// Abstract sockets padded for HAProxy are configured with "@@".
+		dest = "@" + strings.TrimRight(dest, "\x00")
+	}
+	var implied string
+	switch {
+	case dest == "":
+	case dest == "serve-ws-none":
+		implied = "serve"
+	case dest[0] == '/' || dest[0] == '@':
+		implied = "unix"
+	default:
+		if _, _, err := net.SplitHostPort(dest); err == nil {
+			implied = "tcp"
+		}
+	}
+	if typ == implied {
+		typ = ""
+	}
+	return typ, dest
+}
@@ -0,0 +1,153 @@
+package conf_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/core"
+	. "github.com/xtls/xray-core/infra/conf"
+	"github.com/xtls/xray-core/proxy/http"
+	"google.golang.org/protobuf/proto"
+)
+
+func buildJSONConfig(t *testing.T, b []byte) *core.Config {
+	t.Helper()
+	c := new(Config)
+	common.Must(json.Unmarshal(b, c))
+	config, err := c.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestConfigFromProto(t *testing.T) {
+	config := buildJSONConfig(t, []byte(`{
+		"log": {"loglevel": "debug", "access": "none"},
+		"api": {"tag": "api", "services": ["HandlerService", "StatsService"]},
+		"stats": {},
+		"policy": {
+			"levels": {"0": {"handshake": 4, "connIdle": 300, "statsUserUplink": true, "bufferSize": 4}},
+			"system": {"statsInboundUplink": true}
+		},
+		"dns": {
+			"servers": [
+				"1.1.1.1",
+				{"address": "8.8.8.8", "port": 53, "domains": ["domain:example.com", "full:www.example.org"], "expectedIPs": ["10.0.0.0/8"], "skipFallback": true}
+			],
+			"hosts": {"domain:example.com": "127.0.0.1", "example.org": ["1.2.3.4", "::1"], "proxy.example.com": "example.net"},
+			"queryStrategy": "UseIPv4"
+		},
+		"routing": {
+			"domainStrategy": "IPIfNonMatch",
+			"rules": [
+				{"domain": ["keyword:ads", "domain:example.com"], "outboundTag": "block"},
+				{"ip": ["10.0.0.0/8", "fc00::/7"], "port": "53,443", "network": "tcp,udp", "outboundTag": "direct"},
+				{"inboundTag": ["socks"], "balancerTag": "proxies"}
+			],
+			"balancers": [{"tag": "proxies", "selector": ["proxy"], "strategy": {"type": "leastLoad", "settings": {"baselines": ["1s"], "maxRTT": "2s", "expected": 1}}}]
+		},
+		"inbounds": [{
+			"tag": "socks",
+			"protocol": "socks",
+			"listen": "127.0.0.1",
+			"port": 1080,
+			"settings": {"auth": "password", "accounts": [{"user": "user", "pass": "pass"}], "udp": true},
+			"sniffing": {"enabled": true, "destOverride": ["http", "tls"]}
+		}, {
+			"tag": "vless",
+			"protocol": "vless",
+			"port": "443-445",
+			"settings": {
+				"clients": [{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "flow": "xtls-rprx-vision", "email": "a@example.com"}],
+				"decryption": "none",
+				"fallbacks": [{"dest": 80}, {"path": "/ws", "dest": "@@vless-ws", "xver": 1}]
+			},
+			"streamSettings": {
+				"security": "reality",
+				"realitySettings": {"target": "example.com:443", "serverNames": ["example.com"], "privateKey": "aGSYystUbf59_9_6LKRxD27rmSW_-2_nyd9YG_Gwbks", "shortIds": ["", "0123abcd"]},
+				"sockopt": {"tcpFastOpen": true, "tproxy": "tproxy"}
+			}
+		}, {
+			"tag": "shadowsocks",
+			"protocol": "shadowsocks",
+			"port": 8388,
+			"settings": {"method": "aes-256-gcm", "password": "password", "network": "tcp,udp"}
+		}],
+		"outbounds": [{
+			"tag": "direct",
+			"protocol": "freedom",
+			"settings": {"domainStrategy": "UseIPv4", "fragment": {"packets": "tlshello", "length": "100-200", "interval": "10-20"}}
+		}, {
+			"tag": "block",
+			"protocol": "blackhole",
+			"settings": {"response": {"type": "http"}}
+		}, {
+			"tag": "proxy",
+			"protocol": "vmess",
+			"settings": {"vnext": [{"address": "example.com", "port": 443, "users": [{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "security": "aes-128-gcm"}]}]},
+			"streamSettings": {
+				"network": "ws",
+				"security": "tls",
+				"tlsSettings": {"serverName": "example.com", "alpn": ["http/1.1"], "fingerprint": "chrome"},
+				"wsSettings": {"path": "/ws?ed=2048", "host": "example.com"}
+			},
+			"mux": {"enabled": true, "concurrency": 8}
+		}, {
+			"tag": "xhttp",
+			"protocol": "vless",
+			"settings": {"vnext": [{"address": "1.2.3.4", "port": 443, "users": [{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "encryption": "none"}]}]},
+			"streamSettings": {
+				"network": "xhttp",
+				"security": "reality",
+				"realitySettings": {"serverName": "example.com", "password": "aGSYystUbf59_9_6LKRxD27rmSW_-2_nyd9YG_Gwbks", "shortId": "0123", "spiderX": "/path?p=1-3"},
+				"xhttpSettings": {"path": "/xhttp", "mode": "packet-up", "xPaddingBytes": "100-1000"}
+			}
+		}, {
+			"tag": "trojan",
+			"protocol": "trojan",
+			"settings": {"servers": [{"address": "example.com", "port": 443, "password": "password"}]},
+			"proxySettings": {"tag": "direct"}
+		}]
+	}`))
+
+	c, err := ConfigFromProto(config)
+	common.Must(err)
+	b, err := json.Marshal(c)
+	common.Must(err)
+	if rebuilt := buildJSONConfig(t, b); !proto.Equal(config, rebuilt) {
+		t.Error("config changed after conversion: ", string(b))
+	}
+}
+
+func TestConfigFromProtoUnsupported(t *testing.T) {
+	config := buildJSONConfig(t, []byte(`{
+		"inbounds": [{"protocol": "wireguard", "port": 51820, "settings": {"secretKey": "aGSYystUbf59_9_6LKRxD27rmSW_-2_nyd9YG_Gwbks", "peers": [{"publicKey": "aGSYystUbf59_9_6LKRxD27rmSW_-2_nyd9YG_Gwbks"}]}}]
+	}`))
+	if _, err := ConfigFromProto(config); err == nil {
+		t.Error("expected error for unsupported inbound")
+	}
+}
+
+func TestConfigFromProtoLegacyAccounts(t *testing.T) {
+	config := buildJSONConfig(t, []byte(`{
+		"inbounds": [{"protocol": "http", "port": 8080, "settings": {}}]
+	}`))
+	server, err := config.Inbound[0].ProxySettings.GetInstance()
+	common.Must(err)
+	server.(*http.ServerConfig).Accounts = map[string]string{"b": "pb", "a": "pa"}
+	config.Inbound[0].ProxySettings = serial.ToTypedMessage(server)
+
+	c, err := ConfigFromProto(config)
+	common.Must(err)
+	b, err := json.Marshal(c)
+	common.Must(err)
+	rebuilt, err := buildJSONConfig(t, b).Inbound[0].ProxySettings.GetInstance()
+	common.Must(err)
+	users := rebuilt.(*http.ServerConfig).Users
+	if len(users) != 2 || users[0].Email != "a" || users[1].Email != "b" {
+		t.Error("accounts not converted to users: ", string(b))
+	}
+}
@@ -0,0 +1,592 @@
+package conf
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/grpc"
+	"github.com/xtls/xray-core/transport/internet/headers/dns"
+	"github.com/xtls/xray-core/transport/internet/headers/http"
+	"github.com/xtls/xray-core/transport/internet/headers/noop"
+	"github.com/xtls/xray-core/transport/internet/headers/srtp"
+	headertls "github.com/xtls/xray-core/transport/internet/headers/tls"
+	"github.com/xtls/xray-core/transport/internet/headers/utp"
+	"github.com/xtls/xray-core/transport/internet/headers/wechat"
+	"github.com/xtls/xray-core/transport/internet/headers/wireguard"
+	"github.com/xtls/xray-core/transport/internet/httpupgrade"
+	"github.com/xtls/xray-core/transport/internet/kcp"
+	"github.com/xtls/xray-core/transport/internet/reality"
+	"github.com/xtls/xray-core/transport/internet/splithttp"
+	"github.com/xtls/xray-core/transport/internet/tcp"
+	"github.com/xtls/xray-core/transport/internet/tls"
+	"github.com/xtls/xray-core/transport/internet/websocket"
+)
+
+var transportNames = map[string]string{
+	"tcp":         "raw",
+	"splithttp":   "xhttp",
+	"mkcp":        "kcp",
+	"grpc":        "grpc",
+	"websocket":   "ws",
+	"httpupgrade": "httpupgrade",
+}
+
+func streamFromProto(config *internet.StreamConfig) (jsonObject, error) {
+	c := jsonObject{}
+	c.set("address", addressFromProto(config.Address))
+	c.set("port", config.Port)
+	if config.ProtocolName != "" && config.ProtocolName != "tcp" {
+		network, found := transportNames[config.ProtocolName]
+		if !found {
+			return nil, errors.New("unsupported transport: ", config.ProtocolName)
+		}
+		c["network"] = network
+	}
+
+	for _, security := range config.SecuritySettings {
+		instance, err := security.GetInstance()
+		if err != nil {
+			return nil, errors.New("failed to get security settings").Base(err)
+		}
+		switch instance := instance.(type) {
+		case *tls.Config:
+			tlsSettings, err := tlsFromProto(instance)
+			if err != nil {
+				return nil, errors.New("failed to convert TLS settings").Base(err)
+			}
+			c["security"] = "tls"
+			c["tlsSettings"] = tlsSettings
+		case *reality.Config:
+			c["security"] = "reality"
+			c["realitySettings"] = realityFromProto(instance)
+		default:
+			return nil, errors.New("unsupported security: ", security.Type)
+		}
+	}
+
+	for _, transport := range config.TransportSettings {
+		instance, err := transport.Settings.GetInstance()
+		if err != nil {
+			return nil, errors.New("failed to get transport settings").Base(err)
+		}
+		var key string
+		var settings jsonObject
+		switch instance := instance.(type) {
+		case *tcp.Config:
+			key = "rawSettings"
+			settings, err = tcpFromProto(instance)
+		case *splithttp.Config:
+			key = "xhttpSettings"
+			settings, err = splitHTTPFromProto(instance)
+		case *kcp.Config:
+			key = "kcpSettings"
+			settings, err = kcpFromProto(instance)
+		case *grpc.Config:
+			key = "grpcSettings"
+			settings = grpcFromProto(instance)
+		case *websocket.Config:
+			key = "wsSettings"
+			settings = webSocketFromProto(instance)
+		case *httpupgrade.Config:
+			key = "httpupgradeSettings"
+			settings = httpUpgradeFromProto(instance)
+		default:
+			return nil, errors.New("unsupported transport settings: ", transport.Settings.Type)
+		}
+		if err != nil {
+			return nil, errors.New("failed to convert ", key).Base(err)
+		}
+		c[key] = settings
+	}
+
+	if config.SocketSettings != nil {
+		c["sockopt"] = sockoptFromProto(config.SocketSettings)
+	}
+	return c, nil
+}
+
+func tcpFromProto(config *tcp.Config) (jsonObject, error) {
+	c := jsonObject{}
+	if config.HeaderSettings != nil {
+		instance, err := config.HeaderSettings.GetInstance()
+		if err != nil {
+			return nil, errors.New("failed to get header settings").Base(err)
+		}
+		switch instance := instance.(type) {
+		case *noop.ConnectionConfig:
+			c["header"] = jsonObject{"type": "none"}
+		case *http.Config:
+			header := jsonObject{"type": "http"}
+			if request := instance.Request; request != nil {
+				r := jsonObject{}
+				if request.Version != nil {
+					r.set("version", request.Version.Value)
+				}
+				if request.Method != nil {
+					r.set("method", request.Method.Value)
+				}
+				r.set("path", request.Uri)
+				r.set("headers", httpHeadersFromProto(request.Header))
+				header["request"] = r
+			}
+			if response := instance.Response; response != nil {
+				r := jsonObject{}
+				if response.Version != nil {
+					r.set("version", response.Version.Value)
+				}
+				if response.Status != nil {
+					r.set("status", response.Status.Code)
+					r.set("reason", response.Status.Reason)
+				}
+				r.set("headers", httpHeadersFromProto(response.Header))
+				header["response"] = r
+			}
+			c["header"] = header
+		default:
+			return nil, errors.New("unsupported header: ", config.HeaderSettings.Type)
+		}
+	}
+	c.set("acceptProxyProtocol", config.AcceptProxyProtocol)
+	return c, nil
+}
+
+func httpHeadersFromProto(headers []*http.Header) map[string][]string {
+	m := make(map[string][]string, len(headers))
+	for _, header := range headers {
+		m[header.Name] = header.Value
+	}
+	return m
+}
+
+// pathWithEarlyData adds the early data length back to the path of a
+// WebSocket or HTTPUpgrade config.
+func pathWithEarlyData(path string, ed uint32) string {
+	if ed == 0 {
+		return path
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return path
+	}
+	q := u.Query()
+	q.Set("ed", strconv.Itoa(int(ed)))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func webSocketFromProto(config *websocket.Config) jsonObject {
+	c := jsonObject{}
+	c.set("host", config.Host)
+	c.set("path", pathWithEarlyData(config.Path, config.Ed))
+	c.set("headers", config.Header)
+	c.set("acceptProxyProtocol", config.AcceptProxyProtocol)
+	c.set("heartbeatPeriod", config.HeartbeatPeriod)
+	c.set("compression", config.Compression)
+	c.set("h2", config.H2)
+	return c
+}
+
+func httpUpgradeFromProto(config *httpupgrade.Config) jsonObject {
+	c := jsonObject{}
+	c.set("host", config.Host)
+	c.set("path", pathWithEarlyData(config.Path, config.Ed))
+	c.set("headers", config.Header)
+	c.set("acceptProxyProtocol", config.AcceptProxyProtocol)
+	return c
+}
+
+func grpcFromProto(config *grpc.Config) jsonObject {
+	c := jsonObject{}
+	c.set("authority", config.Authority)
+	c.set("serviceName", config.ServiceName)
+	c.set("multiMode", config.MultiMode)
+	c.set("idle_timeout", config.IdleTimeout)
+	c.set("health_check_timeout", config.HealthCheckTimeout)
+	c.set("permit_without_stream", config.PermitWithoutStream)
+	c.set("initial_windows_size", config.InitialWindowsSize)
+	c.set("user_agent", config.UserAgent)
+	c.set("health_service", config.HealthService)
+	c.set("unknown_method_code", config.UnknownMethodCode)
+	c.set("unknown_method_message", config.UnknownMethodMessage)
+	return c
+}
+
+// xhttpRangeFromProto returns the string of an XHTTP range, which Int32Range
+// accepts, or an empty string for a zero range.
+func xhttpRangeFromProto(r *splithttp.RangeConfig) string {
+	if r == nil || (r.From == 0 && r.To == 0) {
+		return ""
+	}
+	if r.From == r.To {
+		return strconv.Itoa(int(r.From))
+	}
+	return strconv.Itoa(int(r.From)) + "-" + strconv.Itoa(int(r.To))
+}
+
+func splitHTTPFromProto(config *splithttp.Config) (jsonObject, error) {
+	c := jsonObject{}
+	c.set("host", config.Host)
+	c.set("path", config.Path)
+	if config.Mode != "auto" {
+		c.set("mode", config.Mode)
+	}
+	c.set("headers", config.Headers)
+	c.set("xPaddingBytes", xhttpRangeFromProto(config.XPaddingBytes))
+	c.set("noGRPCHeader", config.NoGRPCHeader)
+	c.set("noSSEHeader", config.NoSSEHeader)
+	c.set("scMaxEachPostBytes", xhttpRangeFromProto(config.ScMaxEachPostBytes))
+	c.set("scMinPostsIntervalMs", xhttpRangeFromProto(config.ScMinPostsIntervalMs))
+	c.set("scMaxBufferedPosts", config.ScMaxBufferedPosts)
+	c.set("scStreamUpServerSecs", xhttpRangeFromProto(config.ScStreamUpServerSecs))
+	if xmux := config.Xmux; xmux != nil {
+		x := jsonObject{}
+		x.set("maxConcurrency", xhttpRangeFromProto(xmux.MaxConcurrency))
+		x.set("maxConnections", xhttpRangeFromProto(xmux.MaxConnections))
+		x.set("cMaxReuseTimes", xhttpRangeFromProto(xmux.CMaxReuseTimes))
+		x.set("hMaxRequestTimes", xhttpRangeFromProto(xmux.HMaxRequestTimes))
+		x.set("hMaxReusableSecs", xhttpRangeFromProto(xmux.HMaxReusableSecs))
+		x.set("hKeepAlivePeriod", xmux.HKeepAlivePeriod)
+		// The defaults are filled in for an empty "xmux".
+		isDefault := len(x) == 3 && x["maxConcurrency"] == "16-32" &&
+			x["hMaxRequestTimes"] == "600-900" && x["hMaxReusableSecs"] == "1800-3000"
+		if !isDefault {
+			c.set("xmux", x)
+		}
+	}
+	if config.DownloadSettings != nil {
+		download, err := streamFromProto(config.DownloadSettings)
+		if err != nil {
+			return nil, errors.New("failed to convert downloadSettings").Base(err)
+		}
+		c["downloadSettings"] = download
+	}
+	return c, nil
+}
+
+func kcpFromProto(config *kcp.Config) (jsonObject, error) {
+	c := jsonObject{}
+	if config.Mtu != nil {
+		c["mtu"] = config.Mtu.Value
+	}
+	if config.Tti != nil {
+		c["tti"] = config.Tti.Value
+	}
+	if config.UplinkCapacity != nil {
+		c["uplinkCapacity"] = config.UplinkCapacity.Value
+	}
+	if config.DownlinkCapacity != nil {
+		c["downlinkCapacity"] = config.DownlinkCapacity.Value
+	}
+	c.set("congestion", config.Congestion)
+	if config.ReadBuffer != nil {
+		c["readBufferSize"] = config.ReadBuffer.Size / 1024 / 1024
+	}
+	if config.WriteBuffer != nil {
+		c["writeBufferSize"] = config.WriteBuffer.Size / 1024 / 1024
+	}
+	if config.HeaderConfig != nil {
+		instance, err := config.HeaderConfig.GetInstance()
+		if err != nil {
+			return nil, errors.New("failed to get header config").Base(err)
+		}
+		switch instance := instance.(type) {
+		case *noop.Config:
+			c["header"] = jsonObject{"type": "none"}
+		case *srtp.Config:
+			c["header"] = jsonObject{"type": "srtp"}
+		case *utp.Config:
+			c["header"] = jsonObject{"type": "utp"}
+		case *wechat.VideoConfig:
+			c["header"] = jsonObject{"type": "wechat-video"}
+		case *headertls.PacketConfig:
+			c["header"] = jsonObject{"type": "dtls"}
+		case *wireguard.WireguardConfig:
+			c["header"] = jsonObject{"type": "wireguard"}
+		case *dns.Config:
+			c["header"] = jsonObject{"type": "dns", "domain": instance.Domain}
+		default:
+			return nil, errors.New("unsupported header: ", config.HeaderConfig.Type)
+		}
+	}
+	if config.Seed != nil {
+		c["seed"] = config.Seed.Seed
+	}
+	if config.CongestionControl == kcp.CongestionControl_BBR {
+		c["congestionControl"] = "bbr"
+	}
+	if config.Fec != nil {
+		c["fec"] = jsonObject{"dataShards": config.Fec.DataShards, "parityShards": config.Fec.ParityShards}
+	}
+	if config.PortHopping != nil {
+		portHopping := jsonObject{}
+		portHopping.set("ports", portListFromProto(config.PortHopping.Ports))
+		portHopping.set("interval", config.PortHopping.Interval)
+		c["portHopping"] = portHopping
+	}
+	return c, nil
+}
+
+func tlsFromProto(config *tls.Config) (jsonObject, error) {
+	c := jsonObject{}
+	c.set("allowInsecure", config.AllowInsecure)
+	certificates := make([]interface{}, 0, len(config.Certificate))
+	for _, certificate := range config.Certificate {
+		certificates = append(certificates, certificateFromProto(certificate))
+	}
+	c.set("certificates", certificates)
+	c.set("serverName", config.ServerName)
+	c.set("alpn", config.NextProtocol)
+	c.set("enableSessionResumption", config.EnableSessionResumption)
+	c.set("disableSystemRoot", config.DisableSystemRoot)
+	c.set("minVersion", config.MinVersion)
+	c.set("maxVersion", config.MaxVersion)
+	c.set("cipherSuites", config.CipherSuites)
+	c.set("fingerprint", config.Fingerprint)
+	c.set("rejectUnknownSni", config.RejectUnknownSni)
+	c.set("pinnedPeerCertificateChainSha256", base64List(config.PinnedPeerCertificateChainSha256))
+	c.set("pinnedPeerCertificatePublicKeySha256", base64List(config.PinnedPeerCertificatePublicKeySha256))
+	c.set("curvePreferences", config.CurvePreferences)
+	c.set("masterKeyLog", config.MasterKeyLog)
+	c.set("verifyPeerCertInNames", config.VerifyPeerCertInNames)
+	if len(config.EchServerKeys) > 0 {
+		c["echServerKeys"] = base64.StdEncoding.EncodeToString(config.EchServerKeys)
+	}
+	c.set("echConfigList", config.EchConfigList)
+	c.set("echForceQuery", config.EchForceQuery)
+	if config.EchSocketSettings != nil {
+		c["echSockopt"] = sockoptFromProto(config.EchSocketSettings)
+	}
+	if acme := config.Acme; acme != nil {
+		a := jsonObject{}
+		a.set("directoryUrl", acme.DirectoryUrl)
+		a.set("email", acme.Email)
+		a.set("domains", acme.Domains)
+		a.set("storagePath", acme.StoragePath)
+		a.set("httpChallengeInbound", acme.HttpChallengeInbound)
+		a.set("httpChallengeListen", acme.HttpChallengeListen)
+		a.set("renewBeforeDays", acme.RenewBeforeDays)
+		c["acme"] = a
+	}
+	if clientAuth := config.ClientAuth; clientAuth != nil {
+		a := jsonObject{}
+		if clientAuth.Mode == tls.ClientAuth_VERIFY_IF_GIVEN {
+			a["mode"] = "verifyIfGiven"
+		}
+		// CA files are read when building, so their contents are listed.
+		var ca []string
+		for _, certificate := range clientAuth.CertificateAuthority {
+			ca = append(ca, strings.Split(strings.TrimSpace(string(certificate)), "\n")...)
+		}
+		a.set("ca", ca)
+		a.set("crlFile", clientAuth.CrlPath)
+		c["clientAuth"] = a
+	}
+	if keys := config.SessionTicketKeys; keys != nil {
+		k := jsonObject{}
+		k.set("keyFiles", keys.KeyFiles)
+		k.set("rotationInterval", keys.RotationInterval)
+		c["sessionTicketKeys"] = k
+	}
+	return c, nil
+}
+
+func certificateFromProto(certificate *tls.Certificate) jsonObject {
+	c := jsonObject{}
+	if certificate.CertificatePath != "" {
+		c["certificateFile"] = certificate.CertificatePath
+	} else {
+		c.set("certificate", pemLines(certificate.Certificate))
+	}
+	if certificate.KeyPath != "" {
+		c["keyFile"] = certificate.KeyPath
+	} else {
+		c.set("key", pemLines(certificate.Key))
+	}
+	switch certificate.Usage {
+	case tls.Certificate_AUTHORITY_VERIFY:
+		c["usage"] = "verify"
+	case tls.Certificate_AUTHORITY_ISSUE:
+		c["usage"] = "issue"
+	}
+	c.set("ocspStapling", certificate.OcspStapling)
+	if certificate.CertificatePath != "" || certificate.KeyPath != "" {
+		c.set("oneTimeLoading", certificate.OneTimeLoading)
+	}
+	c.set("buildChain", certificate.BuildChain)
+	return c
+}
+
+func pemLines(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+	return strings.Split(string(b), "\n")
+}
+
+func base64List(list [][]byte) []string {
+	s := make([]string, 0, len(list))
+	for _, b := range list {
+		s = append(s, base64.StdEncoding.EncodeToString(b))
+	}
+	return s
+}
+
+func realityFromProto(config *reality.Config) jsonObject {
+	c := jsonObject{}
+	c.set("masterKeyLog", config.MasterKeyLog)
+	c.set("show", config.Show)
+	if len(config.PrivateKey) > 0 {
+		typ, dest := destFromProto(config.Type, config.Dest)
+		c.set("type", typ)
+		c.set("target", dest)
+		c.set("xver", config.Xver)
+		c.set("serverNames", config.ServerNames)
+		c["privateKey"] = base64.RawURLEncoding.EncodeToString(config.PrivateKey)
+		c.set("minClientVer", versionFromProto(config.MinClientVer))
+		c.set("maxClientVer", versionFromProto(config.MaxClientVer))
+		c.set("maxTimeDiff", config.MaxTimeDiff)
+		shortIds := make([]string, 0, len(config.ShortIds))
+		for _, id := range config.ShortIds {
+			shortIds = append(shortIds, shortIdFromProto(id))
+		}
+		c["shortIds"] = shortIds
+		if len(config.Mldsa65Seed) > 0 {
+			c["mldsa65Seed"] = base64.RawURLEncoding.EncodeToString(config.Mldsa65Seed)
+		}
+		c.set("limitFallbackUpload", limitFallbackFromProto(config.LimitFallbackUpload))
+		c.set("limitFallbackDownload", limitFallbackFromProto(config.LimitFallbackDownload))
+		return c
+	}
+
+	c.set("fingerprint", config.Fingerprint)
+	c.set("serverName", config.ServerName)
+	c["password"] = base64.RawURLEncoding.EncodeToString(config.PublicKey)
+	c.set("shortId", shortIdFromProto(config.ShortId))
+	if len(config.Mldsa65Verify) > 0 {
+		c["mldsa65Verify"] = base64.RawURLEncoding.EncodeToString(config.Mldsa65Verify)
+	}
+	if spiderX := spiderXFromProto(config.SpiderX, config.SpiderY); spiderX != "/" {
+		c.set("spiderX", spiderX)
+	}
+	return c
+}
+
+func versionFromProto(version []byte) string {
+	parts := make([]string, 0, len(version))
+	for _, b := range version {
+		parts = append(parts, strconv.Itoa(int(b)))
+	}
+	return strings.Join(parts, ".")
+}
+
+// shortIdFromProto returns the hex of a short ID without the zero padding.
+func shortIdFromProto(id []byte) string {
+	s := hex.EncodeToString(id)
+	for strings.HasSuffix(s, "00") {
+		s = strings.TrimSuffix(s, "00")
+	}
+	return s
+}
+
+func limitFallbackFromProto(limit *reality.LimitFallback) *LimitFallback {
+	if limit == nil || (limit.AfterBytes == 0 && limit.BytesPerSec == 0 && limit.BurstBytesPerSec == 0) {
+		return nil
+	}
+	return &LimitFallback{
+		AfterBytes:       limit.AfterBytes,
+		BytesPerSec:      limit.BytesPerSec,
+		BurstBytesPerSec: limit.BurstBytesPerSec,
+	}
+}
+
+// spiderXFromProto adds the spider parameters back to the query of spiderX.
+func spiderXFromProto(spiderX string, spiderY []int64) string {
+	if spiderX == "" {
+		spiderX = "/"
+	}
+	u, err := url.Parse(spiderX)
+	if err != nil {
+		return spiderX
+	}
+	q := u.Query()
+	for i, param := range []string{"p", "c", "t", "i", "r"} {
+		if len(spiderY) < i*2+2 {
+			break
+		}
+		from, to := spiderY[i*2], spiderY[i*2+1]
+		switch {
+		case from == 0 && to == 0:
+		case from == to:
+			q.Set(param, strconv.FormatInt(from, 10))
+		default:
+			q.Set(param, strconv.FormatInt(from, 10)+"-"+strconv.FormatInt(to, 10))
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func sockoptFromProto(config *internet.SocketConfig) jsonObject {
+	c := jsonObject{}
+	c.set("mark", config.Mark)
+	switch {
+	case config.Tfo == 256:
+		c["tcpFastOpen"] = true
+	case config.Tfo == -1:
+		c["tcpFastOpen"] = false
+	case config.Tfo != 0:
+		c["tcpFastOpen"] = config.Tfo
+	}
+	switch config.Tproxy {
+	case internet.SocketConfig_TProxy:
+		c["tproxy"] = "tproxy"
+	case internet.SocketConfig_Redirect:
+		c["tproxy"] = "redirect"
+	}
+	c.set("acceptProxyProtocol", config.AcceptProxyProtocol)
+	c.set("domainStrategy", domainStrategies[config.DomainStrategy])
+	c.set("dialerProxy", config.DialerProxy)
+	c.set("tcpKeepAliveInterval", config.TcpKeepAliveInterval)
+	c.set("tcpKeepAliveIdle", config.TcpKeepAliveIdle)
+	c.set("tcpCongestion", config.TcpCongestion)
+	c.set("tcpWindowClamp", config.TcpWindowClamp)
+	c.set("tcpMaxSeg", config.TcpMaxSeg)
+	c.set("penetrate", config.Penetrate)
+	c.set("tcpUserTimeout", config.TcpUserTimeout)
+	c.set("v6only", config.V6Only)
+	c.set("interface", config.Interface)
+	c.set("tcpMptcp", config.TcpMptcp)
+	customSockopts := make([]interface{}, 0, len(config.CustomSockopt))
+	for _, opt := range config.CustomSockopt {
+		o := jsonObject{}
+		o.set("system", opt.System)
+		o.set("network", opt.Network)
+		o.set("level", opt.Level)
+		o.set("opt", opt.Opt)
+		o.set("value", opt.Value)
+		o.set("type", opt.Type)
+		customSockopts = append(customSockopts, o)
+	}
+	c.set("customSockopt", customSockopts)
+	if config.AddressPortStrategy != internet.AddressPortStrategy_None {
+		c["addressPortStrategy"] = config.AddressPortStrategy.String()
+	}
+	if he := config.HappyEyeballs; he != nil {
+		h := jsonObject{}
+		h.set("prioritizeIPv6", he.PrioritizeIpv6)
+		h.set("tryDelayMs", he.TryDelayMs)
+		if he.Interleave != 1 {
+			h["interleave"] = he.Interleave
+		}
+		if he.MaxConcurrentTry != 4 {
+			h["maxConcurrentTry"] = he.MaxConcurrentTry
+		}
+		c.set("happyEyeballs", h)
+	}
+	return c
+}
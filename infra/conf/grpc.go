@@ -1,19 +1,24 @@
 package conf
 
 import (
+	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/transport/internet/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/protobuf/proto"
 )
 
 type GRPCConfig struct {
-	Authority           string `json:"authority"`
-	ServiceName         string `json:"serviceName"`
-	MultiMode           bool   `json:"multiMode"`
-	IdleTimeout         int32  `json:"idle_timeout"`
-	HealthCheckTimeout  int32  `json:"health_check_timeout"`
-	PermitWithoutStream bool   `json:"permit_without_stream"`
-	InitialWindowsSize  int32  `json:"initial_windows_size"`
-	UserAgent           string `json:"user_agent"`
+	Authority            string      `json:"authority"`
+	ServiceName          string      `json:"serviceName"`
+	MultiMode            bool        `json:"multiMode"`
+	IdleTimeout          int32       `json:"idle_timeout"`
+	HealthCheckTimeout   int32       `json:"health_check_timeout"`
+	PermitWithoutStream  bool        `json:"permit_without_stream"`
+	InitialWindowsSize   int32       `json:"initial_windows_size"`
+	UserAgent            string      `json:"user_agent"`
+	HealthService        bool        `json:"health_service"`
+	UnknownMethodCode    *codes.Code `json:"unknown_method_code"`
+	UnknownMethodMessage string      `json:"unknown_method_message"`
 }
 
 func (g *GRPCConfig) Build() (proto.Message, error) {
@@ -27,15 +32,25 @@ func (g *GRPCConfig) Build() (proto.Message, error) {
 		// default window size of gRPC-go
 		g.InitialWindowsSize = 0
 	}
+	var unknownMethodCode uint32
+	if g.UnknownMethodCode != nil {
+		if *g.UnknownMethodCode > codes.Unauthenticated {
+			return nil, errors.New("invalid gRPC status code: ", uint32(*g.UnknownMethodCode))
+		}
+		unknownMethodCode = uint32(*g.UnknownMethodCode)
+	}
 
 	return &grpc.Config{
-		Authority:           g.Authority,
-		ServiceName:         g.ServiceName,
-		MultiMode:           g.MultiMode,
-		IdleTimeout:         g.IdleTimeout,
-		HealthCheckTimeout:  g.HealthCheckTimeout,
-		PermitWithoutStream: g.PermitWithoutStream,
-		InitialWindowsSize:  g.InitialWindowsSize,
-		UserAgent:           g.UserAgent,
+		Authority:            g.Authority,
+		ServiceName:          g.ServiceName,
+		MultiMode:            g.MultiMode,
+		IdleTimeout:          g.IdleTimeout,
+		HealthCheckTimeout:   g.HealthCheckTimeout,
+		PermitWithoutStream:  g.PermitWithoutStream,
+		InitialWindowsSize:   g.InitialWindowsSize,
+		UserAgent:            g.UserAgent,
+		HealthService:        g.HealthService,
+		UnknownMethodCode:    unknownMethodCode,
+		UnknownMethodMessage: g.UnknownMethodMessage,
 	}, nil
 }
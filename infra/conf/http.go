@@ -7,12 +7,33 @@ import (
 	"github.com/xtls/xray-core/common/protocol"
 	"github.com/xtls/xray-core/common/serial"
 	"github.com/xtls/xray-core/proxy/http"
+	"github.com/xtls/xray-core/transport/internet/tls"
 	"google.golang.org/protobuf/proto"
 )
 
 type HTTPAccount struct {
 	Username string `json:"user"`
 	Password string `json:"pass"`
+	Email    string `json:"email"`
+	Level    uint32 `json:"level"`
+}
+
+// BuildUser builds the account as a server side user. Email defaults to the
+// username so that routing and stats keep matching it, and level defaults to userLevel.
+func (v *HTTPAccount) BuildUser(userLevel uint32) *protocol.User {
+	email := v.Email
+	if email == "" {
+		email = v.Username
+	}
+	level := v.Level
+	if level == 0 {
+		level = userLevel
+	}
+	return &protocol.User{
+		Email:   email,
+		Level:   level,
+		Account: serial.ToTypedMessage(v.Build()),
+	}
 }
 
 func (v *HTTPAccount) Build() *http.Account {
@@ -23,9 +44,10 @@ func (v *HTTPAccount) Build() *http.Account {
 }
 
 type HTTPServerConfig struct {
-	Accounts    []*HTTPAccount `json:"accounts"`
-	Transparent bool           `json:"allowTransparent"`
-	UserLevel   uint32         `json:"userLevel"`
+	Accounts      []*HTTPAccount `json:"accounts"`
+	Transparent   bool           `json:"allowTransparent"`
+	UserLevel     uint32         `json:"userLevel"`
+	H3TLSSettings *TLSConfig     `json:"h3TlsSettings"`
 }
 
 func (c *HTTPServerConfig) Build() (proto.Message, error) {
@@ -34,11 +56,16 @@ func (c *HTTPServerConfig) Build() (proto.Message, error) {
 		UserLevel:        c.UserLevel,
 	}
 
-	if len(c.Accounts) > 0 {
-		config.Accounts = make(map[string]string)
-		for _, account := range c.Accounts {
-			config.Accounts[account.Username] = account.Password
+	for _, account := range c.Accounts {
+		config.Users = append(config.Users, account.BuildUser(c.UserLevel))
+	}
+
+	if c.H3TLSSettings != nil {
+		ts, err := c.H3TLSSettings.Build()
+		if err != nil {
+			return nil, errors.New("failed to build HTTP/3 TLS settings").Base(err)
 		}
+		config.H3TlsSettings = ts.(*tls.Config)
 	}
 
 	return config, nil
@@ -51,8 +78,10 @@ type HTTPRemoteConfig struct {
 }
 
 type HTTPClientConfig struct {
-	Servers []*HTTPRemoteConfig `json:"servers"`
-	Headers map[string]string   `json:"headers"`
+	Servers       []*HTTPRemoteConfig `json:"servers"`
+	Headers       map[string]string   `json:"headers"`
+	UDPOverH3     bool                `json:"udpOverH3"`
+	H3TLSSettings *TLSConfig          `json:"h3TlsSettings"`
 }
 
 func (v *HTTPClientConfig) Build() (proto.Message, error) {
@@ -84,5 +113,13 @@ func (v *HTTPClientConfig) Build() (proto.Message, error) {
 			Value: value,
 		})
 	}
+	config.UdpOverH3 = v.UDPOverH3
+	if v.H3TLSSettings != nil {
+		ts, err := v.H3TLSSettings.Build()
+		if err != nil {
+			return nil, errors.New("failed to build HTTP/3 TLS settings").Base(err)
+		}
+		config.H3TlsSettings = ts.(*tls.Config)
+	}
 	return config, nil
 }
@@ -3,8 +3,12 @@ package conf_test
 import (
 	"testing"
 
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/serial"
 	. "github.com/xtls/xray-core/infra/conf"
 	"github.com/xtls/xray-core/proxy/http"
+	"github.com/xtls/xray-core/transport/internet/tls"
 )
 
 func TestHTTPServerConfig(t *testing.T) {
@@ -19,6 +23,12 @@ func TestHTTPServerConfig(t *testing.T) {
 					{
 						"user": "my-username",
 						"pass": "my-password"
+					},
+					{
+						"user": "other-username",
+						"pass": "other-password",
+						"email": "other@example.com",
+						"level": 2
 					}
 				],
 				"allowTransparent": true,
@@ -26,12 +36,95 @@ func TestHTTPServerConfig(t *testing.T) {
 			}`,
 			Parser: loadJSON(creator),
 			Output: &http.ServerConfig{
-				Accounts: map[string]string{
-					"my-username": "my-password",
+				Users: []*protocol.User{
+					{
+						Email: "my-username",
+						Level: 1,
+						Account: serial.ToTypedMessage(&http.Account{
+							Username: "my-username",
+							Password: "my-password",
+						}),
+					},
+					{
+						Email: "other@example.com",
+						Level: 2,
+						Account: serial.ToTypedMessage(&http.Account{
+							Username: "other-username",
+							Password: "other-password",
+						}),
+					},
 				},
 				AllowTransparent: true,
 				UserLevel:        1,
 			},
 		},
+		{
+			Input: `{
+				"h3TlsSettings": {
+					"serverName": "proxy.example.com"
+				}
+			}`,
+			Parser: loadJSON(creator),
+			Output: &http.ServerConfig{
+				H3TlsSettings: &tls.Config{
+					ServerName: "proxy.example.com",
+				},
+			},
+		},
+	})
+}
+
+func TestHTTPClientConfig(t *testing.T) {
+	creator := func() Buildable {
+		return new(HTTPClientConfig)
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"servers": [
+					{
+						"address": "127.0.0.1",
+						"port": 443,
+						"users": [
+							{
+								"user": "my-username",
+								"pass": "my-password"
+							}
+						]
+					}
+				],
+				"udpOverH3": true,
+				"h3TlsSettings": {
+					"serverName": "proxy.example.com"
+				}
+			}`,
+			Parser: loadJSON(creator),
+			Output: &http.ClientConfig{
+				Server: []*protocol.ServerEndpoint{
+					{
+						Address: &net.IPOrDomain{
+							Address: &net.IPOrDomain_Ip{
+								Ip: []byte{127, 0, 0, 1},
+							},
+						},
+						Port: 443,
+						User: []*protocol.User{
+							{
+								Account: serial.ToTypedMessage(&http.Account{
+									Username: "my-username",
+									Password: "my-password",
+								}),
+							},
+						},
+					},
+				},
+				Header:    []*http.Header{},
+				UdpOverH3: true,
+				H3TlsSettings: &tls.Config{
+					ServerName: "proxy.example.com",
+				},
+			},
+		},
 	})
 }
@@ -2,4 +2,12 @@ package conf
 
 func init() {
 	RegisterConfigureFilePostProcessingStage("FakeDNS", &FakeDNSPostProcessingStage{})
+
+	RegisterConfigureFileLinter("ShadowedRule", &ShadowedRuleLinter{})
+	RegisterConfigureFileLinter("UndefinedTag", &UndefinedTagLinter{})
+	RegisterConfigureFileLinter("Balancer", &BalancerLinter{})
+	RegisterConfigureFileLinter("DuplicatedUser", &DuplicatedUserLinter{})
+	RegisterConfigureFileLinter("REALITY", &REALITYLinter{})
+	RegisterConfigureFileLinter("PortCollision", &PortCollisionLinter{})
+	RegisterConfigureFileLinter("CertificateExpiry", &CertificateExpiryLinter{})
 }
@@ -1,6 +1,10 @@
 package conf
 
-import "github.com/xtls/xray-core/common/errors"
+import (
+	"sort"
+
+	"github.com/xtls/xray-core/common/errors"
+)
 
 type ConfigureFilePostProcessingStage interface {
 	Process(conf *Config) error
@@ -23,3 +27,58 @@ func PostProcessConfigureFile(conf *Config) error {
 	}
 	return nil
 }
+
+// LintSeverity is the severity of a LintIssue.
+type LintSeverity string
+
+const (
+	LintError   LintSeverity = "error"
+	LintWarning LintSeverity = "warning"
+)
+
+// LintIssue is a problem in a config found by a linter.
+type LintIssue struct {
+	Linter   string       `json:"linter"`
+	Severity LintSeverity `json:"severity"`
+	Path     string       `json:"path"`
+	Message  string       `json:"message"`
+}
+
+func (i *LintIssue) String() string {
+	s := string(i.Severity) + ": "
+	if i.Path != "" {
+		s += i.Path + ": "
+	}
+	return s + i.Message + " (" + i.Linter + ")"
+}
+
+type ConfigureFileLinter interface {
+	Lint(conf *Config) []*LintIssue
+}
+
+var configureFileLinters map[string]ConfigureFileLinter
+
+func RegisterConfigureFileLinter(name string, linter ConfigureFileLinter) {
+	if configureFileLinters == nil {
+		configureFileLinters = make(map[string]ConfigureFileLinter)
+	}
+	configureFileLinters[name] = linter
+}
+
+// LintConfigureFile runs all linters on conf, in the order of their names.
+func LintConfigureFile(conf *Config) []*LintIssue {
+	names := make([]string, 0, len(configureFileLinters))
+	for name := range configureFileLinters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var issues []*LintIssue
+	for _, name := range names {
+		for _, issue := range configureFileLinters[name].Lint(conf) {
+			issue.Linter = name
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
@@ -0,0 +1,141 @@
+package conf_test
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	. "github.com/xtls/xray-core/infra/conf"
+)
+
+func TestLintConfigureFile(t *testing.T) {
+	expiring, _ := cert.MustGenerate(nil, cert.NotAfter(time.Now().Add(24*time.Hour))).ToPEM()
+	expired, _ := cert.MustGenerate(nil, cert.NotBefore(time.Now().Add(-48*time.Hour)), cert.NotAfter(time.Now().Add(-24*time.Hour))).ToPEM()
+	valid, _ := cert.MustGenerate(nil, cert.NotAfter(time.Now().Add(365*24*time.Hour))).ToPEM()
+	certificates, err := json.Marshal([]map[string]interface{}{
+		{"certificate": strings.Split(string(expiring), "\n")},
+		{"certificate": strings.Split(string(expired), "\n")},
+		{"certificate": strings.Split(string(valid), "\n")},
+	})
+	common.Must(err)
+
+	config := `{
+		"inbounds": [{
+			"tag": "vless",
+			"protocol": "vless",
+			"port": 443,
+			"settings": {"decryption": "none", "clients": [
+				{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "email": "a@example.com"},
+				{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "email": "a@example.com"}
+			]},
+			"streamSettings": {"security": "reality", "realitySettings": {"target": "example.com:443", "serverNames": ["example.com"], "privateKey": "aGSYystUbf59_9_6LKRxD27rmSW_-2_nyd9YG_Gwbks"}}
+		}, {
+			"tag": "vmess",
+			"protocol": "vmess",
+			"port": "400-500",
+			"settings": {"clients": [
+				{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "email": "b@example.com"},
+				{"id": "b831381d-6324-4d53-ad4f-8cda48b30811", "email": "a@example.com"}
+			]}
+		}, {
+			"tag": "kcp",
+			"protocol": "vmess",
+			"port": 443,
+			"streamSettings": {"network": "kcp"}
+		}, {
+			"tag": "local",
+			"protocol": "socks",
+			"listen": "127.0.0.1",
+			"port": 1080
+		}, {
+			"tag": "tls",
+			"protocol": "trojan",
+			"listen": "127.0.0.2",
+			"port": 1080,
+			"settings": {"clients": [{"password": "password"}]},
+			"streamSettings": {"security": "tls", "tlsSettings": {"certificates": ` + string(certificates) + `}}
+		}, {
+			"tag": "dns",
+			"protocol": "dokodemo-door",
+			"port": 53,
+			"settings": {"address": "1.1.1.1", "port": 53, "network": "udp"}
+		}, {
+			"tag": "socks",
+			"protocol": "socks",
+			"port": 53
+		}, {
+			"tag": "h3",
+			"protocol": "vless",
+			"port": 1080,
+			"settings": {"decryption": "none"},
+			"streamSettings": {"network": "xhttp", "security": "tls", "tlsSettings": {"alpn": ["h3"]}}
+		}, {
+			"tag": "quic",
+			"protocol": "dokodemo-door",
+			"port": 1080,
+			"settings": {"address": "1.1.1.1", "port": 443, "network": "udp"}
+		}],
+		"outbounds": [{
+			"tag": "direct",
+			"protocol": "freedom"
+		}, {
+			"tag": "proxy",
+			"protocol": "vless",
+			"proxySettings": {"tag": "missing"},
+			"streamSettings": {"security": "reality", "realitySettings": {"serverName": "example.com"}}
+		}],
+		"routing": {
+			"rules": [
+				{"domain": ["geosite:cn", "example.com"], "port": "80,443", "outboundTag": "direct"},
+				{"domain": ["example.com"], "port": 443, "network": "tcp", "outboundTag": "direct"},
+				{"domain": ["example.com", "example.org"], "port": 443, "outboundTag": "direct"},
+				{"inboundTag": ["vless", "unknown"], "outboundTag": "missing"},
+				{"inboundTag": ["vmess"], "balancerTag": "balancer"},
+				{"inboundTag": ["kcp"], "balancerTag": "empty"},
+				{"outboundTag": "direct"},
+				{"protocol": ["bittorrent"], "outboundTag": "direct"}
+			],
+			"balancers": [
+				{"tag": "balancer", "selector": ["sub-"], "fallbackTag": "missing"},
+				{"tag": "empty", "selector": []},
+				{"tag": "none", "selector": ["none"]}
+			]
+		},
+		"subscriptions": [{"tag": "sub", "url": "https://example.com/sub"}]
+	}`
+	c := new(Config)
+	common.Must(json.Unmarshal([]byte(config), c))
+
+	var actual []string
+	for _, issue := range LintConfigureFile(c) {
+		actual = append(actual, issue.Linter+" "+string(issue.Severity)+" "+issue.Path)
+	}
+	expected := []string{
+		"Balancer error routing.balancers[1]",
+		"Balancer warning routing.balancers[2]",
+		"CertificateExpiry error inbounds[4].streamSettings.tlsSettings.certificates[1]",
+		"CertificateExpiry warning inbounds[4].streamSettings.tlsSettings.certificates[0]",
+		"DuplicatedUser warning inbounds[0].settings.clients[1]",
+		"DuplicatedUser warning inbounds[1].settings.clients[0]",
+		"DuplicatedUser warning inbounds[1].settings.clients[1]",
+		"PortCollision error inbounds[1]",
+		"PortCollision error inbounds[8]",
+		"REALITY error inbounds[0].streamSettings.realitySettings",
+		"REALITY error outbounds[1].streamSettings.realitySettings",
+		"ShadowedRule warning routing.rules[1]",
+		"ShadowedRule warning routing.rules[7]",
+		"UndefinedTag error outbounds[1].proxySettings",
+		"UndefinedTag error routing.balancers[0]",
+		"UndefinedTag error routing.rules[3]",
+		"UndefinedTag warning routing.rules[3]",
+	}
+	sort.Strings(actual)
+	if r := cmp.Diff(actual, expected); r != "" {
+		t.Error(r)
+	}
+}
@@ -0,0 +1,569 @@
+package conf
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+)
+
+// certificateExpiryWarning is how long before a certificate expires to warn
+// about it.
+const certificateExpiryWarning = 30 * 24 * time.Hour
+
+func lintIssue(severity LintSeverity, path string, a ...interface{}) *LintIssue {
+	return &LintIssue{
+		Severity: severity,
+		Path:     path,
+		Message:  fmt.Sprint(a...),
+	}
+}
+
+// lintRule is the conditions of a routing rule.
+type lintRule struct {
+	values map[string]map[string]bool
+	ports  map[string]*PortList
+	attrs  map[string]string
+}
+
+func parseLintRule(raw json.RawMessage) (*lintRule, error) {
+	var r RawFieldRule
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return nil, err
+	}
+	if r.SourceIP == nil {
+		r.SourceIP = r.Source
+	}
+	var networks *StringList
+	if r.Network != nil {
+		list := make(StringList, 0, len(*r.Network))
+		for _, network := range *r.Network {
+			list = append(list, strings.ToLower(strings.TrimSpace(string(network))))
+		}
+		networks = &list
+	}
+
+	rule := &lintRule{
+		values: make(map[string]map[string]bool),
+		ports:  make(map[string]*PortList),
+		attrs:  r.Attributes,
+	}
+	rule.addValues("domain", r.Domain, r.Domains)
+	rule.addValues("ip", r.IP)
+	rule.addValues("network", networks)
+	rule.addValues("sourceIP", r.SourceIP)
+	rule.addValues("user", r.User)
+	rule.addValues("inboundTag", r.InboundTag)
+	rule.addValues("protocol", r.Protocols)
+	rule.addValues("localIP", r.LocalIP)
+	rule.addPorts("port", r.Port)
+	rule.addPorts("sourcePort", r.SourcePort)
+	rule.addPorts("localPort", r.LocalPort)
+	return rule, nil
+}
+
+func (r *lintRule) addValues(field string, lists ...*StringList) {
+	for _, list := range lists {
+		if list == nil {
+			continue
+		}
+		for _, value := range *list {
+			if r.values[field] == nil {
+				r.values[field] = make(map[string]bool)
+			}
+			r.values[field][value] = true
+		}
+	}
+}
+
+func (r *lintRule) addPorts(field string, list *PortList) {
+	if list != nil && len(list.Range) > 0 {
+		r.ports[field] = list
+	}
+}
+
+// covers returns whether r matches all the traffic other matches.
+func (r *lintRule) covers(other *lintRule) bool {
+	for field, values := range r.values {
+		if other.values[field] == nil {
+			return false
+		}
+		for value := range other.values[field] {
+			if !values[value] {
+				return false
+			}
+		}
+	}
+	for field, list := range r.ports {
+		if other.ports[field] == nil {
+			return false
+		}
+		for _, o := range other.ports[field].Range {
+			covered := false
+			for _, p := range list.Range {
+				if p.From <= o.From && o.To <= p.To {
+					covered = true
+					break
+				}
+			}
+			if !covered {
+				return false
+			}
+		}
+	}
+	for k, v := range r.attrs {
+		if value, found := other.attrs[k]; !found || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ShadowedRuleLinter finds routing rules which never match, as earlier rules
+// match all their traffic.
+type ShadowedRuleLinter struct{}
+
+func (ShadowedRuleLinter) Lint(config *Config) []*LintIssue {
+	if config.RouterConfig == nil {
+		return nil
+	}
+	var issues []*LintIssue
+	rules := make([]*lintRule, len(config.RouterConfig.RuleList))
+	for i, raw := range config.RouterConfig.RuleList {
+		path := fmt.Sprintf("routing.rules[%d]", i)
+		rule, err := parseLintRule(raw)
+		if err != nil {
+			issues = append(issues, lintIssue(LintError, path, "invalid rule: ", err))
+			continue
+		}
+		rules[i] = rule
+		for j := 0; j < i; j++ {
+			if rules[j] != nil && rules[j].covers(rule) {
+				issues = append(issues, lintIssue(LintWarning, path, "unreachable, as routing.rules[", j, "] matches all its traffic"))
+				break
+			}
+		}
+	}
+	return issues
+}
+
+// UndefinedTagLinter finds references to inbounds, outbounds and balancers
+// which are not defined.
+type UndefinedTagLinter struct{}
+
+func (UndefinedTagLinter) Lint(config *Config) []*LintIssue {
+	inbounds := make(map[string]bool)
+	outbounds := make(map[string]bool)
+	balancers := make(map[string]bool)
+	for _, inbound := range config.InboundConfigs {
+		inbounds[inbound.Tag] = true
+	}
+	for _, outbound := range config.OutboundConfigs {
+		outbounds[outbound.Tag] = true
+	}
+	if config.API != nil && config.API.Tag != "" {
+		inbounds[config.API.Tag] = true
+		outbounds[config.API.Tag] = true
+	}
+	if config.DNSConfig != nil && config.DNSConfig.Tag != "" {
+		inbounds[config.DNSConfig.Tag] = true
+	}
+	if config.Reverse != nil {
+		for _, bridge := range config.Reverse.Bridges {
+			inbounds[bridge.Tag] = true
+		}
+		for _, portal := range config.Reverse.Portals {
+			outbounds[portal.Tag] = true
+		}
+	}
+	if config.RouterConfig != nil {
+		for _, balancer := range config.RouterConfig.Balancers {
+			balancers[balancer.Tag] = true
+		}
+	}
+	isOutbound := func(tag string) bool {
+		if outbounds[tag] {
+			return true
+		}
+		for _, subscription := range config.Subscriptions {
+			if strings.HasPrefix(tag, subscription.Tag+"-") {
+				return true
+			}
+		}
+		return false
+	}
+
+	var issues []*LintIssue
+	if config.RouterConfig != nil {
+		for i, raw := range config.RouterConfig.RuleList {
+			path := fmt.Sprintf("routing.rules[%d]", i)
+			var rule struct {
+				RouterRule
+				InboundTag *StringList `json:"inboundTag"`
+			}
+			if err := json.Unmarshal(raw, &rule); err != nil {
+				continue
+			}
+			if rule.OutboundTag != "" && !isOutbound(rule.OutboundTag) {
+				issues = append(issues, lintIssue(LintError, path, "outbound ", rule.OutboundTag, " is not defined"))
+			}
+			if rule.BalancerTag != "" && !balancers[rule.BalancerTag] {
+				issues = append(issues, lintIssue(LintError, path, "balancer ", rule.BalancerTag, " is not defined"))
+			}
+			if rule.InboundTag != nil {
+				for _, tag := range *rule.InboundTag {
+					if !inbounds[tag] {
+						issues = append(issues, lintIssue(LintWarning, path, "inbound ", tag, " is not defined"))
+					}
+				}
+			}
+		}
+		for i, balancer := range config.RouterConfig.Balancers {
+			if balancer.FallbackTag != "" && !isOutbound(balancer.FallbackTag) {
+				issues = append(issues, lintIssue(LintError, fmt.Sprintf("routing.balancers[%d]", i), "fallback outbound ", balancer.FallbackTag, " is not defined"))
+			}
+		}
+	}
+	for i, outbound := range config.OutboundConfigs {
+		path := fmt.Sprintf("outbounds[%d]", i)
+		if outbound.ProxySettings != nil && outbound.ProxySettings.Tag != "" && !isOutbound(outbound.ProxySettings.Tag) {
+			issues = append(issues, lintIssue(LintError, path+".proxySettings", "outbound ", outbound.ProxySettings.Tag, " is not defined"))
+		}
+		if s := outbound.StreamSetting; s != nil && s.SocketSettings != nil && s.SocketSettings.DialerProxy != "" && !isOutbound(s.SocketSettings.DialerProxy) {
+			issues = append(issues, lintIssue(LintError, path+".streamSettings.sockopt", "outbound ", s.SocketSettings.DialerProxy, " is not defined"))
+		}
+	}
+	for i, subscription := range config.Subscriptions {
+		path := fmt.Sprintf("subscriptions[%d]", i)
+		if subscription.OutboundTag != "" && !isOutbound(subscription.OutboundTag) {
+			issues = append(issues, lintIssue(LintError, path, "outbound ", subscription.OutboundTag, " is not defined"))
+		}
+		for _, tag := range subscription.BalancerTags {
+			if !balancers[tag] {
+				issues = append(issues, lintIssue(LintError, path, "balancer ", tag, " is not defined"))
+			}
+		}
+	}
+	return issues
+}
+
+// BalancerLinter finds balancers without outbounds.
+type BalancerLinter struct{}
+
+func (BalancerLinter) Lint(config *Config) []*LintIssue {
+	if config.RouterConfig == nil {
+		return nil
+	}
+	var issues []*LintIssue
+	for i, balancer := range config.RouterConfig.Balancers {
+		path := fmt.Sprintf("routing.balancers[%d]", i)
+		if len(balancer.Selectors) == 0 {
+			issues = append(issues, lintIssue(LintError, path, "empty selector list"))
+			continue
+		}
+		if !balancerHasOutbounds(config, balancer) {
+			issues = append(issues, lintIssue(LintWarning, path, "selectors ", strings.Join(balancer.Selectors, ","), " match no outbound"))
+		}
+	}
+	return issues
+}
+
+func balancerHasOutbounds(config *Config, balancer *BalancingRule) bool {
+	for _, selector := range balancer.Selectors {
+		for _, outbound := range config.OutboundConfigs {
+			if strings.HasPrefix(outbound.Tag, selector) {
+				return true
+			}
+		}
+		for _, subscription := range config.Subscriptions {
+			prefix := subscription.Tag + "-"
+			if strings.HasPrefix(prefix, selector) || strings.HasPrefix(selector, prefix) {
+				return true
+			}
+		}
+	}
+	for _, subscription := range config.Subscriptions {
+		for _, tag := range subscription.BalancerTags {
+			if tag == balancer.Tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DuplicatedUserLinter finds users sharing emails or credentials with other
+// users.
+type DuplicatedUserLinter struct{}
+
+func (DuplicatedUserLinter) Lint(config *Config) []*LintIssue {
+	type user struct {
+		inbound    int
+		path       string
+		email      string
+		credential string
+	}
+	emails := make(map[string]*user)
+	credentials := make(map[string]*user)
+
+	var issues []*LintIssue
+	for i, inbound := range config.InboundConfigs {
+		switch strings.ToLower(inbound.Protocol) {
+		case "vless", "vmess", "trojan", "shadowsocks":
+		default:
+			continue
+		}
+		if inbound.Settings == nil {
+			continue
+		}
+		var settings struct {
+			Clients []struct {
+				ID       string `json:"id"`
+				Password string `json:"password"`
+				Email    string `json:"email"`
+			} `json:"clients"`
+		}
+		if err := json.Unmarshal(*inbound.Settings, &settings); err != nil {
+			continue
+		}
+		for k, client := range settings.Clients {
+			u := &user{
+				inbound:    i,
+				path:       fmt.Sprintf("inbounds[%d].settings.clients[%d]", i, k),
+				email:      strings.ToLower(client.Email),
+				credential: client.ID + client.Password,
+			}
+			if u.email != "" {
+				if prev := emails[u.email]; prev != nil && prev.credential != u.credential {
+					issues = append(issues, lintIssue(LintWarning, u.path, "email ", client.Email, " is also used by another user at ", prev.path))
+				} else if prev == nil {
+					emails[u.email] = u
+				}
+			}
+			if u.credential != "" {
+				if prev := credentials[u.credential]; prev == nil {
+					credentials[u.credential] = u
+				} else if prev.email != u.email {
+					issues = append(issues, lintIssue(LintWarning, u.path, "the credential is also used by another user at ", prev.path))
+				} else if prev.inbound == u.inbound {
+					issues = append(issues, lintIssue(LintWarning, u.path, "duplicated user of ", prev.path))
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// REALITYLinter finds incomplete REALITY configs.
+type REALITYLinter struct{}
+
+func (REALITYLinter) Lint(config *Config) []*LintIssue {
+	var issues []*LintIssue
+	for i, inbound := range config.InboundConfigs {
+		s := inbound.StreamSetting
+		if s == nil || !strings.EqualFold(s.Security, "reality") {
+			continue
+		}
+		path := fmt.Sprintf("inbounds[%d].streamSettings.realitySettings", i)
+		r := s.REALITYSettings
+		if r == nil {
+			issues = append(issues, lintIssue(LintError, path, "REALITY settings are not set"))
+			continue
+		}
+		if r.Target == nil && r.Dest == nil {
+			issues = append(issues, lintIssue(LintError, path, `empty "target"`))
+		}
+		if len(r.ServerNames) == 0 {
+			issues = append(issues, lintIssue(LintError, path, `empty "serverNames"`))
+		}
+		if r.PrivateKey == "" {
+			issues = append(issues, lintIssue(LintError, path, `empty "privateKey"`))
+		}
+		if len(r.ShortIds) == 0 {
+			issues = append(issues, lintIssue(LintError, path, `empty "shortIds"`))
+		}
+	}
+	for i, outbound := range config.OutboundConfigs {
+		s := outbound.StreamSetting
+		if s == nil || !strings.EqualFold(s.Security, "reality") {
+			continue
+		}
+		path := fmt.Sprintf("outbounds[%d].streamSettings.realitySettings", i)
+		r := s.REALITYSettings
+		if r == nil || (r.Password == "" && r.PublicKey == "") {
+			issues = append(issues, lintIssue(LintError, path, `empty "password"`))
+		}
+	}
+	return issues
+}
+
+// PortCollisionLinter finds inbounds listening on the same port.
+type PortCollisionLinter struct{}
+
+func (PortCollisionLinter) Lint(config *Config) []*LintIssue {
+	type binding struct {
+		listen   string
+		networks []string
+		ports    *PortList
+	}
+	var issues []*LintIssue
+	bindings := make([]*binding, len(config.InboundConfigs))
+	for i, inbound := range config.InboundConfigs {
+		if inbound.PortList == nil {
+			continue
+		}
+		b := &binding{networks: inboundNetworks(&config.InboundConfigs[i]), ports: inbound.PortList}
+		if inbound.ListenOn != nil && inbound.ListenOn.Address != nil {
+			if inbound.ListenOn.Family().IsDomain() {
+				// unix domain sockets
+				continue
+			}
+			if !inbound.ListenOn.IP().IsUnspecified() {
+				b.listen = inbound.ListenOn.IP().String()
+			}
+		}
+		bindings[i] = b
+
+	collision:
+		for j := 0; j < i; j++ {
+			prev := bindings[j]
+			if prev == nil || (prev.listen != "" && b.listen != "" && prev.listen != b.listen) {
+				continue
+			}
+			for _, network := range b.networks {
+				if !slices.Contains(prev.networks, network) {
+					continue
+				}
+				if port, found := overlappedPort(prev.ports, b.ports); found {
+					issues = append(issues, lintIssue(LintError, fmt.Sprintf("inbounds[%d]", i), network, " port ", port, " is also used by inbounds[", j, "]"))
+					break collision
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// inboundNetworks returns the networks inbound listens on its ports, "tcp" or
+// "udp". Streams of the proxy go through its transport, which may listen on
+// UDP, while packets of the proxy are always received by UDP.
+func inboundNetworks(inbound *InboundDetourConfig) []string {
+	var settings struct {
+		Network *NetworkList `json:"network"`
+		UDP     bool         `json:"udp"`
+	}
+	if inbound.Settings != nil {
+		// errors are left to the builder
+		json.Unmarshal(*inbound.Settings, &settings)
+	}
+	var streams, packets bool
+	switch strings.ToLower(inbound.Protocol) {
+	case "wireguard":
+		packets = true
+	case "dokodemo-door", "tunnel", "shadowsocks":
+		for _, network := range settings.Network.Build() {
+			switch network {
+			case net.Network_TCP:
+				streams = true
+			case net.Network_UDP:
+				packets = true
+			}
+		}
+	case "socks", "mixed":
+		streams = true
+		packets = settings.UDP
+	default:
+		streams = true
+	}
+
+	var networks []string
+	if streams {
+		streamNetwork := "tcp"
+		if s := inbound.StreamSetting; s != nil && s.Network != nil {
+			switch strings.ToLower(string(*s.Network)) {
+			case "kcp", "mkcp":
+				streamNetwork = "udp"
+			case "xhttp", "splithttp":
+				// XHTTP serves HTTP/3 over QUIC if it is the only ALPN
+				if strings.EqualFold(s.Security, "tls") && s.TLSSettings != nil && s.TLSSettings.ALPN != nil &&
+					len(*s.TLSSettings.ALPN) == 1 && (*s.TLSSettings.ALPN)[0] == "h3" {
+					streamNetwork = "udp"
+				}
+			}
+		}
+		networks = append(networks, streamNetwork)
+	}
+	if packets && !slices.Contains(networks, "udp") {
+		networks = append(networks, "udp")
+	}
+	return networks
+}
+
+func overlappedPort(a *PortList, b *PortList) (uint32, bool) {
+	for _, x := range a.Range {
+		for _, y := range b.Range {
+			from := max(x.From, y.From)
+			if from <= min(x.To, y.To) {
+				return from, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// CertificateExpiryLinter finds TLS certificates of inbounds which expired or
+// expire soon.
+type CertificateExpiryLinter struct{}
+
+func (CertificateExpiryLinter) Lint(config *Config) []*LintIssue {
+	var issues []*LintIssue
+	now := time.Now()
+	for i, inbound := range config.InboundConfigs {
+		s := inbound.StreamSetting
+		if s == nil || !strings.EqualFold(s.Security, "tls") || s.TLSSettings == nil {
+			continue
+		}
+		for k, c := range s.TLSSettings.Certs {
+			path := fmt.Sprintf("inbounds[%d].streamSettings.tlsSettings.certificates[%d]", i, k)
+			if c.CertFile == "" && len(c.CertStr) == 0 {
+				continue
+			}
+			certificate, err := parseLintCertificate(c)
+			if err != nil {
+				issues = append(issues, lintIssue(LintWarning, path, "failed to read certificate: ", err))
+				continue
+			}
+			notAfter := certificate.NotAfter.UTC().Format(time.RFC3339)
+			switch {
+			case now.After(certificate.NotAfter):
+				issues = append(issues, lintIssue(LintError, path, "certificate expired at ", notAfter))
+			case certificate.NotAfter.Sub(now) < certificateExpiryWarning:
+				issues = append(issues, lintIssue(LintWarning, path, "certificate expires at ", notAfter))
+			}
+		}
+	}
+	return issues
+}
+
+func parseLintCertificate(c *TLSCertConfig) (*x509.Certificate, error) {
+	content, err := readFileOrString(c.CertFile, c.CertStr)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var block *pem.Block
+		block, content = pem.Decode(content)
+		if block == nil {
+			return nil, errors.New("no certificate")
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
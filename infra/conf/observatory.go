@@ -1,6 +1,8 @@
 package conf
 
 import (
+	"strings"
+
 	"google.golang.org/protobuf/proto"
 
 	"github.com/xtls/xray-core/app/observatory"
@@ -14,24 +16,84 @@ type ObservatoryConfig struct {
 	ProbeURL          string            `json:"probeURL"`
 	ProbeInterval     duration.Duration `json:"probeInterval"`
 	EnableConcurrency bool              `json:"enableConcurrency"`
+	Probe             *ProbeConfig      `json:"probe"`
+	EnablePassive     bool              `json:"enablePassive"`
+	HistorySize       uint32            `json:"historySize"`
 }
 
 func (o *ObservatoryConfig) Build() (proto.Message, error) {
-	return &observatory.Config{SubjectSelector: o.SubjectSelector, ProbeUrl: o.ProbeURL, ProbeInterval: int64(o.ProbeInterval), EnableConcurrency: o.EnableConcurrency}, nil
+	config := &observatory.Config{SubjectSelector: o.SubjectSelector, ProbeUrl: o.ProbeURL, ProbeInterval: int64(o.ProbeInterval), EnableConcurrency: o.EnableConcurrency, EnablePassive: o.EnablePassive, HistorySize: o.HistorySize}
+	if o.Probe != nil {
+		probe, err := o.Probe.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.Probe = probe
+	}
+	return config, nil
+}
+
+// ProbeConfig is the health check probe of observatories.
+type ProbeConfig struct {
+	Type           string `json:"type"`
+	Destination    string `json:"destination"`
+	ServerName     string `json:"serverName"`
+	Domain         string `json:"domain"`
+	Payload        string `json:"payload"`
+	ExpectedStatus uint32 `json:"expectedStatus"`
+	ExpectedBody   string `json:"expectedBody"`
+}
+
+func (p *ProbeConfig) Build() (*observatory.ProbeConfig, error) {
+	config := &observatory.ProbeConfig{
+		Destination:    p.Destination,
+		ServerName:     p.ServerName,
+		Domain:         p.Domain,
+		Payload:        []byte(p.Payload),
+		ExpectedStatus: p.ExpectedStatus,
+		ExpectedBody:   p.ExpectedBody,
+	}
+	switch strings.ToLower(p.Type) {
+	case "", "http":
+		config.Kind = observatory.ProbeKind_HTTP
+	case "tcp":
+		config.Kind = observatory.ProbeKind_TCP
+	case "tls":
+		config.Kind = observatory.ProbeKind_TLS
+	case "dns":
+		config.Kind = observatory.ProbeKind_DNS
+	case "udp":
+		config.Kind = observatory.ProbeKind_UDP
+	default:
+		return nil, errors.New("unknown probe type: ", p.Type)
+	}
+	if config.Kind != observatory.ProbeKind_HTTP && config.Destination == "" {
+		return nil, errors.New(p.Type, " probe requires a destination")
+	}
+	return config, nil
 }
 
 type BurstObservatoryConfig struct {
 	SubjectSelector []string `json:"subjectSelector"`
 	// health check settings
 	HealthCheck *healthCheckSettings `json:"pingConfig,omitempty"`
+	// sample health from real traffic
+	EnablePassive bool `json:"enablePassive"`
+	// statuses kept of each outbound
+	HistorySize uint32 `json:"historySize"`
+	// rate of failed connections tolerated in the passive samples
+	PassiveTolerance float64 `json:"passiveTolerance,omitempty"`
 }
 
 func (b BurstObservatoryConfig) Build() (proto.Message, error) {
 	if b.HealthCheck == nil {
 		return nil, errors.New("BurstObservatory requires a valid pingConfig")
 	}
+	if b.PassiveTolerance < 0 || b.PassiveTolerance > 1 {
+		return nil, errors.New("passiveTolerance of BurstObservatory must be between 0 and 1")
+	}
 	if result, err := b.HealthCheck.Build(); err == nil {
-		return &burst.Config{SubjectSelector: b.SubjectSelector, PingConfig: result.(*burst.HealthPingConfig)}, nil
+		return &burst.Config{SubjectSelector: b.SubjectSelector, PingConfig: result.(*burst.HealthPingConfig), EnablePassive: b.EnablePassive, HistorySize: b.HistorySize, PassiveTolerance: float32(b.PassiveTolerance)}, nil
 	} else {
 		return nil, err
 	}
@@ -0,0 +1,80 @@
+package conf_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/xtls/xray-core/app/observatory"
+	"github.com/xtls/xray-core/app/observatory/burst"
+	"github.com/xtls/xray-core/common"
+	. "github.com/xtls/xray-core/infra/conf"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestObservatoryConfig(t *testing.T) {
+	createParser := func(config interface{ Build() (proto.Message, error) }) func(string) (proto.Message, error) {
+		return func(s string) (proto.Message, error) {
+			if err := json.Unmarshal([]byte(s), config); err != nil {
+				return nil, err
+			}
+			return config.Build()
+		}
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"subjectSelector": ["proxy"],
+				"historySize": 32,
+				"probe": {
+					"type": "dns",
+					"destination": "1.1.1.1:53",
+					"domain": "example.com"
+				}
+			}`,
+			Parser: createParser(new(ObservatoryConfig)),
+			Output: &observatory.Config{
+				SubjectSelector: []string{"proxy"},
+				HistorySize:     32,
+				Probe: &observatory.ProbeConfig{
+					Kind:        observatory.ProbeKind_DNS,
+					Destination: "1.1.1.1:53",
+					Domain:      "example.com",
+				},
+			},
+		},
+		{
+			Input: `{
+				"subjectSelector": ["proxy"],
+				"enablePassive": true,
+				"passiveTolerance": 0.3,
+				"pingConfig": {
+					"probe": {
+						"expectedStatus": 204,
+						"expectedBody": "ok"
+					}
+				}
+			}`,
+			Parser: createParser(new(BurstObservatoryConfig)),
+			Output: &burst.Config{
+				SubjectSelector:  []string{"proxy"},
+				EnablePassive:    true,
+				PassiveTolerance: 0.3,
+				PingConfig: &burst.HealthPingConfig{
+					HttpMethod: "HEAD",
+					Probe: &observatory.ProbeConfig{
+						ExpectedStatus: 204,
+						ExpectedBody:   "ok",
+					},
+				},
+			},
+		},
+	})
+
+	for _, probe := range []*ProbeConfig{{Type: "tcp"}, {Type: "icmp"}} {
+		if _, err := probe.Build(); err == nil {
+			t.Error("expected error for probe ", probe.Type)
+		}
+	}
+	common.Must2((&ProbeConfig{Type: "tls", Destination: "example.com:443"}).Build())
+}
@@ -57,6 +57,7 @@ type SystemPolicy struct {
 	StatsInboundDownlink  bool `json:"statsInboundDownlink"`
 	StatsOutboundUplink   bool `json:"statsOutboundUplink"`
 	StatsOutboundDownlink bool `json:"statsOutboundDownlink"`
+	StatsInboundTransport bool `json:"statsInboundTransport"`
 }
 
 func (p *SystemPolicy) Build() (*policy.SystemPolicy, error) {
@@ -66,6 +67,7 @@ func (p *SystemPolicy) Build() (*policy.SystemPolicy, error) {
 			InboundDownlink:  p.StatsInboundDownlink,
 			OutboundUplink:   p.StatsOutboundUplink,
 			OutboundDownlink: p.StatsOutboundDownlink,
+			InboundTransport: p.StatsInboundTransport,
 		},
 	}, nil
 }
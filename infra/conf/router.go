@@ -528,24 +528,26 @@ func ToCidrList(ips StringList) ([]*router.GeoIP, error) {
 	return geoipList, nil
 }
 
+// RawFieldRule is the config of a field routing rule.
+type RawFieldRule struct {
+	RouterRule
+	Domain     *StringList       `json:"domain"`
+	Domains    *StringList       `json:"domains"`
+	IP         *StringList       `json:"ip"`
+	Port       *PortList         `json:"port"`
+	Network    *NetworkList      `json:"network"`
+	SourceIP   *StringList       `json:"sourceIP"`
+	Source     *StringList       `json:"source"`
+	SourcePort *PortList         `json:"sourcePort"`
+	User       *StringList       `json:"user"`
+	InboundTag *StringList       `json:"inboundTag"`
+	Protocols  *StringList       `json:"protocol"`
+	Attributes map[string]string `json:"attrs"`
+	LocalIP    *StringList       `json:"localIP"`
+	LocalPort  *PortList         `json:"localPort"`
+}
+
 func parseFieldRule(msg json.RawMessage) (*router.RoutingRule, error) {
-	type RawFieldRule struct {
-		RouterRule
-		Domain     *StringList       `json:"domain"`
-		Domains    *StringList       `json:"domains"`
-		IP         *StringList       `json:"ip"`
-		Port       *PortList         `json:"port"`
-		Network    *NetworkList      `json:"network"`
-		SourceIP   *StringList       `json:"sourceIP"`
-		Source     *StringList       `json:"source"`
-		SourcePort *PortList         `json:"sourcePort"`
-		User       *StringList       `json:"user"`
-		InboundTag *StringList       `json:"inboundTag"`
-		Protocols  *StringList       `json:"protocol"`
-		Attributes map[string]string `json:"attrs"`
-		LocalIP    *StringList       `json:"localIP"`
-		LocalPort  *PortList         `json:"localPort"`
-	}
 	rawFieldRule := new(RawFieldRule)
 	err := json.Unmarshal(msg, rawFieldRule)
 	if err != nil {
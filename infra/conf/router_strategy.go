@@ -53,6 +53,7 @@ type healthCheckSettings struct {
 	SamplingCount int               `json:"sampling"`
 	Timeout       duration.Duration `json:"timeout"`
 	HttpMethod    string            `json:"httpMethod"`
+	Probe         *ProbeConfig      `json:"probe"`
 }
 
 func (h healthCheckSettings) Build() (proto.Message, error) {
@@ -62,14 +63,22 @@ func (h healthCheckSettings) Build() (proto.Message, error) {
 	} else {
 		httpMethod = strings.TrimSpace(h.HttpMethod)
 	}
-	return &burst.HealthPingConfig{
+	config := &burst.HealthPingConfig{
 		Destination:   h.Destination,
 		Connectivity:  h.Connectivity,
 		Interval:      int64(h.Interval),
 		Timeout:       int64(h.Timeout),
 		SamplingCount: int32(h.SamplingCount),
 		HttpMethod:    httpMethod,
-	}, nil
+	}
+	if h.Probe != nil {
+		probe, err := h.Probe.Build()
+		if err != nil {
+			return nil, err
+		}
+		config.Probe = probe
+	}
+	return config, nil
 }
 
 // Build implements Buildable.
@@ -0,0 +1,398 @@
+package conf
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/xtls/xray-core/infra/conf/cfgcommon/duration"
+	"github.com/xtls/xray-core/transport/internet/tls"
+	"google.golang.org/grpc/codes"
+)
+
+// schemaDraft is the JSON Schema dialect of Schema.
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaLoaders are the fields loaded by JSONConfigLoaders, by
+// "TypeName.jsonKey". The content of such a field is decided by the key of the
+// loader, which is in the same object as the field, or in the content of the
+// field itself, if the config key of the loader is empty.
+var schemaLoaders = map[string]*JSONConfigLoader{
+	"InboundDetourConfig.settings":  inboundConfigLoader,
+	"OutboundDetourConfig.settings": outboundConfigLoader,
+	"StrategyConfig.settings":       strategyConfigLoader,
+	"TCPConfig.header":              tcpHeaderLoader,
+	"KCPConfig.header":              kcpHeaderLoader,
+	"BlackholeConfig.response":      configLoader,
+}
+
+// schemaIDNames are the documented names of the IDs of loaders, which are not
+// in lower case.
+var schemaIDNames = map[string]string{
+	strategyLeastPing:  "leastPing",
+	strategyRoundRobin: "roundRobin",
+	strategyLeastLoad:  "leastLoad",
+}
+
+// schemaFields are merged into the schemas of fields, by "TypeName.jsonKey".
+var schemaFields = map[string]map[string]interface{}{
+	"Config.log":              {"description": "Log settings."},
+	"Config.api":              {"description": "The gRPC API served by Xray."},
+	"Config.dns":              {"description": "The built-in DNS server."},
+	"Config.routing":          {"description": "Routing rules and balancers."},
+	"Config.policy":           {"description": "Policies of user levels and the system."},
+	"Config.inbounds":         {"description": "Inbound proxies, accepting connections."},
+	"Config.outbounds":        {"description": "Outbound proxies, sending connections. The first one is the default."},
+	"Config.stats":            {"description": "Enables traffic statistics."},
+	"Config.reverse":          {"description": "Reverse proxies."},
+	"Config.fakeDns":          {"description": "IP pools of FakeDNS."},
+	"Config.metrics":          {"description": "Metrics served over HTTP."},
+	"Config.observatory":      {"description": "Probes outbounds for balancers."},
+	"Config.burstObservatory": {"description": "Probes outbounds in bursts for balancers."},
+	"Config.version":          {"description": "Versions of Xray the config requires."},
+	"Config.subscriptions":    {"description": "Outbounds fetched from subscriptions."},
+	"Config.transport":        {"description": "Removed. Set transports in streamSettings of inbounds and outbounds.", "deprecated": true},
+
+	"LogConfig.loglevel":    {"enum": []string{"debug", "info", "warning", "error", "none"}},
+	"LogConfig.access":      {"description": `The file of access logs, or "none".`},
+	"LogConfig.error":       {"description": `The file of error logs, or "none".`},
+	"LogConfig.maskAddress": {"enum": []string{"quarter", "half", "full"}},
+
+	"InboundDetourConfig.tag":          {"description": "The tag to refer to the inbound in routing."},
+	"InboundDetourConfig.port":         {"description": "The port, port range, or ports separated by commas to listen on."},
+	"InboundDetourConfig.listen":       {"description": "The IP address, or the path of the Unix domain socket to listen on."},
+	"OutboundDetourConfig.tag":         {"description": "The tag to refer to the outbound in routing."},
+	"OutboundDetourConfig.sendThrough": {"description": `The local IP address to send connections from, or "origin".`},
+
+	"StreamConfig.security":       {"enum": []string{"none", "tls", "reality"}},
+	"TLSConfig.fingerprint":       {"examples": schemaFingerprints()},
+	"REALITYConfig.fingerprint":   {"examples": schemaFingerprints()},
+	"SplitHTTPConfig.mode":        {"enum": []string{"auto", "packet-up", "stream-up", "stream-one"}},
+	"SniffingConfig.destOverride": stringListSchema("http", "tls", "quic", "fakedns", "fakedns+others"),
+
+	"RouterConfig.domainStrategy": {"enum": []string{"AsIs", "AlwaysIP", "IPIfNonMatch", "IPOnDemand"}},
+	"RouterConfig.rules":          {"items": map[string]interface{}{"$ref": "#/$defs/RawFieldRule"}},
+	"RouterRule.type":             {"enum": []string{"field"}},
+	"RouterRule.outboundTag":      {"description": "The outbound of matched traffic."},
+	"RouterRule.balancerTag":      {"description": "The balancer of matched traffic."},
+	"BalancingRule.selector":      {"description": "Prefixes of tags of outbounds to balance."},
+
+	"DNSConfig.queryStrategy":        {"enum": []string{"UseIP", "UseIPv4", "UseIPv6", "UseSystem"}},
+	"NameServerConfig.queryStrategy": {"enum": []string{"UseIP", "UseIPv4", "UseIPv6", "UseSystem"}},
+	"FreedomConfig.domainStrategy": {"enum": []string{
+		"AsIs", "UseIP", "UseIPv4", "UseIPv6", "UseIPv4v6", "UseIPv6v4",
+		"ForceIP", "ForceIPv4", "ForceIPv6", "ForceIPv4v6", "ForceIPv6v4",
+	}},
+
+	"VLessInboundConfig.decryption": {"enum": []string{"none"}},
+	"VLessInboundConfig.flow":       {"enum": []string{"", "none", "xtls-rprx-vision"}},
+	"VLessInboundConfig.clients": {"items": map[string]interface{}{
+		"type":     "object",
+		"required": []string{"id"},
+		"properties": map[string]interface{}{
+			"id":    map[string]interface{}{"type": "string", "description": "UUID, or any string mapped to an UUID."},
+			"flow":  map[string]interface{}{"enum": []string{"", "none", "xtls-rprx-vision"}},
+			"email": map[string]interface{}{"type": "string"},
+			"level": map[string]interface{}{"type": "integer", "minimum": 0},
+		},
+	}},
+	"VMessInboundConfig.clients": {"items": map[string]interface{}{
+		"type":     "object",
+		"required": []string{"id"},
+		"properties": map[string]interface{}{
+			"id":    map[string]interface{}{"type": "string", "description": "UUID, or any string mapped to an UUID."},
+			"email": map[string]interface{}{"type": "string"},
+			"level": map[string]interface{}{"type": "integer", "minimum": 0},
+		},
+	}},
+}
+
+// Schema returns the JSON Schema of the config format.
+func Schema() map[string]interface{} {
+	g := &schemaGenerator{defs: make(map[string]interface{})}
+	root := g.schemaOf(reflect.TypeOf(Config{}))
+	// routing rules are raw messages parsed by ParseRule
+	g.structSchema(reflect.TypeOf(RawFieldRule{}))
+	return map[string]interface{}{
+		"$schema": schemaDraft,
+		"title":   "Xray config",
+		"$ref":    root["$ref"],
+		"$defs":   g.defs,
+	}
+}
+
+type schemaGenerator struct {
+	defs map[string]interface{}
+}
+
+func (g *schemaGenerator) schemaOf(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case reflect.TypeOf(json.RawMessage{}):
+		return map[string]interface{}{}
+	case reflect.TypeOf(StringList{}):
+		return stringListSchema()
+	case reflect.TypeOf(NetworkList{}):
+		return stringListSchema("tcp", "udp")
+	case reflect.TypeOf(Network("")):
+		return map[string]interface{}{"enum": []string{"tcp", "udp"}}
+	case reflect.TypeOf(Address{}):
+		return map[string]interface{}{"type": "string", "description": "IP address or domain."}
+	case reflect.TypeOf(PortList{}):
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 65535},
+				map[string]interface{}{"type": "string", "description": `Ports and port ranges separated by commas, like "80,1000-2000".`},
+			},
+		}
+	case reflect.TypeOf(PortRange{}):
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 65535},
+				map[string]interface{}{"type": "string", "description": `A port range, like "1000-2000".`},
+			},
+		}
+	case reflect.TypeOf(Int32Range{}):
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "integer"},
+				map[string]interface{}{"type": "string", "description": `A range, like "100-1000".`},
+			},
+		}
+	case reflect.TypeOf(duration.Duration(0)):
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "integer", "description": "Nanoseconds."},
+				map[string]interface{}{"type": "string", "description": `A duration, like "10s" or "1h30m".`},
+			},
+		}
+	case reflect.TypeOf(codes.Code(0)):
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "integer", "minimum": 0, "maximum": int(codes.Unauthenticated)},
+				map[string]interface{}{"enum": grpcCodeNames},
+			},
+		}
+	case reflect.TypeOf(TransportProtocol("")):
+		return map[string]interface{}{"enum": []string{"raw", "tcp", "xhttp", "splithttp", "kcp", "mkcp", "grpc", "ws", "websocket", "httpupgrade"}}
+	case reflect.TypeOf(HostAddress{}):
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "string"},
+				map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			},
+		}
+	case reflect.TypeOf(HostsWrapper{}):
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": g.schemaOf(reflect.TypeOf(HostAddress{})),
+		}
+	case reflect.TypeOf(NameServerConfig{}):
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "string"},
+				g.structSchema(t),
+			},
+		}
+	case reflect.TypeOf(FakeDNSConfig{}):
+		pool := g.structSchema(reflect.TypeOf(FakeDNSPoolElementConfig{}))
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				pool,
+				map[string]interface{}{"type": "array", "items": pool},
+			},
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return g.structSchema(t)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema returns the reference to the definition of t, or the schema of
+// t itself if it is anonymous.
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	if t.Name() == "" {
+		return g.objectSchema(t)
+	}
+	name := t.Name()
+	if t.PkgPath() != reflect.TypeOf(Config{}).PkgPath() {
+		name = t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:] + "." + name
+	}
+	if _, found := g.defs[name]; !found {
+		g.defs[name] = nil // breaks recursions
+		g.defs[name] = g.objectSchema(t)
+	}
+	return map[string]interface{}{"$ref": "#/$defs/" + name}
+}
+
+func (g *schemaGenerator) objectSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var allOf []interface{}
+	g.addProperties(t, t, properties, &allOf)
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(allOf) > 0 {
+		schema["allOf"] = allOf
+	}
+	return schema
+}
+
+func (g *schemaGenerator) addProperties(owner reflect.Type, t reflect.Type, properties map[string]interface{}, allOf *[]interface{}) {
+	// the keys of loaders override the fields of the same names
+	var loaded []string
+	defer func() {
+		for _, name := range loaded {
+			properties[name] = g.loaderSchema(schemaLoaders[owner.Name()+"."+name], name, properties, allOf)
+		}
+	}()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addProperties(embedded, embedded, properties, allOf)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		key := owner.Name() + "." + name
+		if _, found := schemaLoaders[key]; found {
+			loaded = append(loaded, name)
+			continue
+		}
+		schema := g.schemaOf(field.Type)
+		if extra, found := schemaFields[key]; found {
+			merged := make(map[string]interface{}, len(schema)+len(extra))
+			for k, v := range schema {
+				merged[k] = v
+			}
+			for k, v := range extra {
+				merged[k] = v
+			}
+			if _, found := extra["enum"]; found {
+				delete(merged, "type")
+			}
+			schema = merged
+		}
+		properties[name] = schema
+	}
+}
+
+// loaderSchema returns the schema of the field loaded by loader. If the key of
+// the loader is in the same object as the field, the conditions on the key are
+// added to allOf.
+func (g *schemaGenerator) loaderSchema(loader *JSONConfigLoader, field string, properties map[string]interface{}, allOf *[]interface{}) map[string]interface{} {
+	ids := make([]string, 0, len(loader.cache))
+	for id := range loader.cache {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var conditions []interface{}
+	var names []string
+	for _, id := range ids {
+		// loaders take IDs case-insensitively
+		idNames := []string{id}
+		if name, found := schemaIDNames[id]; found {
+			idNames = append(idNames, name)
+		}
+		names = append(names, idNames...)
+		config := g.schemaOf(reflect.TypeOf(loader.cache[id]()))
+		then := config
+		if loader.configKey != "" {
+			then = map[string]interface{}{
+				"properties": map[string]interface{}{field: config},
+			}
+		}
+		conditions = append(conditions, map[string]interface{}{
+			"if": map[string]interface{}{
+				"required":   []string{loader.idKey},
+				"properties": map[string]interface{}{loader.idKey: map[string]interface{}{"enum": idNames}},
+			},
+			"then": then,
+		})
+	}
+	idSchema := map[string]interface{}{"enum": names}
+
+	if loader.configKey == "" {
+		return map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{loader.idKey: idSchema},
+			"allOf":      conditions,
+		}
+	}
+	properties[loader.idKey] = idSchema
+	*allOf = append(*allOf, conditions...)
+	return map[string]interface{}{"type": "object"}
+}
+
+func stringListSchema(enum ...string) map[string]interface{} {
+	item := map[string]interface{}{"type": "string"}
+	if len(enum) > 0 {
+		item = map[string]interface{}{"enum": enum}
+	}
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "array", "items": item},
+			map[string]interface{}{"type": "string", "description": "Values separated by commas."},
+		},
+	}
+}
+
+// grpcCodeNames are the names of gRPC status codes, which codes.Code accepts.
+var grpcCodeNames = []string{
+	"OK", "CANCELLED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED",
+	"NOT_FOUND", "ALREADY_EXISTS", "PERMISSION_DENIED", "RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION", "ABORTED", "OUT_OF_RANGE", "UNIMPLEMENTED",
+	"INTERNAL", "UNAVAILABLE", "DATA_LOSS", "UNAUTHENTICATED",
+}
+
+func schemaFingerprints() []string {
+	fingerprints := make([]string, 0, len(tls.PresetFingerprints))
+	for name := range tls.PresetFingerprints {
+		fingerprints = append(fingerprints, name)
+	}
+	sort.Strings(fingerprints)
+	return fingerprints
+}
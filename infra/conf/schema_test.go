@@ -0,0 +1,313 @@
+package conf_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/errors"
+	. "github.com/xtls/xray-core/infra/conf"
+)
+
+func TestSchema(t *testing.T) {
+	b, err := json.Marshal(Schema())
+	common.Must(err)
+
+	var schema struct {
+		Schema string                     `json:"$schema"`
+		Ref    string                     `json:"$ref"`
+		Defs   map[string]json.RawMessage `json:"$defs"`
+	}
+	common.Must(json.Unmarshal(b, &schema))
+	if schema.Schema != "https://json-schema.org/draft/2020-12/schema" || schema.Ref != "#/$defs/Config" {
+		t.Error("unexpected schema header: ", schema.Schema, " ", schema.Ref)
+	}
+
+	for _, ref := range regexp.MustCompile(`"\$ref":"#/\$defs/([^"]+)"`).FindAllStringSubmatch(string(b), -1) {
+		if _, found := schema.Defs[ref[1]]; !found {
+			t.Error("undefined reference ", ref[1])
+		}
+	}
+
+	var inbound struct {
+		Properties map[string]struct {
+			Enum []string `json:"enum"`
+		} `json:"properties"`
+		AllOf []struct {
+			If struct {
+				Properties struct {
+					Protocol struct {
+						Enum []string `json:"enum"`
+					} `json:"protocol"`
+				} `json:"properties"`
+			} `json:"if"`
+			Then struct {
+				Properties struct {
+					Settings struct {
+						Ref string `json:"$ref"`
+					} `json:"settings"`
+				} `json:"properties"`
+			} `json:"then"`
+		} `json:"allOf"`
+	}
+	common.Must(json.Unmarshal(schema.Defs["InboundDetourConfig"], &inbound))
+	if protocols := strings.Join(inbound.Properties["protocol"].Enum, ","); !strings.Contains(protocols, "vless") || !strings.Contains(protocols, "dokodemo-door") {
+		t.Error("unexpected inbound protocols: ", protocols)
+	}
+	settings := make(map[string]string)
+	for _, condition := range inbound.AllOf {
+		for _, protocol := range condition.If.Properties.Protocol.Enum {
+			settings[protocol] = condition.Then.Properties.Settings.Ref
+		}
+	}
+	if settings["vless"] != "#/$defs/VLessInboundConfig" || settings["socks"] != "#/$defs/SocksServerConfig" {
+		t.Error("unexpected inbound settings: ", settings)
+	}
+
+	var stream struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	common.Must(json.Unmarshal(schema.Defs["StreamConfig"], &stream))
+	for _, key := range []string{"network", "security", "realitySettings", "xhttpSettings", "sockopt"} {
+		if _, found := stream.Properties[key]; !found {
+			t.Error("missing stream setting ", key)
+		}
+	}
+	if !strings.Contains(string(stream.Properties["security"]), `"reality"`) {
+		t.Error("unexpected security schema: ", string(stream.Properties["security"]))
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	valid := []string{
+		`{
+			"log": {"loglevel": "warning", "access": "none"},
+			"dns": {"servers": ["1.1.1.1", {"address": "8.8.8.8", "port": 53, "domains": ["geosite:google"]}]},
+			"routing": {
+				"domainStrategy": "AlwaysIP",
+				"rules": [
+					{"domain": ["geosite:cn"], "port": "80,443", "outboundTag": "direct"},
+					{"ip": "geoip:private", "network": "tcp,udp", "outboundTag": "block"},
+					{"inboundTag": ["socks"], "balancerTag": "balancer"}
+				],
+				"balancers": [{
+					"tag": "balancer",
+					"selector": ["proxy"],
+					"strategy": {"type": "leastLoad", "settings": {"tolerance": 0.5, "expected": 2}}
+				}]
+			},
+			"inbounds": [{
+				"tag": "vless",
+				"protocol": "vless",
+				"port": 443,
+				"settings": {"decryption": "none", "clients": [{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "flow": "xtls-rprx-vision"}]},
+				"streamSettings": {"security": "reality", "realitySettings": {"target": "example.com:443", "serverNames": ["example.com"], "privateKey": "aGSYystUbf59_9_6LKRxD27rmSW_-2_nyd9YG_Gwbks", "shortIds": [""]}}
+			}, {
+				"tag": "socks",
+				"protocol": "socks",
+				"listen": "127.0.0.1",
+				"port": 1080,
+				"settings": {"udp": true},
+				"sniffing": {"enabled": true, "destOverride": ["http", "tls"]}
+			}, {
+				"protocol": "dokodemo-door",
+				"port": "5353",
+				"settings": {"address": "1.1.1.1", "port": 53, "network": "udp"}
+			}],
+			"outbounds": [{
+				"tag": "proxy",
+				"protocol": "vless",
+				"settings": {"vnext": [{"address": "example.com", "port": 443, "users": [{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "encryption": "none"}]}]},
+				"streamSettings": {
+					"network": "grpc",
+					"grpcSettings": {"serviceName": "tunnel", "unknown_method_code": "UNIMPLEMENTED"},
+					"security": "tls",
+					"tlsSettings": {"serverName": "example.com", "fingerprint": "chrome", "alpn": ["h2"]}
+				},
+				"mux": {"enabled": true, "concurrency": 8}
+			}, {
+				"tag": "direct",
+				"protocol": "freedom",
+				"settings": {"domainStrategy": "UseIPv4"}
+			}, {
+				"tag": "block",
+				"protocol": "blackhole",
+				"settings": {"response": {"type": "http"}}
+			}],
+			"policy": {"levels": {"0": {"handshake": 4, "connIdle": 300}}, "system": {"statsInboundUplink": true}},
+			"burstObservatory": {"subjectSelector": ["proxy"], "pingConfig": {"interval": "1m", "sampling": 2}, "enablePassive": true}
+		}`,
+		`{
+			"inbounds": [{
+				"protocol": "trojan",
+				"port": 8443,
+				"settings": {"clients": [{"password": "password"}]},
+				"streamSettings": {"network": "grpc", "grpcSettings": {"serviceName": "tunnel", "unknown_method_code": 12}}
+			}],
+			"outbounds": [{"protocol": "freedom"}]
+		}`,
+	}
+	for i, config := range valid {
+		var c interface{}
+		common.Must(json.Unmarshal([]byte(config), &c))
+		if err := validateSchema(c); err != nil {
+			t.Error("config ", i, ": ", err)
+		}
+	}
+
+	invalid := []string{
+		`{"log": {"loglevel": "verbose"}}`,
+		`{"inbounds": [{"protocol": "socks", "port": true}]}`,
+		`{"inbounds": [{"protocol": "unknown", "port": 1080}]}`,
+		`{"outbounds": [{"protocol": "vless", "streamSettings": {"grpcSettings": {"unknown_method_code": "NOPE"}}}]}`,
+		`{"outbounds": [{"protocol": "vless", "streamSettings": {"grpcSettings": {"unknown_method_code": 17}}}]}`,
+		`{"inbounds": [{"protocol": "vless", "port": 443, "settings": {"clients": [{"email": "a@example.com"}]}}]}`,
+	}
+	for _, config := range invalid {
+		var c interface{}
+		common.Must(json.Unmarshal([]byte(config), &c))
+		if err := validateSchema(c); err == nil {
+			t.Error("validated ", config)
+		}
+	}
+}
+
+// validateSchema checks config, which is decoded from JSON, against Schema. It
+// supports the keywords Schema uses only.
+func validateSchema(config interface{}) error {
+	b, err := json.Marshal(Schema())
+	if err != nil {
+		return err
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		return err
+	}
+	v := &schemaValidator{defs: schema["$defs"].(map[string]interface{})}
+	return v.validate(schema, config, "config")
+}
+
+type schemaValidator struct {
+	defs map[string]interface{}
+}
+
+func (v *schemaValidator) validate(schema interface{}, value interface{}, path string) error {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		def, found := v.defs[strings.TrimPrefix(ref, "#/$defs/")]
+		if !found {
+			return errors.New(path, ": undefined reference ", ref)
+		}
+		if err := v.validate(def, value, path); err != nil {
+			return err
+		}
+	}
+	if t, ok := s["type"].(string); ok && !isSchemaType(value, t) {
+		return errors.New(path, ": expected ", t, ", got ", fmt.Sprint(value))
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New(path, ": ", fmt.Sprint(value), " is not one of ", fmt.Sprint(enum))
+		}
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, value) {
+		return errors.New(path, ": expected ", fmt.Sprint(c))
+	}
+	if n, ok := value.(float64); ok {
+		if minimum, ok := s["minimum"].(float64); ok && n < minimum {
+			return errors.New(path, ": ", n, " is less than ", minimum)
+		}
+		if maximum, ok := s["maximum"].(float64); ok && n > maximum {
+			return errors.New(path, ": ", n, " is greater than ", maximum)
+		}
+	}
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if required, ok := s["required"].([]interface{}); ok {
+			for _, key := range required {
+				if _, found := value[key.(string)]; !found {
+					return errors.New(path, ": missing ", key)
+				}
+			}
+		}
+		properties, _ := s["properties"].(map[string]interface{})
+		for key, property := range value {
+			propertySchema, found := properties[key]
+			if !found {
+				propertySchema = s["additionalProperties"]
+			}
+			if err := v.validate(propertySchema, property, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			if err := v.validate(s["items"], item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	if allOf, ok := s["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			if err := v.validate(sub, value, path); err != nil {
+				return err
+			}
+		}
+	}
+	if oneOf, ok := s["oneOf"].([]interface{}); ok {
+		matched := 0
+		var lastErr error
+		for _, sub := range oneOf {
+			if err := v.validate(sub, value, path); err != nil {
+				lastErr = err
+			} else {
+				matched++
+			}
+		}
+		if matched == 0 {
+			return lastErr
+		}
+		if matched > 1 {
+			return errors.New(path, ": matches more than one schema")
+		}
+	}
+	if condition, ok := s["if"]; ok && v.validate(condition, value, path) == nil {
+		if err := v.validate(s["then"], value, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isSchemaType(value interface{}, t string) bool {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return t == "object"
+	case []interface{}:
+		return t == "array"
+	case string:
+		return t == "string"
+	case bool:
+		return t == "boolean"
+	case float64:
+		return t == "number" || (t == "integer" && value == math.Trunc(value))
+	case nil:
+		return t == "null"
+	}
+	return false
+}
@@ -3,6 +3,8 @@ package serial
 import (
 	"context"
 	"io"
+	"path/filepath"
+	"strings"
 
 	"github.com/xtls/xray-core/common/errors"
 	creflect "github.com/xtls/xray-core/common/reflect"
@@ -11,8 +13,10 @@ import (
 	"github.com/xtls/xray-core/main/confloader"
 )
 
+// MergeConfigFromFiles decodes and merges files to a config in JSON. Strings
+// with references to environment variables and files are dumped as written.
 func MergeConfigFromFiles(files []*core.ConfigSource) (string, error) {
-	c, err := mergeConfigs(files)
+	c, err := mergeConfigs(files, true)
 	if err != nil {
 		return "", err
 	}
@@ -23,7 +27,15 @@ func MergeConfigFromFiles(files []*core.ConfigSource) (string, error) {
 	return "", errors.New("marshal to json failed.").AtError()
 }
 
-func mergeConfigs(files []*core.ConfigSource) (*conf.Config, error) {
+// DecodeConfigFromFiles decodes and merges files to a config, without
+// building it.
+func DecodeConfigFromFiles(files []*core.ConfigSource) (*conf.Config, error) {
+	return mergeConfigs(files, false)
+}
+
+// mergeConfigs decodes and merges files. If redacted, strings with references
+// are decoded as written.
+func mergeConfigs(files []*core.ConfigSource, redacted bool) (*conf.Config, error) {
 	cf := &conf.Config{}
 	for i, file := range files {
 		errors.LogInfo(context.Background(), "Reading config: ", file)
@@ -31,7 +43,7 @@ func mergeConfigs(files []*core.ConfigSource) (*conf.Config, error) {
 		if err != nil {
 			return nil, errors.New("failed to read config: ", file).Base(err)
 		}
-		c, err := ReaderDecoderByFormat[file.Format](r)
+		c, err := decodeConfigFile(r, file.Name, file.Format, redacted)
 		if err != nil {
 			return nil, errors.New("failed to decode config: ", file).Base(err)
 		}
@@ -44,8 +56,48 @@ func mergeConfigs(files []*core.ConfigSource) (*conf.Config, error) {
 	return cf, nil
 }
 
+// DecodeConfigFile decodes the config in format read from r, of the file
+// name. Relative includes are in the directory of the file, and configs
+// fetched over HTTP can not refer to local files or environment variables.
+func DecodeConfigFile(r io.Reader, name string, format string) (*conf.Config, error) {
+	return decodeConfigFile(r, name, format, false)
+}
+
+func decodeConfigFile(r io.Reader, name string, format string, redacted bool) (*conf.Config, error) {
+	decode, found := interpolatingDecoders[format]
+	if !found {
+		if decode, found := ReaderDecoderByFormat[format]; found {
+			return decode(r)
+		}
+		return nil, errors.New("unknown config format: ", format)
+	}
+	in := newInterpolation()
+	in.remote = isRemoteConfig(name)
+	c, err := decode(r, configDir(name), in)
+	if err == nil && redacted {
+		in.redact(c)
+	}
+	return c, err
+}
+
+// configDir returns the directory of the config file name, which relative
+// includes of the config are in, or empty for the working directory if the
+// config is not a local file.
+func configDir(name string) string {
+	if name == "stdin:" || isRemoteConfig(name) {
+		return ""
+	}
+	return filepath.Dir(name)
+}
+
+// isRemoteConfig reports whether the config name is fetched over HTTP. Remote
+// configs can not refer to local files or environment variables.
+func isRemoteConfig(name string) bool {
+	return strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://")
+}
+
 func BuildConfig(files []*core.ConfigSource) (*core.Config, error) {
-	config, err := mergeConfigs(files)
+	config, err := mergeConfigs(files, false)
 	if err != nil {
 		return nil, err
 	}
@@ -56,6 +108,14 @@ type readerDecoder func(io.Reader) (*conf.Config, error)
 
 var ReaderDecoderByFormat = make(map[string]readerDecoder)
 
+// interpolatingDecoders are the decoders of formats, which decode configs in
+// a directory and keep the strings with references in the interpolation.
+var interpolatingDecoders = map[string]func(io.Reader, string, *interpolation) (*conf.Config, error){
+	"json": decodeJSONConfig,
+	"yaml": decodeYAMLConfig,
+	"toml": decodeTOMLConfig,
+}
+
 func init() {
 	ReaderDecoderByFormat["json"] = DecodeJSONConfig
 	ReaderDecoderByFormat["yaml"] = DecodeYAMLConfig
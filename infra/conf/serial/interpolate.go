@@ -0,0 +1,346 @@
+package serial
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/platform/filesystem"
+	"github.com/xtls/xray-core/infra/conf"
+)
+
+// reference matches "${env:NAME}" and "${file:PATH}" in config strings, and
+// "$${...}" for a literal "${...}".
+var reference = regexp.MustCompile(`\$?\$\{(env|file):([^}]*)\}`)
+
+// interpolation resolves references in the strings of a config, and keeps
+// where the strings with references are, for redacting dumps of the config.
+type interpolation struct {
+	// resolved maps strings with references to the strings resolved
+	resolved map[string]string
+	// written are the strings with references as written, by their paths in
+	// the config
+	written []writtenString
+	// remote is set for configs fetched over HTTP, which can not refer to
+	// local files and environment variables, nor include files.
+	remote bool
+}
+
+type writtenString struct {
+	path  []interface{}
+	value string
+}
+
+func newInterpolation() *interpolation {
+	return &interpolation{resolved: make(map[string]string)}
+}
+
+// interpolate resolves references in the strings of JSON content b.
+func (in *interpolation) interpolate(b []byte) ([]byte, error) {
+	if !bytes.Contains(b, []byte("${")) {
+		return b, nil
+	}
+	var root interface{}
+	if err := json.Unmarshal(b, &root); err != nil {
+		// Left for the decoder to report.
+		return b, nil
+	}
+	if _, err := in.interpolateValue(root, nil); err != nil {
+		return nil, err
+	}
+
+	// the strings are replaced in place, so that the decoder reports
+	// positions in the file
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); {
+		if b[i] != '"' {
+			out = append(out, b[i])
+			i++
+			continue
+		}
+		end := stringEnd(b, i)
+		if end < 0 {
+			// Left for the decoder to report.
+			return append(out, b[i:]...), nil
+		}
+		literal := b[i : end+1]
+		var s string
+		if bytes.Contains(literal, []byte("${")) && json.Unmarshal(literal, &s) == nil {
+			if resolved, found := in.resolved[s]; found {
+				literal = encodeString(resolved)
+			}
+		}
+		out = append(out, literal...)
+		i = end + 1
+	}
+	return out, nil
+}
+
+// interpolateValue resolves references in the strings of JSON value v at
+// path, and returns v with them resolved.
+func (in *interpolation) interpolateValue(v interface{}, path []interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, x := range v {
+			y, err := in.interpolateValue(x, append(path[:len(path):len(path)], k))
+			if err != nil {
+				return nil, err
+			}
+			v[k] = y
+		}
+	case []interface{}:
+		for i, x := range v {
+			y, err := in.interpolateValue(x, append(path[:len(path):len(path)], i))
+			if err != nil {
+				return nil, err
+			}
+			v[i] = y
+		}
+	case string:
+		resolved, err := in.resolve(v)
+		if err != nil {
+			return nil, err
+		}
+		if resolved != v {
+			in.written = append(in.written, writtenString{path: path, value: v})
+		}
+		return resolved, nil
+	}
+	return v, nil
+}
+
+// stringEnd returns the index of the quote ending the string starting at
+// b[start], or -1 if the string is not terminated.
+func stringEnd(b []byte, start int) int {
+	for i := start + 1; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// resolve returns s with its references resolved.
+func (in *interpolation) resolve(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	if resolved, found := in.resolved[s]; found {
+		return resolved, nil
+	}
+	var sb strings.Builder
+	last := 0
+	for _, m := range reference.FindAllStringSubmatchIndex(s, -1) {
+		sb.WriteString(s[last:m[0]])
+		last = m[1]
+		if s[m[0]+1] == '$' {
+			sb.WriteString(s[m[0]+1 : m[1]])
+			continue
+		}
+		if in.remote {
+			return "", errors.New("remote config can not refer to ", s[m[0]:m[1]])
+		}
+		value, err := resolveReference(s[m[2]:m[3]], s[m[4]:m[5]])
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(value)
+	}
+	sb.WriteString(s[last:])
+	in.resolved[s] = sb.String()
+	return sb.String(), nil
+}
+
+func resolveReference(kind, name string) (string, error) {
+	if name == "" {
+		return "", errors.New("empty ", kind, " reference")
+	}
+	switch kind {
+	case "env":
+		value, found := os.LookupEnv(name)
+		if !found {
+			return "", errors.New("environment variable ", name, " is not set")
+		}
+		return value, nil
+	default:
+		b, err := filesystem.ReadFile(name)
+		if err != nil {
+			return "", errors.New("failed to read file ", name).Base(err)
+		}
+		// Secret files usually end with a newline.
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+}
+
+func encodeString(s string) []byte {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n"))
+}
+
+// redact puts the strings with references back to config as written, so
+// that secrets are not dumped. config must be decoded from the content
+// interpolated by in.
+func (in *interpolation) redact(config *conf.Config) {
+	for _, w := range in.written {
+		setWritten(reflect.ValueOf(config).Elem(), w.path, w.value)
+	}
+}
+
+// setWritten decodes string s into the field of v at JSON path, and reports
+// whether it is found.
+func setWritten(v reflect.Value, path []interface{}, s string) bool {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	if v.Type() == reflect.TypeOf(json.RawMessage{}) || v.Kind() == reflect.Interface {
+		var value interface{}
+		if v.Kind() == reflect.Interface {
+			value = v.Interface()
+		} else if err := json.Unmarshal(v.Bytes(), &value); err != nil {
+			return false
+		}
+		value, ok := setWrittenValue(value, path, s)
+		if !ok {
+			return false
+		}
+		if v.Kind() == reflect.Interface {
+			v.Set(reflect.ValueOf(value))
+			return true
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return false
+		}
+		v.SetBytes(b)
+		return true
+	}
+	if len(path) == 0 {
+		field := reflect.New(v.Type())
+		if err := json.Unmarshal(encodeString(s), field.Interface()); err != nil {
+			return false
+		}
+		v.Set(field.Elem())
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		name, ok := path[0].(string)
+		if !ok {
+			return false
+		}
+		field := jsonField(v, name)
+		return field.IsValid() && setWritten(field, path[1:], s)
+	case reflect.Slice, reflect.Array:
+		i, ok := path[0].(int)
+		if !ok || i >= v.Len() {
+			return false
+		}
+		return setWritten(v.Index(i), path[1:], s)
+	case reflect.Map:
+		name, ok := path[0].(string)
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return false
+		}
+		key := reflect.ValueOf(name).Convert(v.Type().Key())
+		elem := v.MapIndex(key)
+		if !elem.IsValid() {
+			return false
+		}
+		// elements of maps are not addressable
+		copied := reflect.New(elem.Type()).Elem()
+		copied.Set(elem)
+		if !setWritten(copied, path[1:], s) {
+			return false
+		}
+		v.SetMapIndex(key, copied)
+		return true
+	}
+	return false
+}
+
+// setWrittenValue sets string s at path of JSON value v.
+func setWrittenValue(v interface{}, path []interface{}, s string) (interface{}, bool) {
+	if len(path) == 0 {
+		return s, true
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		name, ok := path[0].(string)
+		if !ok {
+			return nil, false
+		}
+		x, found := v[name]
+		if !found {
+			return nil, false
+		}
+		if v[name], ok = setWrittenValue(x, path[1:], s); !ok {
+			return nil, false
+		}
+		return v, true
+	case []interface{}:
+		i, ok := path[0].(int)
+		if !ok || i >= len(v) {
+			return nil, false
+		}
+		if v[i], ok = setWrittenValue(v[i], path[1:], s); !ok {
+			return nil, false
+		}
+		return v, true
+	}
+	return nil, false
+}
+
+// jsonField returns the field of struct v decoded from the JSON key name,
+// matched as encoding/json does.
+func jsonField(v reflect.Value, name string) reflect.Value {
+	var folded reflect.Value
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && tag == "" {
+			embedded := v.Field(i)
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if f := jsonField(embedded, name); f.IsValid() {
+					return f
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if tag == "" {
+			tag = field.Name
+		}
+		if tag == name {
+			return v.Field(i)
+		}
+		if !folded.IsValid() && strings.EqualFold(tag, name) {
+			folded = v.Field(i)
+		}
+	}
+	return folded
+}
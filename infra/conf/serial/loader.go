@@ -41,26 +41,46 @@ func findOffset(b []byte, o int) *offset {
 }
 
 // DecodeJSONConfig reads from reader and decode the config into *conf.Config
-// syntax error could be detected.
+// syntax error could be detected. References to environment variables and
+// files in strings, such as "${env:NAME}" and "${file:/run/secrets/name}",
+// are resolved, and so are "$include" and "$ref" of fragments. Relative paths
+// of "$include" are relative to the working directory.
 func DecodeJSONConfig(reader io.Reader) (*conf.Config, error) {
+	return decodeJSONConfig(reader, "", newInterpolation())
+}
+
+// decodeJSONConfig is DecodeJSONConfig of the config in the directory dir.
+func decodeJSONConfig(reader io.Reader, dir string, in *interpolation) (*conf.Config, error) {
 	jsonConfig := &conf.Config{}
 
-	jsonContent := bytes.NewBuffer(make([]byte, 0, 10240))
-	jsonReader := io.TeeReader(&json_reader.Reader{
+	jsonContent, err := io.ReadAll(&json_reader.Reader{
 		Reader: reader,
-	}, jsonContent)
-	decoder := json.NewDecoder(jsonReader)
+	})
+	if err != nil {
+		return nil, errors.New("failed to read config file").Base(err)
+	}
+	jsonContent, expanded, err := expandTemplates(jsonContent, dir, in)
+	if err != nil {
+		return nil, errors.New("failed to expand config file").Base(err)
+	}
+	if !expanded {
+		if jsonContent, err = in.interpolate(jsonContent); err != nil {
+			return nil, errors.New("failed to interpolate config file").Base(err)
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonContent))
 
 	if err := decoder.Decode(jsonConfig); err != nil {
 		var pos *offset
 		cause := errors.Cause(err)
 		switch tErr := cause.(type) {
 		case *json.SyntaxError:
-			pos = findOffset(jsonContent.Bytes(), int(tErr.Offset))
+			pos = findOffset(jsonContent, int(tErr.Offset))
 		case *json.UnmarshalTypeError:
-			pos = findOffset(jsonContent.Bytes(), int(tErr.Offset))
+			pos = findOffset(jsonContent, int(tErr.Offset))
 		}
-		if pos != nil {
+		// Positions in expanded configs don't match the file.
+		if pos != nil && !expanded {
 			return nil, errors.New("failed to read config file at line ", pos.line, " char ", pos.char).Base(err)
 		}
 		return nil, errors.New("failed to read config file").Base(err)
@@ -86,13 +106,26 @@ func LoadJSONConfig(reader io.Reader) (*core.Config, error) {
 // DecodeTOMLConfig reads from reader and decode the config into *conf.Config
 // using github.com/pelletier/go-toml and map to convert toml to json.
 func DecodeTOMLConfig(reader io.Reader) (*conf.Config, error) {
+	return decodeTOMLConfig(reader, "", newInterpolation())
+}
+
+func decodeTOMLConfig(reader io.Reader, dir string, in *interpolation) (*conf.Config, error) {
 	tomlFile, err := io.ReadAll(reader)
 	if err != nil {
 		return nil, errors.New("failed to read config file").Base(err)
 	}
 
+	jsonFile, err := tomlToJSON(tomlFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeJSONConfig(bytes.NewReader(jsonFile), dir, in)
+}
+
+func tomlToJSON(b []byte) ([]byte, error) {
 	configMap := make(map[string]interface{})
-	if err := toml.Unmarshal(tomlFile, &configMap); err != nil {
+	if err := toml.Unmarshal(b, &configMap); err != nil {
 		return nil, errors.New("failed to convert toml to map").Base(err)
 	}
 
@@ -100,8 +133,7 @@ func DecodeTOMLConfig(reader io.Reader) (*conf.Config, error) {
 	if err != nil {
 		return nil, errors.New("failed to convert map to json").Base(err)
 	}
-
-	return DecodeJSONConfig(bytes.NewReader(jsonFile))
+	return jsonFile, nil
 }
 
 func LoadTOMLConfig(reader io.Reader) (*core.Config, error) {
@@ -121,6 +153,10 @@ func LoadTOMLConfig(reader io.Reader) (*core.Config, error) {
 // DecodeYAMLConfig reads from reader and decode the config into *conf.Config
 // using github.com/ghodss/yaml to convert yaml to json.
 func DecodeYAMLConfig(reader io.Reader) (*conf.Config, error) {
+	return decodeYAMLConfig(reader, "", newInterpolation())
+}
+
+func decodeYAMLConfig(reader io.Reader, dir string, in *interpolation) (*conf.Config, error) {
 	yamlFile, err := io.ReadAll(reader)
 	if err != nil {
 		return nil, errors.New("failed to read config file").Base(err)
@@ -131,7 +167,7 @@ func DecodeYAMLConfig(reader io.Reader) (*conf.Config, error) {
 		return nil, errors.New("failed to convert yaml to json").Base(err)
 	}
 
-	return DecodeJSONConfig(bytes.NewReader(jsonFile))
+	return decodeJSONConfig(bytes.NewReader(jsonFile), dir, in)
 }
 
 func LoadYAMLConfig(reader io.Reader) (*core.Config, error) {
@@ -2,10 +2,17 @@ package serial_test
 
 import (
 	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/infra/conf/serial"
+	_ "github.com/xtls/xray-core/main/confloader/external"
 )
 
 func TestLoaderError(t *testing.T) {
@@ -61,3 +68,180 @@ func TestLoaderError(t *testing.T) {
 		}
 	}
 }
+
+func TestLoaderInterpolation(t *testing.T) {
+	t.Setenv("XRAY_TEST_ID", "27848739-7e62-4138-9fd3-098a63964b6b")
+	secret := filepath.Join(t.TempDir(), "password")
+	common.Must(os.WriteFile(secret, []byte("pass\"word\n"), 0o600))
+
+	config := `{
+		"inbounds": [{
+			"protocol": "vless",
+			"port": 443,
+			"settings": {"decryption": "none", "clients": [{"id": "${env:XRAY_TEST_ID}", "email": "$${env:XRAY_TEST_ID}"}]}
+		}, {
+			"protocol": "trojan",
+			"port": 8443,
+			"settings": {"clients": [{"password": "${file:` + filepath.ToSlash(secret) + `}"}]}
+		}]
+	}`
+	c, err := serial.DecodeJSONConfig(strings.NewReader(config))
+	common.Must(err)
+	if settings := string(*c.InboundConfigs[0].Settings); !strings.Contains(settings, `"id": "27848739-7e62-4138-9fd3-098a63964b6b"`) || !strings.Contains(settings, `"email": "${env:XRAY_TEST_ID}"`) {
+		t.Error("unexpected settings: ", settings)
+	}
+	if settings := string(*c.InboundConfigs[1].Settings); !strings.Contains(settings, `"password": "pass\"word"`) {
+		t.Error("unexpected settings: ", settings)
+	}
+
+	yamlConfig := "inbounds:\n- protocol: vless\n  port: 443\n  settings:\n    decryption: none\n    clients:\n    - id: ${env:XRAY_TEST_ID}\n"
+	c, err = serial.DecodeYAMLConfig(strings.NewReader(yamlConfig))
+	common.Must(err)
+	if settings := string(*c.InboundConfigs[0].Settings); !strings.Contains(settings, "27848739-7e62-4138-9fd3-098a63964b6b") {
+		t.Error("unexpected settings: ", settings)
+	}
+
+	file := filepath.Join(t.TempDir(), "config.json")
+	common.Must(os.WriteFile(file, []byte(config), 0o600))
+	dump, err := serial.MergeConfigFromFiles([]*core.ConfigSource{{Name: file, Format: "json"}})
+	common.Must(err)
+	if strings.Contains(dump, "27848739-7e62-4138-9fd3-098a63964b6b") || strings.Contains(dump, "pass\\\"word") || !strings.Contains(dump, `"id": "${env:XRAY_TEST_ID}"`) || !strings.Contains(dump, `"email": "$${env:XRAY_TEST_ID}"`) {
+		t.Error("secrets not redacted: ", dump)
+	}
+
+	// only the strings with references are redacted, even in fragments, and
+	// strings resolved in other loads are not
+	file = filepath.Join(t.TempDir(), "config.json")
+	common.Must(os.WriteFile(file, []byte(`{
+		"fragments": {"users": {"main": {"id": "${env:XRAY_TEST_ID}", "encryption": "none"}}},
+		"outbounds": [{
+			"tag": "27848739-7e62-4138-9fd3-098a63964b6b",
+			"protocol": "vless",
+			"settings": {"vnext": [{"address": "example.com", "port": 443, "users": [{"$ref": "users.main"}]}]}
+		}]
+	}`), 0o600))
+	dump, err = serial.MergeConfigFromFiles([]*core.ConfigSource{{Name: file, Format: "json"}})
+	common.Must(err)
+	if !strings.Contains(dump, `"tag": "27848739-7e62-4138-9fd3-098a63964b6b"`) || !strings.Contains(dump, `"id": "${env:XRAY_TEST_ID}"`) || strings.Count(dump, "27848739-7e62-4138-9fd3-098a63964b6b") != 1 {
+		t.Error("unexpected dump: ", dump)
+	}
+
+	for _, input := range []string{
+		`{"log": {"access": "${env:XRAY_TEST_MISSING}"}}`,
+		`{"log": {"access": "${file:` + filepath.ToSlash(secret) + `.missing}"}}`,
+	} {
+		if _, err := serial.DecodeJSONConfig(strings.NewReader(input)); err == nil {
+			t.Error("expected error for ", input)
+		}
+	}
+}
+
+func TestLoaderTemplates(t *testing.T) {
+	dir := t.TempDir()
+	outbounds := filepath.ToSlash(filepath.Join(dir, "outbounds.yaml"))
+	common.Must(os.WriteFile(outbounds, []byte("- protocol: freedom\n  tag: direct\n- protocol: blackhole\n  tag: block\n"), 0o600))
+	streams := filepath.ToSlash(filepath.Join(dir, "streams.toml"))
+	common.Must(os.WriteFile(streams, []byte("[reality-main]\nnetwork = \"raw\"\nsecurity = \"reality\"\n\n[reality-main.realitySettings]\nserverName = \"example.com\"\nshortId = \"0123\"\n"), 0o600))
+	loop := filepath.ToSlash(filepath.Join(dir, "loop.json"))
+	common.Must(os.WriteFile(loop, []byte(`{"$include": "`+loop+`"}`), 0o600))
+
+	c, err := serial.DecodeJSONConfig(strings.NewReader(`{
+		"fragments": {
+			"streams": {"$include": "` + streams + `"},
+			"users": {"main": {"id": "27848739-7e62-4138-9fd3-098a63964b6b", "encryption": "none"}}
+		},
+		"outbounds": [{
+			"tag": "proxy",
+			"protocol": "vless",
+			"settings": {"vnext": [{"address": "example.com", "port": 443, "users": [{"$ref": "users.main"}]}]},
+			"streamSettings": {"$ref": "streams.reality-main", "realitySettings": {"shortId": "abcd"}}
+		}, {
+			"$include": "` + outbounds + `"
+		}]
+	}`))
+	common.Must(err)
+	var tags []string
+	for _, outbound := range c.OutboundConfigs {
+		tags = append(tags, outbound.Tag)
+	}
+	if strings.Join(tags, ",") != "proxy,direct,block" {
+		t.Error("unexpected outbounds: ", tags)
+	}
+	stream := c.OutboundConfigs[0].StreamSetting
+	if stream == nil || stream.Security != "reality" || stream.REALITYSettings.ServerName != "example.com" || stream.REALITYSettings.ShortId != "abcd" {
+		t.Error("unexpected stream settings: ", stream)
+	}
+	if settings := string(*c.OutboundConfigs[0].Settings); !strings.Contains(settings, "27848739-7e62-4138-9fd3-098a63964b6b") {
+		t.Error("unexpected settings: ", settings)
+	}
+
+	// relative includes are in the directory of the including file, not in
+	// the working directory
+	common.Must(os.MkdirAll(filepath.Join(dir, "outbounds"), 0o700))
+	common.Must(os.WriteFile(filepath.Join(dir, "outbounds", "proxy.json"), []byte(`[{"tag": "proxy", "protocol": "vless", "streamSettings": {"$include": "../stream.json"}}]`), 0o600))
+	common.Must(os.WriteFile(filepath.Join(dir, "stream.json"), []byte(`{"network": "ws", "security": "tls"}`), 0o600))
+	config := filepath.Join(dir, "config.json")
+	common.Must(os.WriteFile(config, []byte(`{"outbounds": [{"$include": "outbounds/proxy.json"}, {"$include": "./outbounds.yaml"}]}`), 0o600))
+	c, err = serial.DecodeConfigFromFiles([]*core.ConfigSource{{Name: config, Format: "json"}})
+	common.Must(err)
+	tags = nil
+	for _, outbound := range c.OutboundConfigs {
+		tags = append(tags, outbound.Tag)
+	}
+	if strings.Join(tags, ",") != "proxy,direct,block" {
+		t.Error("unexpected outbounds of relative includes: ", tags)
+	}
+	if stream := c.OutboundConfigs[0].StreamSetting; stream == nil || stream.Security != "tls" {
+		t.Error("unexpected stream settings of relative includes: ", stream)
+	}
+
+	for input, expected := range map[string]string{
+		`{"outbounds": [{"$ref": "users.missing"}]}`:                           "undefined fragment",
+		`{"fragments": {"a": {"b": {"$ref": "a.b"}}}, "log": {"$ref": "a.b"}}`: "refers to itself",
+		`{"log": {"$include": "` + loop + `"}}`:                                "includes itself",
+	} {
+		if _, err := serial.DecodeJSONConfig(strings.NewReader(input)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Error("expected error ", expected, " for ", input, ", but got ", err)
+		}
+	}
+}
+
+func TestLoaderRemote(t *testing.T) {
+	t.Setenv("XRAY_TEST_ID", "27848739-7e62-4138-9fd3-098a63964b6b")
+	secret := filepath.ToSlash(filepath.Join(t.TempDir(), "password"))
+	common.Must(os.WriteFile(secret, []byte("password"), 0o600))
+
+	configs := map[string]string{
+		"/fragments.json": `{
+			"fragments": {"users": {"main": {"id": "27848739-7e62-4138-9fd3-098a63964b6b", "encryption": "none"}}},
+			"outbounds": [{"protocol": "vless", "settings": {"vnext": [{"address": "example.com", "port": 443, "users": [{"$ref": "users.main"}]}]}}]
+		}`,
+		"/escaped.json": `{"log": {"access": "$${env:XRAY_TEST_ID}"}}`,
+		"/env.json":     `{"log": {"access": "${env:XRAY_TEST_ID}"}}`,
+		"/file.json":    `{"log": {"access": "${file:` + secret + `}"}}`,
+		"/include.json": `{"log": {"$include": "` + secret + `"}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(configs[r.URL.Path]))
+	}))
+	defer server.Close()
+
+	// remote configs can only refer to themselves
+	for _, name := range []string{"/fragments.json", "/escaped.json"} {
+		if _, err := serial.DecodeConfigFromFiles([]*core.ConfigSource{{Name: server.URL + name, Format: "json"}}); err != nil {
+			t.Error(name, ": ", err)
+		}
+	}
+	for _, name := range []string{"/env.json", "/file.json", "/include.json"} {
+		if _, err := serial.DecodeConfigFromFiles([]*core.ConfigSource{{Name: server.URL + name, Format: "json"}}); err == nil || !strings.Contains(err.Error(), "remote config can not") {
+			t.Error("expected error for ", name, ", but got ", err)
+		}
+		// as read by the callers which fetch configs themselves
+		if _, err := serial.DecodeConfigFile(strings.NewReader(configs[name]), server.URL+name, "json"); err == nil || !strings.Contains(err.Error(), "remote config can not") {
+			t.Error("expected error for ", name, " read by the caller, but got ", err)
+		}
+	}
+	if c, err := serial.DecodeConfigFile(strings.NewReader(configs["/env.json"]), "config.json", "json"); err != nil || c.LogConfig.AccessLog != "27848739-7e62-4138-9fd3-098a63964b6b" {
+		t.Error("local config: ", err)
+	}
+}
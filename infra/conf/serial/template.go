@@ -0,0 +1,242 @@
+package serial
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/platform/filesystem"
+	json_reader "github.com/xtls/xray-core/infra/conf/json"
+)
+
+const (
+	includeKey   = "$include"
+	refKey       = "$ref"
+	fragmentsKey = "fragments"
+)
+
+// expandTemplates resolves includes and fragment references in JSON content
+// b, and references in its strings by in, and reports whether b is expanded.
+// Relative paths of includes are relative to the directory of the including
+// file, which is dir for b.
+//
+// An object with "$include": "file" is replaced by the content of the file,
+// in JSON, YAML or TOML by its extension, and an object with "$ref": "a.b" by
+// the fragment b in the group a of the top-level "fragments". Other keys of
+// the object are merged over the content, recursively for objects. In
+// arrays, an object with only "$include" or "$ref" is replaced by the items
+// of the content if it's an array.
+func expandTemplates(b []byte, dir string, in *interpolation) ([]byte, bool, error) {
+	if !bytes.Contains(b, []byte(`"`+includeKey+`"`)) && !bytes.Contains(b, []byte(`"`+refKey+`"`)) {
+		return b, false, nil
+	}
+	var root interface{}
+	if err := json.Unmarshal(b, &root); err != nil {
+		// Left for the decoder to report.
+		return b, false, nil
+	}
+
+	e := &templateExpander{interpolation: in, dir: dir}
+	root, err := e.expand(root, includeKey, e.include)
+	if err != nil {
+		return nil, false, err
+	}
+	if m, ok := root.(map[string]interface{}); ok {
+		e.fragments = m[fragmentsKey]
+		delete(m, fragmentsKey)
+	}
+	if root, err = e.expand(root, refKey, e.ref); err != nil {
+		return nil, false, err
+	}
+	// included files and fragments are interpolated in place, so that paths
+	// of the strings with references are in the expanded config
+	if root, err = in.interpolateValue(root, nil); err != nil {
+		return nil, false, err
+	}
+
+	if b, err = json.Marshal(root); err != nil {
+		return nil, false, errors.New("failed to marshal expanded config").Base(err)
+	}
+	return b, true, nil
+}
+
+type templateExpander struct {
+	interpolation *interpolation
+	// dir is the directory of the config, or empty for the working directory
+	dir       string
+	fragments interface{}
+	// includes and refs are the files and fragments being expanded, for
+	// detecting loops.
+	includes []string
+	refs     []string
+}
+
+// expand replaces objects with key in v by what resolve returns for the
+// value of key.
+func (e *templateExpander) expand(v interface{}, key string, resolve func(interface{}) (interface{}, error)) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, x := range v {
+			if k == key {
+				continue
+			}
+			y, err := e.expand(x, key, resolve)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = y
+		}
+		target, found := v[key]
+		if !found {
+			return out, nil
+		}
+		content, err := resolve(target)
+		if err != nil {
+			return nil, err
+		}
+		if len(out) == 0 {
+			return content, nil
+		}
+		base, ok := content.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("keys next to ", key, " ", target, " can only be merged into an object")
+		}
+		return mergeObjects(base, out), nil
+
+	case []interface{}:
+		out := make([]interface{}, 0, len(v))
+		for _, x := range v {
+			y, err := e.expand(x, key, resolve)
+			if err != nil {
+				return nil, err
+			}
+			if m, ok := x.(map[string]interface{}); ok && len(m) == 1 && m[key] != nil {
+				if items, ok := y.([]interface{}); ok {
+					out = append(out, items...)
+					continue
+				}
+			}
+			out = append(out, y)
+		}
+		return out, nil
+
+	default:
+		return v, nil
+	}
+}
+
+func (e *templateExpander) include(target interface{}) (interface{}, error) {
+	path, ok := target.(string)
+	if !ok || path == "" {
+		return nil, errors.New("invalid ", includeKey, ": ", target)
+	}
+	if e.interpolation.remote {
+		return nil, errors.New("remote config can not include ", path)
+	}
+	path, err := e.interpolation.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	if !filepath.IsAbs(path) {
+		dir := e.dir
+		if len(e.includes) > 0 {
+			dir = filepath.Dir(e.includes[len(e.includes)-1])
+		}
+		path = filepath.Join(dir, path)
+	}
+	for _, p := range e.includes {
+		if p == path {
+			return nil, errors.New("file ", path, " includes itself")
+		}
+	}
+	b, err := readIncludedFile(path)
+	if err != nil {
+		return nil, errors.New("failed to include ", path).Base(err)
+	}
+	var content interface{}
+	if err := json.Unmarshal(b, &content); err != nil {
+		return nil, errors.New("failed to include ", path).Base(err)
+	}
+
+	e.includes = append(e.includes, path)
+	defer func() { e.includes = e.includes[:len(e.includes)-1] }()
+	return e.expand(content, includeKey, e.include)
+}
+
+func (e *templateExpander) ref(target interface{}) (interface{}, error) {
+	name, ok := target.(string)
+	if !ok || name == "" {
+		return nil, errors.New("invalid ", refKey, ": ", target)
+	}
+	name, err := e.interpolation.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range e.refs {
+		if r == name {
+			return nil, errors.New("fragment ", name, " refers to itself")
+		}
+	}
+	fragment := e.fragments
+	for _, part := range strings.Split(name, ".") {
+		group, ok := fragment.(map[string]interface{})
+		if ok {
+			fragment, ok = group[part]
+		}
+		if !ok {
+			return nil, errors.New("undefined fragment: ", name)
+		}
+	}
+
+	e.refs = append(e.refs, name)
+	defer func() { e.refs = e.refs[:len(e.refs)-1] }()
+	return e.expand(fragment, refKey, e.ref)
+}
+
+// mergeObjects returns base with the keys of over merged, recursively for
+// objects.
+func mergeObjects(base, over map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(base)+len(over))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range over {
+		if b, ok := out[k].(map[string]interface{}); ok {
+			if o, ok := v.(map[string]interface{}); ok {
+				out[k] = mergeObjects(b, o)
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// readIncludedFile reads an included file in JSON.
+func readIncludedFile(path string) ([]byte, error) {
+	b, err := filesystem.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if b, err = yaml.YAMLToJSON(b); err != nil {
+			return nil, errors.New("failed to convert yaml to json").Base(err)
+		}
+	case ".toml":
+		if b, err = tomlToJSON(b); err != nil {
+			return nil, err
+		}
+	default:
+		var buffer bytes.Buffer
+		if _, err = buffer.ReadFrom(&json_reader.Reader{Reader: bytes.NewReader(b)}); err != nil {
+			return nil, err
+		}
+		b = buffer.Bytes()
+	}
+	return b, nil
+}
@@ -0,0 +1,692 @@
+package conf
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cloudflare/circl/sign/mldsa/mldsa65"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"golang.org/x/crypto/curve25519"
+)
+
+// ParseShareLink parses a vless://, vmess://, trojan:// or ss:// share link to
+// the JSON of the outbound it stands for. The remark of the link, if any, is
+// the tag of the outbound.
+func ParseShareLink(link string) ([]byte, error) {
+	link = strings.TrimSpace(link)
+	scheme, _, found := strings.Cut(link, "://")
+	if !found {
+		return nil, errors.New("not a share link")
+	}
+	scheme = strings.ToLower(scheme)
+
+	var outbound map[string]interface{}
+	var err error
+	switch scheme {
+	case "vless":
+		outbound, err = parseVLESSLink(link)
+	case "vmess":
+		outbound, err = parseVMessLink(link)
+	case "trojan":
+		outbound, err = parseTrojanLink(link)
+	case "ss":
+		outbound, err = parseShadowsocksLink(link)
+	case "hysteria2", "hy2":
+		return nil, errors.New("Hysteria2 is not supported in this version")
+	default:
+		return nil, errors.New("unsupported share link scheme: ", scheme)
+	}
+	if err != nil {
+		return nil, errors.New("invalid ", scheme, " share link").Base(err)
+	}
+	return json.Marshal(outbound)
+}
+
+func parseVLESSLink(link string) (map[string]interface{}, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	address, port, err := shareLinkServer(u)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	user := map[string]interface{}{
+		"id":         u.User.Username(),
+		"encryption": "none",
+	}
+	if encryption := q.Get("encryption"); encryption != "" {
+		user["encryption"] = encryption
+	}
+	if flow := q.Get("flow"); flow != "" {
+		user["flow"] = flow
+	}
+	stream, err := shareLinkStreamSettings(q, q.Get("security"))
+	if err != nil {
+		return nil, err
+	}
+	return shareLinkOutbound("vless", u.Fragment, map[string]interface{}{
+		"vnext": []interface{}{map[string]interface{}{
+			"address": address,
+			"port":    port,
+			"users":   []interface{}{user},
+		}},
+	}, stream), nil
+}
+
+func parseTrojanLink(link string) (map[string]interface{}, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	address, port, err := shareLinkServer(u)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	security := q.Get("security")
+	if security == "" {
+		security = "tls"
+	}
+	stream, err := shareLinkStreamSettings(q, security)
+	if err != nil {
+		return nil, err
+	}
+	server := map[string]interface{}{
+		"address":  address,
+		"port":     port,
+		"password": u.User.Username(),
+	}
+	if flow := q.Get("flow"); flow != "" {
+		server["flow"] = flow
+	}
+	return shareLinkOutbound("trojan", u.Fragment, map[string]interface{}{
+		"servers": []interface{}{server},
+	}, stream), nil
+}
+
+// vmessLink is the JSON in vmess:// share links.
+type vmessLink struct {
+	Remark   string      `json:"ps"`
+	Address  string      `json:"add"`
+	Port     json.Number `json:"port"`
+	ID       string      `json:"id"`
+	Security string      `json:"scy"`
+	Network  string      `json:"net"`
+	Type     string      `json:"type"`
+	Host     string      `json:"host"`
+	Path     string      `json:"path"`
+	TLS      string      `json:"tls"`
+	SNI      string      `json:"sni"`
+	ALPN     string      `json:"alpn"`
+	FP       string      `json:"fp"`
+}
+
+func parseVMessLink(link string) (map[string]interface{}, error) {
+	content, err := decodeBase64(link[len("vmess://"):])
+	if err != nil {
+		return nil, err
+	}
+	var v vmessLink
+	if err := json.Unmarshal(content, &v); err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(v.Port.String(), 10, 16)
+	if err != nil || v.Address == "" {
+		return nil, errors.New("invalid server address ", v.Address, ":", v.Port)
+	}
+
+	// the same as the query of other links
+	q := url.Values{}
+	q.Set("type", v.Network)
+	q.Set("headerType", v.Type)
+	q.Set("host", v.Host)
+	q.Set("sni", v.SNI)
+	q.Set("alpn", v.ALPN)
+	q.Set("fp", v.FP)
+	switch v.Network {
+	case "grpc":
+		q.Set("serviceName", v.Path)
+		q.Set("mode", v.Type)
+	case "kcp", "mkcp":
+		q.Set("seed", v.Path)
+	default:
+		q.Set("path", v.Path)
+	}
+	stream, err := shareLinkStreamSettings(q, v.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	security := v.Security
+	if security == "" {
+		security = "auto"
+	}
+	return shareLinkOutbound("vmess", v.Remark, map[string]interface{}{
+		"vnext": []interface{}{map[string]interface{}{
+			"address": v.Address,
+			"port":    port,
+			"users": []interface{}{map[string]interface{}{
+				"id":       v.ID,
+				"security": security,
+			}},
+		}},
+	}, stream), nil
+}
+
+func parseShadowsocksLink(link string) (map[string]interface{}, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	if u.User == nil {
+		// ss://base64(method:password@host:port)#remark
+		content, err := decodeBase64(u.Host)
+		if err != nil {
+			return nil, err
+		}
+		fragment := u.Fragment
+		if u, err = url.Parse("ss://" + string(content)); err != nil {
+			return nil, err
+		}
+		u.Fragment = fragment
+	}
+	if u.Query().Get("plugin") != "" {
+		return nil, errors.New("plugins are not supported")
+	}
+	address, port, err := shareLinkServer(u)
+	if err != nil {
+		return nil, err
+	}
+
+	method := u.User.Username()
+	password, found := u.User.Password()
+	if !found {
+		// ss://base64(method:password)@host:port#remark
+		content, err := decodeBase64(method)
+		if err != nil {
+			return nil, err
+		}
+		method, password, found = strings.Cut(string(content), ":")
+		if !found {
+			return nil, errors.New("no password")
+		}
+	}
+	return shareLinkOutbound("shadowsocks", u.Fragment, map[string]interface{}{
+		"servers": []interface{}{map[string]interface{}{
+			"address":  address,
+			"port":     port,
+			"method":   method,
+			"password": password,
+		}},
+	}, nil), nil
+}
+
+func shareLinkServer(u *url.URL) (string, uint16, error) {
+	port, err := strconv.ParseUint(u.Port(), 10, 16)
+	if err != nil || u.Hostname() == "" {
+		return "", 0, errors.New("invalid server address ", u.Host)
+	}
+	return u.Hostname(), uint16(port), nil
+}
+
+func shareLinkOutbound(protocol string, remark string, settings map[string]interface{}, stream map[string]interface{}) map[string]interface{} {
+	outbound := map[string]interface{}{
+		"protocol": protocol,
+		"settings": settings,
+	}
+	if remark != "" {
+		outbound["tag"] = remark
+	}
+	if stream != nil {
+		outbound["streamSettings"] = stream
+	}
+	return outbound
+}
+
+// shareLinkStreamSettings returns the stream settings of the query of share
+// links, with security.
+func shareLinkStreamSettings(q url.Values, security string) (map[string]interface{}, error) {
+	network := q.Get("type")
+	host, path := q.Get("host"), q.Get("path")
+	stream := make(map[string]interface{})
+	switch network {
+	case "", "tcp", "raw":
+		stream["network"] = "raw"
+		if q.Get("headerType") == "http" {
+			request := make(map[string]interface{})
+			if path != "" {
+				request["path"] = strings.Split(path, ",")
+			}
+			if host != "" {
+				request["headers"] = map[string]interface{}{"Host": strings.Split(host, ",")}
+			}
+			stream["rawSettings"] = map[string]interface{}{
+				"header": map[string]interface{}{"type": "http", "request": request},
+			}
+		}
+	case "ws", "websocket":
+		stream["network"] = "ws"
+		stream["wsSettings"] = nonEmptyValues(map[string]interface{}{"path": path, "host": host})
+	case "httpupgrade":
+		stream["network"] = "httpupgrade"
+		stream["httpupgradeSettings"] = nonEmptyValues(map[string]interface{}{"path": path, "host": host})
+	case "grpc":
+		stream["network"] = "grpc"
+		grpc := nonEmptyValues(map[string]interface{}{"serviceName": q.Get("serviceName"), "authority": q.Get("authority")})
+		if q.Get("mode") == "multi" {
+			grpc["multiMode"] = true
+		}
+		stream["grpcSettings"] = grpc
+	case "xhttp", "splithttp":
+		stream["network"] = "xhttp"
+		xhttp := nonEmptyValues(map[string]interface{}{"path": path, "host": host, "mode": q.Get("mode")})
+		if extra := q.Get("extra"); extra != "" {
+			if !json.Valid([]byte(extra)) {
+				return nil, errors.New("invalid xhttp extra: ", extra)
+			}
+			xhttp["extra"] = json.RawMessage(extra)
+		}
+		stream["xhttpSettings"] = xhttp
+	case "kcp", "mkcp":
+		stream["network"] = "kcp"
+		kcp := nonEmptyValues(map[string]interface{}{"seed": q.Get("seed")})
+		if headerType := q.Get("headerType"); headerType != "" && headerType != "none" {
+			kcp["header"] = map[string]interface{}{"type": headerType}
+		}
+		stream["kcpSettings"] = kcp
+	default:
+		return nil, errors.New("unsupported transport: ", network)
+	}
+
+	switch security {
+	case "", "none":
+	case "tls":
+		stream["security"] = "tls"
+		tls := nonEmptyValues(map[string]interface{}{"serverName": q.Get("sni"), "fingerprint": q.Get("fp"), "echConfigList": q.Get("ech")})
+		if alpn := q.Get("alpn"); alpn != "" {
+			tls["alpn"] = strings.Split(alpn, ",")
+		}
+		if insecure := q.Get("allowInsecure"); insecure == "1" || insecure == "true" {
+			tls["allowInsecure"] = true
+		}
+		stream["tlsSettings"] = tls
+	case "reality":
+		stream["security"] = "reality"
+		stream["realitySettings"] = nonEmptyValues(map[string]interface{}{
+			"serverName":    q.Get("sni"),
+			"fingerprint":   q.Get("fp"),
+			"password":      q.Get("pbk"),
+			"shortId":       q.Get("sid"),
+			"spiderX":       q.Get("spx"),
+			"mldsa65Verify": q.Get("pqv"),
+		})
+	default:
+		return nil, errors.New("unsupported security: ", security)
+	}
+	return stream, nil
+}
+
+// nonEmptyValues removes empty strings from m.
+func nonEmptyValues(m map[string]interface{}) map[string]interface{} {
+	for k, v := range m {
+		if v == "" {
+			delete(m, k)
+		}
+	}
+	return m
+}
+
+// decodeBase64 decodes s of any base64 encoding, padded or not.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "-_") {
+		return base64.RawURLEncoding.DecodeString(s)
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
+
+// ShareLinks returns the share links of clients to connect to the inbound at
+// address, one for each user. The fingerprint is set to TLS and REALITY links,
+// which is "chrome" for REALITY if empty.
+func (c *InboundDetourConfig) ShareLinks(address string, fingerprint string) ([]string, error) {
+	if address == "" && c.ListenOn != nil && c.ListenOn.Family().IsIP() && !c.ListenOn.IP().IsUnspecified() {
+		address = c.ListenOn.IP().String()
+	}
+	if address == "" {
+		return nil, errors.New("no address to connect to inbound ", c.Tag)
+	}
+	if c.PortList == nil || len(c.PortList.Range) == 0 {
+		return nil, errors.New("no port of inbound ", c.Tag)
+	}
+	host := net.TCPDestination(net.ParseAddress(address), net.Port(c.PortList.Range[0].From)).NetAddr()
+
+	stream := c.StreamSetting
+	if stream == nil {
+		stream = new(StreamConfig)
+	}
+	q, err := shareLinkQuery(stream, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	var settings []byte
+	if c.Settings != nil {
+		settings = *c.Settings
+	} else {
+		settings = []byte("{}")
+	}
+
+	remark := func(email string) string {
+		if email != "" {
+			return email
+		}
+		if c.Tag != "" {
+			return c.Tag
+		}
+		return c.Protocol
+	}
+	var links []string
+	switch strings.ToLower(c.Protocol) {
+	case "vless":
+		config := new(VLessInboundConfig)
+		if err := json.Unmarshal(settings, config); err != nil {
+			return nil, errors.New("invalid VLESS settings").Base(err)
+		}
+		for _, rawUser := range config.Clients {
+			var user struct {
+				ID    string `json:"id"`
+				Flow  string `json:"flow"`
+				Email string `json:"email"`
+			}
+			if err := json.Unmarshal(rawUser, &user); err != nil {
+				return nil, errors.New("invalid VLESS user").Base(err)
+			}
+			uq := cloneValues(q)
+			uq.Set("encryption", "none")
+			if flow := user.Flow; flow != "none" {
+				if flow == "" {
+					flow = config.Flow
+				}
+				if flow != "" {
+					uq.Set("flow", flow)
+				}
+			}
+			links = append(links, shareLinkURL("vless", url.User(user.ID), host, uq, remark(user.Email)))
+		}
+	case "trojan":
+		config := new(TrojanServerConfig)
+		if err := json.Unmarshal(settings, config); err != nil {
+			return nil, errors.New("invalid Trojan settings").Base(err)
+		}
+		uq := cloneValues(q)
+		if uq.Get("security") == "" {
+			uq.Set("security", "none")
+		}
+		for _, user := range config.Clients {
+			links = append(links, shareLinkURL("trojan", url.User(user.Password), host, uq, remark(user.Email)))
+		}
+	case "vmess":
+		config := new(VMessInboundConfig)
+		if err := json.Unmarshal(settings, config); err != nil {
+			return nil, errors.New("invalid VMess settings").Base(err)
+		}
+		if q.Get("security") == "reality" {
+			return nil, errors.New("REALITY is not supported by VMess share links")
+		}
+		for _, rawUser := range config.Users {
+			var user struct {
+				VMessAccount
+				Email string `json:"email"`
+			}
+			if err := json.Unmarshal(rawUser, &user); err != nil {
+				return nil, errors.New("invalid VMess user").Base(err)
+			}
+			link, err := vmessShareLink(user.ID, user.Security, address, c.PortList.Range[0].From, q, remark(user.Email))
+			if err != nil {
+				return nil, err
+			}
+			links = append(links, link)
+		}
+	case "shadowsocks":
+		config := new(ShadowsocksServerConfig)
+		if err := json.Unmarshal(settings, config); err != nil {
+			return nil, errors.New("invalid Shadowsocks settings").Base(err)
+		}
+		if q.Get("type") != "raw" || q.Get("security") != "" || q.Get("headerType") != "" {
+			return nil, errors.New("transports are not supported by Shadowsocks share links")
+		}
+		is2022 := strings.HasPrefix(config.Cipher, "2022-")
+		if len(config.Users) == 0 {
+			links = append(links, shadowsocksShareLink(config.Cipher, config.Password, host, remark(config.Email)))
+		}
+		for _, user := range config.Users {
+			method, password := user.Cipher, user.Password
+			if is2022 {
+				method, password = config.Cipher, config.Password+":"+user.Password
+			} else if method == "" {
+				method = config.Cipher
+			}
+			links = append(links, shadowsocksShareLink(method, password, host, remark(user.Email)))
+		}
+	default:
+		return nil, errors.New("unsupported protocol for share links: ", c.Protocol)
+	}
+	return links, nil
+}
+
+func shareLinkURL(scheme string, user *url.Userinfo, host string, q url.Values, remark string) string {
+	return (&url.URL{
+		Scheme:   scheme,
+		User:     user,
+		Host:     host,
+		RawQuery: q.Encode(),
+		Fragment: remark,
+	}).String()
+}
+
+func vmessShareLink(id string, security string, address string, port uint32, q url.Values, remark string) (string, error) {
+	if security == "" {
+		security = "auto"
+	}
+	v := vmessLink{
+		Remark:   remark,
+		Address:  address,
+		Port:     json.Number(strconv.Itoa(int(port))),
+		ID:       id,
+		Security: security,
+		Network:  q.Get("type"),
+		Type:     q.Get("headerType"),
+		Host:     q.Get("host"),
+		Path:     q.Get("path"),
+		TLS:      q.Get("security"),
+		SNI:      q.Get("sni"),
+		ALPN:     q.Get("alpn"),
+		FP:       q.Get("fp"),
+	}
+	switch v.Network {
+	case "grpc":
+		v.Path = q.Get("serviceName")
+		v.Type = q.Get("mode")
+	case "kcp":
+		v.Path = q.Get("seed")
+	}
+	content, err := json.Marshal(struct {
+		Version string `json:"v"`
+		vmessLink
+	}{"2", v})
+	if err != nil {
+		return "", err
+	}
+	return "vmess://" + base64.StdEncoding.EncodeToString(content), nil
+}
+
+func shadowsocksShareLink(method string, password string, host string, remark string) string {
+	var user *url.Userinfo
+	if strings.HasPrefix(method, "2022-") {
+		user = url.UserPassword(method, password)
+	} else {
+		user = url.User(base64.RawURLEncoding.EncodeToString([]byte(method + ":" + password)))
+	}
+	return shareLinkURL("ss", user, host, nil, remark)
+}
+
+// shareLinkQuery returns the query of share links for stream, the reverse of
+// shareLinkStreamSettings.
+func shareLinkQuery(stream *StreamConfig, fingerprint string) (url.Values, error) {
+	q := url.Values{}
+	network := "raw"
+	if stream.Network != nil {
+		network = strings.ToLower(string(*stream.Network))
+	}
+	switch network {
+	case "raw", "tcp":
+		q.Set("type", "raw")
+		settings := stream.RAWSettings
+		if settings == nil {
+			settings = stream.TCPSettings
+		}
+		if settings != nil && len(settings.HeaderConfig) > 0 {
+			var header struct {
+				Type    string `json:"type"`
+				Request struct {
+					Path    StringList             `json:"path"`
+					Headers map[string]*StringList `json:"headers"`
+				} `json:"request"`
+			}
+			if err := json.Unmarshal(settings.HeaderConfig, &header); err != nil {
+				return nil, errors.New("invalid raw header").Base(err)
+			}
+			if header.Type == "http" {
+				q.Set("headerType", "http")
+				setNonEmpty(q, "path", strings.Join(header.Request.Path, ","))
+				if host := header.Request.Headers["Host"]; host != nil {
+					setNonEmpty(q, "host", strings.Join(*host, ","))
+				}
+			}
+		}
+	case "ws", "websocket":
+		q.Set("type", "ws")
+		if s := stream.WSSettings; s != nil {
+			setNonEmpty(q, "path", s.Path)
+			setNonEmpty(q, "host", s.Host)
+		}
+	case "httpupgrade":
+		q.Set("type", "httpupgrade")
+		if s := stream.HTTPUPGRADESettings; s != nil {
+			setNonEmpty(q, "path", s.Path)
+			setNonEmpty(q, "host", s.Host)
+		}
+	case "grpc":
+		q.Set("type", "grpc")
+		if s := stream.GRPCSettings; s != nil {
+			setNonEmpty(q, "serviceName", s.ServiceName)
+			setNonEmpty(q, "authority", s.Authority)
+			if s.MultiMode {
+				q.Set("mode", "multi")
+			}
+		}
+	case "xhttp", "splithttp":
+		q.Set("type", "xhttp")
+		s := stream.XHTTPSettings
+		if s == nil {
+			s = stream.SplitHTTPSettings
+		}
+		if s != nil {
+			setNonEmpty(q, "path", s.Path)
+			setNonEmpty(q, "host", s.Host)
+			setNonEmpty(q, "mode", s.Mode)
+		}
+	case "kcp", "mkcp":
+		q.Set("type", "kcp")
+		if s := stream.KCPSettings; s != nil {
+			if s.Seed != nil {
+				setNonEmpty(q, "seed", *s.Seed)
+			}
+			if len(s.HeaderConfig) > 0 {
+				var header struct {
+					Type string `json:"type"`
+				}
+				if err := json.Unmarshal(s.HeaderConfig, &header); err != nil {
+					return nil, errors.New("invalid kcp header").Base(err)
+				}
+				if header.Type != "none" {
+					setNonEmpty(q, "headerType", header.Type)
+				}
+			}
+		}
+	default:
+		return nil, errors.New("unsupported transport for share links: ", network)
+	}
+
+	switch strings.ToLower(stream.Security) {
+	case "", "none":
+	case "tls":
+		q.Set("security", "tls")
+		if s := stream.TLSSettings; s != nil {
+			setNonEmpty(q, "sni", s.ServerName)
+			if s.ALPN != nil {
+				setNonEmpty(q, "alpn", strings.Join(*s.ALPN, ","))
+			}
+			if fingerprint == "" {
+				fingerprint = s.Fingerprint
+			}
+		}
+		setNonEmpty(q, "fp", fingerprint)
+	case "reality":
+		s := stream.REALITYSettings
+		if s == nil {
+			return nil, errors.New("no REALITY settings")
+		}
+		privateKey, err := base64.RawURLEncoding.DecodeString(s.PrivateKey)
+		if err != nil || len(privateKey) != curve25519.ScalarSize {
+			return nil, errors.New(`invalid "privateKey": `, s.PrivateKey)
+		}
+		publicKey, err := curve25519.X25519(privateKey, curve25519.Basepoint)
+		if err != nil {
+			return nil, err
+		}
+		if fingerprint == "" {
+			fingerprint = "chrome"
+		}
+		q.Set("security", "reality")
+		q.Set("pbk", base64.RawURLEncoding.EncodeToString(publicKey))
+		q.Set("fp", fingerprint)
+		if len(s.ServerNames) > 0 {
+			setNonEmpty(q, "sni", s.ServerNames[0])
+		}
+		if len(s.ShortIds) > 0 {
+			setNonEmpty(q, "sid", s.ShortIds[0])
+		}
+		if s.Mldsa65Seed != "" {
+			seed, err := base64.RawURLEncoding.DecodeString(s.Mldsa65Seed)
+			if err != nil || len(seed) != mldsa65.SeedSize {
+				return nil, errors.New(`invalid "mldsa65Seed": `, s.Mldsa65Seed)
+			}
+			verify, _ := mldsa65.NewKeyFromSeed((*[mldsa65.SeedSize]byte)(seed))
+			q.Set("pqv", base64.RawURLEncoding.EncodeToString(verify.Bytes()))
+		}
+	default:
+		return nil, errors.New("unsupported security for share links: ", stream.Security)
+	}
+	return q, nil
+}
+
+func setNonEmpty(q url.Values, key string, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
+
+func cloneValues(q url.Values) url.Values {
+	clone := make(url.Values, len(q))
+	for k, v := range q {
+		clone[k] = append([]string(nil), v...)
+	}
+	return clone
+}
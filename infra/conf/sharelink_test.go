@@ -0,0 +1,234 @@
+package conf_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	. "github.com/xtls/xray-core/infra/conf"
+)
+
+func TestParseShareLink(t *testing.T) {
+	vmess := base64.StdEncoding.EncodeToString([]byte(`{"v":"2","ps":"vmess","add":"example.com","port":"443","id":"27848739-7e62-4138-9fd3-098a63964b6b","net":"grpc","path":"service","type":"multi","tls":"tls","sni":"example.com"}`))
+	cases := []struct {
+		link   string
+		output string
+	}{
+		{
+			link: "vless://27848739-7e62-4138-9fd3-098a63964b6b@example.com:443?security=reality&sni=www.example.com&fp=chrome&pbk=key&sid=ab&type=xhttp&path=%2Fx&mode=auto&flow=xtls-rprx-vision#vless",
+			output: `{
+				"protocol": "vless",
+				"tag": "vless",
+				"settings": {"vnext": [{"address": "example.com", "port": 443, "users": [{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "encryption": "none", "flow": "xtls-rprx-vision"}]}]},
+				"streamSettings": {
+					"network": "xhttp",
+					"xhttpSettings": {"path": "/x", "mode": "auto"},
+					"security": "reality",
+					"realitySettings": {"serverName": "www.example.com", "fingerprint": "chrome", "password": "key", "shortId": "ab"}
+				}
+			}`,
+		},
+		{
+			link: "vmess://" + vmess,
+			output: `{
+				"protocol": "vmess",
+				"tag": "vmess",
+				"settings": {"vnext": [{"address": "example.com", "port": 443, "users": [{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "security": "auto"}]}]},
+				"streamSettings": {
+					"network": "grpc",
+					"grpcSettings": {"serviceName": "service", "multiMode": true},
+					"security": "tls",
+					"tlsSettings": {"serverName": "example.com"}
+				}
+			}`,
+		},
+		{
+			link: "trojan://password@example.com:443?type=ws&host=example.com&path=%2Fws&alpn=h2,http%2F1.1",
+			output: `{
+				"protocol": "trojan",
+				"settings": {"servers": [{"address": "example.com", "port": 443, "password": "password"}]},
+				"streamSettings": {
+					"network": "ws",
+					"wsSettings": {"path": "/ws", "host": "example.com"},
+					"security": "tls",
+					"tlsSettings": {"alpn": ["h2", "http/1.1"]}
+				}
+			}`,
+		},
+		{
+			link: "ss://" + base64.RawURLEncoding.EncodeToString([]byte("aes-256-gcm:password")) + "@example.com:8388#ss",
+			output: `{
+				"protocol": "shadowsocks",
+				"tag": "ss",
+				"settings": {"servers": [{"address": "example.com", "port": 8388, "method": "aes-256-gcm", "password": "password"}]}
+			}`,
+		},
+		{
+			link: "ss://2022-blake3-aes-128-gcm:cGFzc3dvcmQxMjM0NTY3OA%3D%3D@example.com:8388",
+			output: `{
+				"protocol": "shadowsocks",
+				"settings": {"servers": [{"address": "example.com", "port": 8388, "method": "2022-blake3-aes-128-gcm", "password": "cGFzc3dvcmQxMjM0NTY3OA=="}]}
+			}`,
+		},
+		{
+			link: "ss://" + base64.StdEncoding.EncodeToString([]byte("chacha20-ietf-poly1305:password@example.com:8388")) + "#legacy",
+			output: `{
+				"protocol": "shadowsocks",
+				"tag": "legacy",
+				"settings": {"servers": [{"address": "example.com", "port": 8388, "method": "chacha20-ietf-poly1305", "password": "password"}]}
+			}`,
+		},
+	}
+
+	for _, c := range cases {
+		actual, err := ParseShareLink(c.link)
+		if err != nil {
+			t.Error(c.link, ": ", err)
+			continue
+		}
+		var actualValue, expectedValue interface{}
+		common.Must(json.Unmarshal(actual, &actualValue))
+		common.Must(json.Unmarshal([]byte(c.output), &expectedValue))
+		if !reflect.DeepEqual(actualValue, expectedValue) {
+			t.Error(c.link, ": expected ", c.output, ", but got ", string(actual))
+		}
+	}
+
+	for _, link := range []string{
+		"example.com:443",
+		"hysteria2://password@example.com:443",
+		"vless://27848739-7e62-4138-9fd3-098a63964b6b@example.com",
+		"trojan://password@example.com:443?type=quic",
+	} {
+		if _, err := ParseShareLink(link); err == nil {
+			t.Error("expected error for ", link)
+		}
+	}
+}
+
+func TestParseSubscription(t *testing.T) {
+	links := strings.Join([]string{
+		"trojan://password@example.com:443#first",
+		"hysteria2://password@example.com:443#skipped",
+		"vless://27848739-7e62-4138-9fd3-098a63964b6b@example.com:443?security=tls#second",
+	}, "\r\n")
+	for _, content := range []string{
+		links,
+		base64.StdEncoding.EncodeToString([]byte(links)),
+		`[{"protocol": "trojan", "tag": "first"}, {"protocol": "vless", "tag": "second"}]`,
+		`{"outbounds": [{"protocol": "trojan", "tag": "first"}, {"protocol": "vless", "tag": "second"}]}`,
+	} {
+		outbounds, err := ParseSubscription([]byte(content))
+		common.Must(err)
+		if len(outbounds) != 2 || outbounds[0].Tag != "first" || outbounds[1].Tag != "second" {
+			t.Error("unexpected outbounds of ", content)
+		}
+	}
+
+	if _, err := ParseSubscription([]byte("not a subscription")); err == nil {
+		t.Error("expected error for invalid subscription")
+	}
+
+	// subscriptions provide proxy servers only
+	for _, content := range []string{
+		`[{"protocol": "trojan", "tag": "first"}, {"protocol": "freedom", "tag": "direct", "settings": {"redirect": "127.0.0.1:22"}}]`,
+		`{"outbounds": [{"protocol": "loopback", "settings": {"inboundTag": "api"}}]}`,
+		`[{"protocol": "dns"}]`,
+		`[{"protocol": "blackhole"}]`,
+		`[{"protocol": "vless", "sendThrough": "127.0.0.1"}]`,
+		`[{"protocol": "vless", "proxySettings": {"tag": "direct"}}]`,
+		`[{"protocol": "vless", "streamSettings": {"sockopt": {"dialerProxy": "direct"}}}]`,
+		`[{"protocol": "vless", "streamSettings": {"security": "tls", "tlsSettings": {"echSockopt": {"mark": 255}}}}]`,
+		`[null]`,
+	} {
+		if _, err := ParseSubscription([]byte(content)); err == nil {
+			t.Error("expected error for ", content)
+		}
+	}
+}
+
+func TestSubscriptionTags(t *testing.T) {
+	build := func(config string) error {
+		c := new(Config)
+		common.Must(json.Unmarshal([]byte(config), c))
+		_, err := c.Build()
+		return err
+	}
+	if err := build(`{
+		"outbounds": [{"protocol": "freedom", "tag": "sub"}, {"protocol": "freedom", "tag": "subway"}],
+		"subscriptions": [{"tag": "sub", "url": "https://example.com/a"}, {"tag": "other", "url": "https://example.com/b"}]
+	}`); err != nil {
+		t.Error(err)
+	}
+	for _, config := range []string{
+		`{
+			"outbounds": [{"protocol": "freedom", "tag": "sub-direct"}],
+			"subscriptions": [{"tag": "sub", "url": "https://example.com/a"}]
+		}`,
+		`{
+			"subscriptions": [{"tag": "sub", "url": "https://example.com/a"}, {"tag": "sub-a", "url": "https://example.com/b"}]
+		}`,
+	} {
+		if err := build(config); err == nil {
+			t.Error("built subscriptions of colliding tags: ", config)
+		}
+	}
+}
+
+func TestInboundShareLinks(t *testing.T) {
+	config := `{
+		"inbounds": [{
+			"tag": "reality",
+			"protocol": "vless",
+			"port": 443,
+			"settings": {"decryption": "none", "clients": [{"id": "27848739-7e62-4138-9fd3-098a63964b6b", "flow": "xtls-rprx-vision", "email": "love@example.com"}]},
+			"streamSettings": {
+				"network": "raw",
+				"security": "reality",
+				"realitySettings": {"target": "example.com:443", "serverNames": ["example.com"], "privateKey": "aGSYystUbf59_9_6LKRxD27rmSW_-2_nyd9YG_Gwbks", "shortIds": ["ab"]}
+			}
+		}, {
+			"tag": "trojan",
+			"protocol": "trojan",
+			"listen": "127.0.0.1",
+			"port": 8443,
+			"settings": {"clients": [{"password": "password"}]},
+			"streamSettings": {"network": "ws", "wsSettings": {"path": "/ws"}, "security": "tls", "tlsSettings": {"serverName": "example.com"}}
+		}, {
+			"tag": "ss",
+			"protocol": "shadowsocks",
+			"port": 8388,
+			"settings": {"method": "2022-blake3-aes-128-gcm", "password": "cGFzc3dvcmQxMjM0NTY3OA==", "clients": [{"password": "dXNlcnBhc3N3b3JkMTIzNA=="}]}
+		}]
+	}`
+	c := new(Config)
+	common.Must(json.Unmarshal([]byte(config), c))
+
+	expected := []string{
+		"vless://27848739-7e62-4138-9fd3-098a63964b6b@example.org:443?encryption=none&flow=xtls-rprx-vision&fp=chrome&pbk=E59WjnvZcQMu7tR7_BgyhycuEdBS-CtKxfImRCdAvFM&security=reality&sid=ab&sni=example.com&type=raw#love@example.com",
+		"trojan://password@127.0.0.1:8443?path=%2Fws&security=tls&sni=example.com&type=ws#trojan",
+		"ss://2022-blake3-aes-128-gcm:cGFzc3dvcmQxMjM0NTY3OA==%3AdXNlcnBhc3N3b3JkMTIzNA==@example.org:8388#ss",
+	}
+	for i, inbound := range c.InboundConfigs {
+		address := "example.org"
+		if inbound.ListenOn != nil {
+			address = ""
+		}
+		links, err := inbound.ShareLinks(address, "")
+		common.Must(err)
+		if len(links) != 1 || links[0] != expected[i] {
+			t.Error("expected ", expected[i], ", but got ", links)
+			continue
+		}
+		if _, err := ParseShareLink(links[0]); err != nil {
+			t.Error("failed to parse ", links[0], ": ", err)
+		}
+	}
+
+	if _, err := c.InboundConfigs[0].ShareLinks("", ""); err == nil {
+		t.Error("expected error for inbound without address")
+	}
+}
@@ -13,6 +13,26 @@ import (
 type SocksAccount struct {
 	Username string `json:"user"`
 	Password string `json:"pass"`
+	Email    string `json:"email"`
+	Level    uint32 `json:"level"`
+}
+
+// BuildUser builds the account as a server side user. Email defaults to the
+// username so that routing and stats keep matching it, and level defaults to userLevel.
+func (v *SocksAccount) BuildUser(userLevel uint32) *protocol.User {
+	email := v.Email
+	if email == "" {
+		email = v.Username
+	}
+	level := v.Level
+	if level == 0 {
+		level = userLevel
+	}
+	return &protocol.User{
+		Email:   email,
+		Level:   level,
+		Account: serial.ToTypedMessage(v.Build()),
+	}
 }
 
 func (v *SocksAccount) Build() *socks.Account {
@@ -47,11 +67,8 @@ func (v *SocksServerConfig) Build() (proto.Message, error) {
 		config.AuthType = socks.AuthType_NO_AUTH
 	}
 
-	if len(v.Accounts) > 0 {
-		config.Accounts = make(map[string]string, len(v.Accounts))
-		for _, account := range v.Accounts {
-			config.Accounts[account.Username] = account.Password
-		}
+	for _, account := range v.Accounts {
+		config.Users = append(config.Users, account.BuildUser(v.UserLevel))
 	}
 
 	config.UdpEnabled = v.UDP
@@ -32,8 +32,15 @@ func TestSocksInboundConfig(t *testing.T) {
 			Parser: loadJSON(creator),
 			Output: &socks.ServerConfig{
 				AuthType: socks.AuthType_PASSWORD,
-				Accounts: map[string]string{
-					"my-username": "my-password",
+				Users: []*protocol.User{
+					{
+						Email: "my-username",
+						Level: 1,
+						Account: serial.ToTypedMessage(&socks.Account{
+							Username: "my-username",
+							Password: "my-password",
+						}),
+					},
 				},
 				UdpEnabled: false,
 				Address: &net.IPOrDomain{
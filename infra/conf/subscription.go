@@ -0,0 +1,175 @@
+package conf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/xtls/xray-core/app/subscription"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/infra/conf/cfgcommon/duration"
+)
+
+type SubscriptionConfig struct {
+	Tag          string            `json:"tag"`
+	URL          string            `json:"url"`
+	OutboundTag  string            `json:"outboundTag"`
+	Interval     duration.Duration `json:"interval"`
+	BalancerTags []string          `json:"balancerTags"`
+}
+
+func (c *SubscriptionConfig) Build() (*subscription.Subscription, error) {
+	if c.Tag == "" {
+		return nil, errors.New("subscription tag is not set")
+	}
+	if c.URL == "" {
+		return nil, errors.New("subscription ", c.Tag, " has no url")
+	}
+	return &subscription.Subscription{
+		Tag:          c.Tag,
+		Url:          c.URL,
+		OutboundTag:  c.OutboundTag,
+		Interval:     int64(c.Interval),
+		BalancerTags: c.BalancerTags,
+	}, nil
+}
+
+// checkSubscriptionTags checks that the outbounds of subscriptions, of which
+// the tags start with the tag of the subscription and "-", cannot be mistaken
+// for other outbounds, as balancers select them by the prefix.
+func checkSubscriptionTags(subscriptions []*SubscriptionConfig, outbounds []OutboundDetourConfig) error {
+	for i, s := range subscriptions {
+		prefix := s.Tag + "-"
+		for _, outbound := range outbounds {
+			if strings.HasPrefix(outbound.Tag, prefix) {
+				return errors.New("outbound ", outbound.Tag, " has the prefix of the outbounds of subscription ", s.Tag)
+			}
+		}
+		for _, other := range subscriptions[:i] {
+			if strings.HasPrefix(other.Tag+"-", prefix) || strings.HasPrefix(prefix, other.Tag+"-") {
+				return errors.New("the outbounds of subscriptions ", other.Tag, " and ", s.Tag, " share a prefix")
+			}
+		}
+	}
+	return nil
+}
+
+// subscriptionProtocols are the protocols of outbounds which subscriptions
+// can provide, the protocols of proxy servers which share links are of.
+var subscriptionProtocols = map[string]bool{
+	"vless":       true,
+	"vmess":       true,
+	"trojan":      true,
+	"shadowsocks": true,
+}
+
+// checkSubscriptionOutbound checks that an outbound of a subscription is a
+// proxy server, which reaches no local address or other outbound.
+func checkSubscriptionOutbound(outbound *OutboundDetourConfig) error {
+	if !subscriptionProtocols[strings.ToLower(outbound.Protocol)] {
+		return errors.New("outbound ", outbound.Tag, " of protocol ", outbound.Protocol, " is not allowed in subscriptions")
+	}
+	if outbound.SendThrough != nil {
+		return errors.New("outbound ", outbound.Tag, " has sendThrough, which is not allowed in subscriptions")
+	}
+	if outbound.ProxySettings != nil {
+		return errors.New("outbound ", outbound.Tag, " has proxySettings, which is not allowed in subscriptions")
+	}
+	if stream := outbound.StreamSetting; stream != nil {
+		if stream.SocketSettings != nil || (stream.TLSSettings != nil && stream.TLSSettings.ECHSocketSettings != nil) {
+			return errors.New("outbound ", outbound.Tag, " has sockopt, which is not allowed in subscriptions")
+		}
+	}
+	return nil
+}
+
+// ParseSubscription parses the content of a subscription to outbounds. It is
+// a list of share links, base64 encoded or not, an array of outbounds, or an
+// Xray config with outbounds. Share links which cannot be parsed are skipped.
+// Outbounds in JSON can only be proxy servers of the protocols of share
+// links, without sendThrough, proxySettings or sockopt.
+func ParseSubscription(content []byte) ([]*OutboundDetourConfig, error) {
+	content = bytes.TrimSpace(content)
+	if len(content) == 0 {
+		return nil, errors.New("empty subscription")
+	}
+	var outbounds []*OutboundDetourConfig
+	switch content[0] {
+	case '[':
+		if err := json.Unmarshal(content, &outbounds); err != nil {
+			return nil, errors.New("invalid outbounds").Base(err)
+		}
+	case '{':
+		var config struct {
+			OutboundConfigs []*OutboundDetourConfig `json:"outbounds"`
+		}
+		if err := json.Unmarshal(content, &config); err != nil {
+			return nil, errors.New("invalid config").Base(err)
+		}
+		outbounds = config.OutboundConfigs
+	default:
+		return parseShareLinks(content)
+	}
+	for _, outbound := range outbounds {
+		if outbound == nil {
+			return nil, errors.New("invalid outbounds")
+		}
+		if err := checkSubscriptionOutbound(outbound); err != nil {
+			return nil, err
+		}
+	}
+	return outbounds, nil
+}
+
+// parseShareLinks parses share links, base64 encoded or not, to outbounds.
+func parseShareLinks(content []byte) ([]*OutboundDetourConfig, error) {
+
+	if !bytes.Contains(content, []byte("://")) {
+		decoded, err := decodeBase64(string(content))
+		if err != nil {
+			return nil, errors.New("unknown subscription format").Base(err)
+		}
+		content = decoded
+	}
+	var outbounds []*OutboundDetourConfig
+	for _, link := range strings.Split(string(content), "\n") {
+		link = strings.TrimSpace(link)
+		if link == "" {
+			continue
+		}
+		raw, err := ParseShareLink(link)
+		if err != nil {
+			errors.LogWarningInner(context.Background(), err, "skipped share link in subscription")
+			continue
+		}
+		outbound := new(OutboundDetourConfig)
+		if err := json.Unmarshal(raw, outbound); err != nil {
+			return nil, err
+		}
+		outbounds = append(outbounds, outbound)
+	}
+	if len(outbounds) == 0 {
+		return nil, errors.New("no valid share link in subscription")
+	}
+	return outbounds, nil
+}
+
+func init() {
+	subscription.ParseOutbounds = func(content []byte) ([]*core.OutboundHandlerConfig, error) {
+		outbounds, err := ParseSubscription(content)
+		if err != nil {
+			return nil, err
+		}
+		configs := make([]*core.OutboundHandlerConfig, 0, len(outbounds))
+		for _, outbound := range outbounds {
+			config, err := outbound.Build()
+			if err != nil {
+				return nil, errors.New("failed to build outbound ", outbound.Tag).Base(err)
+			}
+			configs = append(configs, config)
+		}
+		return configs, nil
+	}
+}
@@ -53,6 +53,20 @@ type KCPConfig struct {
 	WriteBufferSize *uint32         `json:"writeBufferSize"`
 	HeaderConfig    json.RawMessage `json:"header"`
 	Seed            *string         `json:"seed"`
+	// CongestionControl is "loss" by default, or "bbr".
+	CongestionControl string                `json:"congestionControl"`
+	FEC               *KCPFECConfig         `json:"fec"`
+	PortHopping       *KCPPortHoppingConfig `json:"portHopping"`
+}
+
+type KCPFECConfig struct {
+	DataShards   uint32 `json:"dataShards"`
+	ParityShards uint32 `json:"parityShards"`
+}
+
+type KCPPortHoppingConfig struct {
+	Ports    *PortList `json:"ports"`
+	Interval uint32    `json:"interval"`
 }
 
 // Build implements Buildable.
@@ -114,6 +128,35 @@ func (c *KCPConfig) Build() (proto.Message, error) {
 		config.Seed = &kcp.EncryptionSeed{Seed: *c.Seed}
 	}
 
+	switch strings.ToLower(c.CongestionControl) {
+	case "", "loss":
+		config.CongestionControl = kcp.CongestionControl_LOSS_BASED
+	case "bbr":
+		config.CongestionControl = kcp.CongestionControl_BBR
+	default:
+		return nil, errors.New("unknown mKCP congestion control: ", c.CongestionControl).AtError()
+	}
+
+	if c.FEC != nil {
+		if c.FEC.DataShards < 1 || c.FEC.DataShards > 32 || c.FEC.ParityShards < 1 || c.FEC.ParityShards > 32 {
+			return nil, errors.New("invalid mKCP FEC shards: ", c.FEC.DataShards, "+", c.FEC.ParityShards, ", 1 to 32 each").AtError()
+		}
+		config.Fec = &kcp.FEC{
+			DataShards:   c.FEC.DataShards,
+			ParityShards: c.FEC.ParityShards,
+		}
+	}
+
+	if c.PortHopping != nil {
+		if c.PortHopping.Ports == nil || len(c.PortHopping.Ports.Range) == 0 {
+			return nil, errors.New("mKCP port hopping needs ports").AtError()
+		}
+		config.PortHopping = &kcp.PortHopping{
+			Ports:    c.PortHopping.Ports.Build(),
+			Interval: c.PortHopping.Interval,
+		}
+	}
+
 	return config, nil
 }
 
@@ -148,6 +191,8 @@ type WebSocketConfig struct {
 	Headers             map[string]string `json:"headers"`
 	AcceptProxyProtocol bool              `json:"acceptProxyProtocol"`
 	HeartbeatPeriod     uint32            `json:"heartbeatPeriod"`
+	Compression         bool              `json:"compression"`
+	H2                  bool              `json:"h2"`
 }
 
 // Build implements Buildable.
@@ -180,6 +225,8 @@ func (c *WebSocketConfig) Build() (proto.Message, error) {
 		AcceptProxyProtocol: c.AcceptProxyProtocol,
 		Ed:                  ed,
 		HeartbeatPeriod:     c.HeartbeatPeriod,
+		Compression:         c.Compression,
+		H2:                  c.H2,
 	}
 	return config, nil
 }
@@ -416,6 +463,9 @@ type TLSConfig struct {
 	ECHConfigList                        string           `json:"echConfigList"`
 	ECHForceQuery                        string           `json:"echForceQuery"`
 	ECHSocketSettings                    *SocketConfig    `json:"echSockopt"`
+	ACME                                 *ACMEConfig      `json:"acme"`
+	ClientAuth                           *TLSClientAuth   `json:"clientAuth"`
+	SessionTicketKeys                    *TLSTicketKeys   `json:"sessionTicketKeys"`
 }
 
 // Build implements Buildable.
@@ -509,10 +559,110 @@ func (c *TLSConfig) Build() (proto.Message, error) {
 		}
 		config.EchSocketSettings = ss
 	}
+	if c.ACME != nil {
+		acme, err := c.ACME.Build()
+		if err != nil {
+			return nil, errors.New("Failed to build acme.").Base(err)
+		}
+		config.Acme = acme
+	}
+	if c.ClientAuth != nil {
+		clientAuth, err := c.ClientAuth.Build()
+		if err != nil {
+			return nil, errors.New("Failed to build clientAuth.").Base(err)
+		}
+		config.ClientAuth = clientAuth
+	}
+	if c.SessionTicketKeys != nil {
+		ticketKeys, err := c.SessionTicketKeys.Build()
+		if err != nil {
+			return nil, errors.New("Failed to build sessionTicketKeys.").Base(err)
+		}
+		config.SessionTicketKeys = ticketKeys
+	}
 
 	return config, nil
 }
 
+type TLSClientAuth struct {
+	Mode    string      `json:"mode"`
+	CAFiles *StringList `json:"caFiles"`
+	CA      []string    `json:"ca"`
+	CRLFile string      `json:"crlFile"`
+}
+
+// Build implements Buildable.
+func (c *TLSClientAuth) Build() (*tls.ClientAuth, error) {
+	config := new(tls.ClientAuth)
+	switch strings.ToLower(c.Mode) {
+	case "", "require":
+		config.Mode = tls.ClientAuth_REQUIRE
+	case "verifyifgiven":
+		config.Mode = tls.ClientAuth_VERIFY_IF_GIVEN
+	default:
+		return nil, errors.New(`unknown "mode": `, c.Mode)
+	}
+	if c.CAFiles != nil {
+		for _, f := range *c.CAFiles {
+			ca, err := filesystem.ReadCert(f)
+			if err != nil {
+				return nil, errors.New("failed to read client CA file ", f).Base(err)
+			}
+			config.CertificateAuthority = append(config.CertificateAuthority, ca)
+		}
+	}
+	if len(c.CA) > 0 {
+		config.CertificateAuthority = append(config.CertificateAuthority, []byte(strings.Join(c.CA, "\n")))
+	}
+	if len(config.CertificateAuthority) == 0 {
+		return nil, errors.New(`"caFiles" or "ca" is required`)
+	}
+	config.CrlPath = c.CRLFile
+	return config, nil
+}
+
+type TLSTicketKeys struct {
+	KeyFiles         *StringList `json:"keyFiles"`
+	RotationInterval uint64      `json:"rotationInterval"`
+}
+
+// Build implements Buildable.
+func (c *TLSTicketKeys) Build() (*tls.SessionTicketKeys, error) {
+	if c.KeyFiles == nil || len(*c.KeyFiles) == 0 {
+		return nil, errors.New(`"keyFiles" is required`)
+	}
+	return &tls.SessionTicketKeys{
+		KeyFiles:         []string(*c.KeyFiles),
+		RotationInterval: c.RotationInterval,
+	}, nil
+}
+
+type ACMEConfig struct {
+	DirectoryURL         string   `json:"directoryUrl"`
+	Email                string   `json:"email"`
+	Domains              []string `json:"domains"`
+	StoragePath          string   `json:"storagePath"`
+	HTTPChallengeInbound string   `json:"httpChallengeInbound"`
+	HTTPChallengeListen  string   `json:"httpChallengeListen"`
+	RenewBeforeDays      uint32   `json:"renewBeforeDays"`
+}
+
+// Build implements Buildable.
+func (c *ACMEConfig) Build() (*tls.AcmeConfig, error) {
+	if c.StoragePath == "" {
+		return nil, errors.New(`"storagePath" is required`)
+	}
+	return &tls.AcmeConfig{
+		DirectoryUrl:         c.DirectoryURL,
+		Email:                c.Email,
+		Domains:              c.Domains,
+		StoragePath:          c.StoragePath,
+		HttpChallengeInbound: c.HTTPChallengeInbound,
+		HttpChallengeListen:  c.HTTPChallengeListen,
+		RenewBeforeDays:      c.RenewBeforeDays,
+	}, nil
+}
+
 type LimitFallback struct {
 	AfterBytes       uint64
 	BytesPerSec      uint64
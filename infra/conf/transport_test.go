@@ -4,8 +4,14 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/xtls/xray-core/common/net"
 	. "github.com/xtls/xray-core/infra/conf"
 	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/grpc"
+	"github.com/xtls/xray-core/transport/internet/kcp"
+	"github.com/xtls/xray-core/transport/internet/tls"
+	"github.com/xtls/xray-core/transport/internet/websocket"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -156,3 +162,242 @@ func TestSocketConfig(t *testing.T) {
 		t.Fatalf("unexpected parsed TFO value, which should be -1")
 	}
 }
+
+func TestTLSConfigACME(t *testing.T) {
+	createParser := func() func(string) (proto.Message, error) {
+		return func(s string) (proto.Message, error) {
+			config := new(TLSConfig)
+			if err := json.Unmarshal([]byte(s), config); err != nil {
+				return nil, err
+			}
+			return config.Build()
+		}
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"serverName": "example.com",
+				"acme": {
+					"email": "admin@example.com",
+					"domains": ["example.com", "www.example.com"],
+					"storagePath": "/var/lib/xray/acme",
+					"httpChallengeInbound": "http",
+					"httpChallengeListen": ":80",
+					"renewBeforeDays": 20
+				}
+			}`,
+			Parser: createParser(),
+			Output: &tls.Config{
+				ServerName:  "example.com",
+				Certificate: []*tls.Certificate{},
+				Acme: &tls.AcmeConfig{
+					Email:                "admin@example.com",
+					Domains:              []string{"example.com", "www.example.com"},
+					StoragePath:          "/var/lib/xray/acme",
+					HttpChallengeInbound: "http",
+					HttpChallengeListen:  ":80",
+					RenewBeforeDays:      20,
+				},
+			},
+		},
+	})
+}
+
+func TestTLSConfigClientAuth(t *testing.T) {
+	createParser := func() func(string) (proto.Message, error) {
+		return func(s string) (proto.Message, error) {
+			config := new(TLSConfig)
+			if err := json.Unmarshal([]byte(s), config); err != nil {
+				return nil, err
+			}
+			return config.Build()
+		}
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"clientAuth": {
+					"mode": "verifyIfGiven",
+					"ca": ["-----BEGIN CERTIFICATE-----", "-----END CERTIFICATE-----"],
+					"crlFile": "/etc/xray/client.crl"
+				}
+			}`,
+			Parser: createParser(),
+			Output: &tls.Config{
+				Certificate: []*tls.Certificate{},
+				ClientAuth: &tls.ClientAuth{
+					Mode:                 tls.ClientAuth_VERIFY_IF_GIVEN,
+					CertificateAuthority: [][]byte{[]byte("-----BEGIN CERTIFICATE-----\n-----END CERTIFICATE-----")},
+					CrlPath:              "/etc/xray/client.crl",
+				},
+			},
+		},
+	})
+}
+
+func TestTLSConfigSessionTicketKeys(t *testing.T) {
+	createParser := func() func(string) (proto.Message, error) {
+		return func(s string) (proto.Message, error) {
+			config := new(TLSConfig)
+			if err := json.Unmarshal([]byte(s), config); err != nil {
+				return nil, err
+			}
+			return config.Build()
+		}
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"sessionTicketKeys": {
+					"keyFiles": ["/etc/xray/ticket.keys"],
+					"rotationInterval": 86400
+				}
+			}`,
+			Parser: createParser(),
+			Output: &tls.Config{
+				Certificate: []*tls.Certificate{},
+				SessionTicketKeys: &tls.SessionTicketKeys{
+					KeyFiles:         []string{"/etc/xray/ticket.keys"},
+					RotationInterval: 86400,
+				},
+			},
+		},
+	})
+}
+
+func TestKCPConfig(t *testing.T) {
+	createParser := func() func(string) (proto.Message, error) {
+		return func(s string) (proto.Message, error) {
+			config := new(KCPConfig)
+			if err := json.Unmarshal([]byte(s), config); err != nil {
+				return nil, err
+			}
+			return config.Build()
+		}
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"congestionControl": "bbr",
+				"fec": {
+					"dataShards": 10,
+					"parityShards": 3
+				}
+			}`,
+			Parser: createParser(),
+			Output: &kcp.Config{
+				CongestionControl: kcp.CongestionControl_BBR,
+				Fec: &kcp.FEC{
+					DataShards:   10,
+					ParityShards: 3,
+				},
+			},
+		},
+		{
+			Input: `{
+				"portHopping": {
+					"ports": "20000-20100,30000",
+					"interval": 60
+				}
+			}`,
+			Parser: createParser(),
+			Output: &kcp.Config{
+				PortHopping: &kcp.PortHopping{
+					Ports: &net.PortList{
+						Range: []*net.PortRange{
+							{From: 20000, To: 20100},
+							{From: 30000, To: 30000},
+						},
+					},
+					Interval: 60,
+				},
+			},
+		},
+	})
+}
+
+func TestWebSocketConfig(t *testing.T) {
+	createParser := func() func(string) (proto.Message, error) {
+		return func(s string) (proto.Message, error) {
+			config := new(WebSocketConfig)
+			if err := json.Unmarshal([]byte(s), config); err != nil {
+				return nil, err
+			}
+			return config.Build()
+		}
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"path": "/ws",
+				"compression": true,
+				"h2": true
+			}`,
+			Parser: createParser(),
+			Output: &websocket.Config{
+				Path:        "/ws",
+				Compression: true,
+				H2:          true,
+			},
+		},
+	})
+}
+
+func TestGRPCConfig(t *testing.T) {
+	createParser := func() func(string) (proto.Message, error) {
+		return func(s string) (proto.Message, error) {
+			config := new(GRPCConfig)
+			if err := json.Unmarshal([]byte(s), config); err != nil {
+				return nil, err
+			}
+			return config.Build()
+		}
+	}
+
+	runMultiTestCase(t, []TestCase{
+		{
+			Input: `{
+				"serviceName": "tunnel",
+				"health_service": true,
+				"unknown_method_code": "NOT_FOUND",
+				"unknown_method_message": "not found"
+			}`,
+			Parser: createParser(),
+			Output: &grpc.Config{
+				ServiceName:          "tunnel",
+				HealthService:        true,
+				UnknownMethodCode:    5,
+				UnknownMethodMessage: "not found",
+			},
+		},
+		{
+			Input: `{
+				"unknown_method_code": 12
+			}`,
+			Parser: createParser(),
+			Output: &grpc.Config{
+				UnknownMethodCode: 12,
+			},
+		},
+	})
+}
+
+func TestGRPCConfigInvalidUnknownMethodCode(t *testing.T) {
+	for _, code := range []codes.Code{codes.Unauthenticated + 1, 1 << 31} {
+		config := &GRPCConfig{UnknownMethodCode: &code}
+		if _, err := config.Build(); err == nil {
+			t.Error("built gRPC config of unknown method code ", uint32(code))
+		}
+	}
+	for _, s := range []string{`{"unknown_method_code": 17}`, `{"unknown_method_code": -1}`} {
+		config := new(GRPCConfig)
+		if err := json.Unmarshal([]byte(s), config); err == nil {
+			t.Error("parsed ", s)
+		}
+	}
+}
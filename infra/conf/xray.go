@@ -12,6 +12,7 @@ import (
 	"github.com/xtls/xray-core/app/dispatcher"
 	"github.com/xtls/xray-core/app/proxyman"
 	"github.com/xtls/xray-core/app/stats"
+	"github.com/xtls/xray-core/app/subscription"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/serial"
@@ -97,10 +98,14 @@ func (c *SniffingConfig) Build() (*proxyman.SniffingConfig, error) {
 }
 
 type MuxConfig struct {
-	Enabled         bool   `json:"enabled"`
-	Concurrency     int16  `json:"concurrency"`
-	XudpConcurrency int16  `json:"xudpConcurrency"`
-	XudpProxyUDP443 string `json:"xudpProxyUDP443"`
+	Enabled           bool   `json:"enabled"`
+	Concurrency       int16  `json:"concurrency"`
+	XudpConcurrency   int16  `json:"xudpConcurrency"`
+	XudpProxyUDP443   string `json:"xudpProxyUDP443"`
+	Adaptive          bool   `json:"adaptive"`
+	MaxLifetime       uint32 `json:"maxLifetime"`
+	MaxBytes          uint64 `json:"maxBytes"`
+	FlowControlWindow uint32 `json:"flowControlWindow"`
 }
 
 // Build creates MultiplexingConfig, Concurrency < 0 completely disables mux.
@@ -113,10 +118,14 @@ func (m *MuxConfig) Build() (*proxyman.MultiplexingConfig, error) {
 		return nil, errors.New(`unknown "xudpProxyUDP443": `, m.XudpProxyUDP443)
 	}
 	return &proxyman.MultiplexingConfig{
-		Enabled:         m.Enabled,
-		Concurrency:     int32(m.Concurrency),
-		XudpConcurrency: int32(m.XudpConcurrency),
-		XudpProxyUDP443: m.XudpProxyUDP443,
+		Enabled:           m.Enabled,
+		Concurrency:       int32(m.Concurrency),
+		XudpConcurrency:   int32(m.XudpConcurrency),
+		XudpProxyUDP443:   m.XudpProxyUDP443,
+		Adaptive:          m.Adaptive,
+		MaxLifetime:       m.MaxLifetime,
+		MaxBytes:          m.MaxBytes,
+		FlowControlWindow: m.FlowControlWindow,
 	}, nil
 }
 
@@ -411,6 +420,7 @@ type Config struct {
 	Observatory      *ObservatoryConfig      `json:"observatory"`
 	BurstObservatory *BurstObservatoryConfig `json:"burstObservatory"`
 	Version          *VersionConfig          `json:"version"`
+	Subscriptions    []*SubscriptionConfig   `json:"subscriptions"`
 }
 
 func (c *Config) findInboundTag(tag string) int {
@@ -483,6 +493,10 @@ func (c *Config) Override(o *Config, fn string) {
 		c.Version = o.Version
 	}
 
+	if len(o.Subscriptions) > 0 {
+		c.Subscriptions = o.Subscriptions
+	}
+
 	// update the Inbound in slice if the only one in override config has same tag
 	if len(o.InboundConfigs) > 0 {
 		for i := range o.InboundConfigs {
@@ -631,6 +645,21 @@ func (c *Config) Build() (*core.Config, error) {
 		config.App = append(config.App, serial.ToTypedMessage(r))
 	}
 
+	if len(c.Subscriptions) > 0 {
+		r := &subscription.Config{}
+		for _, s := range c.Subscriptions {
+			sc, err := s.Build()
+			if err != nil {
+				return nil, errors.New("failed to build subscription configuration").Base(err)
+			}
+			r.Subscription = append(r.Subscription, sc)
+		}
+		if err := checkSubscriptionTags(c.Subscriptions, c.OutboundConfigs); err != nil {
+			return nil, errors.New("failed to build subscription configuration").Base(err)
+		}
+		config.App = append(config.App, serial.ToTypedMessage(r))
+	}
+
 	var inbounds []InboundDetourConfig
 
 	if len(c.InboundConfigs) > 0 {
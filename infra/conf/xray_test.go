@@ -198,6 +198,22 @@ func TestMuxConfig_Build(t *testing.T) {
 			XudpConcurrency: 0,
 			XudpProxyUDP443: "reject",
 		}},
+		{"adaptive", `{"enabled": true, "concurrency": 8, "adaptive": true, "maxLifetime": 600, "maxBytes": 1073741824}`, &proxyman.MultiplexingConfig{
+			Enabled:         true,
+			Concurrency:     8,
+			XudpConcurrency: 0,
+			XudpProxyUDP443: "reject",
+			Adaptive:        true,
+			MaxLifetime:     600,
+			MaxBytes:        1073741824,
+		}},
+		{"flow control", `{"enabled": true, "concurrency": 8, "flowControlWindow": 131072}`, &proxyman.MultiplexingConfig{
+			Enabled:           true,
+			Concurrency:       8,
+			XudpConcurrency:   0,
+			XudpProxyUDP443:   "reject",
+			FlowControlWindow: 131072,
+		}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -17,6 +17,7 @@ var CmdAPI = &base.Command{
 		cmdSysStats,
 		cmdBalancerInfo,
 		cmdBalancerOverride,
+		cmdObservatory,
 		cmdAddInbounds,
 		cmdAddOutbounds,
 		cmdRemoveInbounds,
@@ -12,8 +12,10 @@ import (
 	"github.com/xtls/xray-core/core"
 	"github.com/xtls/xray-core/infra/conf"
 	"github.com/xtls/xray-core/infra/conf/serial"
+	"github.com/xtls/xray-core/proxy/http"
 	"github.com/xtls/xray-core/proxy/shadowsocks"
 	"github.com/xtls/xray-core/proxy/shadowsocks_2022"
+	"github.com/xtls/xray-core/proxy/socks"
 	"github.com/xtls/xray-core/proxy/trojan"
 	vlessin "github.com/xtls/xray-core/proxy/vless/inbound"
 	vmessin "github.com/xtls/xray-core/proxy/vmess/inbound"
@@ -87,6 +89,10 @@ func extractInboundUsers(inb *core.InboundHandlerConfig) []*protocol.User {
 		return ty.Users
 	case *shadowsocks_2022.MultiUserServerConfig:
 		return ty.Users
+	case *socks.ServerConfig:
+		return ty.Users
+	case *http.ServerConfig:
+		return ty.Users
 	default:
 		fmt.Println("unsupported inbound type")
 	}
@@ -100,7 +106,7 @@ func extractInboundsConfig(unnamedArgs []string) []conf.InboundDetourConfig {
 		if err != nil {
 			base.Fatalf("failed to load %s: %s", arg, err)
 		}
-		conf, err := serial.DecodeJSONConfig(r)
+		conf, err := serial.DecodeConfigFile(r, arg, "json")
 		if err != nil {
 			base.Fatalf("failed to decode %s: %s", arg, err)
 		}
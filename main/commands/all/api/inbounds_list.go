@@ -1,13 +1,17 @@
 package api
 
 import (
+	"encoding/json"
+	"fmt"
+
 	handlerService "github.com/xtls/xray-core/app/proxyman/command"
+	"github.com/xtls/xray-core/infra/conf"
 	"github.com/xtls/xray-core/main/commands/base"
 )
 
 var cmdListInbounds = &base.Command{
 	CustomFlags: true,
-	UsageLine:   "{{.Exec}} api lsi [--server=127.0.0.1:8080] [--isOnlyTags=true]",
+	UsageLine:   "{{.Exec}} api lsi [--server=127.0.0.1:8080] [--isOnlyTags=true] [-config]",
 	Short:       "List inbounds",
 	Long: `
 List inbounds in Xray.
@@ -20,9 +24,13 @@ Arguments:
 	-t, -timeout <seconds>
 		Timeout in seconds for calling API. Default 3
 
+	-config
+		Show the inbounds in the JSON config format.
+
 Example:
 
 	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 -config
 `,
 	Run: executeListInbounds,
 }
@@ -31,6 +39,8 @@ func executeListInbounds(cmd *base.Command, args []string) {
 	setSharedFlags(cmd)
 	var isOnlyTagsStr string
 	cmd.Flag.StringVar(&isOnlyTagsStr, "isOnlyTags", "", "")
+	var showConfig bool
+	cmd.Flag.BoolVar(&showConfig, "config", false, "")
 	cmd.Flag.Parse(args)
 	isOnlyTags := isOnlyTagsStr == "true"
 
@@ -43,5 +53,21 @@ func executeListInbounds(cmd *base.Command, args []string) {
 	if err != nil {
 		base.Fatalf("failed to list inbounds: %s", err)
 	}
-	showJSONResponse(resp)
+	if !showConfig {
+		showJSONResponse(resp)
+		return
+	}
+	inbounds := make([]interface{}, 0, len(resp.Inbounds))
+	for _, inbound := range resp.Inbounds {
+		c, err := conf.InboundFromProto(inbound)
+		if err != nil {
+			base.Fatalf("failed to convert inbound %s: %s", inbound.Tag, err)
+		}
+		inbounds = append(inbounds, c)
+	}
+	j, err := json.MarshalIndent(map[string]interface{}{"inbounds": inbounds}, "", "  ")
+	if err != nil {
+		base.Fatalf("failed to marshal inbounds: %s", err)
+	}
+	fmt.Println(string(j))
 }
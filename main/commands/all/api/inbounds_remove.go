@@ -42,7 +42,7 @@ func executeRemoveInbounds(cmd *base.Command, args []string) {
 	tags := make([]string, 0)
 	for _, arg := range unnamedArgs {
 		if r, err := loadArg(arg); err == nil {
-			conf, err := serial.DecodeJSONConfig(r)
+			conf, err := serial.DecodeConfigFile(r, arg, "json")
 			if err != nil {
 				base.Fatalf("failed to decode %s: %s", arg, err)
 			}
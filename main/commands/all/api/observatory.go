@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/xtls/xray-core/app/observatory"
+	observatoryService "github.com/xtls/xray-core/app/observatory/command"
+	"github.com/xtls/xray-core/main/commands/base"
+)
+
+var cmdObservatory = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} api obs [--server=127.0.0.1:8080] [-history] [-watch] [outbound]...",
+	Short:       "Retrieve outbound status from the observatory",
+	Long: `
+Retrieve the status of outbounds under observation, their recent history,
+or watch their new statuses.
+If no outbound tag specified, status of all outbounds is returned.
+
+> Ensure that "ObservatoryService" is enabled under "config.api.services" in the server configuration.
+
+Arguments:
+
+	-s, -server <server:port>
+		The API server address. Default 127.0.0.1:8080
+
+	-t, -timeout <seconds>
+		Timeout in seconds for calling API. Default 3
+
+	-history
+		Retrieve the recent statuses of outbounds.
+
+	-watch
+		Print new statuses of outbounds as they are observed, until interrupted.
+
+	-json
+		Use json output.
+
+Example:
+
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 -history proxy1 proxy2
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 -watch
+`,
+	Run: executeObservatory,
+}
+
+func executeObservatory(cmd *base.Command, args []string) {
+	setSharedFlags(cmd)
+	history := cmd.Flag.Bool("history", false, "")
+	watch := cmd.Flag.Bool("watch", false, "")
+	cmd.Flag.Parse(args)
+	tags := cmd.Flag.Args()
+
+	conn, ctx, close := dialAPIServer()
+	defer close()
+	client := observatoryService.NewObservatoryServiceClient(conn)
+
+	switch {
+	case *watch:
+		stream, err := client.SubscribeOutboundStatus(context.Background(), &observatoryService.SubscribeOutboundStatusRequest{
+			OutboundTags: tags,
+		})
+		if err != nil {
+			base.Fatalf("failed to subscribe outbound status: %s", err)
+		}
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				base.Fatalf("failed to receive outbound status: %s", err)
+			}
+			if apiJSON {
+				showJSONResponse(resp)
+				continue
+			}
+			os.Stdout.WriteString(formatOutboundStatus(resp.Status, true))
+		}
+	case *history:
+		resp, err := client.GetOutboundStatusHistory(ctx, &observatoryService.GetOutboundStatusHistoryRequest{
+			OutboundTags: tags,
+		})
+		if err != nil {
+			base.Fatalf("failed to get outbound status history: %s", err)
+		}
+		if apiJSON {
+			showJSONResponse(resp)
+			return
+		}
+		sb := new(strings.Builder)
+		for _, h := range resp.History {
+			sb.WriteString("  - " + h.OutboundTag + ":\n")
+			for _, s := range h.Status {
+				sb.WriteString("    " + formatOutboundStatus(s, false))
+			}
+		}
+		os.Stdout.WriteString(sb.String())
+	default:
+		resp, err := client.GetOutboundStatus(ctx, &observatoryService.GetOutboundStatusRequest{})
+		if err != nil {
+			base.Fatalf("failed to get outbound status: %s", err)
+		}
+		if len(tags) > 0 {
+			var status []*observatory.OutboundStatus
+			for _, s := range resp.GetStatus().GetStatus() {
+				for _, tag := range tags {
+					if s.OutboundTag == tag {
+						status = append(status, s)
+					}
+				}
+			}
+			resp.Status = &observatory.ObservationResult{Status: status}
+		}
+		if apiJSON {
+			showJSONResponse(resp)
+			return
+		}
+		sb := new(strings.Builder)
+		for _, s := range resp.GetStatus().GetStatus() {
+			sb.WriteString(formatOutboundStatus(s, true))
+		}
+		os.Stdout.WriteString(sb.String())
+	}
+}
+
+// formatOutboundStatus formats status as a line, with the outbound tag if
+// withTag is true.
+func formatOutboundStatus(s *observatory.OutboundStatus, withTag bool) string {
+	sb := new(strings.Builder)
+	if s.LastTryTime != 0 {
+		sb.WriteString(time.Unix(s.LastTryTime, 0).Format(time.DateTime) + "  ")
+	}
+	if withTag {
+		sb.WriteString(fmt.Sprintf("%-20s ", s.OutboundTag))
+	}
+	if s.Alive {
+		sb.WriteString(fmt.Sprintf("alive  %dms", s.Delay))
+	} else {
+		sb.WriteString("dead")
+		if s.LastFailure != observatory.ProbeFailure_NONE {
+			sb.WriteString("   " + strings.ToLower(s.LastFailure.String()))
+		}
+	}
+	sb.WriteByte('\n')
+	return sb.String()
+}
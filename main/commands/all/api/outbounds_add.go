@@ -46,7 +46,7 @@ func executeAddOutbounds(cmd *base.Command, args []string) {
 		if err != nil {
 			base.Fatalf("failed to load %s: %s", arg, err)
 		}
-		conf, err := serial.DecodeJSONConfig(r)
+		conf, err := serial.DecodeConfigFile(r, arg, "json")
 		if err != nil {
 			base.Fatalf("failed to decode %s: %s", arg, err)
 		}
@@ -1,13 +1,17 @@
 package api
 
 import (
+	"encoding/json"
+	"fmt"
+
 	handlerService "github.com/xtls/xray-core/app/proxyman/command"
+	"github.com/xtls/xray-core/infra/conf"
 	"github.com/xtls/xray-core/main/commands/base"
 )
 
 var cmdListOutbounds = &base.Command{
 	CustomFlags: true,
-	UsageLine:   "{{.Exec}} api lso [--server=127.0.0.1:8080]",
+	UsageLine:   "{{.Exec}} api lso [--server=127.0.0.1:8080] [-config]",
 	Short:       "List outbounds",
 	Long: `
 List outbounds in Xray.
@@ -20,15 +24,21 @@ Arguments:
 	-t, -timeout <seconds>
 		Timeout in seconds for calling API. Default 3
 
+	-config
+		Show the outbounds in the JSON config format.
+
 Example:
 
 	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080
+	{{.Exec}} {{.LongName}} --server=127.0.0.1:8080 -config
 `,
 	Run: executeListOutbounds,
 }
 
 func executeListOutbounds(cmd *base.Command, args []string) {
 	setSharedFlags(cmd)
+	var showConfig bool
+	cmd.Flag.BoolVar(&showConfig, "config", false, "")
 	cmd.Flag.Parse(args)
 
 	conn, ctx, close := dialAPIServer()
@@ -39,5 +49,21 @@ func executeListOutbounds(cmd *base.Command, args []string) {
 	if err != nil {
 		base.Fatalf("failed to list outbounds: %s", err)
 	}
-	showJSONResponse(resp)
+	if !showConfig {
+		showJSONResponse(resp)
+		return
+	}
+	outbounds := make([]interface{}, 0, len(resp.Outbounds))
+	for _, outbound := range resp.Outbounds {
+		c, err := conf.OutboundFromProto(outbound)
+		if err != nil {
+			base.Fatalf("failed to convert outbound %s: %s", outbound.Tag, err)
+		}
+		outbounds = append(outbounds, c)
+	}
+	j, err := json.MarshalIndent(map[string]interface{}{"outbounds": outbounds}, "", "  ")
+	if err != nil {
+		base.Fatalf("failed to marshal outbounds: %s", err)
+	}
+	fmt.Println(string(j))
 }
@@ -17,5 +17,7 @@ func init() {
 		cmdX25519,
 		cmdWG,
 		cmdMLDSA65,
+		cmdLint,
+		cmdSchema,
 	)
 }
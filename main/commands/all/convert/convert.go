@@ -13,5 +13,7 @@ var CmdConvert = &base.Command{
 	Commands: []*base.Command{
 		cmdProtobuf,
 		cmdJson,
+		cmdLink,
+		cmdDiff,
 	},
 }
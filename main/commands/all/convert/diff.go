@@ -0,0 +1,85 @@
+package convert
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/xtls/xray-core/common/cmdarg"
+	creflect "github.com/xtls/xray-core/common/reflect"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/infra/conf"
+	"github.com/xtls/xray-core/main/commands/base"
+)
+
+var cmdDiff = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} convert diff [-json] [config file] [config file]",
+	Short:       "Show semantic differences between configs",
+	Long: `
+Show semantic differences between two configs.
+
+The configs are built before compared, so differences only in format,
+such as the order of keys, aliases or default values, are ignored.
+Inbounds and outbounds are matched by tags, and apps by types.
+
+It exits with status 1 if the configs are different.
+
+Arguments:
+
+	-json
+		Print differences as JSON.
+
+Examples:
+
+    {{.Exec}} convert diff old.json new.json
+    {{.Exec}} convert diff config.json config.yaml
+	`,
+	Run: executeDiff,
+}
+
+func executeDiff(cmd *base.Command, args []string) {
+	var optJSON bool
+	cmd.Flag.BoolVar(&optJSON, "json", false, "")
+	cmd.Flag.Parse(args)
+
+	if cmd.Flag.NArg() != 2 {
+		base.Fatalf("two configs are required")
+	}
+	a := loadBuiltConfig(cmd.Flag.Arg(0))
+	b := loadBuiltConfig(cmd.Flag.Arg(1))
+	changes, err := conf.DiffBuiltConfigs(a, b)
+	if err != nil {
+		base.Fatalf("failed to compare configs: %s", err)
+	}
+
+	if optJSON {
+		if changes == nil {
+			changes = []*conf.ConfigChange{}
+		}
+		j, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			base.Fatalf("failed to marshal differences: %s", err)
+		}
+		fmt.Println(string(j))
+	} else {
+		for _, change := range changes {
+			fmt.Println(change)
+		}
+	}
+	if len(changes) > 0 {
+		base.SetExitStatus(1)
+	}
+}
+
+// loadBuiltConfig loads file, and returns the JSON of the built config.
+func loadBuiltConfig(file string) []byte {
+	pbConfig, err := core.LoadConfig("auto", cmdarg.Arg{file})
+	if err != nil {
+		base.Fatalf("failed to load config %s: %s", file, err)
+	}
+	j, ok := creflect.MarshalToJson(pbConfig, true)
+	if !ok {
+		base.Fatalf("failed to marshal config %s to json", file)
+	}
+	return []byte(j)
+}
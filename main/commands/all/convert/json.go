@@ -7,16 +7,20 @@ import (
 
 	creflect "github.com/xtls/xray-core/common/reflect"
 	cserial "github.com/xtls/xray-core/common/serial"
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/infra/conf"
 	"github.com/xtls/xray-core/main/commands/base"
 	"github.com/xtls/xray-core/main/confloader"
+	"google.golang.org/protobuf/proto"
 )
 
 var cmdJson = &base.Command{
 	CustomFlags: true,
-	UsageLine:   "{{.Exec}} convert json [-type] [stdin:] [typedMessage file] ",
-	Short:       "Convert typedMessage to json",
+	UsageLine:   "{{.Exec}} convert json [-type] [-from-pb] [stdin:] [typedMessage file] ",
+	Short:       "Convert typedMessage or protobuf config to json",
 	Long: `
-Convert ONE typedMessage to json.
+Convert ONE typedMessage to json, or with -from-pb, a protobuf config back
+to a JSON config.
 
 Where typedMessage file need to be in the following format:
 
@@ -30,9 +34,15 @@ Arguments:
 	-t, -type
 		Inject type infomation.
 
+	-from-pb
+		Convert a protobuf config, such as one from "{{.Exec}} convert pb",
+		to a JSON config. Default values are left out, and geosite lists
+		are expanded to their domains.
+
 Examples:
 
     {{.Exec}} convert json user.tmsg
+    {{.Exec}} convert json -from-pb config.pb > config.json
 	`,
 	Run: executeTypedMessageToJson,
 }
@@ -42,6 +52,8 @@ func executeTypedMessageToJson(cmd *base.Command, args []string) {
 	var injectTypeInfo bool
 	cmd.Flag.BoolVar(&injectTypeInfo, "t", false, "")
 	cmd.Flag.BoolVar(&injectTypeInfo, "type", false, "")
+	var fromPb bool
+	cmd.Flag.BoolVar(&fromPb, "from-pb", false, "")
 	cmd.Flag.Parse(args)
 
 	if cmd.Flag.NArg() < 1 {
@@ -58,6 +70,23 @@ func executeTypedMessageToJson(cmd *base.Command, args []string) {
 		base.Fatalf("failed to read config: %s", err)
 	}
 
+	if fromPb {
+		config := new(core.Config)
+		if err := proto.Unmarshal(b, config); err != nil {
+			base.Fatalf("failed to unmarshal protobuf config: %s", err)
+		}
+		c, err := conf.ConfigFromProto(config)
+		if err != nil {
+			base.Fatalf("failed to convert config: %s", err)
+		}
+		j, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			base.Fatalf("failed to marshal config: %s", err)
+		}
+		fmt.Println(string(j))
+		return
+	}
+
 	tm := cserial.TypedMessage{}
 	if err = json.Unmarshal(b, &tm); err != nil {
 		base.Fatalf("failed to unmarshal config: %s", err)
@@ -0,0 +1,148 @@
+package convert
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/infra/conf"
+	"github.com/xtls/xray-core/infra/conf/serial"
+	"github.com/xtls/xray-core/main/commands/base"
+	"github.com/xtls/xray-core/main/confloader"
+)
+
+var cmdLink = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} convert link [-address host] [-fp fingerprint] [-tag tag] [share link | config file] ...",
+	Short:       "Convert between share links and configs",
+	Long: `
+Convert share links to outbounds, or inbounds of configs to share links.
+
+Share links of vless://, vmess:// (v2rayN), trojan:// and ss:// (SIP002)
+are converted to an Xray config with outbounds. The remark of a link is
+the tag of its outbound. Use "stdin:" to read links from stdin, one per
+line.
+
+Inbounds of VLESS, VMess, Trojan and Shadowsocks in config files are
+converted to share links for clients, one for each user. The remark of a
+link is the email of the user, or the tag of the inbound.
+
+Arguments:
+
+	-a, -address
+		The address for clients to connect to inbounds. Default to the
+		listen address of inbounds.
+
+	-fp, -fingerprint
+		The TLS fingerprint of links. Default to "chrome" for REALITY.
+
+	-tag
+		Only convert the inbound of the tag.
+
+Examples:
+
+    {{.Exec}} convert link "vless://uuid@example.com:443?security=tls#proxy" > outbound.json
+    {{.Exec}} convert link stdin: < links.txt
+    {{.Exec}} convert link -a example.com config.json
+	`,
+	Run: executeConvertLink,
+}
+
+func executeConvertLink(cmd *base.Command, args []string) {
+	var address, fingerprint, tag string
+	cmd.Flag.StringVar(&address, "a", "", "")
+	cmd.Flag.StringVar(&address, "address", "", "")
+	cmd.Flag.StringVar(&fingerprint, "fp", "", "")
+	cmd.Flag.StringVar(&fingerprint, "fingerprint", "", "")
+	cmd.Flag.StringVar(&tag, "tag", "", "")
+	cmd.Flag.Parse(args)
+
+	if cmd.Flag.NArg() < 1 {
+		base.Fatalf("empty input list")
+	}
+
+	var outbounds []json.RawMessage
+	var links []string
+	for _, arg := range cmd.Flag.Args() {
+		switch {
+		case arg == "stdin:":
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				if line := strings.TrimSpace(scanner.Text()); line != "" {
+					outbounds = append(outbounds, parseShareLink(line))
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				base.Fatalf("failed to read stdin: %s", err)
+			}
+		case isShareLink(arg):
+			outbounds = append(outbounds, parseShareLink(arg))
+		default:
+			inbounds, err := loadInbounds(arg)
+			if err != nil {
+				base.Fatalf("failed to load %s: %s", arg, err)
+			}
+			for _, inbound := range inbounds {
+				if tag != "" && inbound.Tag != tag {
+					continue
+				}
+				l, err := inbound.ShareLinks(address, fingerprint)
+				if err != nil {
+					base.Fatalf("failed to convert inbound %s: %s", inbound.Tag, err)
+				}
+				links = append(links, l...)
+			}
+		}
+	}
+
+	for _, link := range links {
+		fmt.Println(link)
+	}
+	if len(outbounds) > 0 {
+		b, err := json.MarshalIndent(map[string]interface{}{"outbounds": outbounds}, "", "  ")
+		if err != nil {
+			base.Fatalf("failed to marshal outbounds: %s", err)
+		}
+		fmt.Println(string(b))
+	}
+}
+
+func isShareLink(arg string) bool {
+	scheme, _, found := strings.Cut(arg, "://")
+	if !found {
+		return false
+	}
+	switch strings.ToLower(scheme) {
+	case "http", "https":
+		return false
+	}
+	return true
+}
+
+func parseShareLink(link string) json.RawMessage {
+	outbound, err := conf.ParseShareLink(link)
+	if err != nil {
+		base.Fatalf("failed to parse %s: %s", link, err)
+	}
+	return outbound
+}
+
+func loadInbounds(arg string) ([]conf.InboundDetourConfig, error) {
+	format := core.GetFormatByExtension(strings.TrimPrefix(filepath.Ext(arg), "."))
+	if _, ok := serial.ReaderDecoderByFormat[format]; !ok {
+		format = "json"
+	}
+	r, err := confloader.LoadConfig(arg)
+	if err != nil {
+		return nil, err
+	}
+	config, err := serial.DecodeConfigFile(r, arg, format)
+	if err != nil {
+		return nil, err
+	}
+	return config.InboundConfigs, nil
+}
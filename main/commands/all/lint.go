@@ -0,0 +1,99 @@
+package all
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/xtls/xray-core/core"
+	"github.com/xtls/xray-core/infra/conf"
+	"github.com/xtls/xray-core/infra/conf/serial"
+	"github.com/xtls/xray-core/main/commands/base"
+)
+
+var cmdLint = &base.Command{
+	CustomFlags: true,
+	UsageLine:   "{{.Exec}} lint [-json] [config file] [config file] ...",
+	Short:       "Check configs for semantic problems",
+	Long: `
+Check configs for semantic problems, beyond whether they build:
+
+  - routing rules never matched, as earlier rules match all their traffic
+  - inbounds, outbounds and balancers referenced but not defined
+  - balancers with empty selectors, or selectors matching no outbound
+  - users sharing emails or credentials with other users
+  - REALITY configs missing "shortIds", "serverNames", etc.
+  - inbounds listening on the same port
+  - TLS certificates expired or expiring in 30 days
+
+Multiple config files are merged as "{{.Exec}} run" does. It exits with
+status 1 if any error is found.
+
+Arguments:
+
+	-json
+		Print issues as JSON.
+
+Examples:
+
+    {{.Exec}} lint config.json
+    {{.Exec}} lint -json c1.json c2.json
+	`,
+	Run: executeLint,
+}
+
+func executeLint(cmd *base.Command, args []string) {
+	var optJSON bool
+	cmd.Flag.BoolVar(&optJSON, "json", false, "")
+	cmd.Flag.Parse(args)
+
+	if cmd.Flag.NArg() < 1 {
+		base.Fatalf("empty config list")
+	}
+	var files []*core.ConfigSource
+	for _, file := range cmd.Flag.Args() {
+		format := core.GetFormatByExtension(strings.TrimPrefix(filepath.Ext(file), "."))
+		if format == "" || format == "protobuf" {
+			format = "json"
+		}
+		files = append(files, &core.ConfigSource{
+			Name:   file,
+			Format: format,
+		})
+	}
+	config, err := serial.DecodeConfigFromFiles(files)
+	if err != nil {
+		base.Fatalf("failed to load config: %s", err)
+	}
+
+	issues := conf.LintConfigureFile(config)
+	if _, err := config.Build(); err != nil {
+		issues = append(issues, &conf.LintIssue{
+			Linter:   "Build",
+			Severity: conf.LintError,
+			Message:  err.Error(),
+		})
+	}
+
+	if optJSON {
+		if issues == nil {
+			issues = []*conf.LintIssue{}
+		}
+		b, err := json.MarshalIndent(issues, "", "  ")
+		if err != nil {
+			base.Fatalf("failed to marshal issues: %s", err)
+		}
+		fmt.Println(string(b))
+	} else {
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+	}
+	for _, issue := range issues {
+		if issue.Severity == conf.LintError {
+			base.SetExitStatus(1)
+			break
+		}
+	}
+}
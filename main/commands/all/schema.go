@@ -0,0 +1,51 @@
+package all
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/xtls/xray-core/infra/conf"
+	"github.com/xtls/xray-core/main/commands/base"
+)
+
+var cmdSchema = &base.Command{
+	UsageLine: `{{.Exec}} schema [-o file]`,
+	Short:     `Print JSON Schema of the config format`,
+	Long: `
+Print the JSON Schema (draft 2020-12) of the JSON config format, for
+editors and tools to validate configs.
+
+Enums are in their documented forms, while Xray accepts some of them, such
+as protocols and domain strategies, case-insensitively.
+
+Arguments:
+
+	-o
+		Write the schema to the file instead of stdout.
+
+Examples:
+
+    {{.Exec}} schema -o xray.schema.json
+`,
+}
+
+func init() {
+	cmdSchema.Run = executeSchema // break init loop
+}
+
+var schemaOutput = cmdSchema.Flag.String("o", "", "")
+
+func executeSchema(cmd *base.Command, args []string) {
+	b, err := json.MarshalIndent(conf.Schema(), "", "  ")
+	if err != nil {
+		base.Fatalf("failed to marshal schema: %s", err)
+	}
+	if *schemaOutput == "" {
+		fmt.Println(string(b))
+		return
+	}
+	if err := os.WriteFile(*schemaOutput, append(b, '\n'), 0o644); err != nil {
+		base.Fatalf("failed to write schema: %s", err)
+	}
+}
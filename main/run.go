@@ -40,6 +40,21 @@ The -test flag tells Xray to test config files only,
 without launching the server.
 
 The -dump flag tells Xray to print the merged config.
+
+Strings in config files may refer to environment variables and files, such
+as "${env:UUID}" and "${file:/run/secrets/password}", which are resolved when
+loading. Use "$${" for a literal "${". The -dump flag prints these strings as
+written, so that secrets are not printed.
+
+Objects in config files may be "$include": "file" to use the content of
+another JSON, YAML or TOML file, or "$ref": "group.name" to use a fragment
+defined in the top-level "fragments" of the same file, such as
+"fragments": {"group": {"name": {...}}}. Other keys of these objects are
+merged over the content. Relative paths of included files are relative to
+the directory of the including file.
+
+Configs fetched over HTTP can not refer to environment variables or files,
+nor include files.
 	`,
 }
 
@@ -11,6 +11,7 @@ import (
 	"sync"
 	"text/template"
 
+	"github.com/quic-go/quic-go/http3"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/bytespool"
@@ -34,6 +35,14 @@ type Client struct {
 	serverPicker  protocol.ServerPicker
 	policyManager policy.Manager
 	header        []*Header
+	udpOverH3     bool
+	h3TLSSettings *tls.Config
+
+	// h3Conns pools HTTP/3 connections per proxy server, every UDP flow is
+	// a CONNECT-UDP request stream on one of them. The pool is per client,
+	// so connections are never shared by outbounds with other TLS settings.
+	h3Access sync.Mutex
+	h3Conns  map[net.Destination]*http3.ClientConn
 }
 
 type h2Conn struct {
@@ -41,11 +50,49 @@ type h2Conn struct {
 	h2Conn  *http2.ClientConn
 }
 
+// cachedH2Conns pools HTTP/2 connections per proxy server, each of them
+// carries many CONNECT streams until the server refuses new ones.
 var (
 	cachedH2Mutex sync.Mutex
-	cachedH2Conns map[net.Destination]h2Conn
+	cachedH2Conns map[net.Destination][]h2Conn
 )
 
+// pickH2Conn returns a pooled connection to dest which can take a new stream,
+// dropping the ones which are closed or closing.
+func pickH2Conn(dest net.Destination) (h2Conn, bool) {
+	cachedH2Mutex.Lock()
+	defer cachedH2Mutex.Unlock()
+
+	conns := cachedH2Conns[dest][:0]
+	var picked h2Conn
+	var found bool
+	for _, c := range cachedH2Conns[dest] {
+		if state := c.h2Conn.State(); state.Closed || state.Closing {
+			continue
+		}
+		conns = append(conns, c)
+		if !found && c.h2Conn.CanTakeNewRequest() {
+			picked, found = c, true
+		}
+	}
+	if len(conns) == 0 {
+		delete(cachedH2Conns, dest)
+	} else {
+		cachedH2Conns[dest] = conns
+	}
+	return picked, found
+}
+
+func putH2Conn(dest net.Destination, c h2Conn) {
+	cachedH2Mutex.Lock()
+	defer cachedH2Mutex.Unlock()
+
+	if cachedH2Conns == nil {
+		cachedH2Conns = make(map[net.Destination][]h2Conn)
+	}
+	cachedH2Conns[dest] = append(cachedH2Conns[dest], c)
+}
+
 // NewClient create a new http client based on the given config.
 func NewClient(ctx context.Context, config *ClientConfig) (*Client, error) {
 	serverList := protocol.NewServerList()
@@ -65,6 +112,8 @@ func NewClient(ctx context.Context, config *ClientConfig) (*Client, error) {
 		serverPicker:  protocol.NewRoundRobinServerPicker(serverList),
 		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
 		header:        config.Header,
+		udpOverH3:     config.UdpOverH3,
+		h3TLSSettings: config.H3TlsSettings,
 	}, nil
 }
 
@@ -81,7 +130,10 @@ func (c *Client) Process(ctx context.Context, link *transport.Link, dialer inter
 	targetAddr := target.NetAddr()
 
 	if target.Network == net.Network_UDP {
-		return errors.New("UDP is not supported by HTTP outbound")
+		if !c.udpOverH3 {
+			return errors.New("UDP is not supported by HTTP outbound")
+		}
+		return c.processUDP(ctx, link, dialer)
 	}
 
 	var user *protocol.MemoryUser
@@ -249,7 +301,9 @@ func setUpHTTPTunnel(ctx context.Context, dest net.Destination, target string, u
 		return rawConn, nil
 	}
 
-	connectHTTP2 := func(rawConn net.Conn, h2clientConn *http2.ClientConn) (net.Conn, error) {
+	// connectHTTP2 opens a CONNECT stream on a possibly shared connection,
+	// so failures of the stream must not close the connection itself.
+	connectHTTP2 := func(h2clientConn *http2.ClientConn, rawConn net.Conn) (net.Conn, error) {
 		pr, pw := io.Pipe()
 		req.Body = pr
 
@@ -264,37 +318,26 @@ func setUpHTTPTunnel(ctx context.Context, dest net.Destination, target string, u
 
 		resp, err := h2clientConn.RoundTrip(req)
 		if err != nil {
-			rawConn.Close()
+			pw.CloseWithError(err)
 			return nil, err
 		}
 
 		wg.Wait()
 		if pErr != nil {
-			rawConn.Close()
+			resp.Body.Close()
 			return nil, pErr
 		}
 
 		if resp.StatusCode != http.StatusOK {
-			rawConn.Close()
+			pw.Close()
+			resp.Body.Close()
 			return nil, errors.New("Proxy responded with non 200 code: " + resp.Status)
 		}
 		return newHTTP2Conn(rawConn, pw, resp.Body), nil
 	}
 
-	cachedH2Mutex.Lock()
-	cachedConn, cachedConnFound := cachedH2Conns[dest]
-	cachedH2Mutex.Unlock()
-
-	if cachedConnFound {
-		rc, cc := cachedConn.rawConn, cachedConn.h2Conn
-		if cc.CanTakeNewRequest() {
-			proxyConn, err := connectHTTP2(rc, cc)
-			if err != nil {
-				return nil, err
-			}
-
-			return proxyConn, nil
-		}
+	if cached, found := pickH2Conn(dest); found {
+		return connectHTTP2(cached.h2Conn, cached.rawConn)
 	}
 
 	rawConn, err := dialer.Dial(ctx, dest)
@@ -308,43 +351,33 @@ func setUpHTTPTunnel(ctx context.Context, dest net.Destination, target string, u
 	}
 
 	nextProto := ""
-	if tlsConn, ok := iConn.(*tls.Conn); ok {
+	if tlsConn, ok := iConn.(tls.Interface); ok {
 		if err := tlsConn.HandshakeContext(ctx); err != nil {
 			rawConn.Close()
 			return nil, err
 		}
-		nextProto = tlsConn.ConnectionState().NegotiatedProtocol
+		nextProto = tlsConn.NegotiatedProtocol()
 	}
 
 	switch nextProto {
 	case "", "http/1.1":
 		return connectHTTP1(rawConn)
 	case "h2":
-		t := http2.Transport{}
-		h2clientConn, err := t.NewClientConn(rawConn)
-		if err != nil {
-			rawConn.Close()
-			return nil, err
+		t := http2.Transport{
+			IdleConnTimeout: net.ConnIdleTimeout,
+			ReadIdleTimeout: net.ChromeH2KeepAlivePeriod,
 		}
-
-		proxyConn, err := connectHTTP2(rawConn, h2clientConn)
+		h2clientConn, err := t.NewClientConn(rawConn)
 		if err != nil {
 			rawConn.Close()
 			return nil, err
 		}
-
-		cachedH2Mutex.Lock()
-		if cachedH2Conns == nil {
-			cachedH2Conns = make(map[net.Destination]h2Conn)
-		}
-
-		cachedH2Conns[dest] = h2Conn{
+		putH2Conn(dest, h2Conn{
 			rawConn: rawConn,
 			h2Conn:  h2clientConn,
-		}
-		cachedH2Mutex.Unlock()
+		})
 
-		return proxyConn, err
+		return connectHTTP2(h2clientConn, rawConn)
 	default:
 		return nil, errors.New("negotiated unsupported application layer protocol: " + nextProto)
 	}
@@ -0,0 +1,267 @@
+package http
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	"github.com/xtls/xray-core/common/retry"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/signal"
+	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/transport"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/tls"
+)
+
+func (c *Client) getH3Conn(ctx context.Context, dest net.Destination, dialer internet.Dialer) (*http3.ClientConn, error) {
+	c.h3Access.Lock()
+	if cc := c.h3Conns[dest]; cc != nil {
+		select {
+		case <-cc.Context().Done():
+			delete(c.h3Conns, dest)
+		default:
+			c.h3Access.Unlock()
+			return cc, nil
+		}
+	}
+	c.h3Access.Unlock()
+
+	tlsSettings := c.h3TLSSettings
+	if tlsSettings == nil {
+		tlsSettings = &tls.Config{}
+	}
+	tlsConfig := tlsSettings.GetTLSConfig(tls.WithDestination(dest), tls.WithNextProto("h3"))
+
+	rawConn, err := dialer.Dial(ctx, net.UDPDestination(dest.Address, dest.Port))
+	if err != nil {
+		return nil, err
+	}
+	quicConn, err := quic.DialEarly(ctx, &internet.FakePacketConn{Conn: rawConn}, rawConn.RemoteAddr(), tlsConfig, &quic.Config{
+		MaxIdleTimeout:  net.ConnIdleTimeout,
+		KeepAlivePeriod: net.QuicgoH3KeepAlivePeriod,
+		EnableDatagrams: true,
+	})
+	if err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	go func() {
+		<-quicConn.Context().Done()
+		rawConn.Close()
+	}()
+
+	t := &http3.Transport{EnableDatagrams: true}
+	cc := t.NewClientConn(quicConn)
+
+	select {
+	case <-cc.ReceivedSettings():
+	case <-ctx.Done():
+		cc.CloseWithError(0, "")
+		return nil, ctx.Err()
+	case <-cc.Context().Done():
+		return nil, context.Cause(cc.Context())
+	}
+	if settings := cc.Settings(); !settings.EnableDatagrams || !settings.EnableExtendedConnect {
+		cc.CloseWithError(0, "")
+		return nil, errors.New("proxy server does not support HTTP datagrams and extended CONNECT")
+	}
+
+	c.h3Access.Lock()
+	if c.h3Conns == nil {
+		c.h3Conns = make(map[net.Destination]*http3.ClientConn)
+	}
+	c.h3Conns[dest] = cc
+	c.h3Access.Unlock()
+
+	return cc, nil
+}
+
+// setUpUDPTunnel opens a CONNECT-UDP request stream to target on the HTTP/3 connection to dest.
+func (c *Client) setUpUDPTunnel(ctx context.Context, dest net.Destination, target net.Destination, user *protocol.MemoryUser, dialer internet.Dialer, header []*Header) (*http3.RequestStream, error) {
+	cc, err := c.getH3Conn(ctx, dest, dialer)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		Proto:  "connect-udp",
+		URL:    &url.URL{Scheme: "https", Host: dest.NetAddr(), Path: masqueUDPPath(target)},
+		Header: make(http.Header),
+		Host:   dest.NetAddr(),
+	}
+	req.Header.Set(http3.CapsuleProtocolHeader, "?1")
+
+	if user != nil && user.Account != nil {
+		account := user.Account.(*Account)
+		auth := account.GetUsername() + ":" + account.GetPassword()
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(auth)))
+	}
+
+	for _, h := range header {
+		req.Header.Set(h.Key, h.Value)
+	}
+
+	str, err := cc.OpenRequestStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := str.SendRequestHeader(req); err != nil {
+		str.CancelRead(quic.StreamErrorCode(http3.ErrCodeRequestCanceled))
+		str.Close()
+		return nil, err
+	}
+	resp, err := str.ReadResponse()
+	if err != nil {
+		str.CancelRead(quic.StreamErrorCode(http3.ErrCodeRequestCanceled))
+		str.Close()
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		str.CancelRead(quic.StreamErrorCode(http3.ErrCodeRequestCanceled))
+		str.Close()
+		return nil, errors.New("Proxy responded with non 2xx code: " + resp.Status)
+	}
+	return str, nil
+}
+
+// processUDP relays UDP packets of link with CONNECT-UDP over HTTP/3. As a
+// CONNECT-UDP request stream carries the packets of one target, packets to
+// other targets go in streams of their own on the same connection.
+func (c *Client) processUDP(ctx context.Context, link *transport.Link, dialer internet.Dialer) error {
+	outbounds := session.OutboundsFromContext(ctx)
+	ob := outbounds[len(outbounds)-1]
+	target := ob.Target
+	ob.CanSpliceCopy = 3
+
+	header, err := fillRequestHeader(ctx, c.header)
+	if err != nil {
+		return errors.New("failed to fill out header").Base(err)
+	}
+
+	var server *protocol.ServerSpec
+	var user *protocol.MemoryUser
+	var str *http3.RequestStream
+	if err := retry.ExponentialBackoff(5, 100).On(func() error {
+		server = c.serverPicker.PickServer()
+		user = server.PickUser()
+
+		s, err := c.setUpUDPTunnel(ctx, server.Destination(), target, user, dialer, header)
+		if err != nil {
+			return err
+		}
+		str = s
+		return nil
+	}); err != nil {
+		return errors.New("failed to find an available destination").Base(err)
+	}
+
+	var tunnelsAccess sync.Mutex
+	tunnels := map[net.Destination]*http3.RequestStream{target: str}
+	defer func() {
+		tunnelsAccess.Lock()
+		defer tunnelsAccess.Unlock()
+		for _, str := range tunnels {
+			str.CancelRead(quic.StreamErrorCode(http3.ErrCodeNoError))
+			if err := str.Close(); err != nil {
+				errors.LogInfoInner(ctx, err, "failed to closed connection")
+			}
+		}
+	}()
+
+	p := c.policyManager.ForLevel(0)
+	if user != nil {
+		p = c.policyManager.ForLevel(user.Level)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	timer := signal.CancelAfterInactivity(ctx, cancel, p.Timeouts.ConnectionIdle)
+
+	// receive relays the datagrams of the tunnel to target back to link.
+	receive := func(str *http3.RequestStream, target net.Destination) error {
+		for {
+			datagram, err := str.ReceiveDatagram(ctx)
+			if err != nil {
+				return err
+			}
+			payload, ok := decodeUDPDatagram(datagram)
+			if !ok || len(payload) > buf.Size {
+				continue
+			}
+			b := buf.New()
+			b.Write(payload)
+			b.UDP = &target
+			if err := link.Writer.WriteMultiBuffer(buf.MultiBuffer{b}); err != nil {
+				return err
+			}
+			timer.Update()
+		}
+	}
+
+	// tunnelTo returns the tunnel to dest, which is set up on the first packet.
+	tunnelTo := func(dest net.Destination) (*http3.RequestStream, error) {
+		tunnelsAccess.Lock()
+		defer tunnelsAccess.Unlock()
+		if str, found := tunnels[dest]; found {
+			return str, nil
+		}
+		str, err := c.setUpUDPTunnel(ctx, server.Destination(), dest, user, dialer, header)
+		if err != nil {
+			return nil, err
+		}
+		tunnels[dest] = str
+		go func() {
+			if err := receive(str, dest); err != nil {
+				errors.LogDebugInner(ctx, err, "CONNECT-UDP tunnel to ", dest, " ends")
+			}
+		}()
+		return str, nil
+	}
+
+	requestFunc := func() error {
+		defer timer.SetTimeout(p.Timeouts.DownlinkOnly)
+		for {
+			mb, err := link.Reader.ReadMultiBuffer()
+			if err != nil {
+				return err
+			}
+			for _, b := range mb {
+				dest := target
+				if b.UDP != nil {
+					dest = *b.UDP
+					dest.Network = net.Network_UDP
+				}
+				str, err := tunnelTo(dest)
+				if err != nil {
+					errors.LogInfoInner(ctx, err, "failed to set up CONNECT-UDP tunnel to ", dest)
+					continue
+				}
+				if err := str.SendDatagram(encodeUDPDatagram(b.Bytes())); err != nil {
+					errors.LogDebugInner(ctx, err, "failed to send UDP datagram")
+				}
+			}
+			buf.ReleaseMulti(mb)
+			timer.Update()
+		}
+	}
+	responseFunc := func() error {
+		defer timer.SetTimeout(p.Timeouts.UplinkOnly)
+		return receive(str, target)
+	}
+
+	responseDonePost := task.OnSuccess(responseFunc, task.Close(link.Writer))
+	if err := task.Run(ctx, requestFunc, responseDonePost); err != nil {
+		return errors.New("connection ends").Base(err)
+	}
+	return nil
+}
@@ -20,15 +20,3 @@ func (a *Account) ToProto() proto.Message {
 func (a *Account) AsAccount() (protocol.Account, error) {
 	return a, nil
 }
-
-func (sc *ServerConfig) HasAccount(username, password string) bool {
-	if sc.Accounts == nil {
-		return false
-	}
-
-	p, found := sc.Accounts[username]
-	if !found {
-		return false
-	}
-	return p == password
-}
@@ -0,0 +1,185 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/quic-go/quicvarint"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+)
+
+const masqueUDPPrefix = "/.well-known/masque/udp/"
+
+// masqueUDPPath returns the default URI template of RFC 9298 expanded for target.
+func masqueUDPPath(target net.Destination) string {
+	return masqueUDPPrefix + url.PathEscape(target.Address.String()) + "/" + strconv.Itoa(int(target.Port)) + "/"
+}
+
+// parseMasqueUDPPath parses the UDP target out of a path made by masqueUDPPath.
+func parseMasqueUDPPath(path string) (net.Destination, error) {
+	rest, ok := strings.CutPrefix(path, masqueUDPPrefix)
+	if !ok {
+		return net.Destination{}, errors.New("unknown CONNECT-UDP path: ", path)
+	}
+	parts := strings.Split(strings.TrimSuffix(rest, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" {
+		return net.Destination{}, errors.New("malformed CONNECT-UDP path: ", path)
+	}
+	host, err := url.PathUnescape(parts[0])
+	if err != nil {
+		return net.Destination{}, errors.New("malformed CONNECT-UDP host: ", parts[0]).Base(err)
+	}
+	port, err := net.PortFromString(parts[1])
+	if err != nil {
+		return net.Destination{}, errors.New("malformed CONNECT-UDP port: ", parts[1]).Base(err)
+	}
+	return net.UDPDestination(net.ParseAddress(host), port), nil
+}
+
+// encodeUDPDatagram prepends the context ID 0 of UDP payloads to payload.
+func encodeUDPDatagram(payload []byte) []byte {
+	datagram := make([]byte, 1+len(payload))
+	copy(datagram[1:], payload)
+	return datagram
+}
+
+// decodeUDPDatagram returns the UDP payload of datagram, datagrams of other
+// contexts are not UDP payloads and should be dropped.
+func decodeUDPDatagram(datagram []byte) ([]byte, bool) {
+	contextID, n, err := quicvarint.Parse(datagram)
+	if err != nil || contextID != 0 {
+		return nil, false
+	}
+	return datagram[n:], true
+}
+
+// datagramConn carries the HTTP datagrams (RFC 9297) of a request stream.
+type datagramConn interface {
+	ReadDatagram(ctx context.Context) ([]byte, error)
+	WriteDatagram(b []byte) error
+}
+
+// h3DatagramConn sends datagrams in QUIC DATAGRAM frames.
+type h3DatagramConn struct {
+	str *http3.Stream
+}
+
+func (c *h3DatagramConn) ReadDatagram(ctx context.Context) ([]byte, error) {
+	return c.str.ReceiveDatagram(ctx)
+}
+
+func (c *h3DatagramConn) WriteDatagram(b []byte) error {
+	return c.str.SendDatagram(b)
+}
+
+const (
+	capsuleTypeDatagram http3.CapsuleType = 0
+	// maxCapsuleDatagramSize is a UDP payload with its context ID, larger
+	// capsules are skipped.
+	maxCapsuleDatagramSize = 65535 + 8
+)
+
+// capsuleDatagramConn sends datagrams in DATAGRAM capsules on the request
+// stream, which is the only way to send them over HTTP/2.
+type capsuleDatagramConn struct {
+	ctx    context.Context
+	reader *bufio.Reader
+	access sync.Mutex
+	writer io.Writer
+	flush  func() error
+
+	// datagrams are read from the stream in a goroutine, so that reads can
+	// be cancelled, err is set before datagrams is closed.
+	readOnce  sync.Once
+	datagrams chan []byte
+	err       error
+}
+
+// newCapsuleDatagramConn returns a capsuleDatagramConn reading r and writing
+// w, which stops reading when ctx is done.
+func newCapsuleDatagramConn(ctx context.Context, r io.Reader, w http.ResponseWriter) *capsuleDatagramConn {
+	rc := http.NewResponseController(w)
+	return &capsuleDatagramConn{
+		ctx:       ctx,
+		reader:    bufio.NewReader(r),
+		writer:    w,
+		flush:     rc.Flush,
+		datagrams: make(chan []byte),
+	}
+}
+
+func (c *capsuleDatagramConn) ReadDatagram(ctx context.Context) ([]byte, error) {
+	c.readOnce.Do(func() { go c.readLoop() })
+	select {
+	case b, ok := <-c.datagrams:
+		if !ok {
+			return nil, c.err
+		}
+		return b, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *capsuleDatagramConn) readLoop() {
+	for {
+		b, err := c.readDatagram()
+		if err != nil {
+			c.err = err
+			close(c.datagrams)
+			return
+		}
+		select {
+		case c.datagrams <- b:
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *capsuleDatagramConn) readDatagram() ([]byte, error) {
+	for {
+		t, r, err := http3.ParseCapsule(quicvarint.NewReader(c.reader))
+		if err != nil {
+			return nil, err
+		}
+		if t != capsuleTypeDatagram {
+			if _, err := io.Copy(io.Discard, r); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		b, err := io.ReadAll(io.LimitReader(r, maxCapsuleDatagramSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > maxCapsuleDatagramSize {
+			if _, err := io.Copy(io.Discard, r); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return b, nil
+	}
+}
+
+func (c *capsuleDatagramConn) WriteDatagram(b []byte) error {
+	capsule := quicvarint.Append(make([]byte, 0, 16+len(b)), uint64(capsuleTypeDatagram))
+	capsule = quicvarint.Append(capsule, uint64(len(b)))
+	capsule = append(capsule, b...)
+
+	c.access.Lock()
+	defer c.access.Unlock()
+	if _, err := c.writer.Write(capsule); err != nil {
+		return err
+	}
+	return c.flush()
+}
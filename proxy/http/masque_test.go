@@ -0,0 +1,64 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xtls/xray-core/common/net"
+)
+
+func TestMasqueUDPPath(t *testing.T) {
+	for _, target := range []net.Destination{
+		net.UDPDestination(net.DomainAddress("example.com"), 443),
+		net.UDPDestination(net.ParseAddress("192.0.2.1"), 53),
+		net.UDPDestination(net.ParseAddress("2001:db8::1"), 4433),
+	} {
+		path := masqueUDPPath(target)
+		dest, err := parseMasqueUDPPath(path)
+		if err != nil {
+			t.Fatal(path, err)
+		}
+		if dest != target {
+			t.Error("parsed ", path, " as ", dest, ", expected ", target)
+		}
+	}
+
+	for _, path := range []string{
+		"/.well-known/masque/ip/example.com/443/",
+		"/.well-known/masque/udp/example.com/",
+		"/.well-known/masque/udp//443/",
+		"/.well-known/masque/udp/example.com/http/",
+	} {
+		if _, err := parseMasqueUDPPath(path); err == nil {
+			t.Error("expected error for ", path)
+		}
+	}
+}
+
+func TestCapsuleDatagramConn(t *testing.T) {
+	payload := []byte("ping")
+
+	w := httptest.NewRecorder()
+	conn := newCapsuleDatagramConn(context.Background(), nil, w)
+	// an unknown capsule is skipped by the reader
+	w.Body.Write([]byte{0x17, 0x02, 0xff, 0xff})
+	if err := conn.WriteDatagram(encodeUDPDatagram(payload)); err != nil {
+		t.Fatal(err)
+	}
+
+	conn = newCapsuleDatagramConn(context.Background(), bytes.NewReader(w.Body.Bytes()), nil)
+	datagram, err := conn.ReadDatagram(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, ok := decodeUDPDatagram(datagram)
+	if !ok {
+		t.Fatal("not a UDP payload: ", datagram)
+	}
+	if r := cmp.Diff(data, payload); r != "" {
+		t.Error(r)
+	}
+}
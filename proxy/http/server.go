@@ -8,6 +8,8 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/xtls/xray-core/common"
@@ -24,33 +26,102 @@ import (
 	"github.com/xtls/xray-core/features/policy"
 	"github.com/xtls/xray-core/features/routing"
 	"github.com/xtls/xray-core/transport/internet/stat"
+	"github.com/xtls/xray-core/transport/internet/tls"
 )
 
 // Server is an HTTP proxy server.
 type Server struct {
 	config        *ServerConfig
 	policyManager policy.Manager
+	validator     *Validator
+	// authRequired stays set once the server has had users, so removing
+	// the last user does not turn it into an open proxy.
+	authRequired atomic.Bool
+
+	h3Access sync.Mutex
+	h3       *h3Listener
+
+	// xconnectWarning warns once that CONNECT-UDP over HTTP/2 is refused.
+	xconnectWarning sync.Once
 }
 
 // NewServer creates a new HTTP inbound handler.
 func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
+	validator := new(Validator)
+	for _, user := range config.Users {
+		u, err := user.ToMemoryUser()
+		if err != nil {
+			return nil, errors.New("failed to get HTTP user").Base(err).AtError()
+		}
+		if err := validator.Add(u); err != nil {
+			return nil, errors.New("failed to add user").Base(err).AtError()
+		}
+	}
+	if err := validator.AddAccounts(config.Accounts, config.UserLevel, func(username, password string) protocol.Account {
+		return &Account{Username: username, Password: password}
+	}); err != nil {
+		return nil, errors.New("failed to add account").Base(err).AtError()
+	}
+	s := newServer(ctx, config, validator)
+	s.authRequired.Store(validator.GetCount() > 0)
+	return s, nil
+}
+
+// NewServerWithValidator creates a new HTTP inbound handler which always
+// requires authentication by the users of validator.
+func NewServerWithValidator(ctx context.Context, config *ServerConfig, validator *Validator) *Server {
+	s := newServer(ctx, config, validator)
+	s.authRequired.Store(true)
+	return s
+}
+
+func newServer(ctx context.Context, config *ServerConfig, validator *Validator) *Server {
 	v := core.MustFromContext(ctx)
-	s := &Server{
+	return &Server{
 		config:        config,
 		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
+		validator:     validator,
 	}
+}
 
-	return s, nil
+// AddUser implements proxy.UserManager.AddUser().
+func (s *Server) AddUser(ctx context.Context, u *protocol.MemoryUser) error {
+	if err := s.validator.Add(u); err != nil {
+		return err
+	}
+	s.authRequired.Store(true)
+	return nil
+}
+
+// RemoveUser implements proxy.UserManager.RemoveUser().
+func (s *Server) RemoveUser(ctx context.Context, e string) error {
+	return s.validator.Del(e)
 }
 
-func (s *Server) policy() policy.Session {
-	config := s.config
-	p := s.policyManager.ForLevel(config.UserLevel)
-	return p
+// GetUser implements proxy.UserManager.GetUser().
+func (s *Server) GetUser(ctx context.Context, email string) *protocol.MemoryUser {
+	return s.validator.GetByEmail(email)
+}
+
+// GetUsers implements proxy.UserManager.GetUsers().
+func (s *Server) GetUsers(ctx context.Context) []*protocol.MemoryUser {
+	return s.validator.GetAll()
+}
+
+// GetUsersCount implements proxy.UserManager.GetUsersCount().
+func (s *Server) GetUsersCount(context.Context) int64 {
+	return s.validator.GetCount()
+}
+
+func (s *Server) policy(level uint32) policy.Session {
+	return s.policyManager.ForLevel(level)
 }
 
 // Network implements proxy.Inbound.
-func (*Server) Network() []net.Network {
+func (s *Server) Network() []net.Network {
+	if s.config.H3TlsSettings != nil {
+		return []net.Network{net.Network_TCP, net.Network_UNIX, net.Network_UDP}
+	}
 	return []net.Network{net.Network_TCP, net.Network_UNIX}
 }
 
@@ -81,9 +152,38 @@ type readerOnly struct {
 }
 
 func (s *Server) Process(ctx context.Context, network net.Network, conn stat.Connection, dispatcher routing.Dispatcher) error {
+	if network == net.Network_UDP {
+		return s.processH3(ctx, conn, dispatcher)
+	}
+	h2, err := s.negotiatedH2(ctx, conn)
+	if err != nil {
+		return errors.New("failed to complete TLS handshake").Base(err)
+	}
+	if h2 {
+		return s.processH2(ctx, conn, dispatcher)
+	}
 	return s.ProcessWithFirstbyte(ctx, network, conn, dispatcher)
 }
 
+// negotiatedH2 completes the TLS handshake of conn, if any, and tells whether
+// the client chose h2.
+func (s *Server) negotiatedH2(ctx context.Context, conn stat.Connection) (bool, error) {
+	iConn := conn
+	if statConn, ok := iConn.(*stat.CounterConnection); ok {
+		iConn = statConn.Connection
+	}
+	tlsConn, ok := iConn.(tls.Interface)
+	if !ok {
+		return false, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.policy(s.config.UserLevel).Timeouts.Handshake)
+	defer cancel()
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return false, err
+	}
+	return tlsConn.NegotiatedProtocol() == "h2", nil
+}
+
 // Firstbyte is for forwarded conn from SOCKS inbound
 // Because it needs first byte to choose protocol
 // We need to add it back
@@ -105,7 +205,7 @@ func (s *Server) ProcessWithFirstbyte(ctx context.Context, network net.Network,
 	}
 
 Start:
-	if err := conn.SetReadDeadline(time.Now().Add(s.policy().Timeouts.Handshake)); err != nil {
+	if err := conn.SetReadDeadline(time.Now().Add(s.policy(inbound.User.Level).Timeouts.Handshake)); err != nil {
 		errors.LogInfoInner(ctx, err, "failed to set read deadline")
 	}
 
@@ -118,13 +218,24 @@ Start:
 		return trace
 	}
 
-	if len(s.config.Accounts) > 0 {
+	// ACME servers send HTTP-01 challenges to the inbound as the server of a
+	// domain, if the ACME config of the domain names the inbound
+	if response := tls.ACMEHTTPChallengeResponse(request, inbound.Tag); response != nil {
+		errors.LogInfo(ctx, "answering ACME HTTP challenge for ", request.Host)
+		return response.Write(conn)
+	}
+
+	if s.authRequired.Load() {
 		user, pass, ok := parseBasicAuth(request.Header.Get("Proxy-Authorization"))
-		if !ok || !s.config.HasAccount(user, pass) {
+		var u *protocol.MemoryUser
+		if ok {
+			u = s.validator.Get(user, pass)
+		}
+		if u == nil {
 			return common.Error2(conn.Write([]byte("HTTP/1.1 407 Proxy Authentication Required\r\nProxy-Authenticate: Basic realm=\"proxy\"\r\n\r\n")))
 		}
 		if inbound != nil {
-			inbound.User.Email = user
+			inbound.User = u
 		}
 	}
 
@@ -175,7 +286,7 @@ func (s *Server) handleConnect(ctx context.Context, _ *http.Request, reader *buf
 		return errors.New("failed to write back OK response").Base(err)
 	}
 
-	plcy := s.policy()
+	plcy := s.policy(inbound.User.Level)
 	ctx, cancel := context.WithCancel(ctx)
 	timer := signal.CancelAfterInactivity(ctx, cancel, plcy.Timeouts.ConnectionIdle)
 
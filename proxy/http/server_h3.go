@@ -0,0 +1,175 @@
+package http
+
+import (
+	"context"
+	gonet "net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/signal/done"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport/internet/stat"
+	"github.com/xtls/xray-core/transport/internet/tls"
+)
+
+// h3Listener runs an HTTP/3 server on the UDP port of the inbound.
+type h3Listener struct {
+	conn   *packetConn
+	server *http3.Server
+	acme   common.Closable
+}
+
+// processH3 feeds the packets of one UDP source to the HTTP/3 server, which
+// is started with the session of the first source.
+func (s *Server) processH3(ctx context.Context, conn stat.Connection, dispatcher routing.Dispatcher) error {
+	s.h3Access.Lock()
+	if s.h3 == nil {
+		tlsConfig, acme, err := s.config.H3TlsSettings.GetServerTLSConfig(tls.WithNextProto("h3"))
+		if err != nil {
+			s.h3Access.Unlock()
+			return errors.New("failed to start HTTP/3 server").Base(err)
+		}
+		pc := newPacketConn(conn.LocalAddr())
+		h3s := &http3.Server{
+			TLSConfig:       tlsConfig,
+			EnableDatagrams: true,
+			QUICConfig: &quic.Config{
+				MaxIdleTimeout: net.ConnIdleTimeout,
+			},
+			Handler: &tunnelHandler{
+				server:     s,
+				dispatcher: dispatcher,
+				ctx:        context.WithoutCancel(ctx),
+				network:    net.Network_UDP,
+			},
+		}
+		s.h3 = &h3Listener{conn: pc, server: h3s, acme: acme}
+		go func() {
+			if err := h3s.Serve(pc); err != nil && err != http.ErrServerClosed {
+				errors.LogWarningInner(ctx, err, "HTTP/3 server stopped")
+			}
+		}()
+	}
+	pc := s.h3.conn
+	s.h3Access.Unlock()
+
+	return pc.serve(conn)
+}
+
+// Close implements common.Closable.
+func (s *Server) Close() error {
+	s.h3Access.Lock()
+	defer s.h3Access.Unlock()
+
+	if s.h3 == nil {
+		return nil
+	}
+	err := s.h3.server.Close()
+	s.h3.conn.Close()
+	common.Close(s.h3.acme)
+	s.h3 = nil
+	return err
+}
+
+type packet struct {
+	payload *buf.Buffer
+	source  net.Addr
+}
+
+// packetConn joins the connections the inbound makes for every UDP source
+// into the net.PacketConn the QUIC listener needs.
+type packetConn struct {
+	local   net.Addr
+	packets chan packet
+	conns   sync.Map
+	done    *done.Instance
+}
+
+func newPacketConn(local net.Addr) *packetConn {
+	return &packetConn{
+		local:   local,
+		packets: make(chan packet, 256),
+		done:    done.New(),
+	}
+}
+
+// serve reads the packets of conn until it is closed, and writes packets
+// for its source to it meanwhile.
+func (c *packetConn) serve(conn stat.Connection) error {
+	source := conn.RemoteAddr()
+	key := source.String()
+	c.conns.Store(key, conn)
+	defer c.conns.CompareAndDelete(key, conn)
+
+	reader := buf.NewPacketReader(conn)
+	for {
+		mb, err := reader.ReadMultiBuffer()
+		if err != nil {
+			return err
+		}
+		for i, b := range mb {
+			select {
+			case c.packets <- packet{payload: b, source: source}:
+			case <-c.done.Wait():
+				buf.ReleaseMulti(mb[i:])
+				return nil
+			default:
+				// drop packets when the QUIC listener falls behind
+				b.Release()
+			}
+		}
+	}
+}
+
+func (c *packetConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	select {
+	case pkt := <-c.packets:
+		n := copy(p, pkt.payload.Bytes())
+		pkt.payload.Release()
+		return n, pkt.source, nil
+	case <-c.done.Wait():
+		return 0, nil, gonet.ErrClosed
+	}
+}
+
+func (c *packetConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	conn, ok := c.conns.Load(addr.String())
+	if !ok {
+		// the source has gone away, like a lost packet to QUIC
+		return len(p), nil
+	}
+	return conn.(stat.Connection).Write(p)
+}
+
+func (c *packetConn) Close() error {
+	return c.done.Close()
+}
+
+func (c *packetConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (*packetConn) SetDeadline(time.Time) error {
+	return nil
+}
+
+func (*packetConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (*packetConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+func (*packetConn) SetReadBuffer(int) error {
+	// do nothing, this function is only there to suppress quic-go printing
+	// random warnings about UDP buffers to stdout
+	return nil
+}
@@ -0,0 +1,277 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/quic-go/quic-go/http3"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	c "github.com/xtls/xray-core/common/ctx"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/log"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/protocol"
+	http_proto "github.com/xtls/xray-core/common/protocol/http"
+	udp_proto "github.com/xtls/xray-core/common/protocol/udp"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/common/signal"
+	"github.com/xtls/xray-core/common/task"
+	"github.com/xtls/xray-core/features/policy"
+	"github.com/xtls/xray-core/features/routing"
+	"github.com/xtls/xray-core/transport/internet/stat"
+	"github.com/xtls/xray-core/transport/internet/udp"
+	"golang.org/x/net/http2"
+)
+
+// tunnelHandler serves CONNECT and CONNECT-UDP requests of an HTTP/2 or an
+// HTTP/3 connection, each request is a session of its own.
+type tunnelHandler struct {
+	server     *Server
+	dispatcher routing.Dispatcher
+	// ctx carries the session of the connection, or of the UDP port for HTTP/3.
+	ctx     context.Context
+	network net.Network
+}
+
+// processH2 serves a TLS connection which negotiated h2. CONNECT-UDP comes
+// in extended CONNECT requests (RFC 8441), which golang.org/x/net/http2 only
+// accepts with GODEBUG=http2xconnect=1.
+func (s *Server) processH2(ctx context.Context, conn stat.Connection, dispatcher routing.Dispatcher) error {
+	if !http_proto.ExtendedConnectEnabled() {
+		s.xconnectWarning.Do(func() {
+			errors.LogWarning(ctx, "CONNECT-UDP over HTTP/2 is refused without GODEBUG=http2xconnect=1 in the environment")
+		})
+	}
+	h2s := &http2.Server{
+		IdleTimeout: net.ConnIdleTimeout,
+	}
+	h2s.ServeConn(conn, &http2.ServeConnOpts{
+		Context: ctx,
+		Handler: &tunnelHandler{
+			server:     s,
+			dispatcher: dispatcher,
+			ctx:        ctx,
+			network:    net.Network_TCP,
+		},
+	})
+	return nil
+}
+
+func (h *tunnelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s := h.server
+	conn := session.InboundFromContext(h.ctx)
+	inbound := &session.Inbound{
+		Source:        conn.Source,
+		Local:         conn.Local,
+		Gateway:       conn.Gateway,
+		Tag:           conn.Tag,
+		Name:          "http",
+		Conn:          conn.Conn,
+		CanSpliceCopy: 3,
+		User: &protocol.MemoryUser{
+			Level: s.config.UserLevel,
+		},
+	}
+	if h.network == net.Network_UDP {
+		if source, err := net.ParseDestination("udp:" + r.RemoteAddr); err == nil {
+			inbound.Source = source
+		}
+	}
+
+	ctx, cancel := context.WithCancel(session.ContextCloneOutboundsAndContent(h.ctx))
+	defer cancel()
+	defer context.AfterFunc(r.Context(), cancel)()
+	ctx = c.ContextWithID(ctx, session.NewID())
+	ctx = session.ContextWithInbound(ctx, inbound)
+
+	if s.authRequired.Load() {
+		user, pass, ok := parseBasicAuth(r.Header.Get("Proxy-Authorization"))
+		var u *protocol.MemoryUser
+		if ok {
+			u = s.validator.Get(user, pass)
+		}
+		if u == nil {
+			w.Header().Set("Proxy-Authenticate", "Basic realm=\"proxy\"")
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		inbound.User = u
+	}
+
+	if r.Method != http.MethodConnect {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var err error
+	switch protocol := extendedConnectProtocol(r); protocol {
+	case "":
+		err = h.handleConnect(ctx, w, r, inbound)
+	case "connect-udp":
+		err = h.handleConnectUDP(ctx, w, r, inbound)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+		err = errors.New("unsupported extended CONNECT protocol: ", protocol)
+	}
+	if err != nil {
+		errors.LogInfoInner(ctx, err, "connection ends")
+	}
+}
+
+// extendedConnectProtocol returns the :protocol pseudo header of r.
+func extendedConnectProtocol(r *http.Request) string {
+	if r.ProtoMajor == 3 {
+		if r.Proto == "HTTP/3.0" {
+			return ""
+		}
+		return r.Proto
+	}
+	return r.Header.Get(":protocol")
+}
+
+func (h *tunnelHandler) handleConnect(ctx context.Context, w http.ResponseWriter, r *http.Request, inbound *session.Inbound) error {
+	dest, err := http_proto.ParseHost(r.Host, net.Port(443))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return errors.New("malformed proxy host: ", r.Host).AtWarning().Base(err)
+	}
+	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
+		From:   inbound.Source,
+		To:     dest,
+		Status: log.AccessAccepted,
+		Reason: "",
+		Email:  inbound.User.Email,
+	})
+
+	rc := http.NewResponseController(w)
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return errors.New("failed to write back OK response").Base(err)
+	}
+
+	plcy := h.server.policy(inbound.User.Level)
+	ctx, cancel := context.WithCancel(ctx)
+	timer := signal.CancelAfterInactivity(ctx, cancel, plcy.Timeouts.ConnectionIdle)
+	inbound.Timer = timer
+
+	ctx = policy.ContextWithBufferPolicy(ctx, plcy.Buffer)
+	link, err := h.dispatcher.Dispatch(ctx, dest)
+	if err != nil {
+		return err
+	}
+
+	requestDone := func() error {
+		defer timer.SetTimeout(plcy.Timeouts.DownlinkOnly)
+
+		return buf.Copy(buf.NewReader(r.Body), link.Writer, buf.UpdateActivity(timer))
+	}
+
+	responseDone := func() error {
+		defer timer.SetTimeout(plcy.Timeouts.UplinkOnly)
+
+		return buf.Copy(link.Reader, buf.NewWriter(&flushWriter{w: w, rc: rc}), buf.UpdateActivity(timer))
+	}
+
+	closeWriter := task.OnSuccess(requestDone, task.Close(link.Writer))
+	if err := task.Run(ctx, closeWriter, responseDone); err != nil {
+		common.Interrupt(link.Reader)
+		common.Interrupt(link.Writer)
+		return errors.New("connection ends").Base(err)
+	}
+
+	return nil
+}
+
+// handleConnectUDP relays the UDP payloads of a CONNECT-UDP request like a
+// SOCKS UDP association to its fixed target.
+func (h *tunnelHandler) handleConnectUDP(ctx context.Context, w http.ResponseWriter, r *http.Request, inbound *session.Inbound) error {
+	dest, err := parseMasqueUDPPath(r.URL.Path)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return err
+	}
+	ctx = log.ContextWithAccessMessage(ctx, &log.AccessMessage{
+		From:   inbound.Source,
+		To:     dest,
+		Status: log.AccessAccepted,
+		Reason: "",
+		Email:  inbound.User.Email,
+	})
+
+	w.Header().Set(http3.CapsuleProtocolHeader, "?1")
+	w.WriteHeader(http.StatusOK)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var conn datagramConn
+	if streamer, ok := w.(http3.HTTPStreamer); ok {
+		str := streamer.HTTPStream()
+		defer str.Close()
+		// datagrams are not on the stream, so reading it only tells when the client closes it
+		go func() {
+			io.Copy(io.Discard, str)
+			cancel()
+		}()
+		conn = &h3DatagramConn{str: str}
+	} else {
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			return errors.New("failed to write back OK response").Base(err)
+		}
+		conn = newCapsuleDatagramConn(ctx, r.Body, w)
+	}
+
+	plcy := h.server.policy(inbound.User.Level)
+	timer := signal.CancelAfterInactivity(ctx, cancel, plcy.Timeouts.ConnectionIdle)
+	inbound.Timer = timer
+
+	udpServer := udp.NewDispatcher(h.dispatcher, func(ctx context.Context, packet *udp_proto.Packet) {
+		payload := packet.Payload
+		errors.LogDebug(ctx, "writing back UDP response with ", payload.Len(), " bytes")
+
+		datagram := encodeUDPDatagram(payload.Bytes())
+		payload.Release()
+		if err := conn.WriteDatagram(datagram); err != nil {
+			errors.LogInfoInner(ctx, err, "failed to write UDP response")
+			return
+		}
+		timer.Update()
+	})
+	defer udpServer.RemoveRay()
+
+	errors.LogInfo(ctx, "client CONNECT-UDP to ", dest, " from ", inbound.Source)
+
+	for {
+		datagram, err := conn.ReadDatagram(ctx)
+		if err != nil {
+			return err
+		}
+		data, ok := decodeUDPDatagram(datagram)
+		if !ok || len(data) == 0 || len(data) > buf.Size {
+			continue
+		}
+		timer.Update()
+
+		payload := buf.New()
+		payload.Write(data)
+		payload.UDP = &dest
+		udpServer.Dispatch(ctx, dest, payload)
+	}
+}
+
+// flushWriter flushes every write, so tunnelled data is not held back in
+// the buffers of the HTTP/2 or HTTP/3 server.
+type flushWriter struct {
+	w  io.Writer
+	rc *http.ResponseController
+}
+
+func (f *flushWriter) Write(b []byte) (int, error) {
+	n, err := f.w.Write(b)
+	if err != nil {
+		return n, err
+	}
+	return n, f.rc.Flush()
+}
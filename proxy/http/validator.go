@@ -0,0 +1,113 @@
+package http
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/protocol"
+)
+
+// passwordAccount is implemented by HTTP and SOCKS accounts.
+type passwordAccount interface {
+	GetUsername() string
+	GetPassword() string
+}
+
+// Validator stores valid HTTP and SOCKS users, it is shared by a SOCKS inbound
+// with the HTTP server it falls back to.
+type Validator struct {
+	email sync.Map
+	users sync.Map
+	count atomic.Int64
+}
+
+// Add a user, Username must be unique, Email must be empty or unique.
+func (v *Validator) Add(u *protocol.MemoryUser) error {
+	account, ok := u.Account.(passwordAccount)
+	if !ok {
+		return errors.New("account is not a username/password account")
+	}
+	if u.Email != "" {
+		if _, loaded := v.email.LoadOrStore(strings.ToLower(u.Email), u); loaded {
+			return errors.New("User ", u.Email, " already exists.")
+		}
+	}
+	if _, loaded := v.users.LoadOrStore(account.GetUsername(), u); loaded {
+		if u.Email != "" {
+			v.email.Delete(strings.ToLower(u.Email))
+		}
+		return errors.New("Username ", account.GetUsername(), " already exists.")
+	}
+	v.count.Add(1)
+	return nil
+}
+
+// AddAccounts adds the users of the deprecated accounts map of a server config,
+// account builds the Account of each, whose Email is its username.
+func (v *Validator) AddAccounts(accounts map[string]string, level uint32, account func(username, password string) protocol.Account) error {
+	for username, password := range accounts {
+		u := &protocol.MemoryUser{
+			Email:   username,
+			Level:   level,
+			Account: account(username, password),
+		}
+		if err := v.Add(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Del a user with a non-empty Email.
+func (v *Validator) Del(e string) error {
+	if e == "" {
+		return errors.New("Email must not be empty.")
+	}
+	le := strings.ToLower(e)
+	u, _ := v.email.LoadAndDelete(le)
+	if u == nil {
+		return errors.New("User ", e, " not found.")
+	}
+	v.users.Delete(u.(*protocol.MemoryUser).Account.(passwordAccount).GetUsername())
+	v.count.Add(-1)
+	return nil
+}
+
+// Get a user with matching username and password, nil if there is none.
+func (v *Validator) Get(username, password string) *protocol.MemoryUser {
+	u, _ := v.users.Load(username)
+	if u == nil {
+		return nil
+	}
+	user := u.(*protocol.MemoryUser)
+	if user.Account.(passwordAccount).GetPassword() != password {
+		return nil
+	}
+	return user
+}
+
+// GetByEmail gets a user with email, nil if user doesn't exist.
+func (v *Validator) GetByEmail(email string) *protocol.MemoryUser {
+	u, _ := v.email.Load(strings.ToLower(email))
+	if u != nil {
+		return u.(*protocol.MemoryUser)
+	}
+	return nil
+}
+
+// GetAll gets all users.
+func (v *Validator) GetAll() []*protocol.MemoryUser {
+	u := make([]*protocol.MemoryUser, 0, v.count.Load())
+	v.users.Range(func(key, value interface{}) bool {
+		u = append(u, value.(*protocol.MemoryUser))
+		return true
+	})
+	return u
+}
+
+// GetCount gets users count.
+func (v *Validator) GetCount() int64 {
+	return v.count.Load()
+}
@@ -0,0 +1,63 @@
+package http_test
+
+import (
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/protocol"
+	. "github.com/xtls/xray-core/proxy/http"
+)
+
+func TestValidator(t *testing.T) {
+	v := new(Validator)
+	common.Must(v.Add(&protocol.MemoryUser{
+		Email:   "a@example.com",
+		Level:   1,
+		Account: &Account{Username: "a", Password: "pa"},
+	}))
+	common.Must(v.Add(&protocol.MemoryUser{
+		Account: &Account{Username: "b", Password: "pb"},
+	}))
+
+	if err := v.Add(&protocol.MemoryUser{Email: "c@example.com", Account: &Account{Username: "a", Password: "x"}}); err == nil {
+		t.Error("expected error for duplicated username")
+	}
+	if v.GetByEmail("c@example.com") != nil {
+		t.Error("rejected user should not be stored")
+	}
+	if v.GetCount() != 2 {
+		t.Error("unexpected users count ", v.GetCount())
+	}
+
+	if u := v.Get("a", "pa"); u == nil || u.Email != "a@example.com" || u.Level != 1 {
+		t.Error("failed to get user a: ", u)
+	}
+	if v.Get("a", "pb") != nil {
+		t.Error("wrong password accepted")
+	}
+
+	common.Must(v.Del("A@example.com"))
+	if v.Get("a", "pa") != nil {
+		t.Error("removed user accepted")
+	}
+	if v.GetCount() != 1 || len(v.GetAll()) != 1 {
+		t.Error("unexpected users after removal")
+	}
+}
+
+func TestValidatorAddAccounts(t *testing.T) {
+	v := new(Validator)
+	common.Must(v.AddAccounts(map[string]string{"a": "pa", "b": "pb"}, 2, func(username, password string) protocol.Account {
+		return &Account{Username: username, Password: password}
+	}))
+
+	if v.GetCount() != 2 {
+		t.Error("unexpected users count ", v.GetCount())
+	}
+	if u := v.Get("a", "pa"); u == nil || u.Email != "a" || u.Level != 2 {
+		t.Error("failed to get account a: ", u)
+	}
+	if v.Get("b", "pa") != nil {
+		t.Error("wrong password accepted")
+	}
+}
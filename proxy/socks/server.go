@@ -31,6 +31,7 @@ type Server struct {
 	cone          bool
 	udpFilter     *UDPFilter
 	httpServer    *http.Server
+	validator     *http.Validator
 }
 
 // NewServer creates a new Server object.
@@ -40,22 +41,70 @@ func NewServer(ctx context.Context, config *ServerConfig) (*Server, error) {
 		config:        config,
 		policyManager: v.GetFeature(policy.ManagerType()).(policy.Manager),
 		cone:          ctx.Value("cone").(bool),
+		validator:     new(http.Validator),
+	}
+	for _, user := range config.Users {
+		u, err := user.ToMemoryUser()
+		if err != nil {
+			return nil, errors.New("failed to get SOCKS user").Base(err).AtError()
+		}
+		if err := s.validator.Add(u); err != nil {
+			return nil, errors.New("failed to add user").Base(err).AtError()
+		}
+	}
+	if err := s.validator.AddAccounts(config.Accounts, config.UserLevel, func(username, password string) protocol.Account {
+		return &Account{Username: username, Password: password}
+	}); err != nil {
+		return nil, errors.New("failed to add account").Base(err).AtError()
 	}
 	httpConfig := &http.ServerConfig{
 		UserLevel: config.UserLevel,
 	}
 	if config.AuthType == AuthType_PASSWORD {
-		httpConfig.Accounts = config.Accounts
+		s.httpServer = http.NewServerWithValidator(ctx, httpConfig, s.validator)
 		s.udpFilter = new(UDPFilter) // We only use this when auth is enabled
+	} else {
+		s.httpServer, _ = http.NewServer(ctx, httpConfig)
 	}
-	s.httpServer, _ = http.NewServer(ctx, httpConfig)
 	return s, nil
 }
 
-func (s *Server) policy() policy.Session {
-	config := s.config
-	p := s.policyManager.ForLevel(config.UserLevel)
-	return p
+// AddUser implements proxy.UserManager.AddUser().
+func (s *Server) AddUser(ctx context.Context, u *protocol.MemoryUser) error {
+	if s.config.AuthType != AuthType_PASSWORD {
+		return errors.New("users of a SOCKS inbound without password authentication would not be authenticated")
+	}
+	return s.validator.Add(u)
+}
+
+// RemoveUser implements proxy.UserManager.RemoveUser().
+func (s *Server) RemoveUser(ctx context.Context, e string) error {
+	if err := s.validator.Del(e); err != nil {
+		return err
+	}
+	if s.udpFilter != nil {
+		s.udpFilter.RemoveUser(e)
+	}
+	return nil
+}
+
+// GetUser implements proxy.UserManager.GetUser().
+func (s *Server) GetUser(ctx context.Context, email string) *protocol.MemoryUser {
+	return s.validator.GetByEmail(email)
+}
+
+// GetUsers implements proxy.UserManager.GetUsers().
+func (s *Server) GetUsers(ctx context.Context) []*protocol.MemoryUser {
+	return s.validator.GetAll()
+}
+
+// GetUsersCount implements proxy.UserManager.GetUsersCount().
+func (s *Server) GetUsersCount(context.Context) int64 {
+	return s.validator.GetCount()
+}
+
+func (s *Server) policy(level uint32) policy.Session {
+	return s.policyManager.ForLevel(level)
 }
 
 // Network implements proxy.Inbound.
@@ -99,7 +148,7 @@ func (s *Server) Process(ctx context.Context, network net.Network, conn stat.Con
 }
 
 func (s *Server) processTCP(ctx context.Context, conn stat.Connection, dispatcher routing.Dispatcher, firstbyte []byte) error {
-	plcy := s.policy()
+	plcy := s.policy(s.config.UserLevel)
 	if err := conn.SetReadDeadline(time.Now().Add(plcy.Timeouts.Handshake)); err != nil {
 		errors.LogInfoInner(ctx, err, "failed to set deadline")
 	}
@@ -111,6 +160,7 @@ func (s *Server) processTCP(ctx context.Context, conn stat.Connection, dispatche
 
 	svrSession := &ServerSession{
 		config:       s.config,
+		validator:    s.validator,
 		address:      inbound.Gateway.Address,
 		port:         inbound.Gateway.Port,
 		localAddress: net.IPAddress(conn.LocalAddr().(*net.TCPAddr).IP),
@@ -136,7 +186,7 @@ func (s *Server) processTCP(ctx context.Context, conn stat.Connection, dispatche
 		return errors.New("failed to read request").Base(err)
 	}
 	if request.User != nil {
-		inbound.User.Email = request.User.Email
+		inbound.User = request.User
 	}
 
 	if err := conn.SetReadDeadline(time.Time{}); err != nil {
@@ -160,7 +210,7 @@ func (s *Server) processTCP(ctx context.Context, conn stat.Connection, dispatche
 
 	if request.Command == protocol.RequestCommandUDP {
 		if s.udpFilter != nil {
-			s.udpFilter.Add(conn.RemoteAddr())
+			s.udpFilter.Add(conn.RemoteAddr(), request.User)
 		}
 		return s.handleUDP(conn)
 	}
@@ -175,14 +225,14 @@ func (*Server) handleUDP(c io.Reader) error {
 }
 
 func (s *Server) transport(ctx context.Context, reader io.Reader, writer io.Writer, dest net.Destination, dispatcher routing.Dispatcher, inbound *session.Inbound) error {
+	plcy := s.policy(inbound.User.Level)
 	ctx, cancel := context.WithCancel(ctx)
-	timer := signal.CancelAfterInactivity(ctx, cancel, s.policy().Timeouts.ConnectionIdle)
+	timer := signal.CancelAfterInactivity(ctx, cancel, plcy.Timeouts.ConnectionIdle)
 
 	if inbound != nil {
 		inbound.Timer = timer
 	}
 
-	plcy := s.policy()
 	ctx = policy.ContextWithBufferPolicy(ctx, plcy.Buffer)
 	link, err := dispatcher.Dispatch(ctx, dest)
 	if err != nil {
@@ -221,9 +271,15 @@ func (s *Server) transport(ctx context.Context, reader io.Reader, writer io.Writ
 }
 
 func (s *Server) handleUDPPayload(ctx context.Context, conn stat.Connection, dispatcher routing.Dispatcher) error {
-	if s.udpFilter != nil && !s.udpFilter.Check(conn.RemoteAddr()) {
-		errors.LogDebug(ctx, "Unauthorized UDP access from ", conn.RemoteAddr().String())
-		return nil
+	if s.udpFilter != nil {
+		user, ok := s.udpFilter.Check(conn.RemoteAddr())
+		if !ok {
+			errors.LogDebug(ctx, "Unauthorized UDP access from ", conn.RemoteAddr().String())
+			return nil
+		}
+		if user != nil {
+			session.InboundFromContext(ctx).User = user
+		}
 	}
 	udpServer := udp.NewDispatcher(dispatcher, func(ctx context.Context, packet *udp_proto.Packet) {
 		payload := packet.Payload
@@ -2,7 +2,10 @@ package socks
 
 import (
 	"net"
+	"strings"
 	"sync"
+
+	"github.com/xtls/xray-core/common/protocol"
 )
 
 /*
@@ -11,6 +14,9 @@ Tracking a UDP connection may be a bit troublesome.
 Here is a simple solution.
 We create a filter, add remote IP to the pool when it try to establish a UDP connection with auth.
 And drop UDP packets from unauthorized IP.
+The user who authenticated from the IP is remembered for stats. As packets are
+attributed by source IP only, users behind the same address share the user of
+the latest association, and entries of a removed user are dropped with it.
 After discussion, we believe it is not necessary to add a timeout mechanism to this filter.
 */
 
@@ -18,14 +24,28 @@ type UDPFilter struct {
 	ips sync.Map
 }
 
-func (f *UDPFilter) Add(addr net.Addr) bool {
+func (f *UDPFilter) Add(addr net.Addr, user *protocol.MemoryUser) bool {
 	ip, _, _ := net.SplitHostPort(addr.String())
-	f.ips.Store(ip, true)
+	f.ips.Store(ip, user)
 	return true
 }
 
-func (f *UDPFilter) Check(addr net.Addr) bool {
+func (f *UDPFilter) Check(addr net.Addr) (*protocol.MemoryUser, bool) {
 	ip, _, _ := net.SplitHostPort(addr.String())
-	_, ok := f.ips.Load(ip)
-	return ok
+	user, ok := f.ips.Load(ip)
+	if !ok {
+		return nil, false
+	}
+	return user.(*protocol.MemoryUser), true
+}
+
+// RemoveUser drops the IPs authenticated by the user with email, so their
+// packets are no longer accepted.
+func (f *UDPFilter) RemoveUser(email string) {
+	f.ips.Range(func(ip, user interface{}) bool {
+		if strings.EqualFold(user.(*protocol.MemoryUser).Email, email) {
+			f.ips.Delete(ip)
+		}
+		return true
+	})
 }
@@ -0,0 +1,25 @@
+package socks_test
+
+import (
+	gonet "net"
+	"testing"
+
+	"github.com/xtls/xray-core/common/protocol"
+	. "github.com/xtls/xray-core/proxy/socks"
+)
+
+func TestUDPFilterRemoveUser(t *testing.T) {
+	f := new(UDPFilter)
+	alice := &gonet.TCPAddr{IP: gonet.IPv4(10, 0, 0, 1), Port: 1000}
+	bob := &gonet.TCPAddr{IP: gonet.IPv4(10, 0, 0, 2), Port: 1000}
+	f.Add(alice, &protocol.MemoryUser{Email: "alice@example.com"})
+	f.Add(bob, &protocol.MemoryUser{Email: "bob@example.com"})
+
+	f.RemoveUser("Alice@example.com")
+	if _, ok := f.Check(&gonet.UDPAddr{IP: alice.IP, Port: 2000}); ok {
+		t.Error("packets of a removed user are accepted")
+	}
+	if user, ok := f.Check(&gonet.UDPAddr{IP: bob.IP, Port: 2000}); !ok || user.Email != "bob@example.com" {
+		t.Error("user of bob: ", user)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	xnet "github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/net/cnc"
 	"github.com/xtls/xray-core/common/signal/done"
+	"github.com/xtls/xray-core/transport/internet/tls"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 )
@@ -70,9 +72,21 @@ func NewHunkConn(hc HunkConn, cancel context.CancelFunc) net.Conn {
 		cnc.ConnectionOutput(wrc),
 		cnc.ConnectionOnClose(wrc),
 		cnc.ConnectionRemoteAddr(rAddr),
+		cnc.ConnectionPeerIdentity(peerIdentity(hc.Context())),
 	)
 }
 
+// peerIdentity returns the identity in the verified TLS client certificate of
+// a server stream.
+func peerIdentity(ctx context.Context) string {
+	if pr, ok := peer.FromContext(ctx); ok {
+		if info, ok := pr.AuthInfo.(credentials.TLSInfo); ok {
+			return tls.PeerIdentity(&info.State)
+		}
+	}
+	return ""
+}
+
 func (h *HunkReaderWriter) forceFetch() error {
 	hunk, err := h.hc.Recv()
 	if err != nil {
@@ -13,8 +13,12 @@ import (
 	"github.com/xtls/xray-core/transport/internet/reality"
 	"github.com/xtls/xray-core/transport/internet/tls"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 )
 
 type Listener struct {
@@ -24,7 +28,9 @@ type Listener struct {
 	local   net.Addr
 	config  *Config
 
-	s *grpc.Server
+	s      *grpc.Server
+	health *health.Server
+	acme   common.Closable
 }
 
 func (l Listener) Tun(server encoding.GRPCService_TunServer) error {
@@ -42,8 +48,11 @@ func (l Listener) TunMulti(server encoding.GRPCService_TunMultiServer) error {
 }
 
 func (l Listener) Close() error {
+	if l.health != nil {
+		l.health.Shutdown()
+	}
 	l.s.Stop()
-	return nil
+	return common.Close(l.acme)
 }
 
 func (l Listener) Addr() net.Addr {
@@ -52,6 +61,9 @@ func (l Listener) Addr() net.Addr {
 
 func Listen(ctx context.Context, address net.Address, port net.Port, settings *internet.MemoryStreamConfig, handler internet.ConnHandler) (internet.Listener, error) {
 	grpcSettings := settings.ProtocolSettings.(*Config)
+	if grpcSettings.UnknownMethodCode > uint32(codes.Unauthenticated) {
+		return nil, errors.New("invalid gRPC status code of unknown methods: ", grpcSettings.UnknownMethodCode)
+	}
 	var listener *Listener
 	if port == net.Port(0) { // unix
 		listener = &Listener{
@@ -80,8 +92,13 @@ func Listen(ctx context.Context, address net.Address, port net.Port, settings *i
 	var options []grpc.ServerOption
 	var s *grpc.Server
 	if config != nil {
+		tlsConfig, acme, err := config.GetServerTLSConfig(tls.WithNextProto("h2"))
+		if err != nil {
+			return nil, err
+		}
+		listener.acme = acme
 		// gRPC server may silently ignore TLS errors
-		options = append(options, grpc.Creds(credentials.NewTLS(config.GetTLSConfig(tls.WithNextProto("h2")))))
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
 	}
 	if grpcSettings.IdleTimeout > 0 || grpcSettings.HealthCheckTimeout > 0 {
 		options = append(options, grpc.KeepaliveParams(keepalive.ServerParameters{
@@ -90,43 +107,62 @@ func Listen(ctx context.Context, address net.Address, port net.Port, settings *i
 		}))
 	}
 
+	if grpcSettings.UnknownMethodCode != 0 || grpcSettings.UnknownMethodMessage != "" {
+		code := codes.Code(grpcSettings.UnknownMethodCode)
+		if code == codes.OK {
+			code = codes.Unimplemented
+		}
+		options = append(options, grpc.UnknownServiceHandler(func(any, grpc.ServerStream) error {
+			return status.Error(code, grpcSettings.UnknownMethodMessage)
+		}))
+	}
+
 	s = grpc.NewServer(options...)
 	listener.s = s
+	if grpcSettings.HealthService {
+		listener.health = health.NewServer()
+		grpc_health_v1.RegisterHealthServer(s, listener.health)
+	}
 
 	if settings.SocketSettings != nil && settings.SocketSettings.AcceptProxyProtocol {
 		errors.LogWarning(ctx, "accepting PROXY protocol")
 	}
 
-	go func() {
-		var streamListener net.Listener
-		var err error
-		if port == net.Port(0) { // unix
-			streamListener, err = internet.ListenSystem(ctx, &net.UnixAddr{
-				Name: address.Domain(),
-				Net:  "unix",
-			}, settings.SocketSettings)
-			if err != nil {
-				errors.LogErrorInner(ctx, err, "failed to listen on ", address)
-				return
-			}
-		} else { // tcp
-			streamListener, err = internet.ListenSystem(ctx, &net.TCPAddr{
-				IP:   address.IP(),
-				Port: int(port),
-			}, settings.SocketSettings)
-			if err != nil {
-				errors.LogErrorInner(ctx, err, "failed to listen on ", address, ":", port)
-				return
-			}
+	var streamListener net.Listener
+	var err error
+	if port == net.Port(0) { // unix
+		streamListener, err = internet.ListenSystem(ctx, &net.UnixAddr{
+			Name: address.Domain(),
+			Net:  "unix",
+		}, settings.SocketSettings)
+		if err != nil {
+			listener.Close()
+			return nil, errors.New("failed to listen on ", address).Base(err)
+		}
+	} else { // tcp
+		streamListener, err = internet.ListenSystem(ctx, &net.TCPAddr{
+			IP:   address.IP(),
+			Port: int(port),
+		}, settings.SocketSettings)
+		if err != nil {
+			listener.Close()
+			return nil, errors.New("failed to listen on ", address, ":", port).Base(err)
 		}
+	}
 
-		errors.LogDebug(ctx, "gRPC listen for service name `"+grpcSettings.getServiceName()+"` tun `"+grpcSettings.getTunStreamName()+"` multi tun `"+grpcSettings.getTunMultiStreamName()+"`")
-		encoding.RegisterGRPCServiceServerX(s, listener, grpcSettings.getServiceName(), grpcSettings.getTunStreamName(), grpcSettings.getTunMultiStreamName())
+	errors.LogDebug(ctx, "gRPC listen for service name `"+grpcSettings.getServiceName()+"` tun `"+grpcSettings.getTunStreamName()+"` multi tun `"+grpcSettings.getTunMultiStreamName()+"`")
+	encoding.RegisterGRPCServiceServerX(s, listener, grpcSettings.getServiceName(), grpcSettings.getTunStreamName(), grpcSettings.getTunMultiStreamName())
+	if listener.health != nil {
+		// the empty name, the server as a whole, is serving since it is
+		// created, and both are not serving once the listener is closed
+		listener.health.SetServingStatus(grpcSettings.getServiceName(), grpc_health_v1.HealthCheckResponse_SERVING)
+	}
 
-		if config := reality.ConfigFromStreamSettings(settings); config != nil {
-			streamListener = goreality.NewListener(streamListener, config.GetREALITYConfig())
-		}
-		if err = s.Serve(streamListener); err != nil {
+	if config := reality.ConfigFromStreamSettings(settings); config != nil {
+		streamListener = goreality.NewListener(streamListener, config.GetREALITYConfig())
+	}
+	go func() {
+		if err := s.Serve(streamListener); err != nil {
 			errors.LogInfoInner(ctx, err, "Listener for gRPC ended")
 		}
 	}()
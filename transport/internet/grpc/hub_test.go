@@ -0,0 +1,90 @@
+package grpc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/testing/servers/tcp"
+	"github.com/xtls/xray-core/transport/internet"
+	. "github.com/xtls/xray-core/transport/internet/grpc"
+	"github.com/xtls/xray-core/transport/internet/stat"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestHealthServiceAndUnknownMethod(t *testing.T) {
+	listenPort := tcp.PickPort()
+	listener, err := Listen(context.Background(), net.LocalHostIP, listenPort, &internet.MemoryStreamConfig{
+		ProtocolName: "grpc",
+		ProtocolSettings: &Config{
+			ServiceName:          "tunnel",
+			HealthService:        true,
+			UnknownMethodCode:    uint32(codes.NotFound),
+			UnknownMethodMessage: "not found",
+		},
+	}, func(conn stat.Connection) {
+		conn.Close()
+	})
+	common.Must(err)
+	defer listener.Close()
+
+	conn, err := grpc.NewClient(net.LocalHostIP.String()+":"+listenPort.String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	common.Must(err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := grpc_health_v1.NewHealthClient(conn)
+	for _, service := range []string{"", "tunnel"} {
+		response, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: service}, grpc.WaitForReady(true))
+		if err != nil {
+			t.Fatal("health check of ", service, ": ", err)
+		}
+		if response.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+			t.Error("status of ", service, ": ", response.Status)
+		}
+	}
+
+	err = conn.Invoke(ctx, "/foo.Bar/Baz", &emptypb.Empty{}, &emptypb.Empty{})
+	if s := status.Convert(err); s.Code() != codes.NotFound || s.Message() != "not found" {
+		t.Error("status of unknown method: ", s)
+	}
+}
+
+func TestListenInvalidUnknownMethodCode(t *testing.T) {
+	_, err := Listen(context.Background(), net.LocalHostIP, tcp.PickPort(), &internet.MemoryStreamConfig{
+		ProtocolName: "grpc",
+		ProtocolSettings: &Config{
+			UnknownMethodCode: uint32(codes.Unauthenticated) + 1,
+		},
+	}, func(conn stat.Connection) {
+		conn.Close()
+	})
+	if err == nil {
+		t.Error("listened with an invalid unknown method code")
+	}
+}
+
+func TestListenFailure(t *testing.T) {
+	// an address of no local interface
+	_, err := Listen(context.Background(), net.ParseAddress("192.0.2.1"), tcp.PickPort(), &internet.MemoryStreamConfig{
+		ProtocolName: "grpc",
+		ProtocolSettings: &Config{
+			ServiceName:   "tunnel",
+			HealthService: true,
+		},
+	}, func(conn stat.Connection) {
+		conn.Close()
+	})
+	if err == nil {
+		t.Error("listened on an address of no local interface")
+	}
+}
@@ -4,16 +4,23 @@ import "net"
 
 type connection struct {
 	net.Conn
-	remoteAddr net.Addr
+	remoteAddr   net.Addr
+	peerIdentity string
 }
 
-func newConnection(conn net.Conn, remoteAddr net.Addr) *connection {
+func newConnection(conn net.Conn, remoteAddr net.Addr, peerIdentity string) *connection {
 	return &connection{
-		Conn:       conn,
-		remoteAddr: remoteAddr,
+		Conn:         conn,
+		remoteAddr:   remoteAddr,
+		peerIdentity: peerIdentity,
 	}
 }
 
 func (c *connection) RemoteAddr() net.Addr {
 	return c.remoteAddr
 }
+
+// PeerIdentity returns the identity in the verified TLS client certificate.
+func (c *connection) PeerIdentity() string {
+	return c.peerIdentity
+}
@@ -20,9 +20,11 @@ type server struct {
 	config         *Config
 	addConn        internet.ConnHandler
 	innnerListener net.Listener
+	acme           common.Closable
 }
 
 func (s *server) Close() error {
+	common.Close(s.acme)
 	return s.innnerListener.Close()
 }
 
@@ -79,7 +81,14 @@ func (s *server) Handle(conn net.Conn) (stat.Connection, error) {
 		}
 	}
 
-	return stat.Connection(newConnection(conn, remoteAddr)), nil
+	var peerIdentity string
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		// the handshake is complete after the request is read
+		state := tlsConn.ConnectionState()
+		peerIdentity = v2tls.PeerIdentity(&state)
+	}
+
+	return stat.Connection(newConnection(conn, remoteAddr, peerIdentity)), nil
 }
 
 func (s *server) keepAccepting() {
@@ -131,16 +140,22 @@ func ListenHTTPUpgrade(ctx context.Context, address net.Address, port net.Port,
 		errors.LogWarning(ctx, "accepting PROXY protocol")
 	}
 
+	var acme common.Closable
 	if config := v2tls.ConfigFromStreamSettings(streamSettings); config != nil {
-		if tlsConfig := config.GetTLSConfig(); tlsConfig != nil {
-			listener = tls.NewListener(listener, tlsConfig)
+		tlsConfig, closable, err := config.GetServerTLSConfig()
+		if err != nil {
+			listener.Close()
+			return nil, err
 		}
+		acme = closable
+		listener = tls.NewListener(listener, tlsConfig)
 	}
 
 	serverInstance := &server{
 		config:         transportConfiguration,
 		addConn:        addConn,
 		innnerListener: listener,
+		acme:           acme,
 	}
 	go serverInstance.keepAccepting()
 	return serverInstance, nil
@@ -2,8 +2,11 @@ package kcp
 
 import (
 	"crypto/cipher"
+	"time"
 
 	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/dice"
+	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/transport/internet"
 )
 
@@ -104,3 +107,33 @@ func init() {
 		return new(Config)
 	}))
 }
+
+// enabled returns whether port hopping is set.
+func (c *PortHopping) enabled() bool {
+	return len(c.GetPorts().GetRange()) > 0
+}
+
+// getInterval returns the time between hops, 30 seconds by default.
+func (c *PortHopping) getInterval() time.Duration {
+	if c.GetInterval() == 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(c.Interval) * time.Second
+}
+
+// randomPort returns one of the ports at random.
+func (c *PortHopping) randomPort() net.Port {
+	total := 0
+	for _, r := range c.Ports.Range {
+		total += int(r.To-r.From) + 1
+	}
+	n := dice.Roll(total)
+	for _, r := range c.Ports.Range {
+		size := int(r.To-r.From) + 1
+		if n < size {
+			return net.Port(int(r.From) + n)
+		}
+		n -= size
+	}
+	return net.Port(c.Ports.Range[0].From)
+}
@@ -0,0 +1,258 @@
+package kcp
+
+// CongestionController decides how many segments a SendingWorker sends.
+type CongestionController interface {
+	// OnSend is called before a segment is sent or resent at current.
+	OnSend(seg *DataSegment, current uint32)
+	// OnAck is called when a sent segment is acknowledged at current.
+	OnAck(seg *DataSegment, current uint32)
+	// OnPacketLoss is called after a flush with the rate of resent segments in percent.
+	OnPacketLoss(lossRate uint32)
+	// Quota returns how many segments may be sent in a flush at current, and
+	// how many of them may be sent for the first time. peerWindow is the
+	// number of segments the peer accepts.
+	Quota(current uint32, peerWindow uint32) (total uint32, fresh uint32)
+}
+
+// NewCongestionController creates the CongestionController of config.
+func NewCongestionController(config *Config, roundTrip *RoundTripInfo) CongestionController {
+	if config.CongestionControl == CongestionControl_BBR {
+		return newBBRController(config)
+	}
+	return &lossBasedController{
+		config:        config,
+		roundTrip:     roundTrip,
+		controlWindow: config.GetSendingInFlightSize(),
+	}
+}
+
+// lossBasedController sends up to the uplink capacity, and if congestion is
+// enabled, shrinks its window on packet loss.
+type lossBasedController struct {
+	config        *Config
+	roundTrip     *RoundTripInfo
+	controlWindow uint32
+}
+
+func (*lossBasedController) OnSend(*DataSegment, uint32) {}
+
+func (*lossBasedController) OnAck(*DataSegment, uint32) {}
+
+func (c *lossBasedController) OnPacketLoss(lossRate uint32) {
+	if !c.config.Congestion || c.roundTrip.Timeout() == 0 {
+		return
+	}
+
+	if lossRate >= 15 {
+		c.controlWindow = 3 * c.controlWindow / 4
+	} else if lossRate <= 5 {
+		c.controlWindow += c.controlWindow / 4
+	}
+	if c.controlWindow < 16 {
+		c.controlWindow = 16
+	}
+	if c.controlWindow > 2*c.config.GetSendingInFlightSize() {
+		c.controlWindow = 2 * c.config.GetSendingInFlightSize()
+	}
+}
+
+func (c *lossBasedController) Quota(current uint32, peerWindow uint32) (uint32, uint32) {
+	cwnd := c.config.GetSendingInFlightSize()
+	if cwnd > peerWindow {
+		cwnd = peerWindow
+	}
+	if c.config.Congestion && cwnd > c.controlWindow {
+		cwnd = c.controlWindow
+	}
+
+	cwnd *= 20 // magic
+	return cwnd, cwnd
+}
+
+const (
+	bbrStartupGain      = 2.885
+	bbrCwndGain         = 2
+	bbrMinWindow        = 4
+	bbrInitialWindow    = 32
+	bbrBandwidthRounds  = 10
+	bbrMinRTTExpiry     = 10000
+	bbrFullBandwidthMul = 1.25
+	bbrFullRounds       = 3
+)
+
+var bbrPacingGains = [...]float64{1.25, 0.75, 1, 1, 1, 1, 1, 1}
+
+type bbrMode int
+
+const (
+	bbrStartup bbrMode = iota
+	bbrDrain
+	bbrProbeBandwidth
+)
+
+// bbrController paces segments at the estimated bottleneck bandwidth, and
+// keeps about a bandwidth-delay product of them in flight, as BBR does. It
+// ignores packet loss, and has no ProbeRTT state: the min RTT expires
+// after 10 seconds, and the next sample replaces it.
+type bbrController struct {
+	tti  uint32
+	mode bbrMode
+
+	inFlight      uint32
+	delivered     uint32
+	deliveredTime uint32
+
+	// bandwidth holds the max delivery rates of recent rounds, in segments
+	// per millisecond. A round is the time for a segment to be acknowledged.
+	bandwidth      [bbrBandwidthRounds]float64
+	round          uint32
+	roundDelivered uint32
+
+	minRTT      uint32
+	minRTTStamp uint32
+
+	fullBandwidth float64
+	fullRounds    int
+
+	cycleIndex int
+	cycleStamp uint32
+
+	lastQuota uint32
+	credit    float64
+}
+
+func newBBRController(config *Config) *bbrController {
+	return &bbrController{
+		tti: config.GetTTIValue(),
+	}
+}
+
+func (c *bbrController) OnSend(seg *DataSegment, current uint32) {
+	if c.inFlight == 0 {
+		c.deliveredTime = current
+	}
+	if seg.transmit == 0 {
+		c.inFlight++
+	}
+	seg.delivered = c.delivered
+	seg.deliveredTime = c.deliveredTime
+	if c.credit >= 1 {
+		c.credit--
+	}
+}
+
+func (c *bbrController) OnAck(seg *DataSegment, current uint32) {
+	if seg.transmit == 0 {
+		return
+	}
+	if c.inFlight > 0 {
+		c.inFlight--
+	}
+	c.delivered++
+	c.deliveredTime = current
+
+	if int32(seg.delivered-c.roundDelivered) >= 0 {
+		c.round++
+		c.roundDelivered = c.delivered
+		c.bandwidth[c.round%bbrBandwidthRounds] = 0
+		c.checkFullBandwidth()
+	}
+	// segments are sent in bursts once in a TTI, which the rate is averaged over
+	if interval := max(current-seg.deliveredTime, c.tti); interval < 0x7FFFFFFF {
+		rate := float64(c.delivered-seg.delivered) / float64(interval)
+		if i := c.round % bbrBandwidthRounds; rate > c.bandwidth[i] {
+			c.bandwidth[i] = rate
+		}
+	}
+
+	if seg.transmit == 1 {
+		rtt := current - seg.Timestamp
+		if rtt < 0x7FFFFFFF && (c.minRTT == 0 || rtt <= c.minRTT || current-c.minRTTStamp > bbrMinRTTExpiry) {
+			c.minRTT = max(rtt, 1)
+			c.minRTTStamp = current
+		}
+	}
+}
+
+func (*bbrController) OnPacketLoss(uint32) {}
+
+// checkFullBandwidth leaves startup when the bandwidth stops growing.
+func (c *bbrController) checkFullBandwidth() {
+	if c.mode != bbrStartup {
+		return
+	}
+	bandwidth := c.maxBandwidth()
+	if bandwidth == 0 {
+		return
+	}
+	if bandwidth >= c.fullBandwidth*bbrFullBandwidthMul {
+		c.fullBandwidth = bandwidth
+		c.fullRounds = 0
+		return
+	}
+	c.fullRounds++
+	if c.fullRounds >= bbrFullRounds {
+		c.mode = bbrDrain
+	}
+}
+
+func (c *bbrController) maxBandwidth() float64 {
+	var bandwidth float64
+	for _, b := range c.bandwidth {
+		bandwidth = max(bandwidth, b)
+	}
+	return bandwidth
+}
+
+func (c *bbrController) Quota(current uint32, peerWindow uint32) (uint32, uint32) {
+	elapsed := c.tti
+	if c.lastQuota != 0 {
+		elapsed = min(current-c.lastQuota, 4*c.tti)
+	}
+	c.lastQuota = current
+
+	var total, window uint32
+	bandwidth := c.maxBandwidth()
+	if bandwidth == 0 || c.minRTT == 0 {
+		total = bbrInitialWindow
+		window = bbrInitialWindow
+	} else {
+		// segments are sent and acknowledged once in a TTI
+		bdp := bandwidth * float64(c.minRTT+c.tti)
+		pacingGain, cwndGain := c.gains(current, bdp)
+		window = max(uint32(cwndGain*bdp), bbrMinWindow)
+		c.credit = min(c.credit+pacingGain*bandwidth*float64(elapsed), float64(window))
+		total = uint32(c.credit)
+	}
+
+	var fresh uint32
+	if window > c.inFlight {
+		fresh = window - c.inFlight
+	}
+	if peerWindow > c.inFlight {
+		fresh = min(fresh, peerWindow-c.inFlight)
+	} else {
+		fresh = 0
+	}
+	return total, fresh
+}
+
+// gains returns the pacing gain and the window gain of the mode at current.
+func (c *bbrController) gains(current uint32, bdp float64) (float64, float64) {
+	switch c.mode {
+	case bbrStartup:
+		return bbrStartupGain, bbrStartupGain
+	case bbrDrain:
+		if float64(c.inFlight) > bdp {
+			return 1 / bbrStartupGain, bbrStartupGain
+		}
+		c.mode = bbrProbeBandwidth
+		c.cycleIndex = 0
+		c.cycleStamp = current
+	}
+	if current-c.cycleStamp >= c.minRTT {
+		c.cycleIndex = (c.cycleIndex + 1) % len(bbrPacingGains)
+		c.cycleStamp = current
+	}
+	return bbrPacingGains[c.cycleIndex], bbrCwndGain
+}
@@ -196,6 +196,8 @@ type Connection struct {
 
 	receivingWorker *ReceivingWorker
 	sendingWorker   *SendingWorker
+	fec             *fecDecoder
+	peerFEC         atomic.Bool
 
 	output SegmentWriter
 
@@ -215,7 +217,7 @@ func NewConnection(meta ConnMetadata, writer PacketWriter, closer io.Closer, con
 		dataOutput: signal.NewNotifier(),
 		Config:     config,
 		output:     NewRetryableWriter(NewSegmentWriter(writer)),
-		mss:        config.GetMTUValue() - uint32(writer.Overhead()) - DataSegmentOverhead,
+		mss:        config.GetMTUValue() - uint32(writer.Overhead()) - DataSegmentOverhead - config.Fec.overhead(),
 		roundTrip: &RoundTripInfo{
 			rto:    100,
 			minRtt: config.GetTTIValue(),
@@ -224,6 +226,7 @@ func NewConnection(meta ConnMetadata, writer PacketWriter, closer io.Closer, con
 
 	conn.receivingWorker = NewReceivingWorker(conn)
 	conn.sendingWorker = NewSendingWorker(conn)
+	conn.fec = newFECDecoder(config.Fec)
 
 	isTerminating := func() bool {
 		return conn.State().Is(StateTerminating, StateTerminated)
@@ -542,6 +545,9 @@ func (c *Connection) Terminate() {
 }
 
 func (c *Connection) HandleOption(opt SegmentOption) {
+	if (opt&SegmentOptionFEC) == SegmentOptionFEC && c.fec != nil {
+		c.peerFEC.Store(true)
+	}
 	if (opt & SegmentOptionClose) == SegmentOptionClose {
 		c.OnPeerClosed()
 	}
@@ -569,11 +575,31 @@ func (c *Connection) Input(segments []Segment) {
 		switch seg := seg.(type) {
 		case *DataSegment:
 			c.HandleOption(seg.Option)
+			if c.fec != nil {
+				c.fec.AddData(seg)
+			}
 			c.receivingWorker.ProcessSegment(seg)
 			if c.receivingWorker.IsDataAvailable() {
 				c.dataInput.Signal()
 			}
 			c.dataUpdater.WakeUp()
+		case *FECSegment:
+			if c.fec == nil {
+				seg.Release()
+				break
+			}
+			c.HandleOption(seg.Option)
+			recovered := c.fec.AddParity(seg)
+			for _, data := range recovered {
+				c.fec.AddData(data)
+				c.receivingWorker.ProcessSegment(data)
+			}
+			if len(recovered) > 0 {
+				if c.receivingWorker.IsDataAvailable() {
+					c.dataInput.Signal()
+				}
+				c.dataUpdater.WakeUp()
+			}
 		case *AckSegment:
 			c.HandleOption(seg.Option)
 			c.sendingWorker.ProcessSegment(current, seg, c.roundTrip.Timeout())
@@ -591,11 +617,11 @@ func (c *Connection) Input(segments []Segment) {
 					c.SetState(StateTerminated)
 				}
 			}
-			if seg.Option == SegmentOptionClose || seg.Command() == CommandTerminate {
+			if (seg.Option&SegmentOptionClose) == SegmentOptionClose || seg.Command() == CommandTerminate {
 				c.dataInput.Signal()
 				c.dataOutput.Signal()
 			}
-			c.sendingWorker.ProcessReceivingNext(seg.ReceivingNext)
+			c.sendingWorker.ProcessReceivingNext(seg.ReceivingNext, current)
 			c.receivingWorker.ProcessSendingNext(seg.SendingNext)
 			c.roundTrip.UpdatePeerRTO(seg.PeerRTO, current)
 			seg.Release()
@@ -647,6 +673,20 @@ func (c *Connection) State() State {
 	return State(atomic.LoadInt32((*int32)(&c.state)))
 }
 
+// dataOption returns the option of the DataSegments and AckSegments to send.
+// CmdOnlySegments never carry SegmentOptionFEC, as older peers compare their
+// option with SegmentOptionClose.
+func (c *Connection) dataOption() SegmentOption {
+	var opt SegmentOption
+	if c.State() == StateReadyToClose {
+		opt |= SegmentOptionClose
+	}
+	if c.fec != nil {
+		opt |= SegmentOptionFEC
+	}
+	return opt
+}
+
 func (c *Connection) Ping(current uint32, cmd Command) {
 	seg := NewCmdOnlySegment()
 	seg.Conv = c.meta.Conversation
@@ -1,10 +1,14 @@
 package kcp_test
 
 import (
+	"bytes"
+	"crypto/rand"
 	"io"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/buf"
 	. "github.com/xtls/xray-core/transport/internet/kcp"
 )
@@ -36,3 +40,127 @@ func TestConnectionInterface(t *testing.T) {
 	_ = (buf.Reader)(new(Connection))
 	_ = (buf.Writer)(new(Connection))
 }
+
+// memoryLink delivers the packets written by a Connection to its peer.
+type memoryLink struct {
+	peer    *Connection
+	packets chan []byte
+	// drop returns whether to drop a data segment.
+	drop func(seg *DataSegment) bool
+	// parity counts the FEC segments delivered.
+	parity atomic.Int32
+}
+
+func (*memoryLink) Overhead() int {
+	return 0
+}
+
+func (l *memoryLink) Write(b []byte) (int, error) {
+	l.packets <- bytes.Clone(b)
+	return len(b), nil
+}
+
+func (l *memoryLink) run() {
+	reader := &KCPPacketReader{}
+	for packet := range l.packets {
+		var segments []Segment
+		for _, seg := range reader.Read(packet) {
+			if data, ok := seg.(*DataSegment); ok && l.drop != nil && l.drop(data) {
+				data.Release()
+				continue
+			}
+			if _, ok := seg.(*FECSegment); ok {
+				l.parity.Add(1)
+			}
+			segments = append(segments, seg)
+		}
+		l.peer.Input(segments)
+	}
+}
+
+// transfer sends size bytes from a Connection to another of config, over a
+// link which drops the data segments drop returns true for.
+func transfer(t *testing.T, config *Config, size int, drop func(seg *DataSegment) bool) {
+	forward := &memoryLink{packets: make(chan []byte, 1024), drop: drop}
+	backward := &memoryLink{packets: make(chan []byte, 1024)}
+	sender := NewConnection(ConnMetadata{Conversation: 1}, forward, NoOpCloser(0), config)
+	receiver := NewConnection(ConnMetadata{Conversation: 1}, backward, NoOpCloser(0), config)
+	forward.peer = receiver
+	backward.peer = sender
+	go forward.run()
+	go backward.run()
+	defer sender.Terminate()
+	defer receiver.Terminate()
+
+	payload := make([]byte, size)
+	common.Must2(rand.Read(payload))
+	go sender.Write(payload)
+
+	received := make([]byte, size)
+	common.Must(receiver.SetReadDeadline(time.Now().Add(10 * time.Second)))
+	if _, err := io.ReadFull(receiver, received); err != nil {
+		t.Fatal("failed to receive: ", err)
+	}
+	if !bytes.Equal(received, payload) {
+		t.Error("corrupted data")
+	}
+}
+
+func TestConnectionBBR(t *testing.T) {
+	transfer(t, &Config{
+		CongestionControl: CongestionControl_BBR,
+	}, 1024*1024, nil)
+}
+
+func TestConnectionFEC(t *testing.T) {
+	// lose every 5th segment, with its retransmissions, so only FEC recovers
+	// it, once the sender has learnt the receiver decodes FEC
+	lost := make(map[uint32]bool)
+	drop := func(seg *DataSegment) bool {
+		if seg.Number >= 256 && seg.Number%5 == 3 {
+			lost[seg.Number] = true
+		}
+		return lost[seg.Number]
+	}
+	transfer(t, &Config{
+		Fec: &FEC{
+			DataShards:   4,
+			ParityShards: 2,
+		},
+	}, 256*1024, drop)
+}
+
+func TestConnectionFECWithoutPeerFEC(t *testing.T) {
+	forward := &memoryLink{packets: make(chan []byte, 1024)}
+	backward := &memoryLink{packets: make(chan []byte, 1024)}
+	sender := NewConnection(ConnMetadata{Conversation: 1}, forward, NoOpCloser(0), &Config{
+		Fec: &FEC{
+			DataShards:   4,
+			ParityShards: 2,
+		},
+	})
+	receiver := NewConnection(ConnMetadata{Conversation: 1}, backward, NoOpCloser(0), &Config{})
+	forward.peer = receiver
+	backward.peer = sender
+	go forward.run()
+	go backward.run()
+	defer sender.Terminate()
+	defer receiver.Terminate()
+
+	payload := make([]byte, 256*1024)
+	common.Must2(rand.Read(payload))
+	go sender.Write(payload)
+
+	received := make([]byte, len(payload))
+	common.Must(receiver.SetReadDeadline(time.Now().Add(10 * time.Second)))
+	if _, err := io.ReadFull(receiver, received); err != nil {
+		t.Fatal("failed to receive: ", err)
+	}
+	if !bytes.Equal(received, payload) {
+		t.Error("corrupted data")
+	}
+	// the receiver does not decode FECSegments, so none must reach it
+	if n := forward.parity.Load(); n != 0 {
+		t.Error("FEC segments sent to a peer without FEC: ", n)
+	}
+}
@@ -49,13 +49,19 @@ func DialKCP(ctx context.Context, dest net.Destination, streamSettings *internet
 	dest.Network = net.Network_UDP
 	errors.LogInfo(ctx, "dialing mKCP to ", dest)
 
-	rawConn, err := internet.DialSystem(ctx, dest, streamSettings.SocketSettings)
+	kcpSettings := streamSettings.ProtocolSettings.(*Config)
+
+	var rawConn net.Conn
+	var err error
+	if kcpSettings.PortHopping.enabled() {
+		rawConn, err = dialHopping(ctx, dest, streamSettings, kcpSettings.PortHopping)
+	} else {
+		rawConn, err = internet.DialSystem(ctx, dest, streamSettings.SocketSettings)
+	}
 	if err != nil {
 		return nil, errors.New("failed to dial to dest: ", err).AtWarning().Base(err)
 	}
 
-	kcpSettings := streamSettings.ProtocolSettings.(*Config)
-
 	header, err := kcpSettings.GetPackerHeader()
 	if err != nil {
 		return nil, errors.New("failed to create packet header").Base(err)
@@ -73,6 +79,9 @@ func DialKCP(ctx context.Context, dest net.Destination, streamSettings *internet
 		Security: security,
 		Writer:   rawConn,
 	}
+	if kcpSettings.PortHopping.enabled() {
+		writer.Session = newSessionID()
+	}
 
 	conv := uint16(atomic.AddUint32(&globalConv, 1))
 	session := NewConnection(ConnMetadata{
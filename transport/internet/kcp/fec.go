@@ -0,0 +1,261 @@
+package kcp
+
+import (
+	"encoding/binary"
+)
+
+const (
+	fecDataCacheSize  = 2048
+	fecGroupCacheSize = 256
+)
+
+// overhead returns how many bytes a FECSegment takes more than a
+// DataSegment of the same payload.
+func (c *FEC) overhead() uint32 {
+	if c == nil || c.DataShards == 0 || c.ParityShards == 0 {
+		return 0
+	}
+	return FECSegmentOverhead + 4*c.DataShards + 2 - DataSegmentOverhead
+}
+
+// fecEncoder groups the DataSegments sent, and makes the parity segments of
+// each group.
+type fecEncoder struct {
+	dataShards   int
+	parityShards int
+	codes        map[int]*reedSolomon
+
+	group   uint32
+	numbers []uint32
+	shards  [][]byte
+}
+
+func newFECEncoder(config *FEC) *fecEncoder {
+	if config.overhead() == 0 {
+		return nil
+	}
+	return &fecEncoder{
+		dataShards:   int(config.DataShards),
+		parityShards: int(config.ParityShards),
+		codes:        make(map[int]*reedSolomon),
+	}
+}
+
+// Add adds a sent DataSegment to the group, and returns the parity segments
+// when the group is full.
+func (e *fecEncoder) Add(seg *DataSegment) []*FECSegment {
+	payload := seg.Data().Bytes()
+	i := len(e.numbers)
+	if i == len(e.shards) {
+		e.shards = append(e.shards, nil)
+	}
+	shard := e.shards[i][:0]
+	shard = binary.BigEndian.AppendUint16(shard, uint16(len(payload)))
+	e.shards[i] = append(shard, payload...)
+	e.numbers = append(e.numbers, seg.Number)
+
+	if len(e.numbers) < e.dataShards {
+		return nil
+	}
+	return e.Finish(seg.Timestamp)
+}
+
+// Finish ends the group, and returns its parity segments if it is not empty.
+func (e *fecEncoder) Finish(current uint32) []*FECSegment {
+	dataShards := len(e.numbers)
+	if dataShards == 0 {
+		return nil
+	}
+	defer func() {
+		e.numbers = e.numbers[:0]
+		e.group++
+	}()
+
+	code := e.codes[dataShards]
+	if code == nil {
+		var err error
+		if code, err = newReedSolomon(dataShards, e.parityShards); err != nil {
+			return nil
+		}
+		e.codes[dataShards] = code
+	}
+
+	shardSize := 0
+	for _, shard := range e.shards[:dataShards] {
+		shardSize = max(shardSize, len(shard))
+	}
+	shards := make([][]byte, dataShards+e.parityShards)
+	for i := range dataShards {
+		shard := e.shards[i]
+		if len(shard) < shardSize {
+			shard = append(shard, make([]byte, shardSize-len(shard))...)
+			e.shards[i] = shard
+		}
+		shards[i] = shard
+	}
+
+	parity := make([]*FECSegment, e.parityShards)
+	for i := range parity {
+		seg := NewFECSegment()
+		seg.Timestamp = current
+		seg.Group = e.group
+		seg.Index = byte(i)
+		seg.DataShards = byte(dataShards)
+		seg.ParityShards = byte(e.parityShards)
+		seg.Numbers = append(seg.Numbers, e.numbers...)
+		shards[dataShards+i] = seg.Data().Extend(int32(shardSize))
+		parity[i] = seg
+	}
+	code.Encode(shards)
+	return parity
+}
+
+type fecGroup struct {
+	numbers []uint32
+	parity  [][]byte
+	size    int
+	done    bool
+}
+
+// fecDecoder keeps the recent DataSegments received, and recovers the lost
+// ones of a group from its parity segments.
+type fecDecoder struct {
+	dataShards   int
+	parityShards int
+	codes        map[int]*reedSolomon
+
+	data      map[uint32][]byte
+	dataRing  []uint32
+	groups    map[uint32]*fecGroup
+	groupRing []uint32
+}
+
+func newFECDecoder(config *FEC) *fecDecoder {
+	if config.overhead() == 0 {
+		return nil
+	}
+	return &fecDecoder{
+		dataShards:   int(config.DataShards),
+		parityShards: int(config.ParityShards),
+		codes:        make(map[int]*reedSolomon),
+		data:         make(map[uint32][]byte),
+		groups:       make(map[uint32]*fecGroup),
+	}
+}
+
+// AddData keeps the shard of a received DataSegment.
+func (d *fecDecoder) AddData(seg *DataSegment) {
+	if _, found := d.data[seg.Number]; found {
+		return
+	}
+	payload := seg.Data().Bytes()
+	shard := make([]byte, 2+len(payload))
+	binary.BigEndian.PutUint16(shard, uint16(len(payload)))
+	copy(shard[2:], payload)
+
+	if len(d.dataRing) == fecDataCacheSize {
+		delete(d.data, d.dataRing[0])
+		d.dataRing = d.dataRing[1:]
+	}
+	d.data[seg.Number] = shard
+	d.dataRing = append(d.dataRing, seg.Number)
+}
+
+// AddParity adds a parity segment, and returns the DataSegments it recovers.
+// Segments of other shards than the local config, which both sides share,
+// are dropped. Groups may have fewer data shards, when they are finished
+// early.
+func (d *fecDecoder) AddParity(seg *FECSegment) []*DataSegment {
+	defer seg.Release()
+
+	dataShards := int(seg.DataShards)
+	parityShards := int(seg.ParityShards)
+	if dataShards == 0 || dataShards > d.dataShards || parityShards != d.parityShards ||
+		int(seg.Index) >= parityShards || len(seg.Numbers) != dataShards {
+		return nil
+	}
+
+	group := d.groups[seg.Group]
+	if group == nil {
+		if len(d.groupRing) == fecGroupCacheSize {
+			delete(d.groups, d.groupRing[0])
+			d.groupRing = d.groupRing[1:]
+		}
+		group = &fecGroup{
+			numbers: append([]uint32(nil), seg.Numbers...),
+			parity:  make([][]byte, parityShards),
+			size:    int(seg.Data().Len()),
+		}
+		d.groups[seg.Group] = group
+		d.groupRing = append(d.groupRing, seg.Group)
+	}
+	if group.done || len(group.numbers) != dataShards || len(group.parity) != parityShards || group.size != int(seg.Data().Len()) {
+		return nil
+	}
+	if group.parity[seg.Index] == nil {
+		group.parity[seg.Index] = append([]byte(nil), seg.Data().Bytes()...)
+	}
+
+	shards := make([][]byte, dataShards+parityShards)
+	present := 0
+	missing := 0
+	for i, number := range group.numbers {
+		if shard, found := d.data[number]; found && len(shard) <= group.size {
+			shards[i] = make([]byte, group.size)
+			copy(shards[i], shard)
+			present++
+		} else {
+			missing++
+		}
+	}
+	if missing == 0 {
+		group.done = true
+		return nil
+	}
+	for i, shard := range group.parity {
+		if shard != nil {
+			shards[dataShards+i] = shard
+			present++
+		}
+	}
+	if present < dataShards {
+		return nil
+	}
+
+	code := d.codes[dataShards]
+	if code == nil {
+		var err error
+		if code, err = newReedSolomon(dataShards, parityShards); err != nil {
+			return nil
+		}
+		d.codes[dataShards] = code
+	}
+	recovering := make([]bool, dataShards)
+	for i := range recovering {
+		recovering[i] = shards[i] == nil
+	}
+	if err := code.Reconstruct(shards, group.size); err != nil {
+		return nil
+	}
+	group.done = true
+
+	var recovered []*DataSegment
+	for i, number := range group.numbers {
+		if !recovering[i] {
+			continue
+		}
+		shard := shards[i]
+		size := int(binary.BigEndian.Uint16(shard))
+		if size+2 > len(shard) {
+			continue
+		}
+		data := NewDataSegment()
+		data.Conv = seg.Conv
+		data.Timestamp = seg.Timestamp
+		data.Number = number
+		data.SendingNext = seg.SendingNext
+		data.Data().Write(shard[2 : 2+size])
+		recovered = append(recovered, data)
+	}
+	return recovered
+}
@@ -0,0 +1,187 @@
+package kcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/buf"
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/common/signal/done"
+	"github.com/xtls/xray-core/transport/internet"
+)
+
+// newSessionID returns a random session id of port hopping, which servers
+// tell the connections from the same remote IP apart with.
+func newSessionID() uint64 {
+	var b [SessionIDSize]byte
+	for {
+		common.Must2(rand.Read(b[:]))
+		if id := binary.BigEndian.Uint64(b[:]); id != 0 {
+			return id
+		}
+	}
+}
+
+// hoppingConn sends to a port of the server at random, and hops to another
+// port, from a new local port, every interval. It receives from the current
+// and the previous connection, for the replies on the way.
+type hoppingConn struct {
+	ctx            context.Context
+	dest           net.Destination
+	streamSettings *internet.MemoryStreamConfig
+	hopping        *PortHopping
+
+	access   sync.Mutex
+	current  net.Conn
+	previous net.Conn
+
+	packets chan *buf.Buffer
+	done    *done.Instance
+}
+
+func dialHopping(ctx context.Context, dest net.Destination, streamSettings *internet.MemoryStreamConfig, hopping *PortHopping) (*hoppingConn, error) {
+	c := &hoppingConn{
+		ctx:            context.WithoutCancel(ctx),
+		dest:           dest,
+		streamSettings: streamSettings,
+		hopping:        hopping,
+		packets:        make(chan *buf.Buffer, 1024),
+		done:           done.New(),
+	}
+	conn, err := c.dial(ctx, dest.Port)
+	if err != nil {
+		return nil, err
+	}
+	c.current = conn
+	go c.hop()
+	return c, nil
+}
+
+func (c *hoppingConn) dial(ctx context.Context, port net.Port) (net.Conn, error) {
+	dest := c.dest
+	dest.Port = port
+	conn, err := internet.DialSystem(ctx, dest, c.streamSettings.SocketSettings)
+	if err != nil {
+		return nil, err
+	}
+	go c.receive(conn)
+	return conn, nil
+}
+
+func (c *hoppingConn) receive(conn net.Conn) {
+	for {
+		payload := buf.New()
+		if _, err := payload.ReadFrom(conn); err != nil {
+			payload.Release()
+			return
+		}
+		select {
+		case c.packets <- payload:
+		case <-c.done.Wait():
+			payload.Release()
+			return
+		default:
+			payload.Release()
+		}
+	}
+}
+
+func (c *hoppingConn) hop() {
+	ticker := time.NewTicker(c.hopping.getInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done.Wait():
+			return
+		case <-ticker.C:
+		}
+
+		port := c.hopping.randomPort()
+		conn, err := c.dial(c.ctx, port)
+		if err != nil {
+			errors.LogInfoInner(c.ctx, err, "failed to hop to port ", port)
+			continue
+		}
+		errors.LogDebug(c.ctx, "mKCP hopped to port ", port)
+
+		c.access.Lock()
+		if c.done.Done() {
+			c.access.Unlock()
+			conn.Close()
+			return
+		}
+		if c.previous != nil {
+			c.previous.Close()
+		}
+		c.previous = c.current
+		c.current = conn
+		c.access.Unlock()
+	}
+}
+
+func (c *hoppingConn) Read(b []byte) (int, error) {
+	select {
+	case payload := <-c.packets:
+		n := copy(b, payload.Bytes())
+		payload.Release()
+		return n, nil
+	case <-c.done.Wait():
+		return 0, io.EOF
+	}
+}
+
+func (c *hoppingConn) Write(b []byte) (int, error) {
+	c.access.Lock()
+	conn := c.current
+	c.access.Unlock()
+	return conn.Write(b)
+}
+
+func (c *hoppingConn) Close() error {
+	c.access.Lock()
+	defer c.access.Unlock()
+
+	if c.done.Done() {
+		return nil
+	}
+	c.done.Close()
+	if c.previous != nil {
+		c.previous.Close()
+	}
+	return c.current.Close()
+}
+
+// LocalAddr returns the local address of the current connection.
+func (c *hoppingConn) LocalAddr() net.Addr {
+	c.access.Lock()
+	defer c.access.Unlock()
+	return c.current.LocalAddr()
+}
+
+// RemoteAddr returns the remote address of the current connection.
+func (c *hoppingConn) RemoteAddr() net.Addr {
+	c.access.Lock()
+	defer c.access.Unlock()
+	return c.current.RemoteAddr()
+}
+
+// Connection has its own deadlines, and sets none of these.
+
+func (*hoppingConn) SetDeadline(time.Time) error {
+	return nil
+}
+
+func (*hoppingConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (*hoppingConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
@@ -3,6 +3,7 @@ package kcp
 import (
 	"crypto/cipher"
 	"crypto/rand"
+	"encoding/binary"
 	"io"
 
 	"github.com/xtls/xray-core/common"
@@ -25,6 +26,21 @@ type KCPPacketReader struct {
 }
 
 func (r *KCPPacketReader) Read(b []byte) []Segment {
+	return readSegments(r.open(b))
+}
+
+// ReadSession reads a packet of a client of port hopping, which has the
+// session id of the client before the segments.
+func (r *KCPPacketReader) ReadSession(b []byte) (uint64, []Segment) {
+	b = r.open(b)
+	if len(b) < SessionIDSize {
+		return 0, nil
+	}
+	return binary.BigEndian.Uint64(b), readSegments(b[SessionIDSize:])
+}
+
+// open removes the header of a packet, and decrypts the rest.
+func (r *KCPPacketReader) open(b []byte) []byte {
 	if r.Header != nil {
 		if int32(len(b)) <= r.Header.Size() {
 			return nil
@@ -43,6 +59,10 @@ func (r *KCPPacketReader) Read(b []byte) []Segment {
 		}
 		b = out
 	}
+	return b
+}
+
+func readSegments(b []byte) []Segment {
 	var result []Segment
 	for len(b) > 0 {
 		seg, x := ReadSegment(b)
@@ -55,10 +75,16 @@ func (r *KCPPacketReader) Read(b []byte) []Segment {
 	return result
 }
 
+// SessionIDSize is the size of the session ids of port hopping.
+const SessionIDSize = 8
+
 type KCPPacketWriter struct {
 	Header   internet.PacketHeader
 	Security cipher.AEAD
-	Writer   io.Writer
+	// Session is the session id of a client of port hopping, which precedes
+	// the segments of every packet. 0 for none.
+	Session uint64
+	Writer  io.Writer
 }
 
 func (w *KCPPacketWriter) Overhead() int {
@@ -69,6 +95,9 @@ func (w *KCPPacketWriter) Overhead() int {
 	if w.Security != nil {
 		overhead += w.Security.Overhead()
 	}
+	if w.Session != 0 {
+		overhead += SessionIDSize
+	}
 	return overhead
 }
 
@@ -76,6 +105,15 @@ func (w *KCPPacketWriter) Write(b []byte) (int, error) {
 	bb := buf.StackNew()
 	defer bb.Release()
 
+	payload := b
+	if w.Session != 0 {
+		sb := buf.StackNew()
+		defer sb.Release()
+		binary.BigEndian.PutUint64(sb.Extend(SessionIDSize), w.Session)
+		sb.Write(b)
+		payload = sb.Bytes()
+	}
+
 	if w.Header != nil {
 		w.Header.Serialize(bb.Extend(w.Header.Size()))
 	}
@@ -84,10 +122,10 @@ func (w *KCPPacketWriter) Write(b []byte) (int, error) {
 		common.Must2(bb.ReadFullFrom(rand.Reader, int32(nonceSize)))
 		nonce := bb.BytesFrom(int32(-nonceSize))
 
-		encrypted := bb.Extend(int32(w.Security.Overhead() + len(b)))
-		w.Security.Seal(encrypted[:0], nonce, b, nil)
+		encrypted := bb.Extend(int32(w.Security.Overhead() + len(payload)))
+		w.Security.Seal(encrypted[:0], nonce, payload, nil)
 	} else {
-		bb.Write(b)
+		bb.Write(payload)
 	}
 
 	_, err := w.Writer.Write(bb.Bytes())
@@ -11,6 +11,7 @@ import (
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/testing/servers/udp"
 	"github.com/xtls/xray-core/transport/internet"
 	. "github.com/xtls/xray-core/transport/internet/kcp"
 	"github.com/xtls/xray-core/transport/internet/stat"
@@ -83,3 +84,121 @@ func TestDialAndListen(t *testing.T) {
 		t.Error("active connections: ", v)
 	}
 }
+
+func TestDialAndListenWithPortHopping(t *testing.T) {
+	hopping := &PortHopping{
+		Ports:    &net.PortList{},
+		Interval: 1,
+	}
+	streamSettings := &internet.MemoryStreamConfig{
+		ProtocolName: "mkcp",
+		ProtocolSettings: &Config{
+			PortHopping: hopping,
+		},
+	}
+
+	for i := 0; i < 3; i++ {
+		port := udp.PickPort()
+		hopping.Ports.Range = append(hopping.Ports.Range, &net.PortRange{From: uint32(port), To: uint32(port)})
+	}
+
+	var listeners []*Listener
+	for _, r := range hopping.Ports.Range {
+		listener, err := NewListener(context.Background(), net.LocalHostIP, net.Port(r.From), streamSettings, func(conn stat.Connection) {
+			go func(c stat.Connection) {
+				defer c.Close()
+				io.Copy(c, c)
+			}(conn)
+		})
+		common.Must(err)
+		defer listener.Close()
+		listeners = append(listeners, listener)
+	}
+
+	clientConn, err := DialKCP(context.Background(), net.UDPDestination(net.LocalHostIP, net.Port(hopping.Ports.Range[0].From)), streamSettings)
+	common.Must(err)
+
+	// hop a few times in the middle of the connection
+	deadline := time.Now().Add(3500 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		clientSend := make([]byte, 4096)
+		rand.Read(clientSend)
+		common.Must2(clientConn.Write(clientSend))
+
+		clientReceived := make([]byte, len(clientSend))
+		common.Must2(io.ReadFull(clientConn, clientReceived))
+		if r := cmp.Diff(clientReceived, clientSend); r != "" {
+			t.Fatal(r)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// the listeners share the connection
+	for _, listener := range listeners {
+		if v := listener.ActiveConnections(); v != 1 {
+			t.Error("active connections: ", v)
+		}
+	}
+
+	common.Must(clientConn.Close())
+	for i := 0; i < 60 && listeners[0].ActiveConnections() > 0; i++ {
+		time.Sleep(500 * time.Millisecond)
+	}
+	if v := listeners[0].ActiveConnections(); v != 0 {
+		t.Error("active connections: ", v)
+	}
+}
+
+func TestListenWithPortHoppingSessions(t *testing.T) {
+	port := udp.PickPort()
+	config := &Config{
+		PortHopping: &PortHopping{
+			Ports: &net.PortList{Range: []*net.PortRange{{From: uint32(port), To: uint32(port)}}},
+		},
+	}
+	streamSettings := &internet.MemoryStreamConfig{
+		ProtocolName:     "mkcp",
+		ProtocolSettings: config,
+	}
+	listener, err := NewListener(context.Background(), net.LocalHostIP, port, streamSettings, func(stat.Connection) {})
+	common.Must(err)
+	security, err := config.GetSecurity()
+	common.Must(err)
+	defer listener.Close()
+
+	// ping from a client of session and local port of conn, with the same
+	// conversation as all the others behind the same NAT
+	ping := func(conn net.Conn, session uint64) {
+		seg := NewCmdOnlySegment()
+		seg.Conv = 1
+		seg.Cmd = CommandPing
+		b := make([]byte, seg.ByteSize())
+		seg.Serialize(b)
+		writer := &KCPPacketWriter{Security: security, Session: session, Writer: conn}
+		common.Must2(writer.Write(b))
+		time.Sleep(100 * time.Millisecond)
+	}
+	dial := func() net.Conn {
+		conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: net.LocalHostIP.IP(), Port: int(port)})
+		common.Must(err)
+		return conn
+	}
+	conn1 := dial()
+	defer conn1.Close()
+	conn2 := dial()
+	defer conn2.Close()
+
+	ping(conn1, 1)
+	ping(conn2, 1) // the client of session 1 hops
+	if v := listener.ActiveConnections(); v != 1 {
+		t.Error("active connections of one session: ", v)
+	}
+	ping(conn2, 2)
+	if v := listener.ActiveConnections(); v != 2 {
+		t.Error("active connections of two sessions: ", v)
+	}
+	ping(conn1, 0)
+	if v := listener.ActiveConnections(); v != 2 {
+		t.Error("active connections after a packet without session: ", v)
+	}
+}
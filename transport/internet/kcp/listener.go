@@ -11,25 +11,84 @@ import (
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/transport/internet"
-	"github.com/xtls/xray-core/transport/internet/stat"
 	"github.com/xtls/xray-core/transport/internet/tls"
 	"github.com/xtls/xray-core/transport/internet/udp"
 )
 
 type ConnectionID struct {
-	Remote net.Address
-	Port   net.Port
-	Conv   uint16
+	Remote  net.Address
+	Port    net.Port
+	Conv    uint16
+	Session uint64
+}
+
+// sessionTable holds the connections of listeners. Listeners of the same
+// settings share one with port hopping, and find connections by conversation,
+// remote IP and the session id of the client, instead of remote port.
+type sessionTable struct {
+	sync.Mutex
+	sessions  map[ConnectionID]*Connection
+	writers   map[ConnectionID]*Writer
+	listeners int
+}
+
+var (
+	hoppingTablesAccess sync.Mutex
+	hoppingTables       = make(map[*Config]*sessionTable)
+)
+
+func newSessionTable() *sessionTable {
+	return &sessionTable{
+		sessions: make(map[ConnectionID]*Connection),
+		writers:  make(map[ConnectionID]*Writer),
+	}
+}
+
+// acquireSessionTable returns the table of a new listener of config.
+func acquireSessionTable(config *Config) *sessionTable {
+	if !config.PortHopping.enabled() {
+		table := newSessionTable()
+		table.listeners = 1
+		return table
+	}
+
+	hoppingTablesAccess.Lock()
+	defer hoppingTablesAccess.Unlock()
+
+	table := hoppingTables[config]
+	if table == nil {
+		table = newSessionTable()
+		hoppingTables[config] = table
+	}
+	table.listeners++
+	return table
+}
+
+// release removes a listener of config, and returns whether it is the last
+// one of the table.
+func (t *sessionTable) release(config *Config) bool {
+	hoppingTablesAccess.Lock()
+	defer hoppingTablesAccess.Unlock()
+
+	t.listeners--
+	if t.listeners > 0 {
+		return false
+	}
+	if hoppingTables[config] == t {
+		delete(hoppingTables, config)
+	}
+	return true
 }
 
 // Listener defines a server listening for connections
 type Listener struct {
 	sync.Mutex
-	sessions  map[ConnectionID]*Connection
+	table     *sessionTable
 	hub       *udp.Hub
 	tlsConfig *gotls.Config
+	acme      common.Closable
 	config    *Config
-	reader    PacketReader
+	reader    *KCPPacketReader
 	header    internet.PacketHeader
 	security  cipher.AEAD
 	addConn   internet.ConnHandler
@@ -52,17 +111,22 @@ func NewListener(ctx context.Context, address net.Address, port net.Port, stream
 			Header:   header,
 			Security: security,
 		},
-		sessions: make(map[ConnectionID]*Connection),
-		config:   kcpSettings,
-		addConn:  addConn,
+		table:   acquireSessionTable(kcpSettings),
+		config:  kcpSettings,
+		addConn: addConn,
 	}
 
 	if config := tls.ConfigFromStreamSettings(streamSettings); config != nil {
-		l.tlsConfig = config.GetTLSConfig()
+		if l.tlsConfig, l.acme, err = config.GetServerTLSConfig(); err != nil {
+			l.table.release(kcpSettings)
+			return nil, err
+		}
 	}
 
 	hub, err := udp.ListenUDP(ctx, address, port, streamSettings, udp.HubCapacity(1024))
 	if err != nil {
+		l.table.release(kcpSettings)
+		common.Close(l.acme)
 		return nil, err
 	}
 	l.Lock()
@@ -83,7 +147,14 @@ func (l *Listener) handlePackets() {
 }
 
 func (l *Listener) OnReceive(payload *buf.Buffer, src net.Destination) {
-	segments := l.reader.Read(payload.Bytes())
+	hopping := l.config.PortHopping.enabled()
+	var session uint64
+	var segments []Segment
+	if hopping {
+		session, segments = l.reader.ReadSession(payload.Bytes())
+	} else {
+		segments = l.reader.Read(payload.Bytes())
+	}
 	payload.Release()
 
 	if len(segments) == 0 {
@@ -99,13 +170,25 @@ func (l *Listener) OnReceive(payload *buf.Buffer, src net.Destination) {
 		Port:   src.Port,
 		Conv:   conv,
 	}
+	if hopping {
+		if session == 0 {
+			errors.LogInfo(context.Background(), "discarding payload without session from ", src)
+			return
+		}
+		// the remote port changes on every hop, while the session stays
+		id.Port = 0
+		id.Session = session
+	}
 
-	l.Lock()
-	defer l.Unlock()
+	l.table.Lock()
+	defer l.table.Unlock()
 
-	conn, found := l.sessions[id]
+	conn, found := l.table.sessions[id]
 
-	if !found {
+	if found {
+		// reply where the last packet comes from
+		l.table.writers[id].update(l.hub, src)
+	} else {
 		if cmd == CommandTerminate {
 			return
 		}
@@ -129,31 +212,51 @@ func (l *Listener) OnReceive(payload *buf.Buffer, src net.Destination) {
 			Security: l.security,
 			Writer:   writer,
 		}, writer, l.config)
-		var netConn stat.Connection = conn
 		if l.tlsConfig != nil {
-			netConn = tls.Server(conn, l.tlsConfig)
+			tlsConn := tls.Server(conn, l.tlsConfig).(*tls.Conn)
+			if l.tlsConfig.ClientAuth != gotls.NoClientCert {
+				// the handshake needs the segments put in below
+				go func() {
+					if err := tls.VerifyClient(tlsConn, l.tlsConfig); err != nil {
+						errors.LogInfoInner(context.Background(), err, "failed to verify TLS client")
+						tlsConn.Close()
+						return
+					}
+					l.addConn(tlsConn)
+				}()
+			} else {
+				l.addConn(tlsConn)
+			}
+		} else {
+			l.addConn(conn)
 		}
-
-		l.addConn(netConn)
-		l.sessions[id] = conn
+		l.table.sessions[id] = conn
+		l.table.writers[id] = writer
 	}
 	conn.Input(segments)
 }
 
 func (l *Listener) Remove(id ConnectionID) {
-	l.Lock()
-	delete(l.sessions, id)
-	l.Unlock()
+	l.table.Lock()
+	delete(l.table.sessions, id)
+	delete(l.table.writers, id)
+	l.table.Unlock()
 }
 
-// Close stops listening on the UDP address. Already Accepted connections are not closed.
+// Close stops listening on the UDP address. Already Accepted connections are
+// terminated, unless other listeners of port hopping serve them.
 func (l *Listener) Close() error {
 	l.hub.Close()
+	common.Close(l.acme)
 
-	l.Lock()
-	defer l.Unlock()
+	if !l.table.release(l.config) {
+		return nil
+	}
+
+	l.table.Lock()
+	defer l.table.Unlock()
 
-	for _, conn := range l.sessions {
+	for _, conn := range l.table.sessions {
 		go conn.Terminate()
 	}
 
@@ -161,10 +264,10 @@ func (l *Listener) Close() error {
 }
 
 func (l *Listener) ActiveConnections() int {
-	l.Lock()
-	defer l.Unlock()
+	l.table.Lock()
+	defer l.table.Unlock()
 
-	return len(l.sessions)
+	return len(l.table.sessions)
 }
 
 // Addr returns the listener's network address, The Addr returned is shared by all invocations of Addr, so do not modify it.
@@ -173,6 +276,7 @@ func (l *Listener) Addr() net.Addr {
 }
 
 type Writer struct {
+	sync.Mutex
 	id       ConnectionID
 	dest     net.Destination
 	hub      *udp.Hub
@@ -180,7 +284,18 @@ type Writer struct {
 }
 
 func (w *Writer) Write(payload []byte) (int, error) {
-	return w.hub.WriteTo(payload, w.dest)
+	w.Lock()
+	hub, dest := w.hub, w.dest
+	w.Unlock()
+	return hub.WriteTo(payload, dest)
+}
+
+// update sends the following packets to dest through hub.
+func (w *Writer) update(hub *udp.Hub, dest net.Destination) {
+	w.Lock()
+	w.hub = hub
+	w.dest = dest
+	w.Unlock()
 }
 
 func (w *Writer) Close() error {
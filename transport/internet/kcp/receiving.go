@@ -240,10 +240,7 @@ func (w *ReceivingWorker) Write(seg Segment) error {
 	ackSeg.Conv = w.conn.meta.Conversation
 	ackSeg.ReceivingNext = w.nextNumber
 	ackSeg.ReceivingWindow = w.nextNumber + w.windowSize
-	ackSeg.Option = 0
-	if w.conn.State() == StateReadyToClose {
-		ackSeg.Option = SegmentOptionClose
-	}
+	ackSeg.Option = w.conn.dataOption()
 	return w.conn.output.Write(ackSeg)
 }
 
@@ -0,0 +1,183 @@
+package kcp
+
+import (
+	"github.com/xtls/xray-core/common/errors"
+)
+
+// Arithmetic of GF(2^8) with the polynomial x^8 + x^4 + x^3 + x^2 + 1.
+var (
+	gfExp [510]byte
+	gfLog [256]byte
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfExp[i+255] = byte(x)
+		gfLog[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfInv(a byte) byte {
+	return gfExp[255-int(gfLog[a])]
+}
+
+func gfPow(a byte, n int) byte {
+	if n == 0 {
+		return 1
+	}
+	if a == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])*n%255]
+}
+
+// gfMulAdd adds c * in to out.
+func gfMulAdd(c byte, in, out []byte) {
+	if c == 0 {
+		return
+	}
+	logC := int(gfLog[c])
+	for i, v := range in {
+		if v != 0 {
+			out[i] ^= gfExp[logC+int(gfLog[v])]
+		}
+	}
+}
+
+func invertMatrix(m [][]byte) ([][]byte, error) {
+	n := len(m)
+	work := make([][]byte, n)
+	for i := range m {
+		work[i] = make([]byte, 2*n)
+		copy(work[i], m[i])
+		work[i][n+i] = 1
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for pivot < n && work[pivot][col] == 0 {
+			pivot++
+		}
+		if pivot == n {
+			return nil, errors.New("singular matrix")
+		}
+		work[col], work[pivot] = work[pivot], work[col]
+		if c := work[col][col]; c != 1 {
+			inv := gfInv(c)
+			for j := range work[col] {
+				work[col][j] = gfMul(work[col][j], inv)
+			}
+		}
+		for row := 0; row < n; row++ {
+			if row != col && work[row][col] != 0 {
+				gfMulAdd(work[row][col], work[col], work[row])
+			}
+		}
+	}
+	inverse := make([][]byte, n)
+	for i := range work {
+		inverse[i] = work[i][n:]
+	}
+	return inverse, nil
+}
+
+// reedSolomon is a systematic Reed-Solomon erasure code: any dataShards of
+// the dataShards+parityShards shards recover the data shards.
+type reedSolomon struct {
+	dataShards   int
+	parityShards int
+	// matrix turns data shards to all shards. Its top is the identity matrix.
+	matrix [][]byte
+}
+
+func newReedSolomon(dataShards, parityShards int) (*reedSolomon, error) {
+	if dataShards <= 0 || parityShards <= 0 || dataShards+parityShards > 255 {
+		return nil, errors.New("invalid Reed-Solomon shards: ", dataShards, "+", parityShards)
+	}
+	total := dataShards + parityShards
+	vandermonde := make([][]byte, total)
+	for r := range vandermonde {
+		vandermonde[r] = make([]byte, dataShards)
+		for c := range vandermonde[r] {
+			vandermonde[r][c] = gfPow(byte(r), c)
+		}
+	}
+	top, err := invertMatrix(vandermonde[:dataShards])
+	if err != nil {
+		return nil, err
+	}
+	matrix := make([][]byte, total)
+	for r := range matrix {
+		matrix[r] = make([]byte, dataShards)
+		for c := 0; c < dataShards; c++ {
+			var v byte
+			for i := 0; i < dataShards; i++ {
+				v ^= gfMul(vandermonde[r][i], top[i][c])
+			}
+			matrix[r][c] = v
+		}
+	}
+	return &reedSolomon{
+		dataShards:   dataShards,
+		parityShards: parityShards,
+		matrix:       matrix,
+	}, nil
+}
+
+// Encode fills the parity shards from the data shards, all of the same size.
+func (r *reedSolomon) Encode(shards [][]byte) {
+	for p := 0; p < r.parityShards; p++ {
+		out := shards[r.dataShards+p]
+		clear(out)
+		for d := 0; d < r.dataShards; d++ {
+			gfMulAdd(r.matrix[r.dataShards+p][d], shards[d], out)
+		}
+	}
+}
+
+// Reconstruct fills the missing data shards, which are nil, from at least
+// dataShards present shards of size.
+func (r *reedSolomon) Reconstruct(shards [][]byte, size int) error {
+	rows := make([][]byte, 0, r.dataShards)
+	present := make([][]byte, 0, r.dataShards)
+	for i, shard := range shards {
+		if shard == nil {
+			continue
+		}
+		rows = append(rows, r.matrix[i])
+		present = append(present, shard)
+		if len(rows) == r.dataShards {
+			break
+		}
+	}
+	if len(rows) < r.dataShards {
+		return errors.New("insufficient shards: ", len(rows), " of ", r.dataShards)
+	}
+	decode, err := invertMatrix(rows)
+	if err != nil {
+		return err
+	}
+	for d := 0; d < r.dataShards; d++ {
+		if shards[d] != nil {
+			continue
+		}
+		out := make([]byte, size)
+		for i, shard := range present {
+			gfMulAdd(decode[d][i], shard, out)
+		}
+		shards[d] = out
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package kcp
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+)
+
+func newShards(r *reedSolomon, size int) [][]byte {
+	shards := make([][]byte, r.dataShards+r.parityShards)
+	for i := range shards {
+		shards[i] = make([]byte, size)
+		if i < r.dataShards {
+			rand.Read(shards[i])
+		}
+	}
+	r.Encode(shards)
+	return shards
+}
+
+func TestReedSolomonRoundTrip(t *testing.T) {
+	for _, tc := range [][2]int{{1, 1}, {2, 1}, {3, 2}, {4, 4}, {10, 3}} {
+		r, err := newReedSolomon(tc[0], tc[1])
+		common.Must(err)
+		shards := newShards(r, 64)
+		total := len(shards)
+
+		// every loss of up to parityShards shards is recovered
+		for lost := 0; lost < 1<<total; lost++ {
+			n := 0
+			for i := 0; i < total; i++ {
+				n += lost >> i & 1
+			}
+			if n > r.parityShards {
+				continue
+			}
+			received := make([][]byte, total)
+			for i := range received {
+				if lost>>i&1 == 0 {
+					received[i] = shards[i]
+				}
+			}
+			if err := r.Reconstruct(received, 64); err != nil {
+				t.Fatal(tc, " lost ", lost, ": ", err)
+			}
+			for i := 0; i < r.dataShards; i++ {
+				if !bytes.Equal(received[i], shards[i]) {
+					t.Fatal(tc, " lost ", lost, ": shard ", i, " is not recovered")
+				}
+			}
+		}
+	}
+}
+
+func TestReedSolomonCorruption(t *testing.T) {
+	r, err := newReedSolomon(4, 2)
+	common.Must(err)
+	shards := newShards(r, 16)
+
+	// more losses than parity shards
+	received := [][]byte{nil, nil, nil, shards[3], shards[4], shards[5]}
+	if err := r.Reconstruct(received, 16); err == nil {
+		t.Error("recovered with too few shards")
+	}
+
+	// erasure codes do not detect corrupted shards, which KCP leaves to its
+	// authenticator, but the damage stays within the bytes corrupted
+	corrupted := append([]byte(nil), shards[4]...)
+	corrupted[5] ^= 0xff
+	received = [][]byte{nil, shards[1], shards[2], shards[3], corrupted, nil}
+	common.Must(r.Reconstruct(received, 16))
+	for i := range received[0] {
+		if (received[0][i] != shards[0][i]) != (i == 5) {
+			t.Error("byte ", i, " of the recovered shard")
+		}
+	}
+
+	for _, tc := range [][2]int{{0, 1}, {1, 0}, {200, 56}} {
+		if _, err := newReedSolomon(tc[0], tc[1]); err == nil {
+			t.Error("shards ", tc)
+		}
+	}
+}
+
+func TestFECDecoderShards(t *testing.T) {
+	config := &FEC{DataShards: 4, ParityShards: 2}
+	encoder := newFECEncoder(config)
+	var parity []*FECSegment
+	var lost *DataSegment
+	for i := uint32(0); i < 4; i++ {
+		seg := NewDataSegment()
+		seg.Number = i
+		seg.Data().Write([]byte{byte(i), 'a', 'b'})
+		if p := encoder.Add(seg); p != nil {
+			parity = p
+		}
+		if i == 1 {
+			lost = seg
+		}
+	}
+
+	newDecoder := func() *fecDecoder {
+		d := newFECDecoder(config)
+		for i := uint32(0); i < 4; i++ {
+			if i != lost.Number {
+				seg := NewDataSegment()
+				seg.Number = i
+				seg.Data().Write([]byte{byte(i), 'a', 'b'})
+				d.AddData(seg)
+			}
+		}
+		return d
+	}
+	clone := func(seg *FECSegment) *FECSegment {
+		c := *seg
+		c.payload = nil
+		c.Numbers = append([]uint32(nil), seg.Numbers...)
+		c.Data().Write(seg.Data().Bytes())
+		return &c
+	}
+
+	// shard counts of the peer other than the local config are dropped
+	for _, shards := range [][2]byte{{4, 3}, {4, 1}, {5, 2}, {200, 55}} {
+		seg := clone(parity[0])
+		seg.DataShards, seg.ParityShards = shards[0], shards[1]
+		for len(seg.Numbers) < int(shards[0]) {
+			seg.Numbers = append(seg.Numbers, uint32(len(seg.Numbers)))
+		}
+		if recovered := newDecoder().AddParity(seg); recovered != nil {
+			t.Error("recovered with shards ", shards)
+		}
+	}
+
+	recovered := newDecoder().AddParity(clone(parity[0]))
+	if len(recovered) != 1 || recovered[0].Number != lost.Number || !bytes.Equal(recovered[0].Data().Bytes(), lost.Data().Bytes()) {
+		t.Error("recovered: ", recovered)
+	}
+}
@@ -18,12 +18,17 @@ const (
 	CommandTerminate Command = 2
 	// CommandPing indicates a ping.
 	CommandPing Command = 3
+	// CommandFEC indicates a FECSegment.
+	CommandFEC Command = 4
 )
 
 type SegmentOption byte
 
 const (
 	SegmentOptionClose SegmentOption = 1
+	// SegmentOptionFEC indicates the sender decodes FECSegments. Peers without
+	// FEC misparse FECSegments, so they are only sent once the peer sets it.
+	SegmentOptionFEC SegmentOption = 2
 )
 
 type Segment interface {
@@ -49,6 +54,11 @@ type DataSegment struct {
 	payload  *buf.Buffer
 	timeout  uint32
 	transmit uint32
+
+	// delivered and deliveredTime are the state of the CongestionController
+	// when the segment is sent.
+	delivered     uint32
+	deliveredTime uint32
 }
 
 func NewDataSegment() *DataSegment {
@@ -273,6 +283,116 @@ func (s *CmdOnlySegment) Serialize(b []byte) {
 
 func (*CmdOnlySegment) Release() {}
 
+const (
+	FECSegmentOverhead = 21
+)
+
+// FECSegment carries a parity shard of a group of DataSegments. A shard of
+// a DataSegment is the length of its payload in 2 bytes and the payload,
+// padded to ShardSize.
+type FECSegment struct {
+	Conv         uint16
+	Option       SegmentOption
+	Timestamp    uint32
+	SendingNext  uint32
+	Group        uint32
+	Index        byte
+	DataShards   byte
+	ParityShards byte
+	// Numbers are the DataSegments of the group.
+	Numbers []uint32
+
+	payload *buf.Buffer
+}
+
+func NewFECSegment() *FECSegment {
+	return new(FECSegment)
+}
+
+func (s *FECSegment) parse(conv uint16, cmd Command, opt SegmentOption, buf []byte) (bool, []byte) {
+	s.Conv = conv
+	s.Option = opt
+	if len(buf) < 17 {
+		return false, nil
+	}
+
+	s.Timestamp = binary.BigEndian.Uint32(buf)
+	buf = buf[4:]
+
+	s.SendingNext = binary.BigEndian.Uint32(buf)
+	buf = buf[4:]
+
+	s.Group = binary.BigEndian.Uint32(buf)
+	buf = buf[4:]
+
+	s.Index = buf[0]
+	s.DataShards = buf[1]
+	s.ParityShards = buf[2]
+	buf = buf[3:]
+
+	shardSize := int(binary.BigEndian.Uint16(buf))
+	buf = buf[2:]
+
+	if len(buf) < int(s.DataShards)*4+shardSize {
+		return false, nil
+	}
+	s.Numbers = s.Numbers[:0]
+	for i := 0; i < int(s.DataShards); i++ {
+		s.Numbers = append(s.Numbers, binary.BigEndian.Uint32(buf))
+		buf = buf[4:]
+	}
+
+	s.Data().Clear()
+	s.Data().Write(buf[:shardSize])
+	buf = buf[shardSize:]
+
+	return true, buf
+}
+
+func (s *FECSegment) Conversation() uint16 {
+	return s.Conv
+}
+
+func (*FECSegment) Command() Command {
+	return CommandFEC
+}
+
+// Data returns the parity shard.
+func (s *FECSegment) Data() *buf.Buffer {
+	if s.payload == nil {
+		s.payload = buf.New()
+	}
+	return s.payload
+}
+
+func (s *FECSegment) ByteSize() int32 {
+	return FECSegmentOverhead + int32(len(s.Numbers)*4) + s.payload.Len()
+}
+
+func (s *FECSegment) Serialize(b []byte) {
+	binary.BigEndian.PutUint16(b, s.Conv)
+	b[2] = byte(CommandFEC)
+	b[3] = byte(s.Option)
+	binary.BigEndian.PutUint32(b[4:], s.Timestamp)
+	binary.BigEndian.PutUint32(b[8:], s.SendingNext)
+	binary.BigEndian.PutUint32(b[12:], s.Group)
+	b[16] = s.Index
+	b[17] = s.DataShards
+	b[18] = s.ParityShards
+	binary.BigEndian.PutUint16(b[19:], uint16(s.payload.Len()))
+	n := 21
+	for _, number := range s.Numbers {
+		binary.BigEndian.PutUint32(b[n:], number)
+		n += 4
+	}
+	copy(b[n:], s.payload.Bytes())
+}
+
+func (s *FECSegment) Release() {
+	s.payload.Release()
+	s.payload = nil
+}
+
 func ReadSegment(buf []byte) (Segment, []byte) {
 	if len(buf) < 4 {
 		return nil, nil
@@ -291,6 +411,8 @@ func ReadSegment(buf []byte) (Segment, []byte) {
 		seg = NewDataSegment()
 	case CommandACK:
 		seg = NewAckSegment()
+	case CommandFEC:
+		seg = NewFECSegment()
 	default:
 		seg = NewCmdOnlySegment()
 	}
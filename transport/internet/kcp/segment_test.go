@@ -104,3 +104,30 @@ func TestCmdSegment(t *testing.T) {
 		t.Error(r)
 	}
 }
+
+func TestFECSegment(t *testing.T) {
+	seg := &FECSegment{
+		Conv:         1,
+		Timestamp:    3,
+		SendingNext:  5,
+		Group:        6,
+		Index:        1,
+		DataShards:   2,
+		ParityShards: 2,
+		Numbers:      []uint32{7, 8},
+	}
+	seg.Data().Write([]byte{'a', 'b', 'c', 'd'})
+
+	nBytes := seg.ByteSize()
+	bytes := make([]byte, nBytes)
+	seg.Serialize(bytes)
+
+	iseg, _ := ReadSegment(bytes)
+	seg2 := iseg.(*FECSegment)
+	if r := cmp.Diff(seg2, seg, cmpopts.IgnoreUnexported(FECSegment{})); r != "" {
+		t.Error(r)
+	}
+	if r := cmp.Diff(seg2.Data().Bytes(), seg.Data().Bytes()); r != "" {
+		t.Error(r)
+	}
+}
@@ -11,14 +11,14 @@ type SendingWindow struct {
 	cache             *list.List
 	totalInFlightSize uint32
 	writer            SegmentWriter
-	onPacketLoss      func(uint32)
+	controller        CongestionController
 }
 
-func NewSendingWindow(writer SegmentWriter, onPacketLoss func(uint32)) *SendingWindow {
+func NewSendingWindow(writer SegmentWriter, controller CongestionController) *SendingWindow {
 	window := &SendingWindow{
-		cache:        list.New(),
-		writer:       writer,
-		onPacketLoss: onPacketLoss,
+		cache:      list.New(),
+		writer:     writer,
+		controller: controller,
 	}
 	return window
 }
@@ -54,12 +54,13 @@ func (sw *SendingWindow) FirstNumber() uint32 {
 	return sw.cache.Front().Value.(*DataSegment).Number
 }
 
-func (sw *SendingWindow) Clear(una uint32) {
+func (sw *SendingWindow) Clear(una uint32, current uint32) {
 	for !sw.IsEmpty() {
 		seg := sw.cache.Front().Value.(*DataSegment)
 		if seg.Number >= una {
 			break
 		}
+		sw.controller.OnAck(seg, current)
 		seg.Release()
 		sw.cache.Remove(sw.cache.Front())
 	}
@@ -95,13 +96,17 @@ func (sw *SendingWindow) Visit(visitor func(seg *DataSegment) bool) {
 	}
 }
 
-func (sw *SendingWindow) Flush(current uint32, rto uint32, maxInFlightSize uint32) {
+// Flush sends the due segments at current, no more than maxInFlightSize of
+// them, and no more than maxFreshSize for the first time. It sends at least
+// one, which probes a full window.
+func (sw *SendingWindow) Flush(current uint32, rto uint32, maxInFlightSize uint32, maxFreshSize uint32) {
 	if sw.IsEmpty() {
 		return
 	}
 
 	var lost uint32
 	var inFlightSize uint32
+	var freshSize uint32
 
 	sw.Visit(func(segment *DataSegment) bool {
 		if current-segment.timeout >= 0x7FFFFFFF {
@@ -109,10 +114,15 @@ func (sw *SendingWindow) Flush(current uint32, rto uint32, maxInFlightSize uint3
 		}
 		if segment.transmit == 0 {
 			// First time
+			if freshSize >= maxFreshSize && inFlightSize > 0 {
+				return false
+			}
+			freshSize++
 			sw.totalInFlightSize++
 		} else {
 			lost++
 		}
+		sw.controller.OnSend(segment, current)
 		segment.timeout = current + rto
 
 		segment.Timestamp = current
@@ -122,13 +132,13 @@ func (sw *SendingWindow) Flush(current uint32, rto uint32, maxInFlightSize uint3
 		return inFlightSize < maxInFlightSize
 	})
 
-	if sw.onPacketLoss != nil && inFlightSize > 0 && sw.totalInFlightSize != 0 {
+	if inFlightSize > 0 && sw.totalInFlightSize != 0 {
 		rate := lost * 100 / sw.totalInFlightSize
-		sw.onPacketLoss(rate)
+		sw.controller.OnPacketLoss(rate)
 	}
 }
 
-func (sw *SendingWindow) Remove(number uint32) bool {
+func (sw *SendingWindow) Remove(number uint32, current uint32) bool {
 	if sw.IsEmpty() {
 		return false
 	}
@@ -141,6 +151,7 @@ func (sw *SendingWindow) Remove(number uint32) bool {
 			if sw.totalInFlightSize > 0 {
 				sw.totalInFlightSize--
 			}
+			sw.controller.OnAck(seg, current)
 			seg.Release()
 			sw.cache.Remove(e)
 			return true
@@ -157,11 +168,11 @@ type SendingWorker struct {
 	firstUnacknowledged        uint32
 	nextNumber                 uint32
 	remoteNextNumber           uint32
-	controlWindow              uint32
 	fastResend                 uint32
 	windowSize                 uint32
 	firstUnacknowledgedUpdated bool
 	closed                     bool
+	fec                        *fecEncoder
 }
 
 func NewSendingWorker(kcp *Connection) *SendingWorker {
@@ -169,10 +180,10 @@ func NewSendingWorker(kcp *Connection) *SendingWorker {
 		conn:             kcp,
 		fastResend:       2,
 		remoteNextNumber: 32,
-		controlWindow:    kcp.Config.GetSendingInFlightSize(),
 		windowSize:       kcp.Config.GetSendingBufferSize(),
+		fec:              newFECEncoder(kcp.Config.Fec),
 	}
-	worker.window = NewSendingWindow(worker, worker.OnPacketLoss)
+	worker.window = NewSendingWindow(worker, NewCongestionController(kcp.Config, kcp.roundTrip))
 	return worker
 }
 
@@ -183,15 +194,15 @@ func (w *SendingWorker) Release() {
 	w.Unlock()
 }
 
-func (w *SendingWorker) ProcessReceivingNext(nextNumber uint32) {
+func (w *SendingWorker) ProcessReceivingNext(nextNumber uint32, current uint32) {
 	w.Lock()
 	defer w.Unlock()
 
-	w.ProcessReceivingNextWithoutLock(nextNumber)
+	w.ProcessReceivingNextWithoutLock(nextNumber, current)
 }
 
-func (w *SendingWorker) ProcessReceivingNextWithoutLock(nextNumber uint32) {
-	w.window.Clear(nextNumber)
+func (w *SendingWorker) ProcessReceivingNextWithoutLock(nextNumber uint32, current uint32) {
+	w.window.Clear(nextNumber, current)
 	w.FindFirstUnacknowledged()
 }
 
@@ -207,13 +218,13 @@ func (w *SendingWorker) FindFirstUnacknowledged() {
 	}
 }
 
-func (w *SendingWorker) processAck(number uint32) bool {
+func (w *SendingWorker) processAck(number uint32, current uint32) bool {
 	// number < v.firstUnacknowledged || number >= v.nextNumber
 	if number-w.firstUnacknowledged > 0x7FFFFFFF || number-w.nextNumber < 0x7FFFFFFF {
 		return false
 	}
 
-	removed := w.window.Remove(number)
+	removed := w.window.Remove(number, current)
 	if removed {
 		w.FindFirstUnacknowledged()
 	}
@@ -233,7 +244,7 @@ func (w *SendingWorker) ProcessSegment(current uint32, seg *AckSegment, rto uint
 	if w.remoteNextNumber < seg.ReceivingWindow {
 		w.remoteNextNumber = seg.ReceivingWindow
 	}
-	w.ProcessReceivingNextWithoutLock(seg.ReceivingNext)
+	w.ProcessReceivingNextWithoutLock(seg.ReceivingNext, current)
 
 	if seg.IsEmpty() {
 		return
@@ -242,7 +253,7 @@ func (w *SendingWorker) ProcessSegment(current uint32, seg *AckSegment, rto uint
 	var maxack uint32
 	var maxackRemoved bool
 	for _, number := range seg.NumberList {
-		removed := w.processAck(number)
+		removed := w.processAck(number, current)
 		if maxack < number {
 			maxack = number
 			maxackRemoved = removed
@@ -279,29 +290,25 @@ func (w *SendingWorker) Write(seg Segment) error {
 
 	dataSeg.Conv = w.conn.meta.Conversation
 	dataSeg.SendingNext = w.firstUnacknowledged
-	dataSeg.Option = 0
-	if w.conn.State() == StateReadyToClose {
-		dataSeg.Option = SegmentOptionClose
-	}
+	dataSeg.Option = w.conn.dataOption()
 
-	return w.conn.output.Write(dataSeg)
-}
-
-func (w *SendingWorker) OnPacketLoss(lossRate uint32) {
-	if !w.conn.Config.Congestion || w.conn.roundTrip.Timeout() == 0 {
-		return
+	if err := w.conn.output.Write(dataSeg); err != nil {
+		return err
 	}
-
-	if lossRate >= 15 {
-		w.controlWindow = 3 * w.controlWindow / 4
-	} else if lossRate <= 5 {
-		w.controlWindow += w.controlWindow / 4
-	}
-	if w.controlWindow < 16 {
-		w.controlWindow = 16
+	if w.fec != nil && w.conn.peerFEC.Load() {
+		if parity := w.fec.Add(dataSeg); parity != nil {
+			w.writeParity(parity)
+		}
 	}
-	if w.controlWindow > 2*w.conn.Config.GetSendingInFlightSize() {
-		w.controlWindow = 2 * w.conn.Config.GetSendingInFlightSize()
+	return nil
+}
+
+func (w *SendingWorker) writeParity(parity []*FECSegment) {
+	for _, seg := range parity {
+		seg.Conv = w.conn.meta.Conversation
+		seg.SendingNext = w.firstUnacknowledged
+		w.conn.output.Write(seg)
+		seg.Release()
 	}
 }
 
@@ -313,20 +320,16 @@ func (w *SendingWorker) Flush(current uint32) {
 		return
 	}
 
-	cwnd := w.conn.Config.GetSendingInFlightSize()
-	if cwnd > w.remoteNextNumber-w.firstUnacknowledged {
-		cwnd = w.remoteNextNumber - w.firstUnacknowledged
-	}
-	if w.conn.Config.Congestion && cwnd > w.controlWindow {
-		cwnd = w.controlWindow
-	}
-
-	cwnd *= 20 // magic
-
 	if !w.window.IsEmpty() {
-		w.window.Flush(current, w.conn.roundTrip.Timeout(), cwnd)
+		total, fresh := w.window.controller.Quota(current, w.remoteNextNumber-w.firstUnacknowledged)
+		w.window.Flush(current, w.conn.roundTrip.Timeout(), total, fresh)
 		w.firstUnacknowledgedUpdated = false
 	}
+	if w.fec != nil && w.conn.peerFEC.Load() {
+		if parity := w.fec.Finish(current); parity != nil {
+			w.writeParity(parity)
+		}
+	}
 
 	updated := w.firstUnacknowledgedUpdated
 	w.firstUnacknowledgedUpdated = false
@@ -342,7 +345,7 @@ func (w *SendingWorker) CloseWrite() {
 	w.Lock()
 	defer w.Unlock()
 
-	w.window.Clear(0xFFFFFFFF)
+	w.window.Release()
 }
 
 func (w *SendingWorker) IsEmpty() bool {
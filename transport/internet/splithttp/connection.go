@@ -7,11 +7,12 @@ import (
 )
 
 type splitConn struct {
-	writer     io.WriteCloser
-	reader     io.ReadCloser
-	remoteAddr net.Addr
-	localAddr  net.Addr
-	onClose    func()
+	writer       io.WriteCloser
+	reader       io.ReadCloser
+	remoteAddr   net.Addr
+	localAddr    net.Addr
+	peerIdentity string
+	onClose      func()
 }
 
 func (c *splitConn) Write(b []byte) (int, error) {
@@ -48,6 +49,12 @@ func (c *splitConn) RemoteAddr() net.Addr {
 	return c.remoteAddr
 }
 
+// PeerIdentity returns the identity in the verified TLS client certificate of
+// the download request.
+func (c *splitConn) PeerIdentity() string {
+	return c.peerIdentity
+}
+
 func (c *splitConn) SetDeadline(t time.Time) error {
 	// TODO cannot do anything useful
 	return nil
@@ -34,6 +34,7 @@ type requestHandler struct {
 	sessionMu *sync.Mutex
 	sessions  sync.Map
 	localAddr net.Addr
+	stats     *serverStats
 }
 
 type httpSession struct {
@@ -43,6 +44,8 @@ type httpSession struct {
 	// after the client connects, this becomes "done" and the session lives as
 	// long as the GET request.
 	isFullyConnected *done.Instance
+	removeOnce       sync.Once
+	packetUpOnce     sync.Once
 }
 
 func (h *requestHandler) upsertSession(sessionId string) *httpSession {
@@ -62,11 +65,12 @@ func (h *requestHandler) upsertSession(sessionId string) *httpSession {
 	}
 
 	s := &httpSession{
-		uploadQueue:      NewUploadQueue(h.ln.config.GetNormalizedScMaxBufferedPosts()),
+		uploadQueue:      newUploadQueue(h.ln.config.GetNormalizedScMaxBufferedPosts(), h.stats),
 		isFullyConnected: done.New(),
 	}
 
 	h.sessions.Store(sessionId, s)
+	h.stats.activeSessions.Add(1)
 
 	shouldReap := done.New()
 	go func() {
@@ -76,7 +80,9 @@ func (h *requestHandler) upsertSession(sessionId string) *httpSession {
 	go func() {
 		select {
 		case <-shouldReap.Wait():
-			h.sessions.Delete(sessionId)
+			if h.removeSession(sessionId, s) {
+				h.stats.sessionTimeouts.Add(1)
+			}
 			s.uploadQueue.Close()
 		case <-s.isFullyConnected.Wait():
 		}
@@ -85,6 +91,17 @@ func (h *requestHandler) upsertSession(sessionId string) *httpSession {
 	return s
 }
 
+// removeSession removes s, and returns whether it has not been removed.
+func (h *requestHandler) removeSession(sessionId string, s *httpSession) bool {
+	h.sessions.CompareAndDelete(sessionId, s)
+	removed := false
+	s.removeOnce.Do(func() {
+		h.stats.activeSessions.Add(-1)
+		removed = true
+	})
+	return removed
+}
+
 func (h *requestHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 	if len(h.host) > 0 && !internet.IsValidHTTPHost(request.Host, h.host) {
 		errors.LogInfo(context.Background(), "failed to validate host, request:", request.Host, ", config:", h.host)
@@ -192,6 +209,7 @@ func (h *requestHandler) ServeHTTP(writer http.ResponseWriter, request *http.Req
 				errors.LogInfoInner(context.Background(), err, "failed to upload (PushReader)")
 				writer.WriteHeader(http.StatusConflict)
 			} else {
+				h.stats.streamUp.Add(1)
 				writer.Header().Set("X-Accel-Buffering", "no")
 				writer.Header().Set("Cache-Control", "no-store")
 				writer.WriteHeader(http.StatusOK)
@@ -253,6 +271,9 @@ func (h *requestHandler) ServeHTTP(writer http.ResponseWriter, request *http.Req
 			writer.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+		currentSession.packetUpOnce.Do(func() {
+			h.stats.packetUp.Add(1)
+		})
 
 		writer.WriteHeader(http.StatusOK)
 	} else if request.Method == "GET" || sessionId == "" { // stream-down, stream-one
@@ -260,7 +281,9 @@ func (h *requestHandler) ServeHTTP(writer http.ResponseWriter, request *http.Req
 			// after GET is done, the connection is finished. disable automatic
 			// session reaping, and handle it in defer
 			currentSession.isFullyConnected.Close()
-			defer h.sessions.Delete(sessionId)
+			defer h.removeSession(sessionId, currentSession)
+		} else {
+			h.stats.streamOne.Add(1)
 		}
 
 		// magic header instructs nginx + apache to not buffer response body
@@ -284,10 +307,11 @@ func (h *requestHandler) ServeHTTP(writer http.ResponseWriter, request *http.Req
 			ResponseWriter: writer,
 		}
 		conn := splitConn{
-			writer:     httpSC,
-			reader:     httpSC,
-			remoteAddr: remoteAddr,
-			localAddr:  h.localAddr,
+			writer:       httpSC,
+			reader:       httpSC,
+			remoteAddr:   remoteAddr,
+			localAddr:    h.localAddr,
+			peerIdentity: tls.PeerIdentity(request.TLS),
 		}
 		if sessionId != "" { // if not stream-one
 			conn.reader = currentSession.uploadQueue
@@ -343,6 +367,7 @@ type Listener struct {
 	config     *Config
 	addConn    internet.ConnHandler
 	isH3       bool
+	acme       common.Closable
 }
 
 func ListenXH(ctx context.Context, address net.Address, port net.Port, streamSettings *internet.MemoryStreamConfig, addConn internet.ConnHandler) (internet.Listener, error) {
@@ -362,11 +387,17 @@ func ListenXH(ctx context.Context, address net.Address, port net.Port, streamSet
 		ln:        l,
 		sessionMu: &sync.Mutex{},
 		sessions:  sync.Map{},
+		stats:     newServerStats(ctx),
+	}
+	tlsConfig, acme, err := getTLSConfig(streamSettings)
+	if err != nil {
+		return nil, err
+	}
+	l.acme = acme
+	if config := tls.ConfigFromStreamSettings(streamSettings); config != nil {
+		l.isH3 = len(config.NextProtocol) == 1 && config.NextProtocol[0] == "h3"
 	}
-	tlsConfig := getTLSConfig(streamSettings)
-	l.isH3 = len(tlsConfig.NextProtos) == 1 && tlsConfig.NextProtos[0] == "h3"
 
-	var err error
 	if port == net.Port(0) { // unix
 		l.listener, err = internet.ListenSystem(ctx, &net.UnixAddr{
 			Name: address.Domain(),
@@ -414,9 +445,7 @@ func ListenXH(ctx context.Context, address net.Address, port net.Port, streamSet
 	// tcp/unix (h1/h2)
 	if l.listener != nil {
 		if config := tls.ConfigFromStreamSettings(streamSettings); config != nil {
-			if tlsConfig := config.GetTLSConfig(); tlsConfig != nil {
-				l.listener = gotls.NewListener(l.listener, tlsConfig)
-			}
+			l.listener = gotls.NewListener(l.listener, tlsConfig)
 		}
 		if config := reality.ConfigFromStreamSettings(streamSettings); config != nil {
 			l.listener = goreality.NewListener(l.listener, config.GetREALITYConfig())
@@ -457,6 +486,7 @@ func (ln *Listener) Addr() net.Addr {
 
 // Close implements net.Listener.Close().
 func (ln *Listener) Close() error {
+	common.Close(ln.acme)
 	if ln.h3server != nil {
 		if err := ln.h3server.Close(); err != nil {
 			return err
@@ -466,12 +496,12 @@ func (ln *Listener) Close() error {
 	}
 	return errors.New("listener does not have an HTTP/3 server or a net.listener")
 }
-func getTLSConfig(streamSettings *internet.MemoryStreamConfig) *gotls.Config {
+func getTLSConfig(streamSettings *internet.MemoryStreamConfig) (*gotls.Config, common.Closable, error) {
 	config := tls.ConfigFromStreamSettings(streamSettings)
 	if config == nil {
-		return &gotls.Config{}
+		return &gotls.Config{}, nil, nil
 	}
-	return config.GetTLSConfig()
+	return config.GetServerTLSConfig()
 }
 func init() {
 	common.Must(internet.RegisterTransportListener(protocolName, ListenXH))
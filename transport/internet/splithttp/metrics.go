@@ -0,0 +1,63 @@
+package splithttp
+
+import (
+	"context"
+
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/features/stats"
+)
+
+// serverStats are the counters of a listener, registered as
+// "inbound>>>[tag]>>>xhttp>>>[name]" in the stats manager. activeSessions
+// and queuedPackets are gauges, which are not reset with the others.
+type serverStats struct {
+	activeSessions   stats.Counter
+	sessionTimeouts  stats.Counter
+	streamOne        stats.Counter
+	streamUp         stats.Counter
+	packetUp         stats.Counter
+	reorderedPackets stats.Counter
+	droppedPackets   stats.Counter
+	queuedPackets    stats.Counter
+}
+
+// newServerStats returns the counters of the inbound in ctx. They count
+// nothing if transport stats are not enabled for it.
+func newServerStats(ctx context.Context) *serverStats {
+	manager := stats.ManagerFromContext(ctx)
+	inbound := session.InboundFromContext(ctx)
+	register := func(name string, getOrRegister func(stats.Manager, string) (stats.Counter, error)) stats.Counter {
+		if manager == nil || inbound == nil || len(inbound.Tag) == 0 {
+			return noopCounter{}
+		}
+		c, _ := getOrRegister(manager, "inbound>>>"+inbound.Tag+">>>xhttp>>>"+name)
+		if c == nil {
+			return noopCounter{}
+		}
+		return c
+	}
+	counter := func(name string) stats.Counter {
+		return register(name, stats.GetOrRegisterCounter)
+	}
+	gauge := func(name string) stats.Counter {
+		return register(name, stats.GetOrRegisterGauge)
+	}
+	return &serverStats{
+		activeSessions:   gauge("activeSessions"),
+		sessionTimeouts:  counter("sessionTimeouts"),
+		streamOne:        counter("streamOne"),
+		streamUp:         counter("streamUp"),
+		packetUp:         counter("packetUp"),
+		reorderedPackets: counter("reorderedPackets"),
+		droppedPackets:   counter("droppedPackets"),
+		queuedPackets:    gauge("queuedPackets"),
+	}
+}
+
+type noopCounter struct{}
+
+func (noopCounter) Value() int64 { return 0 }
+
+func (noopCounter) Set(int64) int64 { return 0 }
+
+func (noopCounter) Add(int64) int64 { return 0 }
@@ -12,10 +12,13 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	appstats "github.com/xtls/xray-core/app/stats"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/buf"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	"github.com/xtls/xray-core/common/session"
+	"github.com/xtls/xray-core/features/stats"
 	"github.com/xtls/xray-core/testing/servers/tcp"
 	"github.com/xtls/xray-core/testing/servers/udp"
 	"github.com/xtls/xray-core/transport/internet"
@@ -458,3 +461,84 @@ func Test_maxUpload(t *testing.T) {
 
 	common.Must(listen.Close())
 }
+
+func Test_serverStats(t *testing.T) {
+	statsManager, err := appstats.NewManager(context.Background(), &appstats.Config{})
+	common.Must(err)
+	ctx := stats.ContextWithManager(context.Background(), statsManager)
+	ctx = session.ContextWithInbound(ctx, &session.Inbound{Tag: "in"})
+
+	listenPort := tcp.PickPort()
+	listen, err := ListenXH(ctx, net.LocalHostIP, listenPort, &internet.MemoryStreamConfig{
+		ProtocolName: "splithttp",
+		ProtocolSettings: &Config{
+			Path:          "/sh",
+			XPaddingBytes: &RangeConfig{From: 0, To: 1},
+		},
+	}, func(conn stat.Connection) {
+		go func(c stat.Connection) {
+			defer c.Close()
+
+			var b [2]byte
+			c.SetReadDeadline(time.Now().Add(2 * time.Second))
+			if _, err := io.ReadFull(c, b[:]); err != nil {
+				return
+			}
+			common.Must2(c.Write(b[:]))
+		}(conn)
+	})
+	common.Must(err)
+	defer listen.Close()
+
+	counter := func(name string) int64 {
+		return statsManager.GetCounter("inbound>>>in>>>xhttp>>>" + name).Value()
+	}
+	url := fmt.Sprintf("http://127.0.0.1:%d/sh/session/", listenPort)
+
+	// the second packet arrives first
+	for _, seq := range []string{"1", "0"} {
+		resp, err := http.Post(url+seq, "", bytes.NewReader([]byte{'a' + seq[0] - '0'}))
+		common.Must(err)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatal("status: ", resp.Status)
+		}
+	}
+	if v := counter("activeSessions"); v != 1 {
+		t.Error("activeSessions: ", v)
+	}
+	if v := counter("packetUp"); v != 1 {
+		t.Error("packetUp: ", v)
+	}
+	if v := counter("queuedPackets"); v != 2 {
+		t.Error("queuedPackets: ", v)
+	}
+	for _, name := range []string{"activeSessions", "queuedPackets"} {
+		if _, ok := statsManager.GetCounter("inbound>>>in>>>xhttp>>>" + name).(*appstats.Gauge); !ok {
+			t.Error(name, " is not a gauge")
+		}
+	}
+
+	resp, err := http.Get(url)
+	common.Must(err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ab" {
+		t.Error("response: ", string(body))
+	}
+
+	if v := counter("reorderedPackets"); v != 1 {
+		t.Error("reorderedPackets: ", v)
+	}
+	if v := counter("queuedPackets"); v != 0 {
+		t.Error("queuedPackets: ", v)
+	}
+	if v := counter("droppedPackets"); v != 0 {
+		t.Error("droppedPackets: ", v)
+	}
+	// the session is removed after the GET request ends
+	time.Sleep(100 * time.Millisecond)
+	if v := counter("activeSessions"); v != 0 {
+		t.Error("activeSessions: ", v)
+	}
+}
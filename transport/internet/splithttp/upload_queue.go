@@ -5,6 +5,7 @@ package splithttp
 
 import (
 	"container/heap"
+	"context"
 	"io"
 	"runtime"
 	"sync"
@@ -27,15 +28,36 @@ type uploadQueue struct {
 	nextSeq         uint64
 	closed          bool
 	maxPackets      int
+	stats           *serverStats
+	// queued is the number of packets pushed and not yet read
+	queued       int64
+	queuedAccess sync.Mutex
 }
 
 func NewUploadQueue(maxPackets int) *uploadQueue {
+	return newUploadQueue(maxPackets, newServerStats(context.Background()))
+}
+
+func newUploadQueue(maxPackets int, stats *serverStats) *uploadQueue {
 	return &uploadQueue{
 		pushedPackets: make(chan Packet, maxPackets),
 		heap:          uploadHeap{},
 		nextSeq:       0,
 		closed:        false,
 		maxPackets:    maxPackets,
+		stats:         stats,
+	}
+}
+
+// dequeue counts a packet which is read or dropped, unless the queue is
+// closed and has counted it already.
+func (h *uploadQueue) dequeue() {
+	h.queuedAccess.Lock()
+	defer h.queuedAccess.Unlock()
+
+	if h.queued > 0 {
+		h.queued--
+		h.stats.queuedPackets.Add(-1)
 	}
 }
 
@@ -51,6 +73,11 @@ func (h *uploadQueue) Push(p Packet) error {
 	}
 	if p.Reader != nil {
 		h.nomore = true
+	} else {
+		h.queuedAccess.Lock()
+		h.queued++
+		h.stats.queuedPackets.Add(1)
+		h.queuedAccess.Unlock()
 	}
 	h.pushedPackets <- p
 	return nil
@@ -75,6 +102,15 @@ func (h *uploadQueue) Close() error {
 			}
 		}
 		close(h.pushedPackets)
+
+		// the packets not read yet are dropped
+		h.queuedAccess.Lock()
+		if h.queued > 0 {
+			h.stats.queuedPackets.Add(-h.queued)
+			h.stats.droppedPackets.Add(h.queued)
+			h.queued = 0
+		}
+		h.queuedAccess.Unlock()
 	}
 	if h.reader != nil {
 		return h.reader.Close()
@@ -100,6 +136,7 @@ func (h *uploadQueue) Read(b []byte) (int, error) {
 			h.reader = packet.Reader
 			return h.reader.Read(b)
 		}
+		h.countReordered(packet)
 		heap.Push(&h.heap, packet)
 	}
 
@@ -117,6 +154,7 @@ func (h *uploadQueue) Read(b []byte) (int, error) {
 				heap.Push(&h.heap, packet)
 			} else {
 				h.nextSeq = packet.Seq + 1
+				h.dequeue()
 			}
 
 			return n, nil
@@ -135,13 +173,25 @@ func (h *uploadQueue) Read(b []byte) (int, error) {
 			if !more {
 				return 0, io.EOF
 			}
+			h.countReordered(packet2)
 			heap.Push(&h.heap, packet2)
+		} else {
+			// duplicated packet
+			h.stats.droppedPackets.Add(1)
+			h.dequeue()
 		}
 	}
 
 	return 0, nil
 }
 
+// countReordered counts a packet received out of order.
+func (h *uploadQueue) countReordered(packet Packet) {
+	if packet.Seq > h.nextSeq {
+		h.stats.reorderedPackets.Add(1)
+	}
+}
+
 // heap code directly taken from https://pkg.go.dev/container/heap
 type uploadHeap []Packet
 
@@ -32,3 +32,21 @@ func (c *CounterConnection) Write(b []byte) (int, error) {
 	}
 	return nBytes, err
 }
+
+// PeerIdentifier is a connection whose peer is authenticated by the
+// transport, like with a verified TLS client certificate.
+type PeerIdentifier interface {
+	PeerIdentity() string
+}
+
+// PeerIdentity returns the identity the peer of conn is authenticated with,
+// or empty if there is none.
+func PeerIdentity(conn net.Conn) string {
+	if counterConn, ok := conn.(*CounterConnection); ok {
+		conn = counterConn.Connection
+	}
+	if identifier, ok := conn.(PeerIdentifier); ok {
+		return identifier.PeerIdentity()
+	}
+	return ""
+}
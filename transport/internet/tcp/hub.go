@@ -24,6 +24,7 @@ type Listener struct {
 	authConfig    internet.ConnectionAuthenticator
 	config        *Config
 	addConn       internet.ConnHandler
+	acme          common.Closable
 }
 
 // ListenTCP creates a new Listener based on configurations.
@@ -71,7 +72,10 @@ func ListenTCP(ctx context.Context, address net.Address, port net.Port, streamSe
 	l.listener = listener
 
 	if config := tls.ConfigFromStreamSettings(streamSettings); config != nil {
-		l.tlsConfig = config.GetTLSConfig()
+		if l.tlsConfig, l.acme, err = config.GetServerTLSConfig(); err != nil {
+			listener.Close()
+			return nil, err
+		}
 	}
 	if config := reality.ConfigFromStreamSettings(streamSettings); config != nil {
 		l.realityConfig = config.GetREALITYConfig()
@@ -110,7 +114,13 @@ func (v *Listener) keepAccepting() {
 		}
 		go func() {
 			if v.tlsConfig != nil {
-				conn = tls.Server(conn, v.tlsConfig)
+				tlsConn := tls.Server(conn, v.tlsConfig).(*tls.Conn)
+				if err := tls.VerifyClient(tlsConn, v.tlsConfig); err != nil {
+					errors.LogInfoInner(context.Background(), err, "failed to verify TLS client")
+					tlsConn.Close()
+					return
+				}
+				conn = tlsConn
 			} else if v.realityConfig != nil {
 				if conn, err = reality.Server(conn, v.realityConfig); err != nil {
 					errors.LogInfo(context.Background(), err.Error())
@@ -132,6 +142,7 @@ func (v *Listener) Addr() net.Addr {
 
 // Close implements internet.Listener.Close.
 func (v *Listener) Close() error {
+	common.Close(v.acme)
 	return v.listener.Close()
 }
 
@@ -0,0 +1,334 @@
+package tls
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	gonet "net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+var (
+	acmeAccess   sync.Mutex
+	acmeManagers = make(map[string]*acmeManager)
+	// acmeChallengeServers answer HTTP-01 challenges, by listen address.
+	acmeChallengeServers = make(map[string]*acmeChallengeServer)
+
+	// acmeHTTPManagers are the managers answering HTTP-01 challenges, by
+	// domain, as several inbounds may receive them. A domain may have several
+	// managers, of different accounts or storage paths.
+	acmeHTTPAccess   sync.RWMutex
+	acmeHTTPManagers = make(map[string][]*acmeManager)
+)
+
+// acmeManager is an autocert.Manager shared by the servers of the same
+// account and domains, so certificates are not obtained twice. It is stopped
+// with its challenge server when the last of them is closed.
+type acmeManager struct {
+	*autocert.Manager
+	key             string
+	domains         []string
+	challengeServer *acmeChallengeServer
+	// httpInbound is the tag of the inbound answering HTTP-01 challenges.
+	httpInbound string
+	httpHandler http.Handler
+	refs        int
+}
+
+// acmeChallengeServer is a listener of its own answering HTTP-01 challenges,
+// shared by the managers with the same listen address.
+type acmeChallengeServer struct {
+	addr   string
+	server *http.Server
+	refs   int
+}
+
+func (s *acmeChallengeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	response := acmeHTTPChallengeResponse(r, func(m *acmeManager) bool {
+		return m.challengeServer == s
+	})
+	if response == nil {
+		http.NotFound(w, r)
+		return
+	}
+	for k, v := range response.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(response.status)
+	w.Write(response.body.Bytes())
+}
+
+// acmeHTTPChallengeResponse answers r if it is an HTTP-01 challenge for a
+// domain of a manager which accept returns true for. Of several managers of
+// the domain, the one which knows the token answers, the latest if none does.
+func acmeHTTPChallengeResponse(r *http.Request, accept func(*acmeManager) bool) *acmeResponseRecorder {
+	if !strings.HasPrefix(r.URL.Path, "/.well-known/acme-challenge/") {
+		return nil
+	}
+	host := r.Host
+	if h, _, err := gonet.SplitHostPort(host); err == nil {
+		host = h
+	}
+	acmeHTTPAccess.RLock()
+	var managers []*acmeManager
+	for _, m := range acmeHTTPManagers[strings.ToLower(strings.TrimSuffix(host, "."))] {
+		if accept(m) {
+			managers = append(managers, m)
+		}
+	}
+	acmeHTTPAccess.RUnlock()
+
+	// the host policy of the manager takes no port
+	r.Host = host
+	var response *acmeResponseRecorder
+	for i := len(managers) - 1; i >= 0; i-- {
+		w := &acmeResponseRecorder{header: make(http.Header)}
+		managers[i].httpHandler.ServeHTTP(w, r)
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		if response == nil || w.status == http.StatusOK {
+			response = w
+		}
+		if w.status == http.StatusOK {
+			break
+		}
+	}
+	return response
+}
+
+// ACMEHTTPChallengeResponse returns the response to r if it is an HTTP-01
+// challenge for a domain of a server with ACME, of which the HTTP-01
+// challenges are answered by the inbound with tag, nil otherwise. r is a
+// request in origin form, which the inbound received as the server of the
+// domain.
+func ACMEHTTPChallengeResponse(r *http.Request, tag string) *http.Response {
+	if r.Method != http.MethodGet || r.URL.Host != "" || tag == "" {
+		return nil
+	}
+	w := acmeHTTPChallengeResponse(r, func(m *acmeManager) bool {
+		return m.httpInbound == tag
+	})
+	if w == nil {
+		return nil
+	}
+	return &http.Response{
+		StatusCode:    w.status,
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        w.header,
+		Body:          io.NopCloser(&w.body),
+		ContentLength: int64(w.body.Len()),
+		Close:         true,
+	}
+}
+
+// acmeResponseRecorder keeps the response of an HTTP-01 challenge.
+type acmeResponseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *acmeResponseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *acmeResponseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *acmeResponseRecorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(b)
+}
+
+// acmeLease is a server's use of an acmeManager, closed with the server.
+type acmeLease struct {
+	once sync.Once
+	m    *acmeManager
+}
+
+// Close implements common.Closable.
+func (l *acmeLease) Close() error {
+	var err error
+	l.once.Do(func() {
+		err = l.m.release()
+	})
+	return err
+}
+
+// release drops a use of m, and stops m and its challenge server when they
+// are no longer used.
+func (m *acmeManager) release() error {
+	acmeAccess.Lock()
+	defer acmeAccess.Unlock()
+
+	if m.refs--; m.refs > 0 {
+		return nil
+	}
+	delete(acmeManagers, m.key)
+
+	if m.httpHandler != nil {
+		acmeHTTPAccess.Lock()
+		for _, domain := range m.domains {
+			managers := slices.DeleteFunc(acmeHTTPManagers[domain], func(x *acmeManager) bool {
+				return x == m
+			})
+			if len(managers) == 0 {
+				delete(acmeHTTPManagers, domain)
+			} else {
+				acmeHTTPManagers[domain] = managers
+			}
+		}
+		acmeHTTPAccess.Unlock()
+	}
+
+	s := m.challengeServer
+	if s == nil {
+		return nil
+	}
+	if s.refs--; s.refs > 0 {
+		return nil
+	}
+	delete(acmeChallengeServers, s.addr)
+	errors.LogInfo(context.Background(), "stopped answering ACME HTTP challenges on ", s.addr)
+	return s.server.Close()
+}
+
+// domains returns the lower case domains of c, serverName if there is none.
+func (c *AcmeConfig) domains(serverName string) []string {
+	domains := c.Domains
+	if len(domains) == 0 && serverName != "" {
+		domains = []string{serverName}
+	}
+	lower := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		lower = append(lower, strings.ToLower(strings.TrimSuffix(domain, ".")))
+	}
+	return lower
+}
+
+// acquireManager returns the manager of c, and starts its challenge server
+// if needed. The manager is used until the returned lease is closed.
+func (c *AcmeConfig) acquireManager(domains []string, root *x509.CertPool) (*acmeManager, *acmeLease, error) {
+	if len(domains) == 0 {
+		return nil, nil, errors.New("no domain to obtain certificates for")
+	}
+	if c.StoragePath == "" {
+		return nil, nil, errors.New("ACME storage path is not set")
+	}
+
+	key := strings.Join([]string{c.StoragePath, c.DirectoryUrl, c.Email, strings.Join(domains, ","), c.HttpChallengeListen, c.HttpChallengeInbound}, "|")
+
+	acmeAccess.Lock()
+	defer acmeAccess.Unlock()
+
+	if m, found := acmeManagers[key]; found {
+		m.refs++
+		return m, &acmeLease{m: m}, nil
+	}
+
+	renewBefore := time.Duration(c.RenewBeforeDays) * 24 * time.Hour
+	if renewBefore == 0 {
+		renewBefore = 30 * 24 * time.Hour
+	}
+	m := &acmeManager{
+		Manager: &autocert.Manager{
+			Prompt:      autocert.AcceptTOS,
+			Cache:       autocert.DirCache(c.StoragePath),
+			HostPolicy:  autocert.HostWhitelist(domains...),
+			RenewBefore: renewBefore,
+			Email:       c.Email,
+			Client: &acme.Client{
+				DirectoryURL: c.DirectoryUrl,
+				HTTPClient: &http.Client{
+					Transport: &http.Transport{
+						Proxy:           http.ProxyFromEnvironment,
+						TLSClientConfig: &tls.Config{RootCAs: root},
+					},
+				},
+			},
+		},
+		key:         key,
+		domains:     domains,
+		httpInbound: c.HttpChallengeInbound,
+		refs:        1,
+	}
+	if m.Client.DirectoryURL == "" {
+		m.Client.DirectoryURL = autocert.DefaultACMEDirectory
+	}
+
+	if addr := c.HttpChallengeListen; addr != "" {
+		s, found := acmeChallengeServers[addr]
+		if !found {
+			listener, err := gonet.Listen("tcp", addr)
+			if err != nil {
+				return nil, nil, errors.New("failed to listen for ACME HTTP challenges on ", addr).Base(err)
+			}
+			s = &acmeChallengeServer{addr: addr}
+			s.server = &http.Server{
+				Handler:           s,
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			acmeChallengeServers[addr] = s
+			go func() {
+				if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+					errors.LogErrorInner(context.Background(), err, "ACME HTTP challenge server on ", addr, " stopped")
+				}
+			}()
+			errors.LogInfo(context.Background(), "answering ACME HTTP challenges on ", addr)
+		}
+		s.refs++
+		m.challengeServer = s
+	}
+	if c.HttpChallengeInbound != "" || c.HttpChallengeListen != "" {
+		// HTTP-01 is only tried when challenges are answered, as failed
+		// validations count against the rate limits of the ACME server
+		m.httpHandler = m.HTTPHandler(http.NotFoundHandler())
+		acmeHTTPAccess.Lock()
+		for _, domain := range domains {
+			acmeHTTPManagers[domain] = append(acmeHTTPManagers[domain], m)
+		}
+		acmeHTTPAccess.Unlock()
+	}
+
+	acmeManagers[key] = m
+	return m, &acmeLease{m: m}, nil
+}
+
+// getACMEGetCertificateFunc serves the certificates of m for its domains and
+// TLS-ALPN-01 challenges, and falls back for other server names. Renewed
+// certificates are picked up by the next handshake.
+func getACMEGetCertificateFunc(m *acmeManager, domains []string, fallback func(*tls.ClientHelloInfo) (*tls.Certificate, error)) func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+		if slices.Contains(domains, name) || slices.Contains(hello.SupportedProtos, acme.ALPNProto) {
+			certificate, err := m.GetCertificate(hello)
+			if err != nil {
+				errors.LogErrorInner(context.Background(), err, "failed to get ACME certificate for ", name)
+			}
+			return certificate, err
+		}
+		certificate, err := fallback(hello)
+		if certificate == nil && name == "" {
+			// clients without SNI get the certificate of the first domain
+			h := *hello
+			h.ServerName = domains[0]
+			return m.GetCertificate(&h)
+		}
+		return certificate, err
+	}
+}
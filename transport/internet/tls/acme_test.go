@@ -0,0 +1,272 @@
+package tls_test
+
+import (
+	"bufio"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"log"
+	gonet "net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/letsencrypt/pebble/v2/ca"
+	"github.com/letsencrypt/pebble/v2/db"
+	"github.com/letsencrypt/pebble/v2/va"
+	"github.com/letsencrypt/pebble/v2/wfe"
+	"github.com/miekg/dns"
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	"github.com/xtls/xray-core/testing/servers/tcp"
+	. "github.com/xtls/xray-core/transport/internet/tls"
+)
+
+func TestACMEHTTPChallengeResponse(t *testing.T) {
+	newConfig := func() *Config {
+		return &Config{
+			Acme: &AcmeConfig{
+				DirectoryUrl:         "https://127.0.0.1:1/directory",
+				Domains:              []string{"acme.example.com"},
+				StoragePath:          t.TempDir(),
+				HttpChallengeInbound: "http",
+			},
+		}
+	}
+	_, acme, err := newConfig().GetServerTLSConfig()
+	common.Must(err)
+
+	challenge := "GET /.well-known/acme-challenge/token HTTP/1.1\r\nHost: acme.example.com\r\n\r\n"
+	answered := func(s string, tag string) bool {
+		request, err := http.ReadRequest(bufio.NewReader(strings.NewReader(s)))
+		common.Must(err)
+		return ACMEHTTPChallengeResponse(request, tag) != nil
+	}
+	for _, tc := range []struct {
+		request string
+		tag     string
+		want    bool
+	}{
+		{request: challenge, tag: "http", want: true},
+		// other inbounds are not asked to answer
+		{request: challenge, tag: "other", want: false},
+		{request: challenge, tag: "", want: false},
+		{request: "GET /.well-known/acme-challenge/token HTTP/1.1\r\nHost: other.example.com\r\n\r\n", tag: "http", want: false},
+		{request: "GET / HTTP/1.1\r\nHost: acme.example.com\r\n\r\n", tag: "http", want: false},
+		// requests to proxy are not for the inbound itself
+		{request: "GET http://acme.example.com/.well-known/acme-challenge/token HTTP/1.1\r\nHost: acme.example.com\r\n\r\n", tag: "http", want: false},
+	} {
+		if answered(tc.request, tc.tag) != tc.want {
+			t.Error("response to ", tc.request, " in ", tc.tag)
+		}
+	}
+
+	// another manager of the domain, which is closed first
+	_, other, err := newConfig().GetServerTLSConfig()
+	common.Must(err)
+	common.Must(other.Close())
+	if !answered(challenge, "http") {
+		t.Error("challenges not answered after closing another manager")
+	}
+
+	common.Must(acme.Close())
+	if answered(challenge, "http") {
+		t.Error("challenges answered after close")
+	}
+}
+
+func TestACMECertificate(t *testing.T) {
+	storage := t.TempDir()
+
+	// a certificate obtained before, which needs no ACME server
+	acmeCert := cert.MustGenerate(nil, cert.CommonName("acme.example.com"), cert.DNSNames("acme.example.com"))
+	certPEM, keyPEM := acmeCert.ToPEM()
+	common.Must(os.WriteFile(filepath.Join(storage, "acme.example.com"), append(keyPEM, certPEM...), 0o600))
+
+	staticCert := cert.MustGenerate(nil, cert.CommonName("static.example.com"), cert.DNSNames("static.example.com"))
+
+	c := &Config{
+		Certificate: []*Certificate{ParseCertificate(staticCert)},
+		Acme: &AcmeConfig{
+			DirectoryUrl: "https://127.0.0.1:1/directory",
+			Domains:      []string{"acme.example.com"},
+			StoragePath:  storage,
+		},
+	}
+	tlsConfig, acme, err := c.GetServerTLSConfig()
+	common.Must(err)
+	defer acme.Close()
+
+	if slices.Contains(c.GetTLSConfig().NextProtos, "acme-tls/1") {
+		t.Error("ACME is set up for clients")
+	}
+	if !slices.Contains(tlsConfig.NextProtos, "acme-tls/1") {
+		t.Error("NextProtos: ", tlsConfig.NextProtos)
+	}
+
+	for _, tc := range []struct {
+		serverName string
+		want       string
+	}{
+		{serverName: "acme.example.com", want: "acme.example.com"},
+		{serverName: "static.example.com", want: "static.example.com"},
+	} {
+		certificate, err := tlsConfig.GetCertificate(&gotls.ClientHelloInfo{
+			ServerName:       tc.serverName,
+			SignatureSchemes: []gotls.SignatureScheme{gotls.ECDSAWithP256AndSHA256},
+			CipherSuites:     []uint16{gotls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		})
+		common.Must(err)
+		leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+		common.Must(err)
+		if leaf.Subject.CommonName != tc.want {
+			t.Error("certificate of ", tc.serverName, ": ", leaf.Subject.CommonName)
+		}
+	}
+}
+
+// startPebble starts a Pebble ACME server, which resolves every domain to
+// 127.0.0.1 and validates HTTP-01 and TLS-ALPN-01 challenges on the given
+// ports. It returns the directory URL and the certificate to trust it with.
+func startPebble(t *testing.T, httpPort, tlsPort int) (string, *Certificate) {
+	resolver := &dns.Server{
+		Addr: "127.0.0.1:" + strconv.Itoa(int(tcp.PickPort())),
+		Net:  "tcp",
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+			m := new(dns.Msg)
+			m.SetReply(r)
+			if q := r.Question[0]; q.Qtype == dns.TypeA {
+				m.Answer = append(m.Answer, &dns.A{
+					Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+					A:   gonet.IPv4(127, 0, 0, 1),
+				})
+			}
+			w.WriteMsg(m)
+		}),
+	}
+	started := make(chan struct{})
+	resolver.NotifyStartedFunc = func() { close(started) }
+	go resolver.ListenAndServe()
+	<-started
+	t.Cleanup(func() { resolver.Shutdown() })
+
+	t.Setenv("PEBBLE_VA_NOSLEEP", "1")
+	logger := log.New(io.Discard, "", 0)
+	store := db.NewMemoryStore()
+	authority := ca.New(logger, store, "", "ecdsa", 0, 1, map[string]ca.Profile{"default": {}})
+	validator := va.New(logger, httpPort, tlsPort, false, resolver.Addr, store)
+	frontEnd := wfe.New(logger, store, validator, authority, []string{"pebble.letsencrypt.org"}, false, false, 0, 0)
+
+	handler := frontEnd.Handler()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// x/crypto/acme polls the order at the Location of the finalize
+		// response, which Pebble leaves out
+		if id, ok := strings.CutPrefix(r.URL.Path, "/finalize-order/"); ok {
+			w.Header().Set("Location", "https://"+r.Host+"/my-order/"+id)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server.URL + wfe.DirectoryPath, &Certificate{
+		Certificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}),
+		Usage:       Certificate_AUTHORITY_VERIFY,
+	}
+}
+
+func TestACMEPebble(t *testing.T) {
+	for _, challenge := range []string{"tls-alpn-01", "http-01", "http-01-inbound"} {
+		t.Run(challenge, func(t *testing.T) {
+			httpPort := int(tcp.PickPort())
+			tlsPort := int(tcp.PickPort())
+			directory, root := startPebble(t, httpPort, tlsPort)
+
+			c := &Config{
+				ServerName:  "acme.test",
+				Certificate: []*Certificate{root},
+				Acme: &AcmeConfig{
+					DirectoryUrl: directory,
+					StoragePath:  t.TempDir(),
+				},
+			}
+			switch challenge {
+			case "http-01":
+				c.Acme.HttpChallengeListen = "127.0.0.1:" + strconv.Itoa(httpPort)
+			case "http-01-inbound":
+				c.Acme.HttpChallengeInbound = "http"
+				// answers challenges the way the HTTP inbound does
+				listener, err := gonet.Listen("tcp", "127.0.0.1:"+strconv.Itoa(httpPort))
+				common.Must(err)
+				defer listener.Close()
+				go func() {
+					for {
+						conn, err := listener.Accept()
+						if err != nil {
+							return
+						}
+						go func() {
+							defer conn.Close()
+							request, err := http.ReadRequest(bufio.NewReader(conn))
+							if err != nil {
+								return
+							}
+							if response := ACMEHTTPChallengeResponse(request, "http"); response != nil {
+								response.Write(conn)
+							}
+						}()
+					}
+				}()
+			}
+			tlsConfig, acme, err := c.GetServerTLSConfig()
+			common.Must(err)
+
+			if challenge == "tls-alpn-01" {
+				// the server answers the challenges itself
+				listener, err := gotls.Listen("tcp", "127.0.0.1:"+strconv.Itoa(tlsPort), tlsConfig)
+				common.Must(err)
+				defer listener.Close()
+				go func() {
+					for {
+						conn, err := listener.Accept()
+						if err != nil {
+							return
+						}
+						go func() {
+							conn.(*gotls.Conn).Handshake()
+							conn.Close()
+						}()
+					}
+				}()
+			}
+
+			certificate, err := tlsConfig.GetCertificate(&gotls.ClientHelloInfo{
+				ServerName:       "acme.test",
+				SignatureSchemes: []gotls.SignatureScheme{gotls.ECDSAWithP256AndSHA256},
+				CipherSuites:     []uint16{gotls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+			common.Must(err)
+			if !slices.Contains(leaf.DNSNames, "acme.test") {
+				t.Error("DNS names of the certificate: ", leaf.DNSNames)
+			}
+
+			common.Must(acme.Close())
+			if challenge == "http-01" {
+				// the challenge server is stopped with the last user
+				listener, err := gonet.Listen("tcp", c.Acme.HttpChallengeListen)
+				if err != nil {
+					t.Fatal("challenge listener is not closed: ", err)
+				}
+				listener.Close()
+			}
+		})
+	}
+}
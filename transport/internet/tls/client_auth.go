@@ -0,0 +1,209 @@
+package tls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+)
+
+const (
+	crlCheckInterval       = time.Minute
+	clientHandshakeTimeout = 10 * time.Second
+)
+
+// applyClientAuth makes config verify client certificates as c requires.
+func (c *ClientAuth) applyClientAuth(config *tls.Config) error {
+	pool := x509.NewCertPool()
+	var authorities []*x509.Certificate
+	for _, pemBytes := range c.CertificateAuthority {
+		for {
+			var block *pem.Block
+			block, pemBytes = pem.Decode(pemBytes)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			authority, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return errors.New("failed to parse client CA").Base(err)
+			}
+			pool.AddCert(authority)
+			authorities = append(authorities, authority)
+		}
+	}
+	if len(authorities) == 0 {
+		return errors.New("no client CA")
+	}
+
+	config.ClientCAs = pool
+	switch c.Mode {
+	case ClientAuth_VERIFY_IF_GIVEN:
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	if c.CrlPath != "" {
+		checker := &crlChecker{
+			path:        c.CrlPath,
+			authorities: authorities,
+		}
+		if err := checker.reload(); err != nil {
+			return err
+		}
+		config.VerifyConnection = checker.verifyConnection
+	}
+	return nil
+}
+
+// crlChecker rejects client certificates revoked by the CRLs of a file,
+// which is reloaded when it is modified.
+type crlChecker struct {
+	path        string
+	authorities []*x509.Certificate
+
+	access    sync.Mutex
+	checkTime time.Time
+	modTime   time.Time
+	// revoked holds the issuer and the serial number of revoked certificates.
+	revoked map[string]struct{}
+}
+
+func revocationKey(rawIssuer []byte, serial string) string {
+	return string(rawIssuer) + "|" + serial
+}
+
+func (c *crlChecker) reload() error {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return errors.New("failed to read CRL file ", c.path).Base(err)
+	}
+	c.checkTime = time.Now()
+	if info.ModTime().Equal(c.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return errors.New("failed to read CRL file ", c.path).Base(err)
+	}
+	var ders [][]byte
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == "X509 CRL" {
+			ders = append(ders, block.Bytes)
+		}
+	}
+	if len(ders) == 0 {
+		// a DER encoded CRL
+		ders = append(ders, data)
+	}
+
+	revoked := make(map[string]struct{})
+	for _, der := range ders {
+		crl, err := x509.ParseRevocationList(der)
+		if err != nil {
+			return errors.New("failed to parse CRL file ", c.path).Base(err)
+		}
+		if !c.verifyCRL(crl) {
+			errors.LogWarning(context.Background(), "CRL in ", c.path, " is not signed by any client CA, ignored")
+			continue
+		}
+		if !crl.NextUpdate.IsZero() && crl.NextUpdate.Before(time.Now()) {
+			errors.LogWarning(context.Background(), "CRL in ", c.path, " is out of date since ", crl.NextUpdate.Format(time.RFC3339))
+		}
+		for _, entry := range crl.RevokedCertificateEntries {
+			revoked[revocationKey(crl.RawIssuer, entry.SerialNumber.String())] = struct{}{}
+		}
+	}
+
+	c.modTime = info.ModTime()
+	c.revoked = revoked
+	errors.LogInfo(context.Background(), "loaded ", len(revoked), " revoked certificates from ", c.path)
+	return nil
+}
+
+func (c *crlChecker) verifyCRL(crl *x509.RevocationList) bool {
+	for _, authority := range c.authorities {
+		if crl.CheckSignatureFrom(authority) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *crlChecker) verifyConnection(state tls.ConnectionState) error {
+	if len(state.VerifiedChains) == 0 {
+		return nil
+	}
+
+	c.access.Lock()
+	if time.Since(c.checkTime) > crlCheckInterval {
+		if err := c.reload(); err != nil {
+			// keep the CRLs loaded before
+			errors.LogWarningInner(context.Background(), err, "failed to reload CRL")
+		}
+	}
+	revoked := c.revoked
+	c.access.Unlock()
+
+	for _, chain := range state.VerifiedChains {
+		if !isChainRevoked(chain, revoked) {
+			return nil
+		}
+	}
+	return errors.New("client certificate ", state.VerifiedChains[0][0].SerialNumber, " is revoked")
+}
+
+func isChainRevoked(chain []*x509.Certificate, revoked map[string]struct{}) bool {
+	for _, cert := range chain {
+		if _, found := revoked[revocationKey(cert.RawIssuer, cert.SerialNumber.String())]; found {
+			return true
+		}
+	}
+	return false
+}
+
+// PeerIdentity returns the identity in the verified client certificate of a
+// TLS server connection, or empty if there is none. It is the first email
+// address, DNS name or URI in the certificate, or else its common name.
+func PeerIdentity(state *tls.ConnectionState) string {
+	if state == nil || !state.HandshakeComplete || len(state.VerifiedChains) == 0 {
+		return ""
+	}
+	leaf := state.VerifiedChains[0][0]
+	switch {
+	case len(leaf.EmailAddresses) > 0:
+		return leaf.EmailAddresses[0]
+	case len(leaf.DNSNames) > 0:
+		return leaf.DNSNames[0]
+	case len(leaf.URIs) > 0:
+		return leaf.URIs[0].String()
+	default:
+		return leaf.Subject.CommonName
+	}
+}
+
+// VerifyClient completes the handshake of a server connection when config
+// verifies client certificates, so that the identity of the client is known
+// before listeners hand the connection to inbounds.
+func VerifyClient(conn *Conn, config *tls.Config) error {
+	if config.ClientAuth == tls.NoClientCert {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), clientHandshakeTimeout)
+	defer cancel()
+	return conn.HandshakeContext(ctx)
+}
@@ -0,0 +1,110 @@
+package tls_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	. "github.com/xtls/xray-core/transport/internet/tls"
+)
+
+func clientCertificate(parent *cert.Certificate, email string) *cert.Certificate {
+	return cert.MustGenerate(parent, cert.CommonName("client"), func(c *x509.Certificate) {
+		c.EmailAddresses = []string{email}
+		c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	})
+}
+
+// handshake returns the peer identity the server sees, or the handshake error.
+func handshake(serverConfig *gotls.Config, client *cert.Certificate) (string, error) {
+	clientPEM, clientKeyPEM := client.ToPEM()
+	clientCert, err := gotls.X509KeyPair(clientPEM, clientKeyPEM)
+	common.Must(err)
+
+	c, s := net.Pipe()
+	defer c.Close()
+	defer s.Close()
+
+	go func() {
+		conn := gotls.Client(c, &gotls.Config{
+			ServerName:         "example.com",
+			InsecureSkipVerify: true,
+			Certificates:       []gotls.Certificate{clientCert},
+		})
+		conn.Handshake()
+		// wait for the server to verify the certificate
+		conn.Read(make([]byte, 1))
+	}()
+
+	conn := Server(s, serverConfig).(*Conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return "", err
+	}
+	return conn.PeerIdentity(), nil
+}
+
+func TestClientAuth(t *testing.T) {
+	anyUsage := func(c *x509.Certificate) {
+		c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+	ca := cert.MustGenerate(nil, cert.Authority(true), cert.KeyUsage(x509.KeyUsageCertSign|x509.KeyUsageCRLSign), anyUsage)
+	caPEM, _ := ca.ToPEM()
+	otherCA := cert.MustGenerate(nil, cert.Authority(true), cert.KeyUsage(x509.KeyUsageCertSign), anyUsage)
+
+	good := clientCertificate(ca, "good@example.com")
+	revoked := clientCertificate(ca, "revoked@example.com")
+	stranger := clientCertificate(otherCA, "stranger@example.com")
+
+	caCert, err := x509.ParseCertificate(ca.Certificate)
+	common.Must(err)
+	caKey, err := x509.ParsePKCS8PrivateKey(ca.PrivateKey)
+	common.Must(err)
+	revokedCert, err := x509.ParseCertificate(revoked.Certificate)
+	common.Must(err)
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now(),
+		NextUpdate: time.Now().Add(time.Hour),
+		RevokedCertificateEntries: []x509.RevocationListEntry{
+			{SerialNumber: revokedCert.SerialNumber, RevocationTime: time.Now()},
+		},
+	}, caCert, caKey.(crypto.Signer))
+	common.Must(err)
+	crlPath := filepath.Join(t.TempDir(), "client.crl")
+	common.Must(os.WriteFile(crlPath, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl}), 0o600))
+
+	c := &Config{
+		Certificate: []*Certificate{ParseCertificate(cert.MustGenerate(nil, cert.DNSNames("example.com")))},
+		ClientAuth: &ClientAuth{
+			CertificateAuthority: [][]byte{caPEM},
+			CrlPath:              crlPath,
+		},
+	}
+	serverConfig := c.GetTLSConfig()
+
+	identity, err := handshake(serverConfig, good)
+	common.Must(err)
+	if identity != "good@example.com" {
+		t.Error("identity: ", identity)
+	}
+
+	if _, err := handshake(serverConfig, revoked); err == nil {
+		t.Error("revoked certificate accepted")
+	}
+	if _, err := handshake(serverConfig, stranger); err == nil {
+		t.Error("certificate of another CA accepted")
+	}
+}
@@ -14,12 +14,14 @@ import (
 	"sync"
 	"time"
 
+	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/errors"
 	"github.com/xtls/xray-core/common/net"
 	"github.com/xtls/xray-core/common/ocsp"
 	"github.com/xtls/xray-core/common/platform/filesystem"
 	"github.com/xtls/xray-core/common/protocol/tls/cert"
 	"github.com/xtls/xray-core/transport/internet"
+	"golang.org/x/crypto/acme"
 )
 
 var globalSessionCache = tls.NewLRUClientSessionCache(128)
@@ -405,6 +407,20 @@ func (c *Config) GetTLSConfig(opts ...Option) *tls.Config {
 		config.NextProtos = []string{"h2", "http/1.1"}
 	}
 
+	if c.ClientAuth != nil {
+		if err := c.ClientAuth.applyClientAuth(config); err != nil {
+			errors.LogErrorInner(context.Background(), err, "failed to set up client certificate verification, rejecting all clients")
+			config.ClientCAs = x509.NewCertPool()
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	if c.SessionTicketKeys != nil {
+		if err := c.SessionTicketKeys.applySessionTicketKeys(config); err != nil {
+			errors.LogErrorInner(context.Background(), err, "failed to load session ticket keys")
+		}
+	}
+
 	switch c.MinVersion {
 	case "1.0":
 		config.MinVersion = tls.VersionTLS10
@@ -461,6 +477,29 @@ func (c *Config) GetTLSConfig(opts ...Option) *tls.Config {
 	return config
 }
 
+// GetServerTLSConfig converts this Config into the tls.Config of a server.
+// Unlike GetTLSConfig, it also obtains and renews certificates with ACME,
+// until the returned Closable, if any, is closed with the listener.
+func (c *Config) GetServerTLSConfig(opts ...Option) (*tls.Config, common.Closable, error) {
+	config := c.GetTLSConfig(opts...)
+	if c == nil || c.Acme == nil {
+		return config, nil, nil
+	}
+
+	root, err := c.getCertPool()
+	if err != nil {
+		errors.LogErrorInner(context.Background(), err, "failed to load system root certificate")
+	}
+	domains := c.Acme.domains(c.ServerName)
+	m, lease, err := c.Acme.acquireManager(domains, root)
+	if err != nil {
+		return nil, nil, errors.New("failed to set up ACME").Base(err)
+	}
+	config.GetCertificate = getACMEGetCertificateFunc(m, domains, config.GetCertificate)
+	config.NextProtos = append(config.NextProtos, acme.ALPNProto)
+	return config, lease, nil
+}
+
 // Option for building TLS config.
 type Option func(*tls.Config)
 
@@ -0,0 +1,148 @@
+package tls
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/platform/filesystem"
+)
+
+const sessionTicketKeysReloadInterval = 10 * time.Second
+
+// sessionTicketKeys keeps the ticket keys of a server up to date with the
+// key files and the rotation.
+type sessionTicketKeys struct {
+	config       *SessionTicketKeys
+	ticketConfig *tls.Config
+
+	access     sync.Mutex
+	checkTime  time.Time
+	contents   [][]byte
+	keys       [][32]byte
+	activeTurn int64
+}
+
+// applySessionTicketKeys makes config encrypt and decrypt tickets with the
+// keys, which are updated on handshakes. The keys are kept in a config of
+// their own, so that clones of config, like those of gRPC and net/http, use
+// the updated keys too.
+func (c *SessionTicketKeys) applySessionTicketKeys(config *tls.Config) error {
+	k := &sessionTicketKeys{
+		config:       c,
+		ticketConfig: &tls.Config{},
+		activeTurn:   -1,
+	}
+	if err := k.update(time.Now()); err != nil {
+		return err
+	}
+	config.SessionTicketsDisabled = false
+	config.WrapSession = func(state tls.ConnectionState, session *tls.SessionState) ([]byte, error) {
+		return k.get().EncryptTicket(state, session)
+	}
+	config.UnwrapSession = func(identity []byte, state tls.ConnectionState) (*tls.SessionState, error) {
+		return k.get().DecryptTicket(identity, state)
+	}
+	return nil
+}
+
+// get returns the config with the up to date keys.
+func (k *sessionTicketKeys) get() *tls.Config {
+	k.access.Lock()
+	defer k.access.Unlock()
+	if err := k.update(time.Now()); err != nil {
+		// keep the keys loaded before
+		errors.LogWarningInner(context.Background(), err, "failed to reload session ticket keys")
+	}
+	return k.ticketConfig
+}
+
+// update reloads the key files if they are not checked recently, and sets
+// the keys if they or the active key change.
+func (k *sessionTicketKeys) update(now time.Time) error {
+	changed := false
+	if now.Sub(k.checkTime) >= sessionTicketKeysReloadInterval {
+		k.checkTime = now
+		contents := make([][]byte, len(k.config.KeyFiles))
+		for i, file := range k.config.KeyFiles {
+			content, err := filesystem.ReadFile(file)
+			if err != nil {
+				return errors.New("failed to read session ticket key file ", file).Base(err)
+			}
+			contents[i] = content
+		}
+		if !equalContents(contents, k.contents) {
+			keys, err := parseSessionTicketKeys(contents)
+			if err != nil {
+				return err
+			}
+			k.contents = contents
+			k.keys = keys
+			changed = true
+			errors.LogInfo(context.Background(), "loaded ", len(keys), " session ticket keys")
+		}
+	}
+
+	var turn int64
+	if interval := int64(k.config.RotationInterval); interval > 0 {
+		turn = now.Unix() / interval
+	}
+	if changed || turn != k.activeTurn {
+		k.activeTurn = turn
+		k.ticketConfig.SetSessionTicketKeys(rotateSessionTicketKeys(k.keys, turn))
+	}
+	return nil
+}
+
+func equalContents(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseSessionTicketKeys(contents [][]byte) ([][32]byte, error) {
+	var keys [][32]byte
+	for _, content := range contents {
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			b, err := base64.StdEncoding.DecodeString(line)
+			if err != nil {
+				return nil, errors.New("invalid session ticket key").Base(err)
+			}
+			if len(b) != 32 {
+				return nil, errors.New("session ticket key of ", len(b), " bytes, 32 expected")
+			}
+			keys = append(keys, [32]byte(b))
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no session ticket key")
+	}
+	return keys, nil
+}
+
+// rotateSessionTicketKeys moves the active key of the turn to the front, as
+// the first key encrypts new tickets. Servers with the same keys and clock
+// agree on the active key.
+func rotateSessionTicketKeys(keys [][32]byte, turn int64) [][32]byte {
+	active := int(turn % int64(len(keys)))
+	rotated := make([][32]byte, 0, len(keys))
+	rotated = append(rotated, keys[active:]...)
+	return append(rotated, keys[:active]...)
+}
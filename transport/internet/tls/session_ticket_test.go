@@ -0,0 +1,87 @@
+package tls_test
+
+import (
+	"crypto/rand"
+	gotls "crypto/tls"
+	"encoding/base64"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xtls/xray-core/common"
+	"github.com/xtls/xray-core/common/protocol/tls/cert"
+	. "github.com/xtls/xray-core/transport/internet/tls"
+)
+
+// resume connects to a server with clientConfig, and returns whether the
+// session is resumed.
+func resume(serverConfig *gotls.Config, clientConfig *gotls.Config) bool {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	common.Must(err)
+	defer listener.Close()
+
+	go func() {
+		s, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		conn := Server(s, serverConfig)
+		defer conn.Close()
+		conn.Write([]byte{0})
+	}()
+
+	c, err := net.Dial("tcp", listener.Addr().String())
+	common.Must(err)
+	defer c.Close()
+
+	conn := gotls.Client(c, clientConfig)
+	common.Must(conn.Handshake())
+	// the ticket arrives with the data after the handshake
+	_, err = conn.Read(make([]byte, 1))
+	common.Must(err)
+	return conn.ConnectionState().DidResume
+}
+
+func TestSessionTicketKeys(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "ticket.keys")
+	var keys []byte
+	for range 3 {
+		key := make([]byte, 32)
+		common.Must2(rand.Read(key))
+		keys = append(keys, base64.StdEncoding.EncodeToString(key)+"\n"...)
+	}
+	common.Must(os.WriteFile(keyFile, keys, 0o600))
+
+	c := &Config{
+		Certificate: []*Certificate{ParseCertificate(cert.MustGenerate(nil, cert.DNSNames("example.com")))},
+		SessionTicketKeys: &SessionTicketKeys{
+			KeyFiles:         []string{keyFile},
+			RotationInterval: 3600,
+		},
+	}
+	clientConfig := &gotls.Config{
+		ServerName:         "example.com",
+		InsecureSkipVerify: true,
+		ClientSessionCache: gotls.NewLRUClientSessionCache(8),
+	}
+
+	// two servers, as two nodes behind a load balancer
+	if resume(c.GetTLSConfig(), clientConfig) {
+		t.Error("resumed the first session")
+	}
+	if !resume(c.GetTLSConfig(), clientConfig) {
+		t.Error("failed to resume the session on another server")
+	}
+	// gRPC and net/http serve with clones of the config
+	if !resume(c.GetTLSConfig().Clone(), clientConfig) {
+		t.Error("failed to resume the session with a clone of the config")
+	}
+
+	other := &Config{
+		Certificate: c.Certificate,
+	}
+	if resume(other.GetTLSConfig(), clientConfig) {
+		t.Error("resumed the session on a server without the keys")
+	}
+}
@@ -56,6 +56,13 @@ func (c *Conn) NegotiatedProtocol() string {
 	return state.NegotiatedProtocol
 }
 
+// PeerIdentity returns the identity in the verified client certificate, once
+// the handshake is complete.
+func (c *Conn) PeerIdentity() string {
+	state := c.ConnectionState()
+	return PeerIdentity(&state)
+}
+
 // Client initiates a TLS client handshake on the given connection.
 func Client(c net.Conn, config *tls.Config) net.Conn {
 	tlsConn := tls.Client(c, config)
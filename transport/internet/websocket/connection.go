@@ -17,9 +17,10 @@ var _ buf.Writer = (*connection)(nil)
 // remoteAddr is used to pass "virtual" remote IP addresses in X-Forwarded-For.
 // so we shouldn't directly read it form conn.
 type connection struct {
-	conn       *websocket.Conn
-	reader     io.Reader
-	remoteAddr net.Addr
+	conn         *websocket.Conn
+	reader       io.Reader
+	remoteAddr   net.Addr
+	peerIdentity string
 }
 
 func NewConnection(conn *websocket.Conn, remoteAddr net.Addr, extraReader io.Reader, heartbeatPeriod uint32) *connection {
@@ -108,6 +109,11 @@ func (c *connection) RemoteAddr() net.Addr {
 	return c.remoteAddr
 }
 
+// PeerIdentity returns the identity in the verified TLS client certificate.
+func (c *connection) PeerIdentity() string {
+	return c.peerIdentity
+}
+
 func (c *connection) SetDeadline(t time.Time) error {
 	if err := c.SetReadDeadline(t); err != nil {
 		return err
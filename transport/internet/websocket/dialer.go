@@ -51,9 +51,10 @@ func dialWebSocket(ctx context.Context, dest net.Destination, streamSettings *in
 		NetDial: func(network, addr string) (net.Conn, error) {
 			return internet.DialSystem(ctx, dest, streamSettings.SocketSettings)
 		},
-		ReadBufferSize:   4 * 1024,
-		WriteBufferSize:  4 * 1024,
-		HandshakeTimeout: time.Second * 8,
+		ReadBufferSize:    4 * 1024,
+		WriteBufferSize:   4 * 1024,
+		HandshakeTimeout:  time.Second * 8,
+		EnableCompression: wsSettings.Compression,
 	}
 
 	protocol := "ws"
@@ -94,6 +95,18 @@ func dialWebSocket(ctx context.Context, dest net.Destination, streamSettings *in
 	}
 	uri := protocol + "://" + host + wsSettings.GetNormalizedPath()
 
+	if wsSettings.H2 && !browser_dialer.HasBrowserDialer() {
+		transport := getH2Transport(dest, streamSettings)
+		dialer.NetDial = nil
+		dialer.NetDialTLSContext = nil
+		dialer.TLSClientConfig = nil
+		dialer.NetDialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return newH2ClientConn(ctx, transport, tConfig != nil), nil
+		}
+		// the stream is secured by the transport
+		uri = "ws://" + host + wsSettings.GetNormalizedPath()
+	}
+
 	if browser_dialer.HasBrowserDialer() {
 		conn, err := browser_dialer.DialWS(uri, ed)
 		if err != nil {
@@ -0,0 +1,415 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	gotls "crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xtls/xray-core/common/errors"
+	"github.com/xtls/xray-core/common/net"
+	"github.com/xtls/xray-core/transport/internet"
+	"github.com/xtls/xray-core/transport/internet/tls"
+	"golang.org/x/net/http2"
+	"google.golang.org/protobuf/proto"
+)
+
+// WebSockets over HTTP/2 (RFC 8441) go in streams of extended CONNECT
+// requests. The HTTP/1.1 handshakes of gorilla/websocket are translated to
+// and from these requests, and the frames go as they are.
+
+// handshakeEnd ends the headers of an HTTP/1.1 handshake.
+var handshakeEnd = []byte("\r\n\r\n")
+
+func computeAcceptKey(challengeKey string) string {
+	h := sha1.New()
+	h.Write([]byte(challengeKey))
+	h.Write([]byte("258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// isExtendedConnect returns whether r asks for a WebSocket over HTTP/2.
+func isExtendedConnect(r *http.Request) bool {
+	return r.Method == http.MethodConnect && r.Header.Get(":protocol") == "websocket"
+}
+
+type h2TransportKey struct {
+	dest net.Destination
+	// settings are the socket and TLS settings the transport dials with, for
+	// outbounds of the same settings to share it.
+	settings string
+}
+
+func newH2TransportKey(dest net.Destination, streamSettings *internet.MemoryStreamConfig) h2TransportKey {
+	var settings []byte
+	for _, m := range []proto.Message{streamSettings.SocketSettings, tls.ConfigFromStreamSettings(streamSettings)} {
+		b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+		settings = binary.AppendUvarint(settings, uint64(len(b)))
+		settings = append(settings, b...)
+	}
+	return h2TransportKey{dest: dest, settings: string(settings)}
+}
+
+var (
+	h2TransportsAccess sync.Mutex
+	h2Transports       map[h2TransportKey]*http2.Transport
+)
+
+// getH2Transport returns the transport to dest, whose connections are shared
+// by WebSockets of the same settings.
+func getH2Transport(dest net.Destination, streamSettings *internet.MemoryStreamConfig) *http2.Transport {
+	h2TransportsAccess.Lock()
+	defer h2TransportsAccess.Unlock()
+
+	if h2Transports == nil {
+		h2Transports = make(map[h2TransportKey]*http2.Transport)
+	}
+	key := newH2TransportKey(dest, streamSettings)
+	if transport, found := h2Transports[key]; found {
+		return transport
+	}
+
+	tConfig := tls.ConfigFromStreamSettings(streamSettings)
+	transport := &http2.Transport{
+		DialTLSContext: func(ctx context.Context, _, _ string, _ *gotls.Config) (net.Conn, error) {
+			conn, err := internet.DialSystem(ctx, dest, streamSettings.SocketSettings)
+			if err != nil {
+				return nil, err
+			}
+			if tConfig == nil {
+				// h2c with prior knowledge
+				return conn, nil
+			}
+			tlsConfig := tConfig.GetTLSConfig(tls.WithDestination(dest), tls.WithNextProto("h2"))
+			var tlsConn tls.Interface
+			if fingerprint := tls.GetFingerprint(tConfig.Fingerprint); fingerprint != nil {
+				tlsConn = tls.UClient(conn, tlsConfig, fingerprint).(*tls.UConn)
+			} else {
+				tlsConn = tls.Client(conn, tlsConfig).(*tls.Conn)
+			}
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			if protocol := tlsConn.NegotiatedProtocol(); protocol != http2.NextProtoTLS {
+				tlsConn.Close()
+				return nil, errors.New("server does not support HTTP/2, negotiated: ", protocol)
+			}
+			return tlsConn, nil
+		},
+		AllowHTTP:       true,
+		IdleConnTimeout: net.ConnIdleTimeout,
+		ReadIdleTimeout: net.ChromeH2KeepAlivePeriod,
+	}
+	h2Transports[key] = transport
+	return transport
+}
+
+// h2ClientConn is the connection gorilla/websocket dials. It sends the
+// handshake request as an extended CONNECT request in a stream of transport,
+// and answers with a handshake response of the result.
+type h2ClientConn struct {
+	ctx       context.Context
+	transport *http2.Transport
+	scheme    string
+
+	request bytes.Buffer
+	reader  io.Reader
+	writer  *io.PipeWriter
+	body    io.Closer
+	cancel  context.CancelFunc
+
+	localAddr  net.Addr
+	remoteAddr net.Addr
+}
+
+func newH2ClientConn(ctx context.Context, transport *http2.Transport, secure bool) *h2ClientConn {
+	c := &h2ClientConn{
+		ctx:        ctx,
+		transport:  transport,
+		scheme:     "http",
+		localAddr:  &net.TCPAddr{},
+		remoteAddr: &net.TCPAddr{},
+	}
+	if secure {
+		c.scheme = "https"
+	}
+	return c
+}
+
+func (c *h2ClientConn) Write(b []byte) (int, error) {
+	if c.writer != nil {
+		return c.writer.Write(b)
+	}
+	c.request.Write(b)
+	if !bytes.Contains(c.request.Bytes(), handshakeEnd) {
+		return len(b), nil
+	}
+	if err := c.connect(); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *h2ClientConn) connect() error {
+	request, err := http.ReadRequest(bufio.NewReader(&c.request))
+	if err != nil {
+		return errors.New("invalid WebSocket handshake").Base(err)
+	}
+	challengeKey := request.Header.Get("Sec-WebSocket-Key")
+
+	header := request.Header.Clone()
+	for _, key := range []string{"Connection", "Upgrade", "Sec-WebSocket-Key"} {
+		header.Del(key)
+	}
+	header.Set(":protocol", "websocket")
+
+	// the stream outlives the handshake, and is canceled by Close
+	ctx, cancel := context.WithCancel(context.WithoutCancel(c.ctx))
+	stop := context.AfterFunc(c.ctx, cancel)
+	defer stop()
+
+	reader, writer := io.Pipe()
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			c.localAddr = info.Conn.LocalAddr()
+			c.remoteAddr = info.Conn.RemoteAddr()
+		},
+	})
+	h2Request := (&http.Request{
+		Method: http.MethodConnect,
+		URL: &url.URL{
+			Scheme:   c.scheme,
+			Host:     request.Host,
+			Path:     request.URL.Path,
+			RawQuery: request.URL.RawQuery,
+		},
+		Header: header,
+		Host:   request.Host,
+		Body:   reader,
+	}).WithContext(ctx)
+	response, err := c.transport.RoundTrip(h2Request)
+	if err != nil {
+		writer.Close()
+		cancel()
+		return errors.New("failed to send extended CONNECT request").Base(err)
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		writer.Close()
+		cancel()
+		return errors.New("unexpected status of extended CONNECT request: ", response.Status)
+	}
+
+	handshake := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " + computeAcceptKey(challengeKey) + "\r\n"
+	for _, key := range []string{"Sec-WebSocket-Protocol", "Sec-WebSocket-Extensions"} {
+		if value := response.Header.Get(key); value != "" {
+			handshake += key + ": " + value + "\r\n"
+		}
+	}
+	handshake += "\r\n"
+
+	c.reader = io.MultiReader(strings.NewReader(handshake), response.Body)
+	c.writer = writer
+	c.body = response.Body
+	c.cancel = cancel
+	return nil
+}
+
+func (c *h2ClientConn) Read(b []byte) (int, error) {
+	if c.reader == nil {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return c.reader.Read(b)
+}
+
+func (c *h2ClientConn) Close() error {
+	if c.writer == nil {
+		return nil
+	}
+	c.writer.Close()
+	err := c.body.Close()
+	c.cancel()
+	return err
+}
+
+func (c *h2ClientConn) LocalAddr() net.Addr {
+	return c.localAddr
+}
+
+func (c *h2ClientConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+// Streams of the client have no deadlines.
+
+func (*h2ClientConn) SetDeadline(time.Time) error {
+	return nil
+}
+
+func (*h2ClientConn) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (*h2ClientConn) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+// h2ResponseWriter lets gorilla/websocket hijack the stream of an extended
+// CONNECT request.
+type h2ResponseWriter struct {
+	http.ResponseWriter
+	conn *h2ServerConn
+}
+
+func (w *h2ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.conn, bufio.NewReadWriter(bufio.NewReader(w.conn), bufio.NewWriter(w.conn)), nil
+}
+
+// h2Upgrade turns an extended CONNECT request to a WebSocket upgrade request
+// of HTTP/1.1, for gorilla/websocket to upgrade.
+func h2Upgrade(writer http.ResponseWriter, request *http.Request) (*h2ResponseWriter, *http.Request) {
+	challengeKey := make([]byte, 16)
+	rand.Read(challengeKey)
+
+	upgrade := request.Clone(request.Context())
+	upgrade.Method = http.MethodGet
+	upgrade.Header.Del(":protocol")
+	upgrade.Header.Set("Connection", "Upgrade")
+	upgrade.Header.Set("Upgrade", "websocket")
+	upgrade.Header.Set("Sec-WebSocket-Key", base64.StdEncoding.EncodeToString(challengeKey))
+
+	conn := &h2ServerConn{
+		writer:     writer,
+		body:       request.Body,
+		controller: http.NewResponseController(writer),
+		done:       make(chan struct{}),
+		localAddr:  &net.TCPAddr{},
+		remoteAddr: &net.TCPAddr{},
+	}
+	if addr, ok := request.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		conn.localAddr = addr
+	}
+	if addr, err := net.ResolveTCPAddr("tcp", request.RemoteAddr); err == nil {
+		conn.remoteAddr = addr
+	}
+	return &h2ResponseWriter{
+		ResponseWriter: writer,
+		conn:           conn,
+	}, upgrade
+}
+
+// h2ServerConn is the stream of an extended CONNECT request. Its first
+// write, the handshake response of gorilla/websocket, becomes the response
+// headers.
+type h2ServerConn struct {
+	writer     http.ResponseWriter
+	body       io.ReadCloser
+	controller *http.ResponseController
+
+	response  bytes.Buffer
+	responded bool
+	done      chan struct{}
+	closeOnce sync.Once
+	// the writer may not be used after the handler returns
+	access   sync.Mutex
+	finished bool
+
+	localAddr  net.Addr
+	remoteAddr net.Addr
+}
+
+func (c *h2ServerConn) Write(b []byte) (int, error) {
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.finished {
+		return 0, io.ErrClosedPipe
+	}
+	if c.responded {
+		n, err := c.writer.Write(b)
+		if err != nil {
+			return n, err
+		}
+		return n, c.controller.Flush()
+	}
+	c.response.Write(b)
+	if !bytes.Contains(c.response.Bytes(), handshakeEnd) {
+		return len(b), nil
+	}
+	response, err := http.ReadResponse(bufio.NewReader(&c.response), nil)
+	if err != nil {
+		return 0, errors.New("invalid WebSocket handshake").Base(err)
+	}
+	for _, key := range []string{"Sec-WebSocket-Protocol", "Sec-WebSocket-Extensions"} {
+		if value := response.Header.Get(key); value != "" {
+			c.writer.Header().Set(key, value)
+		}
+	}
+	c.writer.WriteHeader(http.StatusOK)
+	c.responded = true
+	return len(b), c.controller.Flush()
+}
+
+// finish is called when the handler of the request returns.
+func (c *h2ServerConn) finish() {
+	c.access.Lock()
+	c.finished = true
+	c.access.Unlock()
+}
+
+func (c *h2ServerConn) Read(b []byte) (int, error) {
+	return c.body.Read(b)
+}
+
+// Close ends the stream, by closing the request body and returning from the
+// handler of the request.
+func (c *h2ServerConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	return c.body.Close()
+}
+
+func (c *h2ServerConn) LocalAddr() net.Addr {
+	return c.localAddr
+}
+
+func (c *h2ServerConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+func (c *h2ServerConn) SetDeadline(t time.Time) error {
+	if err := c.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.SetWriteDeadline(t)
+}
+
+func (c *h2ServerConn) SetReadDeadline(t time.Time) error {
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.finished {
+		return io.ErrClosedPipe
+	}
+	return c.controller.SetReadDeadline(t)
+}
+
+func (c *h2ServerConn) SetWriteDeadline(t time.Time) error {
+	c.access.Lock()
+	defer c.access.Unlock()
+	if c.finished {
+		return io.ErrClosedPipe
+	}
+	return c.controller.SetWriteDeadline(t)
+}
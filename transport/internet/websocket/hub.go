@@ -18,12 +18,15 @@ import (
 	http_proto "github.com/xtls/xray-core/common/protocol/http"
 	"github.com/xtls/xray-core/transport/internet"
 	v2tls "github.com/xtls/xray-core/transport/internet/tls"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 type requestHandler struct {
-	host string
-	path string
-	ln   *Listener
+	host     string
+	path     string
+	ln       *Listener
+	upgrader *websocket.Upgrader
 }
 
 var replacer = strings.NewReplacer("+", "-", "/", "_", "=", "")
@@ -49,6 +52,14 @@ func (h *requestHandler) ServeHTTP(writer http.ResponseWriter, request *http.Req
 		return
 	}
 
+	var h2Conn *h2ServerConn
+	if isExtendedConnect(request) && h.ln.config.H2 {
+		var h2Writer *h2ResponseWriter
+		h2Writer, request = h2Upgrade(writer, request)
+		writer = h2Writer
+		h2Conn = h2Writer.conn
+	}
+
 	var extraReader io.Reader
 	responseHeader := http.Header{}
 	if str := request.Header.Get("Sec-WebSocket-Protocol"); str != "" {
@@ -58,7 +69,7 @@ func (h *requestHandler) ServeHTTP(writer http.ResponseWriter, request *http.Req
 		}
 	}
 
-	conn, err := upgrader.Upgrade(writer, request, responseHeader)
+	conn, err := h.upgrader.Upgrade(writer, request, responseHeader)
 	if err != nil {
 		errors.LogInfoInner(context.Background(), err, "failed to convert to WebSocket connection")
 		return
@@ -73,7 +84,18 @@ func (h *requestHandler) ServeHTTP(writer http.ResponseWriter, request *http.Req
 		}
 	}
 
-	h.ln.addConn(NewConnection(conn, remoteAddr, extraReader, h.ln.config.HeartbeatPeriod))
+	wsConn := NewConnection(conn, remoteAddr, extraReader, h.ln.config.HeartbeatPeriod)
+	wsConn.peerIdentity = v2tls.PeerIdentity(request.TLS)
+	h.ln.addConn(wsConn)
+
+	if h2Conn != nil {
+		// the stream ends when the handler returns
+		select {
+		case <-h2Conn.done:
+		case <-request.Context().Done():
+		}
+		h2Conn.finish()
+	}
 }
 
 type Listener struct {
@@ -82,6 +104,7 @@ type Listener struct {
 	listener net.Listener
 	config   *Config
 	addConn  internet.ConnHandler
+	acme     common.Closable
 }
 
 func ListenWS(ctx context.Context, address net.Address, port net.Port, streamSettings *internet.MemoryStreamConfig, addConn internet.ConnHandler) (internet.Listener, error) {
@@ -90,6 +113,9 @@ func ListenWS(ctx context.Context, address net.Address, port net.Port, streamSet
 	}
 	wsSettings := streamSettings.ProtocolSettings.(*Config)
 	l.config = wsSettings
+	if wsSettings.H2 && !http_proto.ExtendedConnectEnabled() {
+		return nil, errors.New("WebSockets over HTTP/2 need GODEBUG=http2xconnect=1 in the environment")
+	}
 	if l.config != nil {
 		if streamSettings.SocketSettings == nil {
 			streamSettings.SocketSettings = &internet.SocketConfig{}
@@ -123,22 +149,43 @@ func ListenWS(ctx context.Context, address net.Address, port net.Port, streamSet
 	}
 
 	if config := v2tls.ConfigFromStreamSettings(streamSettings); config != nil {
-		if tlsConfig := config.GetTLSConfig(); tlsConfig != nil {
-			listener = tls.NewListener(listener, tlsConfig)
+		tlsConfig, acme, err := config.GetServerTLSConfig()
+		if err != nil {
+			listener.Close()
+			return nil, err
 		}
+		l.acme = acme
+		listener = tls.NewListener(listener, tlsConfig)
 	}
 
 	l.listener = listener
 
+	upgrader := *upgrader
+	upgrader.EnableCompression = wsSettings.Compression
+	var handler http.Handler = &requestHandler{
+		host:     wsSettings.Host,
+		path:     wsSettings.GetNormalizedPath(),
+		ln:       l,
+		upgrader: &upgrader,
+	}
+	h2s := &http2.Server{
+		IdleTimeout: net.ConnIdleTimeout,
+	}
+	if wsSettings.H2 && v2tls.ConfigFromStreamSettings(streamSettings) == nil {
+		handler = h2c.NewHandler(handler, h2s)
+	}
+
 	l.server = http.Server{
-		Handler: &requestHandler{
-			host: wsSettings.Host,
-			path: wsSettings.GetNormalizedPath(),
-			ln:   l,
-		},
+		Handler:           handler,
 		ReadHeaderTimeout: time.Second * 4,
 		MaxHeaderBytes:    8192,
 	}
+	if wsSettings.H2 && v2tls.ConfigFromStreamSettings(streamSettings) != nil {
+		if err := http2.ConfigureServer(&l.server, h2s); err != nil {
+			l.Close()
+			return nil, errors.New("failed to serve HTTP/2 for WebSocket").Base(err)
+		}
+	}
 
 	go func() {
 		if err := l.server.Serve(l.listener); err != nil {
@@ -156,6 +203,7 @@ func (ln *Listener) Addr() net.Addr {
 
 // Close implements net.Listener.Close().
 func (ln *Listener) Close() error {
+	common.Close(ln.acme)
 	return ln.listener.Close()
 }
 
@@ -2,12 +2,17 @@ package websocket_test
 
 import (
 	"context"
+	gotls "crypto/tls"
+	"crypto/x509"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/xtls/xray-core/common"
 	"github.com/xtls/xray-core/common/net"
+	http_proto "github.com/xtls/xray-core/common/protocol/http"
 	"github.com/xtls/xray-core/common/protocol/tls/cert"
 	"github.com/xtls/xray-core/testing/servers/tcp"
 	"github.com/xtls/xray-core/transport/internet"
@@ -16,6 +21,14 @@ import (
 	. "github.com/xtls/xray-core/transport/internet/websocket"
 )
 
+// skipWithoutExtendedConnect skips tests of WebSockets over HTTP/2, which
+// servers accept only with GODEBUG=http2xconnect=1 set when the test starts.
+func skipWithoutExtendedConnect(t *testing.T) {
+	if !http_proto.ExtendedConnectEnabled() {
+		t.Skip("GODEBUG=http2xconnect=1 is not set")
+	}
+}
+
 func Test_listenWSAndDial(t *testing.T) {
 	listenPort := tcp.PickPort()
 	listen, err := ListenWS(context.Background(), net.LocalHostIP, listenPort, &internet.MemoryStreamConfig{
@@ -151,3 +164,148 @@ func Test_listenWSAndDial_TLS(t *testing.T) {
 		t.Error("end: ", end, " start: ", start)
 	}
 }
+
+func testListenWSAndEcho(t *testing.T, streamSettings *internet.MemoryStreamConfig) {
+	listenPort := tcp.PickPort()
+	listen, err := ListenWS(context.Background(), net.LocalHostIP, listenPort, streamSettings, func(conn stat.Connection) {
+		go func(c stat.Connection) {
+			defer c.Close()
+
+			var b [1024]byte
+			for {
+				n, err := c.Read(b[:])
+				if err != nil {
+					return
+				}
+				if _, err := c.Write(b[:n]); err != nil {
+					return
+				}
+			}
+		}(conn)
+	})
+	common.Must(err)
+	defer listen.Close()
+
+	for i := 0; i < 2; i++ {
+		conn, err := Dial(context.Background(), net.TCPDestination(net.DomainAddress("localhost"), listenPort), streamSettings)
+		common.Must(err)
+
+		payload := []byte(strings.Repeat("Test connection ", 64))
+		common.Must2(conn.Write(payload))
+
+		var b [2048]byte
+		total := 0
+		for total < len(payload) {
+			n, err := conn.Read(b[total:])
+			common.Must(err)
+			total += n
+		}
+		if string(b[:total]) != string(payload) {
+			t.Error("response: ", string(b[:total]))
+		}
+		common.Must(conn.Close())
+	}
+}
+
+func Test_listenWSAndDial_Compression(t *testing.T) {
+	testListenWSAndEcho(t, &internet.MemoryStreamConfig{
+		ProtocolName: "websocket",
+		ProtocolSettings: &Config{
+			Path:        "ws",
+			Compression: true,
+		},
+	})
+}
+
+func Test_listenWSAndDial_H2C(t *testing.T) {
+	skipWithoutExtendedConnect(t)
+	testListenWSAndEcho(t, &internet.MemoryStreamConfig{
+		ProtocolName: "websocket",
+		ProtocolSettings: &Config{
+			Path:        "ws",
+			Compression: true,
+			H2:          true,
+		},
+	})
+}
+
+func Test_listenWSAndDial_H2WithoutXConnect(t *testing.T) {
+	t.Setenv("GODEBUG", "")
+	_, err := ListenWS(context.Background(), net.LocalHostIP, tcp.PickPort(), &internet.MemoryStreamConfig{
+		ProtocolName: "websocket",
+		ProtocolSettings: &Config{
+			Path: "ws",
+			H2:   true,
+		},
+	}, func(conn stat.Connection) {
+		conn.Close()
+	})
+	if err == nil {
+		t.Error("expected error for WebSockets over HTTP/2 without extended CONNECT")
+	}
+}
+
+func Test_listenWSAndDial_H2(t *testing.T) {
+	skipWithoutExtendedConnect(t)
+	for _, fingerprint := range []string{"", "chrome"} {
+		testListenWSAndEcho(t, &internet.MemoryStreamConfig{
+			ProtocolName: "websocket",
+			ProtocolSettings: &Config{
+				Path: "ws",
+				H2:   true,
+			},
+			SecurityType: "tls",
+			SecuritySettings: &tls.Config{
+				AllowInsecure: true,
+				Fingerprint:   fingerprint,
+				Certificate:   []*tls.Certificate{tls.ParseCertificate(cert.MustGenerate(nil, cert.CommonName("localhost")))},
+			},
+		})
+	}
+}
+
+func Test_listenWSAndDial_ClientAuth(t *testing.T) {
+	listenPort := tcp.PickPort()
+
+	ca := cert.MustGenerate(nil, cert.Authority(true), cert.KeyUsage(x509.KeyUsageCertSign), func(c *x509.Certificate) {
+		c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	})
+	caPEM, _ := ca.ToPEM()
+	client := cert.MustGenerate(ca, cert.CommonName("client"), func(c *x509.Certificate) {
+		c.EmailAddresses = []string{"client@example.com"}
+		c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	})
+
+	identities := make(chan string, 1)
+	listen, err := ListenWS(context.Background(), net.LocalHostIP, listenPort, &internet.MemoryStreamConfig{
+		ProtocolName:     "websocket",
+		ProtocolSettings: &Config{Path: "wss"},
+		SecurityType:     "tls",
+		SecuritySettings: &tls.Config{
+			Certificate: []*tls.Certificate{tls.ParseCertificate(cert.MustGenerate(nil, cert.CommonName("localhost")))},
+			ClientAuth:  &tls.ClientAuth{CertificateAuthority: [][]byte{caPEM}},
+		},
+	}, func(conn stat.Connection) {
+		identities <- stat.PeerIdentity(conn)
+		conn.Close()
+	})
+	common.Must(err)
+	defer listen.Close()
+
+	clientPEM, clientKeyPEM := client.ToPEM()
+	clientCert, err := gotls.X509KeyPair(clientPEM, clientKeyPEM)
+	common.Must(err)
+	dialer := &websocket.Dialer{
+		TLSClientConfig: &gotls.Config{
+			InsecureSkipVerify: true,
+			Certificates:       []gotls.Certificate{clientCert},
+		},
+	}
+	conn, _, err := dialer.Dial("wss://localhost:"+listenPort.String()+"/wss", nil)
+	common.Must(err)
+	defer conn.Close()
+
+	if identity := <-identities; identity != "client@example.com" {
+		t.Error("identity: ", identity)
+	}
+}